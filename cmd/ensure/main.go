@@ -6,35 +6,106 @@ import (
 	"os"
 
 	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/ensure-cli/internal/changes"
 	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/configmigrate"
+	"github.com/JosiahWitt/ensure-cli/internal/doctor"
 	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
 	"github.com/JosiahWitt/ensure-cli/internal/exitcleanup"
 	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/hooks"
+	"github.com/JosiahWitt/ensure-cli/internal/importrewrite"
 	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mockimport"
+	"github.com/JosiahWitt/ensure-cli/internal/mockmove"
+	"github.com/JosiahWitt/ensure-cli/internal/mocksync"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
 	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/unused"
+	"github.com/JosiahWitt/ensure-cli/internal/watch"
 )
 
-//nolint:gochecknoglobals // Allows injecting the version
 // Version of the CLI.
 // Should be tied to the release version.
-var Version = "0.1.4"
+var Version = "0.1.4" //nolint:gochecknoglobals // Allows injecting the version
 
 func main() {
 	logger := log.New(os.Stdout, "", 0)
 	exitCleanup, cleanup := exitcleanup.New(logger)
+	reporter := &report.Reporter{Writer: os.Stdout}
+
+	runner := &runcmd.Runner{}
+	fsWrite := &fswrite.FSWrite{}
+	modFinder := &modfiles.Finder{}
+	ensureFileLoader := &ensurefile.Loader{FS: fs.DirFS(""), Finder: modFinder, LookupEnv: os.LookupEnv}
+	mockGenerator := &mockgen.MockGen{
+		CmdRun:   runner,
+		FSWrite:  fsWrite,
+		Logger:   logger,
+		Cleanup:  exitCleanup,
+		Reporter: reporter,
+	}
 
 	app := cmd.App{
 		Version: Version,
 
 		Logger:           logger,
+		Stdout:           os.Stdout,
 		Getwd:            os.Getwd,
-		EnsureFileLoader: &ensurefile.Loader{FS: fs.DirFS("")},
+		EnsureFileLoader: ensureFileLoader,
 		Cleanup:          exitCleanup,
-		MockGenerator: &mockgen.MockGen{
-			CmdRun:  &runcmd.Runner{},
-			FSWrite: &fswrite.FSWrite{},
-			Logger:  logger,
-			Cleanup: exitCleanup,
+		Reporter:         reporter,
+		MockGenerator:    mockGenerator,
+		MockWatcher: &watch.Watcher{
+			CmdRun:        runner,
+			MockGenerator: mockGenerator,
+			Logger:        logger,
+			Reporter:      reporter,
+		},
+		Doctor: &doctor.Doctor{
+			CmdRun:           runner,
+			EnsureFileLoader: ensureFileLoader,
+			FS:               fs.DirFS(""),
+			FSWrite:          fsWrite,
+			Logger:           logger,
+			Reporter:         reporter,
+		},
+		MockImporter: &mockimport.Importer{
+			Finder:           modFinder,
+			EnsureFileLoader: ensureFileLoader,
+			FS:               fs.DirFS(""),
+			FSWrite:          fsWrite,
+		},
+		ImportRewriter: &importrewrite.Rewriter{
+			Finder:  modFinder,
+			FSWrite: fsWrite,
+		},
+		MockMover: &mockmove.Mover{
+			EnsureFileLoader: ensureFileLoader,
+			FSWrite:          fsWrite,
+		},
+		MockSyncer: &mocksync.Syncer{
+			Finder:           modFinder,
+			EnsureFileLoader: ensureFileLoader,
+			FSWrite:          fsWrite,
+		},
+		UnusedDetector: &unused.Detector{
+			Finder:           modFinder,
+			EnsureFileLoader: ensureFileLoader,
+			FSWrite:          fsWrite,
+		},
+		ConfigMigrator: &configmigrate.Migrator{
+			EnsureFileLoader: ensureFileLoader,
+			FSWrite:          fsWrite,
+		},
+		ChangeDetector: &changes.Detector{
+			CmdRun: runner,
+		},
+		HookInstaller: &hooks.Installer{
+			CmdRun:           runner,
+			FSWrite:          fsWrite,
+			EnsureFileLoader: ensureFileLoader,
 		},
 	}
 
@@ -42,7 +113,14 @@ func main() {
 	cleanup() // Run cleanups before handling error
 
 	if err != nil {
-		fmt.Printf("ERROR: %v\n", err) //nolint:forbidigo // Allow printing error messages
-		os.Exit(1)
+		exitCode := cmd.ExitCode(err)
+
+		if reporter.Enabled() {
+			reporter.Report(&report.Event{Type: report.EventError, Error: report.NewError(err), ExitCode: exitCode})
+		} else {
+			fmt.Printf("ERROR: %v\n", err) //nolint:forbidigo // Allow printing error messages
+		}
+
+		os.Exit(exitCode)
 	}
 }
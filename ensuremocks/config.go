@@ -0,0 +1,241 @@
+package ensuremocks
+
+import (
+	"os"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+)
+
+// Config is a loaded .ensure.yml file, or a config created in code.
+type Config struct {
+	RootPath   string // Absolute path of the module's root directory
+	ModulePath string // Such as github.com/my/app
+	ConfigPath string // Absolute path of the .ensure.yml file, which is used to describe where packages are configured
+	Mocks      *MockConfig
+
+	annotations []*ensurefile.Annotation // Interfaces marked with //ensure:mock, which are merged when generating
+}
+
+// MockConfig is the mocks key of the .ensure.yml file.
+type MockConfig struct {
+	PrimaryDestination  string
+	InternalDestination string
+	TidyAfterGenerate   bool
+	Typed               bool
+	Templates           []*Template
+	Packages            []*Package
+}
+
+// Package with interfaces for which to generate mocks.
+type Package struct {
+	Path       string
+	Interfaces []string
+	Typed      *bool             // Optional, overrides MockConfig.Typed
+	MockNames  map[string]string // Optional, keyed by interface
+	File       string            // The .ensure.yml file listing the package, when it differs from Config.ConfigPath
+	Line       int               // Line of the package in its .ensure.yml file
+
+	// Optional, overrides the destinations of the MockConfig, such as for packages listed in nested .ensure.yml files
+	PrimaryDestination  string // Relative to the root of the module
+	InternalDestination string
+}
+
+// Template is a text/template that is appended to each generated mock file.
+type Template struct {
+	Path     string
+	Scope    string // Either "package" or "interface"
+	Contents string // Read from Path by the Loader
+}
+
+// Loader loads .ensure.yml files from the OS's file system, including nested .ensure.yml files,
+// //ensure:mock annotations, and ENSURE_* environment variables.
+type Loader struct {
+	fs        fs.FS
+	lookupEnv func(key string) (string, bool)
+}
+
+// NewLoader returns a Loader that reads from the OS's file system.
+func NewLoader() *Loader {
+	return &Loader{fs: fs.DirFS(""), lookupEnv: os.LookupEnv}
+}
+
+// LoadConfig loads the config of the module containing dir.
+func (l *Loader) LoadConfig(dir string) (*Config, error) {
+	config, err := l.loader().LoadConfig(dir)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	return newConfig(config), nil
+}
+
+// LoadConfigs loads the config of every module in the workspace containing dir,
+// or only the config of the module containing dir when it is not in a workspace.
+func (l *Loader) LoadConfigs(dir string) ([]*Config, error) {
+	configs, err := l.loader().LoadConfigs(dir)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	ownedConfigs := make([]*Config, 0, len(configs))
+	for _, config := range configs {
+		ownedConfigs = append(ownedConfigs, newConfig(config))
+	}
+
+	return ownedConfigs, nil
+}
+
+// LoadConfigFile loads the config from the file at the absolute configPath,
+// instead of the .ensure.yml file of the module. The module is still found using dir.
+func (l *Loader) LoadConfigFile(dir, configPath string) (*Config, error) {
+	loader := l.loader()
+	loader.UseConfigFile(configPath)
+
+	config, err := loader.LoadConfig(dir)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	return newConfig(config), nil
+}
+
+// loader returns a new loader for each load, so loading a config file does not affect later loads.
+func (l *Loader) loader() *ensurefile.Loader {
+	return &ensurefile.Loader{FS: l.fs, Finder: &modfiles.Finder{}, LookupEnv: l.lookupEnv}
+}
+
+// newConfig copies the config loaded by the CLI's loader, so the caller's changes do not affect the loader.
+func newConfig(config *ensurefile.Config) *Config {
+	ownedConfig := &Config{
+		RootPath:    config.RootPath,
+		ModulePath:  config.ModulePath,
+		ConfigPath:  config.ConfigPath,
+		annotations: config.Annotations,
+	}
+
+	if config.Mocks == nil {
+		return ownedConfig
+	}
+
+	ownedConfig.Mocks = &MockConfig{
+		PrimaryDestination:  config.Mocks.PrimaryDestination,
+		InternalDestination: config.Mocks.InternalDestination,
+		TidyAfterGenerate:   config.Mocks.TidyAfterGenerate,
+		Typed:               config.Mocks.Typed,
+	}
+
+	for _, template := range config.Mocks.Templates {
+		ownedConfig.Mocks.Templates = append(ownedConfig.Mocks.Templates, &Template{
+			Path:     template.Path,
+			Scope:    template.Scope,
+			Contents: template.Contents,
+		})
+	}
+
+	for _, pkg := range config.Mocks.Packages {
+		ownedConfig.Mocks.Packages = append(ownedConfig.Mocks.Packages, &Package{
+			Path:                pkg.Path,
+			Interfaces:          copyStrings(pkg.Interfaces),
+			Typed:               copyBool(pkg.Typed),
+			MockNames:           copyMockNames(pkg.MockNames),
+			File:                pkg.File,
+			Line:                pkg.Line,
+			PrimaryDestination:  pkg.PrimaryDestination,
+			InternalDestination: pkg.InternalDestination,
+		})
+	}
+
+	return ownedConfig
+}
+
+// toEnsureFile converts the config to the one used by the CLI, applying the options.
+// The packages are copied, since generating applies defaults and merges annotations into them,
+// which must not change the caller's config.
+func (c *Config) toEnsureFile(options *Options) *ensurefile.Config {
+	config := &ensurefile.Config{
+		RootPath:    c.RootPath,
+		ModulePath:  c.ModulePath,
+		ConfigPath:  c.ConfigPath,
+		Annotations: c.annotations,
+	}
+
+	if options != nil {
+		config.PackageFilters = options.Match
+		config.DisableParallelGeneration = options.Sequential
+	}
+
+	if c.Mocks == nil {
+		return config
+	}
+
+	config.Mocks = &ensurefile.MockConfig{
+		PrimaryDestination:  c.Mocks.PrimaryDestination,
+		InternalDestination: c.Mocks.InternalDestination,
+		TidyAfterGenerate:   c.Mocks.TidyAfterGenerate,
+		Typed:               c.Mocks.Typed,
+	}
+
+	for _, template := range c.Mocks.Templates {
+		config.Mocks.Templates = append(config.Mocks.Templates, &ensurefile.Template{
+			Path:     template.Path,
+			Scope:    template.Scope,
+			Contents: template.Contents,
+		})
+	}
+
+	for _, pkg := range c.Mocks.Packages {
+		config.Mocks.Packages = append(config.Mocks.Packages, &ensurefile.Package{
+			Path:                pkg.Path,
+			Interfaces:          copyStrings(pkg.Interfaces),
+			Typed:               copyBool(pkg.Typed),
+			MockNames:           copyMockNames(pkg.MockNames),
+			File:                pkg.File,
+			Line:                pkg.Line,
+			PrimaryDestination:  pkg.PrimaryDestination,
+			InternalDestination: pkg.InternalDestination,
+		})
+	}
+
+	return config
+}
+
+func toEnsureFiles(configs []*Config, options *Options) []*ensurefile.Config {
+	ensureFiles := make([]*ensurefile.Config, 0, len(configs))
+	for _, config := range configs {
+		ensureFiles = append(ensureFiles, config.toEnsureFile(options))
+	}
+
+	return ensureFiles
+}
+
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	return append([]string{}, values...)
+}
+
+func copyBool(value *bool) *bool {
+	if value == nil {
+		return nil
+	}
+
+	copied := *value
+	return &copied
+}
+
+func copyMockNames(mockNames map[string]string) map[string]string {
+	if mockNames == nil {
+		return nil
+	}
+
+	copied := make(map[string]string, len(mockNames))
+	for iface, mockName := range mockNames {
+		copied[iface] = mockName
+	}
+
+	return copied
+}
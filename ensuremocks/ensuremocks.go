@@ -0,0 +1,386 @@
+// Package ensuremocks allows other Go programs to load .ensure.yml files and generate mocks,
+// without running the ensure CLI.
+//
+// Configs loaded by this package behave the same way as in the CLI, and errors match the
+// exported errors using errors.Is.
+package ensuremocks
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/JosiahWitt/ensure-cli/internal/exitcleanup"
+	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/erk"
+)
+
+// Runner runs mockgen. The default runs commands using os/exec.
+type Runner interface {
+	Exec(ctx context.Context, params *ExecParams) (string, error)
+}
+
+// ExecParams are the command and working directory passed to Runner.
+type ExecParams struct {
+	PWD  string
+	CMD  string
+	Args []string
+}
+
+// FSWriter reads and writes the mock files. The default uses the OS's file system.
+type FSWriter interface {
+	WriteFile(filename string, data string, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	GlobRemoveAll(pattern string) error
+	ListRecursive(dir string) ([]string, error)
+	ReadFile(filename string) (string, error)
+	RemoveAll(paths string) error
+}
+
+// ExitCleaner registers cleanups for the temporary directories created by mockgen.
+type ExitCleaner interface {
+	ToContext(ctx context.Context) context.Context
+	Register(fn func() error)
+}
+
+var _ exitcleanup.ExitCleaner = ExitCleaner(nil)
+
+var errChmodUnsupported = errors.New("changing file modes is not supported")
+
+// Options select the packages to generate, and how they are generated.
+// A nil Options generates every package in parallel.
+type Options struct {
+	Match      []string // Package patterns, such as 'store/*', matched like `ensure mocks generate --match`
+	Sequential bool     // Generates one package at a time, instead of in parallel
+}
+
+// Plan describes where the mocks of each package are generated, after defaults are applied.
+type Plan struct {
+	RootPath            string         `json:"rootPath"`
+	ModulePath          string         `json:"modulePath"`
+	ConfigPath          string         `json:"configPath"`
+	PrimaryDestination  string         `json:"primaryDestination"`
+	InternalDestination string         `json:"internalDestination"`
+	TidyAfterGenerate   bool           `json:"tidyAfterGenerate"`
+	Typed               bool           `json:"typed"`
+	Templates           []string       `json:"templates,omitempty"`
+	Packages            []*PlanPackage `json:"packages"`
+}
+
+// PlanPackage describes where the mocks for a package are generated.
+type PlanPackage struct {
+	Path            string           `json:"path"`
+	PWD             string           `json:"pwd"` // Where mockgen runs
+	MockPackageName string           `json:"mockPackageName"`
+	ImportPath      string           `json:"importPath"`
+	FilePath        string           `json:"filePath"`
+	Typed           bool             `json:"typed"`
+	Interfaces      []*PlanInterface `json:"interfaces"`
+}
+
+// PlanInterface describes the mock generated for an interface.
+type PlanInterface struct {
+	Name     string `json:"name"`
+	MockName string `json:"mockName"`
+}
+
+// GenerateResult lists the mock files that were generated, sorted by path.
+type GenerateResult struct {
+	Generated []*MockFile `json:"generated"`
+}
+
+// CheckResult lists the differences between the mocks that would be generated and the files on disk.
+type CheckResult struct {
+	Outdated []*MockFile `json:"outdated"` // Mock files with different contents
+	Missing  []*MockFile `json:"missing"`  // Mock files that do not exist
+	Extra    []string    `json:"extra"`    // Paths that would be tidied, when tidyAfterGenerate is set
+}
+
+// MockFile is a mock file generated for a package.
+type MockFile struct {
+	Package string `json:"package"`          // Such as github.com/my/app/pkg:Iface1,Iface2
+	Source  string `json:"source,omitempty"` // Where the package is configured, such as /my/app/.ensure.yml:4
+	Path    string `json:"path"`
+}
+
+// TidyResult lists the paths removed by tidying.
+type TidyResult struct {
+	Removed []string `json:"removed"`
+}
+
+// UpToDate is true if generating the mocks would not change any files.
+func (r *CheckResult) UpToDate() bool {
+	return len(r.Outdated) == 0 && len(r.Missing) == 0 && len(r.Extra) == 0
+}
+
+// Err returns an error matching ErrOutOfDate if any files would change.
+func (r *CheckResult) Err() error {
+	if r.UpToDate() {
+		return nil
+	}
+
+	return wrapError(erk.WithParams(mockgen.ErrMocksOutOfDate, erk.Params{
+		"count": len(r.Outdated) + len(r.Missing) + len(r.Extra),
+	}))
+}
+
+// PlanMocks returns where the mocks for the config would be generated, without running mockgen.
+// Only packages matching the options are included.
+func PlanMocks(config *Config, options *Options) (*Plan, error) {
+	resolved, err := mockgen.ResolveConfig(config.toEnsureFile(options))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	plan := &Plan{
+		RootPath:            resolved.RootPath,
+		ModulePath:          resolved.ModulePath,
+		ConfigPath:          resolved.ConfigPath,
+		PrimaryDestination:  resolved.PrimaryDestination,
+		InternalDestination: resolved.InternalDestination,
+		TidyAfterGenerate:   resolved.TidyAfterGenerate,
+		Typed:               resolved.Typed,
+		Templates:           resolved.Templates,
+		Packages:            make([]*PlanPackage, 0, len(resolved.Packages)),
+	}
+
+	for _, resolvedPackage := range resolved.Packages {
+		pkg := &PlanPackage{
+			Path:            resolvedPackage.Path,
+			PWD:             resolvedPackage.PWD,
+			MockPackageName: resolvedPackage.MockPackageName,
+			ImportPath:      resolvedPackage.ImportPath,
+			FilePath:        resolvedPackage.FilePath,
+			Typed:           resolvedPackage.Typed,
+			Interfaces:      make([]*PlanInterface, 0, len(resolvedPackage.Interfaces)),
+		}
+
+		for _, iface := range resolvedPackage.Interfaces {
+			pkg.Interfaces = append(pkg.Interfaces, &PlanInterface{Name: iface.Name, MockName: iface.MockName})
+		}
+
+		plan.Packages = append(plan.Packages, pkg)
+	}
+
+	return plan, nil
+}
+
+// Generator generates mocks using mockgen.
+// Each field is optional, and defaults to the implementation used by the CLI.
+type Generator struct {
+	Runner  Runner
+	FSWrite FSWriter
+	Logger  *log.Logger // Defaults to discarding the progress logs
+
+	// Defaults to running the cleanups before each method returns.
+	// When set, the caller is responsible for running the cleanups.
+	Cleanup ExitCleaner
+}
+
+// Generate the mocks for the config.
+func (g *Generator) Generate(ctx context.Context, config *Config, options *Options) (*GenerateResult, error) {
+	return g.GenerateAll(ctx, []*Config{config}, options)
+}
+
+// GenerateAll generates the mocks for the configs of multiple modules in a single run, such as a workspace.
+// Package patterns only need to match packages in one of the configs.
+// The result includes the mocks that were generated, even when others could not be generated.
+func (g *Generator) GenerateAll(ctx context.Context, configs []*Config, options *Options) (*GenerateResult, error) {
+	mockGen, cleanup := g.mockGen()
+	defer cleanup()
+
+	collector := &generatedCollector{}
+	mockGen.Reporter = collector
+
+	err := mockGen.GenerateAllMocks(ctx, toEnsureFiles(configs, options))
+	return collector.result(), wrapError(err)
+}
+
+// Check generates the mocks for the configs in memory, and returns the mock files that differ from the ones on disk.
+// No files are changed. Use CheckResult.Err to get an error when the mocks are out of date.
+func (g *Generator) Check(ctx context.Context, configs []*Config, options *Options) (*CheckResult, error) {
+	mockGen, cleanup := g.mockGen()
+	defer cleanup()
+
+	checkResult, err := mockGen.CheckMocks(ctx, toEnsureFiles(configs, options))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	return &CheckResult{
+		Outdated: newMockFiles(checkResult.Outdated),
+		Missing:  newMockFiles(checkResult.Missing),
+		Extra:    checkResult.Extra,
+	}, nil
+}
+
+// Verify type-checks the mock packages generated for the configs using `go build`, such as after GenerateAll.
+// The error for each mock that fails includes the package and config entry that generated it.
+func (g *Generator) Verify(ctx context.Context, configs []*Config, options *Options) error {
+	mockGen, cleanup := g.mockGen()
+	defer cleanup()
+
+	return wrapError(mockGen.VerifyMocks(ctx, toEnsureFiles(configs, options)))
+}
+
+// PlanTidy returns the paths in the mock directories that Tidy would remove, without removing them.
+func (g *Generator) PlanTidy(config *Config) ([]string, error) {
+	mockGen, cleanup := g.mockGen()
+	defer cleanup()
+
+	paths, err := mockGen.TidyPaths(config.toEnsureFile(nil))
+	return paths, wrapError(err)
+}
+
+// Tidy removes the paths in the mock directories that would not be generated for the config.
+func (g *Generator) Tidy(config *Config) (*TidyResult, error) {
+	paths, err := g.PlanTidy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	fsWrite := g.fsWrite()
+	result := &TidyResult{Removed: []string{}}
+	for _, path := range paths {
+		if err := fsWrite.RemoveAll(path); err != nil {
+			return result, wrapError(erk.WrapWith(mockgen.ErrTidyUnableToCleanup, err, erk.Params{
+				"path": path,
+			}))
+		}
+
+		result.Removed = append(result.Removed, path)
+	}
+
+	return result, nil
+}
+
+// mockGen returns the MockGen using the defaults for any unset fields, and a function to run the default cleanups.
+func (g *Generator) mockGen() (*mockgen.MockGen, func()) {
+	mockGen := &mockgen.MockGen{
+		CmdRun:  &runcmd.Runner{},
+		FSWrite: g.fsWrite(),
+		Logger:  g.Logger,
+		Cleanup: g.Cleanup,
+	}
+
+	if g.Runner != nil {
+		mockGen.CmdRun = &runnerAdapter{runner: g.Runner}
+	}
+
+	if mockGen.Logger == nil {
+		mockGen.Logger = log.New(ioutil.Discard, "", 0)
+	}
+
+	if mockGen.Cleanup != nil {
+		return mockGen, func() {}
+	}
+
+	cleaner := &deferredCleanup{}
+	mockGen.Cleanup = cleaner
+	return mockGen, cleaner.run
+}
+
+func (g *Generator) fsWrite() fswrite.FSWriteIface {
+	if g.FSWrite == nil {
+		return &fswrite.FSWrite{}
+	}
+
+	return &fsWriteAdapter{FSWriter: g.FSWrite}
+}
+
+// runnerAdapter runs mockgen using the caller's Runner.
+type runnerAdapter struct {
+	runner Runner
+}
+
+var _ runcmd.RunnerIface = &runnerAdapter{}
+
+func (r *runnerAdapter) Exec(ctx context.Context, params *runcmd.ExecParams) (string, error) {
+	return r.runner.Exec(ctx, &ExecParams{PWD: params.PWD, CMD: params.CMD, Args: params.Args})
+}
+
+// fsWriteAdapter uses the caller's FSWriter for the methods used to generate mocks.
+type fsWriteAdapter struct {
+	FSWriter
+}
+
+var _ fswrite.FSWriteIface = &fsWriteAdapter{}
+
+// Chmod is only used to install git hooks, which is not supported by this package.
+func (*fsWriteAdapter) Chmod(name string, perm os.FileMode) error {
+	return errChmodUnsupported
+}
+
+// generatedCollector records the mock files that are generated, using the events reported by the CLI's generator.
+type generatedCollector struct {
+	mu        sync.Mutex
+	generated []*MockFile
+}
+
+var _ report.ReporterIface = &generatedCollector{}
+
+func (c *generatedCollector) Enable() {}
+
+func (c *generatedCollector) Enabled() bool {
+	return true
+}
+
+func (c *generatedCollector) Report(event *report.Event) {
+	if event.Type != report.EventPackageGenerated {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generated = append(c.generated, &MockFile{Package: event.Package, Path: event.Path})
+}
+
+func (c *generatedCollector) result() *GenerateResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	generated := append([]*MockFile{}, c.generated...)
+	sort.Slice(generated, func(i, j int) bool {
+		return generated[i].Path < generated[j].Path
+	})
+
+	return &GenerateResult{Generated: generated}
+}
+
+func newMockFiles(mockFiles []*mockgen.MockFile) []*MockFile {
+	ownedMockFiles := make([]*MockFile, 0, len(mockFiles))
+	for _, mockFile := range mockFiles {
+		ownedMockFiles = append(ownedMockFiles, &MockFile{Package: mockFile.Package, Source: mockFile.Source, Path: mockFile.Path})
+	}
+
+	return ownedMockFiles
+}
+
+// deferredCleanup runs the registered cleanups when run is called, instead of when the program is interrupted,
+// since a library should not handle the signals of the program using it.
+type deferredCleanup struct {
+	cleanups []func() error
+}
+
+var _ ExitCleaner = &deferredCleanup{}
+
+func (c *deferredCleanup) ToContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (c *deferredCleanup) Register(fn func() error) {
+	c.cleanups = append(c.cleanups, fn)
+}
+
+func (c *deferredCleanup) run() {
+	for _, cleanup := range c.cleanups {
+		_ = cleanup() // Best effort, like the CLI's cleanups
+	}
+}
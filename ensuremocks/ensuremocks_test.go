@@ -0,0 +1,318 @@
+package ensuremocks_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/ensuremocks"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/github.com/JosiahWitt/ensure-cli/mock_ensuremocks"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func newConfig() *ensuremocks.Config {
+	return &ensuremocks.Config{
+		RootPath:   "/root/path",
+		ModulePath: "github.com/my/mod",
+		ConfigPath: "/root/path/.ensure.yml",
+		Mocks: &ensuremocks.MockConfig{
+			PrimaryDestination: "mocks",
+			Packages: []*ensuremocks.Package{
+				{Path: "github.com/some/pkg/abc", Interfaces: []string{"Iface1"}, Line: 4},
+			},
+		},
+	}
+}
+
+func TestGeneratorGenerate(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Runner  *mock_ensuremocks.MockRunner
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	mockDir := "/root/path/mocks/github.com/some/pkg/mock_abc"
+
+	table := []struct {
+		Name           string
+		Options        *ensuremocks.Options
+		ExpectedResult *ensuremocks.GenerateResult
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensuremocks.Generator
+	}{
+		{
+			Name:    "with generated mock",
+			Options: &ensuremocks.Options{Sequential: true},
+			ExpectedResult: &ensuremocks.GenerateResult{
+				Generated: []*ensuremocks.MockFile{{
+					Package: "github.com/some/pkg/abc:Iface1",
+					Path:    mockDir + "/mock_abc.go",
+				}},
+			},
+			SetupMocks: func(m *Mocks) {
+				m.Runner.EXPECT().
+					Exec(gomock.Any(), &ensuremocks.ExecParams{
+						PWD:  "/root/path",
+						CMD:  "mockgen",
+						Args: []string{"github.com/some/pkg/abc", "Iface1"},
+					}).
+					Return("package mock_abc\n", nil)
+
+				m.FSWrite.EXPECT().MkdirAll(mockDir, gomock.Any()).Return(nil)
+				m.FSWrite.EXPECT().WriteFile(mockDir+"/mock_abc.go", gomock.Any(), gomock.Any()).Return(nil)
+				m.FSWrite.EXPECT().GlobRemoveAll("/root/path/gomock_reflect_*").Return(nil)
+			},
+		},
+		{
+			Name:           "when no packages match the options",
+			Options:        &ensuremocks.Options{Match: []string{"github.com/other/*"}},
+			ExpectedResult: &ensuremocks.GenerateResult{Generated: []*ensuremocks.MockFile{}},
+			ExpectedError:  ensuremocks.ErrInvalidConfig,
+		},
+		{
+			Name:           "when mockgen fails",
+			ExpectedResult: &ensuremocks.GenerateResult{Generated: []*ensuremocks.MockFile{}},
+			ExpectedError:  ensuremocks.ErrMockGen,
+			SetupMocks: func(m *Mocks) {
+				m.Runner.EXPECT().Exec(gomock.Any(), gomock.Any()).Return("", exampleError)
+				m.FSWrite.EXPECT().GlobRemoveAll("/root/path/gomock_reflect_*").Return(nil)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Runner = entry.Mocks.Runner
+		entry.Subject.FSWrite = entry.Mocks.FSWrite
+
+		result, err := entry.Subject.Generate(context.Background(), newConfig(), entry.Options)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(result).Equals(entry.ExpectedResult)
+	})
+}
+
+func TestPlanMocks(t *testing.T) {
+	ensure := ensure.New(t)
+
+	plan, err := ensuremocks.PlanMocks(newConfig(), nil)
+	ensure(err).IsNotError()
+	ensure(plan).Equals(&ensuremocks.Plan{
+		RootPath:            "/root/path",
+		ModulePath:          "github.com/my/mod",
+		ConfigPath:          "/root/path/.ensure.yml",
+		PrimaryDestination:  "mocks",
+		InternalDestination: "mocks",
+		Packages: []*ensuremocks.PlanPackage{{
+			Path:            "github.com/some/pkg/abc",
+			PWD:             "/root/path",
+			MockPackageName: "mock_abc",
+			ImportPath:      "github.com/my/mod/mocks/github.com/some/pkg/mock_abc",
+			FilePath:        "/root/path/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+			Interfaces:      []*ensuremocks.PlanInterface{{Name: "Iface1", MockName: "MockIface1"}},
+		}},
+	})
+
+	_, err = ensuremocks.PlanMocks(&ensuremocks.Config{RootPath: "/root/path"}, nil)
+	ensure(errors.Is(err, ensuremocks.ErrInvalidConfig)).IsTrue()
+}
+
+func TestGeneratorCheck(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Runner  *mock_ensuremocks.MockRunner
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	table := []struct {
+		Name           string
+		ExpectedResult *ensuremocks.CheckResult
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensuremocks.Generator
+	}{
+		{
+			Name: "with outdated mock",
+			ExpectedResult: &ensuremocks.CheckResult{
+				Outdated: []*ensuremocks.MockFile{{
+					Package: "github.com/some/pkg/abc:Iface1",
+					Source:  "/root/path/.ensure.yml:4",
+					Path:    "/root/path/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+				}},
+				Missing: []*ensuremocks.MockFile{},
+				Extra:   []string{},
+			},
+			SetupMocks: func(m *Mocks) {
+				m.Runner.EXPECT().
+					Exec(gomock.Any(), &ensuremocks.ExecParams{
+						PWD:  "/root/path",
+						CMD:  "mockgen",
+						Args: []string{"github.com/some/pkg/abc", "Iface1"},
+					}).
+					Return("package mock_abc\n", nil)
+
+				m.FSWrite.EXPECT().
+					ReadFile("/root/path/mocks/github.com/some/pkg/mock_abc/mock_abc.go").
+					Return("package mock_abc // Old\n", nil)
+
+				// Cleanups run before returning, since no ExitCleaner is provided
+				m.FSWrite.EXPECT().GlobRemoveAll("/root/path/gomock_reflect_*").Return(nil)
+			},
+		},
+		{
+			Name:          "when mockgen fails",
+			ExpectedError: ensuremocks.ErrMockGen,
+			SetupMocks: func(m *Mocks) {
+				m.Runner.EXPECT().Exec(gomock.Any(), gomock.Any()).Return("", exampleError)
+				m.FSWrite.EXPECT().GlobRemoveAll("/root/path/gomock_reflect_*").Return(nil)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Runner = entry.Mocks.Runner
+		entry.Subject.FSWrite = entry.Mocks.FSWrite
+
+		result, err := entry.Subject.Check(context.Background(), []*ensuremocks.Config{newConfig()}, nil)
+		if entry.ExpectedError != nil {
+			ensure(errors.Is(err, entry.ExpectedError)).IsTrue()
+			return
+		}
+
+		ensure(err).IsNotError()
+		ensure(result).Equals(entry.ExpectedResult)
+	})
+}
+
+func TestGeneratorTidy(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	mockDir := "/root/path/mocks"
+
+	table := []struct {
+		Name           string
+		ExpectedResult *ensuremocks.TidyResult
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensuremocks.Generator
+	}{
+		{
+			Name: "with files to remove",
+			ExpectedResult: &ensuremocks.TidyResult{
+				Removed: []string{mockDir + "/github.com/some/pkg/mock_old", mockDir + "/github.com/some/pkg/mock_xyz"},
+			},
+			SetupMocks: func(m *Mocks) {
+				m.FSWrite.EXPECT().ListRecursive(mockDir).Return([]string{
+					mockDir + "/github.com/some/pkg/mock_xyz",
+					mockDir + "/github.com/some/pkg/mock_abc/mock_abc.go",
+					mockDir + "/github.com/some/pkg/mock_old",
+				}, nil)
+
+				m.FSWrite.EXPECT().RemoveAll(mockDir + "/github.com/some/pkg/mock_old").Return(nil)
+				m.FSWrite.EXPECT().RemoveAll(mockDir + "/github.com/some/pkg/mock_xyz").Return(nil)
+			},
+		},
+		{
+			Name:           "when unable to remove a file",
+			ExpectedResult: &ensuremocks.TidyResult{Removed: []string{}},
+			ExpectedError:  ensuremocks.ErrFileSystem,
+			SetupMocks: func(m *Mocks) {
+				m.FSWrite.EXPECT().ListRecursive(mockDir).Return([]string{mockDir + "/github.com/some/pkg/mock_old"}, nil)
+				m.FSWrite.EXPECT().RemoveAll(mockDir + "/github.com/some/pkg/mock_old").Return(exampleError)
+			},
+		},
+		{
+			Name:          "when unable to list the mock directory",
+			ExpectedError: ensuremocks.ErrFileSystem,
+			SetupMocks: func(m *Mocks) {
+				m.FSWrite.EXPECT().ListRecursive(mockDir).Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.FSWrite = entry.Mocks.FSWrite
+
+		result, err := entry.Subject.Tidy(newConfig())
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(result).Equals(entry.ExpectedResult)
+	})
+}
+
+func TestGeneratorGenerateDoesNotChangeConfig(t *testing.T) {
+	ensure := ensure.New(t)
+
+	rootPath, err := ioutil.TempDir("", "ensuremocks")
+	ensure(err).IsNotError()
+	defer os.RemoveAll(rootPath)
+
+	writeFile := func(path, contents string) {
+		ensure(os.MkdirAll(filepath.Dir(filepath.Join(rootPath, path)), 0755)).IsNotError()
+		ensure(ioutil.WriteFile(filepath.Join(rootPath, path), []byte(contents), 0600)).IsNotError()
+	}
+
+	writeFile("go.mod", "module github.com/my/mod\n")
+	writeFile(".ensure.yml", "mocks:\n  packages:\n    - path: github.com/my/mod/store\n"+
+		"      interfaces: [Store]\n      mockNames: {Store: FakeStore}\n")
+	writeFile("store/store.go", "package store\n\ntype Store interface{}\n\n//ensure:mock name=FakeCache\ntype Cache interface{}\n")
+
+	loader := ensuremocks.NewLoader()
+	config, err := loader.LoadConfig(rootPath)
+	ensure(err).IsNotError()
+
+	expectedConfig, err := loader.LoadConfig(rootPath)
+	ensure(err).IsNotError()
+
+	runner := mock_ensuremocks.NewMockRunner(ensure.GoMockController())
+	runner.EXPECT().Exec(gomock.Any(), gomock.Any()).Return("package mock_store\n", nil).Times(2)
+
+	fsWrite := mock_fswrite.NewMockFSWriteIface(ensure.GoMockController())
+	fsWrite.EXPECT().MkdirAll(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	fsWrite.EXPECT().WriteFile(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	fsWrite.EXPECT().GlobRemoveAll(gomock.Any()).Return(nil).Times(2)
+
+	generator := &ensuremocks.Generator{Runner: runner, FSWrite: fsWrite}
+
+	// Generating in parallel using the same config must not write to shared slices or maps
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = generator.Generate(context.Background(), config, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	ensure(errs).Equals([]error{nil, nil})
+
+	ensure(config).Equals(expectedConfig)
+	ensure(config.Mocks.Packages[0].Interfaces).Equals([]string{"Store"})
+	ensure(config.Mocks.Packages[0].MockNames).Equals(map[string]string{"Store": "FakeStore"})
+}
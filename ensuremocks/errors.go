@@ -0,0 +1,86 @@
+package ensuremocks
+
+import (
+	"errors"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/erk/erg"
+)
+
+// Errors returned by the Loader and Generator match one of these using errors.Is,
+// which are the same groups the CLI uses for its exit codes.
+// The messages of the returned errors are the same as the CLI's.
+var (
+	ErrInvalidConfig = errors.New("invalid .ensure.yml config")
+	ErrMockGen       = errors.New("unable to generate mocks")
+	ErrFileSystem    = errors.New("unable to read or write the mock files")
+	ErrOutOfDate     = errors.New("mocks are out of date")
+)
+
+// kindError matches one of the exported errors, while keeping the message and cause of the original error.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+// wrapError wraps the error so it matches the exported error for its erk kind.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if kind := errorKind(err); kind != nil {
+		return &kindError{kind: kind, err: err}
+	}
+
+	return err
+}
+
+func errorKind(err error) error {
+	switch erk.GetKind(err).(type) {
+	case ensurefile.ErkCannotLoadConfig, mockgen.ErkInvalidConfig, mockgen.ErkMockDestination, modfiles.ErkCannotParseFile:
+		return ErrInvalidConfig
+	case mockgen.ErkMockGenError, mockgen.ErkTemplateError:
+		return ErrMockGen
+	case mockgen.ErkFSWriteError, mockgen.ErkFSReadError, mockgen.ErkUnableToTidy, modfiles.ErkCannotFindFiles:
+		return ErrFileSystem
+	case mockgen.ErkMocksOutOfDate:
+		return ErrOutOfDate
+	case mockgen.ErkMultipleFailures:
+		return groupErrorKind(erg.GetErrors(err))
+	default:
+		return nil
+	}
+}
+
+// groupErrorKind returns the kind shared by all the errors.
+// If they differ, the mocks failed to generate for a variety of reasons.
+func groupErrorKind(errs []error) error {
+	if len(errs) == 0 {
+		return ErrMockGen
+	}
+
+	kind := errorKind(errs[0])
+	for _, err := range errs[1:] {
+		if errorKind(err) != kind {
+			return ErrMockGen
+		}
+	}
+
+	return kind
+}
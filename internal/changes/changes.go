@@ -0,0 +1,245 @@
+// Package changes finds the configured packages affected by changed files, such as the files changed since a git ref.
+package changes
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/erk"
+)
+
+type ErkChangesError struct{ erk.DefaultKind }
+
+var (
+	ErrCannotFindMergeBase = erk.New(ErkChangesError{}, "Could not find a common ancestor of '{{.ref}}' and HEAD: {{.err}}")
+	ErrCannotListChanges   = erk.New(ErkChangesError{}, "Could not list the files changed since '{{.ref}}': {{.err}}")
+	ErrCannotListStaged    = erk.New(ErkChangesError{}, "Could not list the staged files: {{.err}}")
+	ErrCannotListPackages  = erk.New(ErkChangesError{}, "Could not list the packages in '{{.path}}': {{.err}}")
+)
+
+// Result lists the configured packages affected by the changes.
+type Result struct {
+	// All is true when every package may be affected, such as when go.mod, a config file, or a template changed.
+	All bool
+
+	// Packages are the paths of the configured packages that are affected, when All is false.
+	Packages []string
+}
+
+type DetectorIface interface {
+	Affected(ctx context.Context, config *ensurefile.Config, ref string) (*Result, error)
+	AffectedByStaged(ctx context.Context, config *ensurefile.Config) (*Result, error)
+}
+
+// Detector uses git to list the changed files, and `go list` to map them to packages.
+type Detector struct {
+	CmdRun runcmd.RunnerIface
+}
+
+var _ DetectorIface = &Detector{}
+
+// goPackage is a package within the module, as listed by `go list`.
+type goPackage struct {
+	path    string
+	dir     string
+	imports []string
+}
+
+// Affected returns the configured packages whose Go files changed since the merge base of ref and HEAD,
+// along with the configured packages that import them through packages within the module.
+// Uncommitted and untracked files are included. Test files are ignored, since they are not used to generate mocks.
+func (d *Detector) Affected(ctx context.Context, config *ensurefile.Config, ref string) (*Result, error) {
+	changedPaths, err := d.changedPaths(ctx, config.RootPath, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.affected(ctx, config, changedPaths)
+}
+
+// AffectedByStaged is like Affected, but only considers the files staged in git's index, such as in a pre-commit hook.
+func (d *Detector) AffectedByStaged(ctx context.Context, config *ensurefile.Config) (*Result, error) {
+	out, err := d.git(ctx, config.RootPath, "diff", "--cached", "--name-only", "--relative", "--")
+	if err != nil {
+		return nil, erk.WrapAs(ErrCannotListStaged, err)
+	}
+
+	return d.affected(ctx, config, splitPaths(config.RootPath, out))
+}
+
+// affected returns the configured packages affected by the changed paths.
+// Changed mock files are mapped back to their packages, so edited or deleted mocks are regenerated.
+func (d *Detector) affected(ctx context.Context, config *ensurefile.Config, changedPaths []string) (*Result, error) {
+	templatePaths := map[string]bool{}
+	for _, templatePath := range config.TemplatePaths() {
+		templatePaths[templatePath] = true
+	}
+
+	changedDirs := map[string]bool{}
+	for _, changedPath := range changedPaths {
+		name := filepath.Base(changedPath)
+
+		// Dependencies and destinations can change for any package, and templates are appended to every mock
+		if isModuleFileName(name) || ensurefile.IsConfigFileName(name) || templatePaths[changedPath] {
+			return &Result{All: true}, nil
+		}
+
+		if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			changedDirs[filepath.Dir(changedPath)] = true
+		}
+	}
+
+	result := &Result{Packages: []string{}}
+	if len(changedDirs) == 0 {
+		return result, nil
+	}
+
+	// Packages can also be listed by annotations, and each package has a single mock file
+	configCopy := *config
+	configCopy.PackageFilters = nil
+	configCopy.ChangedPackages = nil
+	resolved, err := mockgen.ResolveConfig(&configCopy)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs, err := d.listPackages(ctx, config.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	affected := affectedPackages(pkgs, changedDirs)
+	for _, changedPath := range changedPaths {
+		for _, pkg := range resolved.Packages {
+			if pkg.FilePath == changedPath {
+				affected[pkg.Path] = true
+			}
+		}
+	}
+
+	for _, pkg := range resolved.Packages {
+		if affected[pkg.Path] {
+			result.Packages = append(result.Packages, pkg.Path)
+		}
+	}
+
+	sort.Strings(result.Packages)
+	return result, nil
+}
+
+// changedPaths returns the absolute paths of the files within rootPath that changed since the merge base of ref and HEAD.
+func (d *Detector) changedPaths(ctx context.Context, rootPath, ref string) ([]string, error) {
+	mergeBase, err := d.git(ctx, rootPath, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotFindMergeBase, err, erk.Params{
+			"ref": ref,
+		})
+	}
+
+	// Compares the merge base to the working tree, so uncommitted changes are included
+	diff, err := d.git(ctx, rootPath, "diff", "--name-only", "--relative", strings.TrimSpace(mergeBase), "--")
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotListChanges, err, erk.Params{
+			"ref": ref,
+		})
+	}
+
+	untracked, err := d.git(ctx, rootPath, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotListChanges, err, erk.Params{
+			"ref": ref,
+		})
+	}
+
+	return append(splitPaths(rootPath, diff), splitPaths(rootPath, untracked)...), nil
+}
+
+func (d *Detector) git(ctx context.Context, rootPath string, args ...string) (string, error) {
+	return d.CmdRun.Exec(ctx, &runcmd.ExecParams{PWD: rootPath, CMD: "git", Args: args})
+}
+
+// splitPaths converts the lines of paths relative to rootPath, as output by git, to absolute paths.
+func splitPaths(rootPath, out string) []string {
+	paths := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, filepath.Join(rootPath, filepath.FromSlash(line)))
+		}
+	}
+
+	return paths
+}
+
+// listPackages returns the packages within the module rooted at rootPath.
+func (d *Detector) listPackages(ctx context.Context, rootPath string) ([]*goPackage, error) {
+	out, err := d.CmdRun.Exec(ctx, &runcmd.ExecParams{
+		PWD:  rootPath,
+		CMD:  "go",
+		Args: []string{"list", "-e", "-f", "{{.ImportPath}}\t{{.Dir}}\t{{join .Imports \" \"}}", "./..."},
+	})
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotListPackages, err, erk.Params{
+			"path": rootPath,
+		})
+	}
+
+	pkgs := []*goPackage{}
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "\t")
+		if len(parts) < 2 || parts[1] == "" {
+			continue
+		}
+
+		pkg := &goPackage{path: parts[0], dir: parts[1]}
+		if len(parts) > 2 {
+			pkg.imports = strings.Fields(parts[2])
+		}
+
+		pkgs = append(pkgs, pkg)
+	}
+
+	return pkgs, nil
+}
+
+// affectedPackages returns the paths of the packages in the changed directories,
+// along with the packages that import them, directly or through other packages within the module.
+func affectedPackages(pkgs []*goPackage, changedDirs map[string]bool) map[string]bool {
+	importers := map[string][]string{}
+	for _, pkg := range pkgs {
+		for _, imp := range pkg.imports {
+			importers[imp] = append(importers[imp], pkg.path)
+		}
+	}
+
+	affected := map[string]bool{}
+	queue := []string{}
+	for _, pkg := range pkgs {
+		if changedDirs[pkg.dir] {
+			affected[pkg.path] = true
+			queue = append(queue, pkg.path)
+		}
+	}
+
+	for len(queue) > 0 {
+		pkgPath := queue[0]
+		queue = queue[1:]
+
+		for _, importer := range importers[pkgPath] {
+			if !affected[importer] {
+				affected[importer] = true
+				queue = append(queue, importer)
+			}
+		}
+	}
+
+	return affected
+}
+
+func isModuleFileName(name string) bool {
+	return name == "go.mod" || name == "go.sum" || name == "go.work" || name == "go.work.sum"
+}
@@ -0,0 +1,279 @@
+package changes_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/changes"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestAffected(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		CmdRun *mock_runcmd.MockRunnerIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	config := &ensurefile.Config{
+		RootPath:   "/repo/api",
+		ModulePath: "github.com/my/api",
+		Mocks: &ensurefile.MockConfig{
+			Templates: []*ensurefile.Template{{Path: "templates/mock.tmpl"}},
+			Packages: []*ensurefile.Package{
+				{Path: "github.com/my/api/internal/store", Interfaces: []string{"Store"}},
+				{Path: "github.com/my/api/internal/queue", Interfaces: []string{"Queue"}},
+				{Path: "github.com/other/ext", Interfaces: []string{"Client"}},
+			},
+		},
+	}
+
+	gitParams := func(args ...string) *runcmd.ExecParams {
+		return &runcmd.ExecParams{PWD: "/repo/api", CMD: "git", Args: args}
+	}
+
+	expectChanges := func(m *Mocks, diff, untracked string) {
+		m.CmdRun.EXPECT().Exec(context.Background(), gitParams("merge-base", "origin/main", "HEAD")).Return("abc123\n", nil)
+		m.CmdRun.EXPECT().Exec(context.Background(), gitParams("diff", "--name-only", "--relative", "abc123", "--")).Return(diff, nil)
+		m.CmdRun.EXPECT().Exec(context.Background(), gitParams("ls-files", "--others", "--exclude-standard")).Return(untracked, nil)
+	}
+
+	goListParams := &runcmd.ExecParams{
+		PWD:  "/repo/api",
+		CMD:  "go",
+		Args: []string{"list", "-e", "-f", "{{.ImportPath}}\t{{.Dir}}\t{{join .Imports \" \"}}", "./..."},
+	}
+
+	goListOutput := "github.com/my/api/internal/store\t/repo/api/internal/store\tcontext github.com/my/api/internal/model\n" +
+		"github.com/my/api/internal/model\t/repo/api/internal/model\t\n" +
+		"github.com/my/api/internal/queue\t/repo/api/internal/queue\tgithub.com/my/api/internal/db\n" +
+		"github.com/my/api/internal/db\t/repo/api/internal/db\tgithub.com/my/api/internal/model github.com/other/ext\n"
+
+	table := []struct {
+		Name           string
+		ExpectedResult *changes.Result
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *changes.Detector
+	}{
+		{
+			Name:           "with changed package",
+			ExpectedResult: &changes.Result{Packages: []string{"github.com/my/api/internal/store"}},
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "internal/store/store.go\nREADME.md\n", "")
+				m.CmdRun.EXPECT().Exec(context.Background(), goListParams).Return(goListOutput, nil)
+			},
+		},
+		{
+			Name:           "with changed package imported by a configured package",
+			ExpectedResult: &changes.Result{Packages: []string{"github.com/my/api/internal/queue"}},
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "internal/db/db.go\n", "")
+				m.CmdRun.EXPECT().Exec(context.Background(), goListParams).Return(goListOutput, nil)
+			},
+		},
+		{
+			Name: "with changed package imported transitively",
+			ExpectedResult: &changes.Result{Packages: []string{
+				"github.com/my/api/internal/queue",
+				"github.com/my/api/internal/store",
+			}},
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "internal/model/model.go\n", "")
+				m.CmdRun.EXPECT().Exec(context.Background(), goListParams).Return(goListOutput, nil)
+			},
+		},
+		{
+			Name:           "with untracked file",
+			ExpectedResult: &changes.Result{Packages: []string{"github.com/my/api/internal/store"}},
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "", "internal/store/new.go\n")
+				m.CmdRun.EXPECT().Exec(context.Background(), goListParams).Return(goListOutput, nil)
+			},
+		},
+		{
+			Name:           "with only test files and other files changed",
+			ExpectedResult: &changes.Result{Packages: []string{}},
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "internal/store/store_test.go\nREADME.md\n", "")
+			},
+		},
+		{
+			Name:           "when go.mod changed",
+			ExpectedResult: &changes.Result{All: true},
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "internal/store/store.go\ngo.mod\n", "")
+			},
+		},
+		{
+			Name:           "when a nested config file changed",
+			ExpectedResult: &changes.Result{All: true},
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "", "internal/.ensure.yml\n")
+			},
+		},
+		{
+			Name:           "when a template changed",
+			ExpectedResult: &changes.Result{All: true},
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "templates/mock.tmpl\n", "")
+			},
+		},
+		{
+			Name:          "when unable to find the merge base",
+			ExpectedError: changes.ErrCannotFindMergeBase,
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), gitParams("merge-base", "origin/main", "HEAD")).Return("", exampleError)
+			},
+		},
+		{
+			Name:          "when unable to list changed files",
+			ExpectedError: changes.ErrCannotListChanges,
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), gitParams("merge-base", "origin/main", "HEAD")).Return("abc123\n", nil)
+				m.CmdRun.EXPECT().Exec(context.Background(), gitParams("diff", "--name-only", "--relative", "abc123", "--")).Return("", exampleError)
+			},
+		},
+		{
+			Name:          "when unable to list untracked files",
+			ExpectedError: changes.ErrCannotListChanges,
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), gitParams("merge-base", "origin/main", "HEAD")).Return("abc123\n", nil)
+				m.CmdRun.EXPECT().Exec(context.Background(), gitParams("diff", "--name-only", "--relative", "abc123", "--")).Return("", nil)
+				m.CmdRun.EXPECT().Exec(context.Background(), gitParams("ls-files", "--others", "--exclude-standard")).Return("", exampleError)
+			},
+		},
+		{
+			Name:          "when unable to list packages",
+			ExpectedError: changes.ErrCannotListPackages,
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "internal/store/store.go\n", "")
+				m.CmdRun.EXPECT().Exec(context.Background(), goListParams).Return("", exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		result, err := entry.Subject.Affected(context.Background(), config, "origin/main")
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(result).Equals(entry.ExpectedResult)
+	})
+}
+
+func TestAffectedByStaged(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		CmdRun *mock_runcmd.MockRunnerIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	config := &ensurefile.Config{
+		RootPath:   "/repo/api",
+		ModulePath: "github.com/my/api",
+		Mocks: &ensurefile.MockConfig{
+			Templates: []*ensurefile.Template{{Path: "templates/mock.tmpl"}},
+			Packages: []*ensurefile.Package{
+				{Path: "github.com/my/api/internal/store", Interfaces: []string{"Store"}},
+				{Path: "github.com/my/api/internal/queue", Interfaces: []string{"Queue"}},
+			},
+		},
+		Annotations: []*ensurefile.Annotation{
+			{PackagePath: "github.com/my/api/internal/db", Interface: "DB", File: "/repo/api/internal/db/db.go", Line: 5},
+		},
+	}
+
+	stagedParams := &runcmd.ExecParams{
+		PWD:  "/repo/api",
+		CMD:  "git",
+		Args: []string{"diff", "--cached", "--name-only", "--relative", "--"},
+	}
+
+	goListParams := &runcmd.ExecParams{
+		PWD:  "/repo/api",
+		CMD:  "go",
+		Args: []string{"list", "-e", "-f", "{{.ImportPath}}\t{{.Dir}}\t{{join .Imports \" \"}}", "./..."},
+	}
+
+	goListOutput := "github.com/my/api/internal/store\t/repo/api/internal/store\t\n" +
+		"github.com/my/api/internal/queue\t/repo/api/internal/queue\tgithub.com/my/api/internal/db\n" +
+		"github.com/my/api/internal/db\t/repo/api/internal/db\t\n"
+
+	table := []struct {
+		Name           string
+		ExpectedResult *changes.Result
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *changes.Detector
+	}{
+		{
+			Name: "with staged package listed by an annotation",
+			ExpectedResult: &changes.Result{Packages: []string{
+				"github.com/my/api/internal/db",
+				"github.com/my/api/internal/queue",
+			}},
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), stagedParams).Return("internal/db/db.go\n", nil)
+				m.CmdRun.EXPECT().Exec(context.Background(), goListParams).Return(goListOutput, nil)
+			},
+		},
+		{
+			Name:           "with staged mock file",
+			ExpectedResult: &changes.Result{Packages: []string{"github.com/my/api/internal/store"}},
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), stagedParams).Return("internal/mocks/mock_store/mock_store.go\n", nil)
+				m.CmdRun.EXPECT().Exec(context.Background(), goListParams).Return(goListOutput, nil)
+			},
+		},
+		{
+			Name:           "without staged files",
+			ExpectedResult: &changes.Result{Packages: []string{}},
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), stagedParams).Return("", nil)
+			},
+		},
+		{
+			Name:           "with staged config file",
+			ExpectedResult: &changes.Result{All: true},
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), stagedParams).Return(".ensure.yml\n", nil)
+			},
+		},
+		{
+			Name:           "with staged template",
+			ExpectedResult: &changes.Result{All: true},
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), stagedParams).Return("templates/mock.tmpl\n", nil)
+			},
+		},
+		{
+			Name:          "when unable to list staged files",
+			ExpectedError: changes.ErrCannotListStaged,
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), stagedParams).Return("", exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		result, err := entry.Subject.AffectedByStaged(context.Background(), config)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(result).Equals(entry.ExpectedResult)
+	})
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/erk"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatYAML = "yaml"
+	formatJSON = "json"
+)
+
+var ErrInvalidConfigFormat = erk.New(ErkInvalidFlag{}, "Invalid config format '{{.format}}'. It must be either 'yaml' or 'json'.")
+
+func (a *App) configCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "commands related to the .ensure.yml config",
+		Subcommands: []*cli.Command{
+			a.configShowCmd(),
+			a.configSchemaCmd(),
+			a.configValidateCmd(),
+			a.configMigrateCmd(),
+		},
+	}
+}
+
+func (a *App) configShowCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "show",
+		Usage:     "prints the effective config, including defaults and the destination of each mock",
+		ArgsUsage: "[package patterns...]",
+		Description: "Prints the module root, module path, destinations after defaults are applied,\n" +
+			"and the working directory and file that mockgen uses for every package and interface.\n" +
+			"The sources show whether each overridable field came from the default, the config file, an ENSURE_* environment variable, or a flag.\n" +
+			"Only the scalar mock options can be overridden; lists such as mocks.packages and mocks.templates are only read from .ensure.yml.\n" +
+			"Package patterns limit the packages that are printed, and match like `ensure mocks generate`.\n" +
+			"Within a workspace, the config of each module is printed, as separate YAML documents or JSON objects.",
+
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Value: formatYAML,
+				Usage: "Format of the printed config, either 'yaml' or 'json'. Defaults to 'json' when using '--output json'",
+			},
+			&cli.StringSliceFlag{
+				Name:  "match",
+				Usage: "Only prints packages matching the pattern; can be repeated",
+			},
+		}, configOverrideFlags()...),
+
+		Action: func(c *cli.Context) error {
+			format := c.String("format")
+			if !c.IsSet("format") && c.String("output") == outputJSON {
+				format = formatJSON
+			}
+
+			if format != formatYAML && format != formatJSON {
+				return erk.WithParams(ErrInvalidConfigFormat, erk.Params{
+					"format": format,
+				})
+			}
+
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			configs, err := a.EnsureFileLoader.LoadConfigs(pwd)
+			if err != nil {
+				return err
+			}
+
+			if err := applyConfigOverrides(c, configs...); err != nil {
+				return err
+			}
+
+			filters := packageFilters(c)
+			resolvedConfigs := make([]*mockgen.ResolvedConfig, 0, len(configs))
+			for _, config := range configs {
+				config.PackageFilters = filters
+				resolved, err := mockgen.ResolveConfig(config)
+				if err != nil {
+					return err
+				}
+
+				resolvedConfigs = append(resolvedConfigs, resolved)
+			}
+
+			if format == formatJSON {
+				encoder := json.NewEncoder(a.Stdout)
+				encoder.SetIndent("", "  ")
+				for _, resolved := range resolvedConfigs {
+					if err := encoder.Encode(resolved); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			}
+
+			// Each config is printed as a separate YAML document
+			encoder := yaml.NewEncoder(a.Stdout)
+			encoder.SetIndent(2)
+			for _, resolved := range resolvedConfigs {
+				if err := encoder.Encode(resolved); err != nil {
+					return err
+				}
+			}
+
+			return encoder.Close()
+		},
+	}
+}
+
+func (a *App) configSchemaCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "schema",
+		Usage: "prints the JSON Schema of the .ensure.yml config, for editor completion and validation",
+		Description: "The schema is the same one used by `ensure config validate`.\n" +
+			"For example, it can be saved and referenced in the YAML language server's settings.",
+
+		Action: func(c *cli.Context) error {
+			encoder := json.NewEncoder(a.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(ensurefile.Schema())
+		},
+	}
+}
+
+// validateOutput is printed by `ensure config validate` when using JSON output.
+type validateOutput struct {
+	Path     string                    `json:"path"`
+	Problems []*ensurefile.SchemaError `json:"problems"`
+}
+
+func (a *App) configValidateCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Usage:     "checks the .ensure.yml config against the schema printed by `ensure config schema`",
+		ArgsUsage: "[config file]",
+		Description: "Validates the config file of the current module, or the provided file.\n" +
+			"Each problem is printed with the line of the config file on which it occurs.\n" +
+			"When using '--output json', the problems are printed as a single JSON object instead.",
+
+		Action: func(c *cli.Context) error {
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			configPath := c.Args().First()
+			if configPath == "" {
+				configPath, err = a.EnsureFileLoader.FindConfigFile(pwd)
+				if err != nil {
+					return err
+				}
+			} else if !filepath.IsAbs(configPath) {
+				configPath = filepath.Join(pwd, configPath)
+			}
+
+			problems, err := a.EnsureFileLoader.ValidateConfigFile(configPath)
+			if err != nil {
+				return err
+			}
+
+			if c.String("output") == outputJSON {
+				output := &validateOutput{Path: configPath, Problems: problems}
+				if output.Problems == nil {
+					output.Problems = []*ensurefile.SchemaError{}
+				}
+
+				if err := json.NewEncoder(a.Stdout).Encode(output); err != nil {
+					return err
+				}
+			} else {
+				for _, problem := range problems {
+					fmt.Fprintf(a.Stdout, "%s:%d: %s %s\n", configPath, problem.Line, problem.Field, problem.Message)
+				}
+			}
+
+			if len(problems) == 0 {
+				a.Logger.Printf("%s is valid", configPath)
+				return nil
+			}
+
+			return erk.WithParams(ensurefile.ErrInvalidConfig, erk.Params{
+				"path":  configPath,
+				"count": len(problems),
+			})
+		},
+	}
+}
+
+func (a *App) configMigrateCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "upgrades the .ensure.yml config to the current version of the config format",
+		Description: "Rewrites the config file of the current module and any nested config files in place, preserving their comments.\n" +
+			"Config files without a version key are from before versions were introduced, and are upgraded to version " +
+			strconv.Itoa(ensurefile.CurrentVersion) + ".",
+
+		Action: func(c *cli.Context) error {
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			migrations, err := a.ConfigMigrator.Migrate(pwd)
+			if err != nil {
+				return err
+			}
+
+			if c.String("output") == outputJSON {
+				return json.NewEncoder(a.Stdout).Encode(migrations)
+			}
+
+			for _, migration := range migrations {
+				if migration.Migrated() {
+					a.Logger.Printf("Migrated %s from version %d to %d", migration.Path, migration.FromVersion, migration.ToVersion)
+				} else {
+					a.Logger.Printf("%s is already version %d", migration.Path, migration.ToVersion)
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+// configOverrideFlags returns a flag for each config field that can be overridden, such as --primary-destination.
+// Flags take precedence over the config file and ENSURE_* environment variables.
+func configOverrideFlags() []cli.Flag {
+	flags := []cli.Flag{}
+	for _, field := range ensurefile.OverridableFields() {
+		usage := field.Usage + " (env " + field.EnvVar + ")"
+		if field.IsBool {
+			flags = append(flags, &cli.BoolFlag{Name: field.Flag, Usage: usage})
+			continue
+		}
+
+		flags = append(flags, &cli.StringFlag{Name: field.Flag, Usage: usage})
+	}
+
+	return flags
+}
+
+// applyConfigOverrides applies the override flags that are set to each config.
+func applyConfigOverrides(c *cli.Context, configs ...*ensurefile.Config) error {
+	overrides := []*ensurefile.Override{}
+	for _, field := range ensurefile.OverridableFields() {
+		if !c.IsSet(field.Flag) {
+			continue
+		}
+
+		value := c.String(field.Flag)
+		if field.IsBool {
+			value = strconv.FormatBool(c.Bool(field.Flag))
+		}
+
+		overrides = append(overrides, &ensurefile.Override{
+			Key:    field.Key,
+			Value:  value,
+			Source: "flag --" + field.Flag,
+		})
+	}
+
+	for _, config := range configs {
+		if err := config.ApplyOverrides(overrides); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,509 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/configmigrate"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_configmigrate"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestConfigShow(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		Reporter         *mock_report.MockReporterIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/test", nil
+	}
+
+	newConfig := func() *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			ConfigPath: "/my/app/.ensure.yml",
+			Sources:    map[string]string{"mocks.primaryDestination": "/my/app/.ensure.yml"},
+			Mocks: &ensurefile.MockConfig{
+				PrimaryDestination: "internal/mocks",
+				Packages: []*ensurefile.Package{
+					{
+						Path:       "github.com/my/app/some/pkg",
+						Interfaces: []string{"Iface1"},
+					},
+					{
+						Path:       "github.com/my/app/internal/pkg",
+						Interfaces: []string{"Iface2"},
+					},
+				},
+			},
+		}
+	}
+
+	const expectedYAML = `rootPath: /my/app
+modulePath: github.com/my/app
+configPath: /my/app/.ensure.yml
+primaryDestination: internal/mocks
+internalDestination: mocks
+tidyAfterGenerate: false
+typed: false
+sources:
+  mocks.internalDestination: default
+  mocks.primaryDestination: /my/app/.ensure.yml
+  mocks.tidyAfterGenerate: default
+  mocks.typed: default
+packages:
+  - path: github.com/my/app/some/pkg
+    pwd: /my/app
+    mockPackageName: mock_pkg
+    importPath: github.com/my/app/internal/mocks/github.com/my/app/some/mock_pkg
+    filePath: /my/app/internal/mocks/github.com/my/app/some/mock_pkg/mock_pkg.go
+    typed: false
+    interfaces:
+      - name: Iface1
+        mockName: MockIface1
+  - path: github.com/my/app/internal/pkg
+    pwd: /my/app
+    mockPackageName: mock_pkg
+    importPath: github.com/my/app/internal/mocks/mock_pkg
+    filePath: /my/app/internal/mocks/mock_pkg/mock_pkg.go
+    typed: false
+    interfaces:
+      - name: Iface2
+        mockName: MockIface2
+`
+
+	const expectedFilteredJSON = `{
+  "rootPath": "/my/app",
+  "modulePath": "github.com/my/app",
+  "configPath": "/my/app/.ensure.yml",
+  "primaryDestination": "internal/mocks",
+  "internalDestination": "mocks",
+  "tidyAfterGenerate": false,
+  "typed": false,
+  "sources": {
+    "mocks.internalDestination": "default",
+    "mocks.primaryDestination": "/my/app/.ensure.yml",
+    "mocks.tidyAfterGenerate": "default",
+    "mocks.typed": "default"
+  },
+  "packages": [
+    {
+      "path": "github.com/my/app/internal/pkg",
+      "pwd": "/my/app",
+      "mockPackageName": "mock_pkg",
+      "importPath": "github.com/my/app/internal/mocks/mock_pkg",
+      "filePath": "/my/app/internal/mocks/mock_pkg/mock_pkg.go",
+      "typed": false,
+      "interfaces": [
+        {
+          "name": "Iface2",
+          "mockName": "MockIface2"
+        }
+      ]
+    }
+  ]
+}
+`
+
+	const expectedOverriddenJSON = `{
+  "rootPath": "/my/app",
+  "modulePath": "github.com/my/app",
+  "configPath": "/my/app/.ensure.yml",
+  "primaryDestination": "internal/mocks",
+  "internalDestination": "fakes",
+  "tidyAfterGenerate": false,
+  "typed": true,
+  "sources": {
+    "mocks.internalDestination": "flag --internal-destination",
+    "mocks.primaryDestination": "/my/app/.ensure.yml",
+    "mocks.tidyAfterGenerate": "flag --tidy-after-generate",
+    "mocks.typed": "flag --typed"
+  },
+  "packages": [
+    {
+      "path": "github.com/my/app/internal/pkg",
+      "pwd": "/my/app",
+      "mockPackageName": "mock_pkg",
+      "importPath": "github.com/my/app/internal/fakes/mock_pkg",
+      "filePath": "/my/app/internal/fakes/mock_pkg/mock_pkg.go",
+      "typed": true,
+      "interfaces": [
+        {
+          "name": "Iface2",
+          "mockName": "MockIface2"
+        }
+      ]
+    }
+  ]
+}
+`
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput string
+		ExpectedError  error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:           "with yaml format",
+			Args:           []string{"ensure", "config", "show"},
+			Getwd:          defaultWd,
+			ExpectedOutput: expectedYAML,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{newConfig()}, nil)
+			},
+		},
+
+		{
+			Name:           "within a workspace",
+			Args:           []string{"ensure", "config", "show"},
+			Getwd:          defaultWd,
+			ExpectedOutput: expectedYAML + "---\n" + strings.ReplaceAll(expectedYAML, "/my/app", "/my/lib"),
+			SetupMocks: func(m *Mocks) {
+				libConfig := newConfig()
+				libConfig.RootPath = "/my/lib"
+				libConfig.ModulePath = "github.com/my/lib"
+				libConfig.ConfigPath = "/my/lib/.ensure.yml"
+				libConfig.Sources = map[string]string{"mocks.primaryDestination": "/my/lib/.ensure.yml"}
+				libConfig.Mocks.Packages[0].Path = "github.com/my/lib/some/pkg"
+				libConfig.Mocks.Packages[1].Path = "github.com/my/lib/internal/pkg"
+
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{newConfig(), libConfig}, nil)
+			},
+		},
+
+		{
+			Name:           "with json format and package filters",
+			Args:           []string{"ensure", "config", "show", "--format", "json", "internal/*"},
+			Getwd:          defaultWd,
+			ExpectedOutput: expectedFilteredJSON,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{newConfig()}, nil)
+			},
+		},
+
+		{
+			Name:           "with json output",
+			Args:           []string{"ensure", "--output", "json", "config", "show", "--match", "internal/*"},
+			Getwd:          defaultWd,
+			ExpectedOutput: expectedFilteredJSON,
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{newConfig()}, nil)
+			},
+		},
+
+		{
+			Name: "with override flags",
+			Args: []string{
+				"ensure", "config", "show", "--format", "json", "--match", "internal/*",
+				"--internal-destination", "fakes", "--typed", "--tidy-after-generate=false",
+			},
+			Getwd:          defaultWd,
+			ExpectedOutput: expectedOverriddenJSON,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{newConfig()}, nil)
+			},
+		},
+
+		{
+			Name:          "with invalid format",
+			Args:          []string{"ensure", "config", "show", "--format", "toml"},
+			Getwd:         defaultWd,
+			ExpectedError: cmd.ErrInvalidConfigFormat,
+		},
+
+		{
+			Name:          "when error loading working directory",
+			Args:          []string{"ensure", "config", "show"},
+			Getwd:         func() (string, error) { return "", exampleError },
+			ExpectedError: exampleError,
+		},
+
+		{
+			Name:          "when cannot load config",
+			Args:          []string{"ensure", "config", "show"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when cannot resolve config",
+			Args:          []string{"ensure", "config", "show"},
+			Getwd:         defaultWd,
+			ExpectedError: mockgen.ErrMissingMockConfig,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{&ensurefile.Config{}}, nil)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		var stdout bytes.Buffer
+		entry.Subject.Stdout = &stdout
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(stdout.String()).Equals(entry.ExpectedOutput)
+	})
+}
+
+func TestConfigSchema(t *testing.T) {
+	ensure := ensure.New(t)
+
+	app := &cmd.App{}
+
+	var stdout bytes.Buffer
+	app.Stdout = &stdout
+	app.Logger = log.New(ioutil.Discard, "", 0)
+
+	err := app.Run([]string{"ensure", "config", "schema"})
+	ensure(err).IsNotError()
+
+	var schema ensurefile.JSONSchema
+	ensure(json.Unmarshal(stdout.Bytes(), &schema)).IsNotError()
+	ensure(schema.Schema).Equals("http://json-schema.org/draft-07/schema#")
+	ensure(schema.Properties["mocks"].Properties["packages"].Items.Required).Equals([]string{"path", "interfaces"})
+}
+
+func TestConfigValidate(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		Reporter         *mock_report.MockReporterIface `ensure:"ignoreunused"`
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/test", nil
+	}
+
+	problems := []*ensurefile.SchemaError{
+		{Line: 2, Field: "mocks.primaryDestinaton", Message: "is not a known field"},
+		{Line: 4, Field: "mocks.packages[0].interfaces", Message: "is required"},
+	}
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput string
+		ExpectedError  error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:  "with valid config file of the current module",
+			Args:  []string{"ensure", "config", "validate"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().FindConfigFile("/test").Return("/my/app/.ensure.yml", nil)
+				m.EnsureFileLoader.EXPECT().ValidateConfigFile("/my/app/.ensure.yml").Return(nil, nil)
+			},
+		},
+
+		{
+			Name:  "with relative config file",
+			Args:  []string{"ensure", "config", "validate", "configs/ensure.yml"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().ValidateConfigFile("/test/configs/ensure.yml").Return(nil, nil)
+			},
+		},
+
+		{
+			Name:  "with invalid config file",
+			Args:  []string{"ensure", "config", "validate", "/my/app/.ensure.yml"},
+			Getwd: defaultWd,
+			ExpectedOutput: "/my/app/.ensure.yml:2: mocks.primaryDestinaton is not a known field\n" +
+				"/my/app/.ensure.yml:4: mocks.packages[0].interfaces is required\n",
+			ExpectedError: ensurefile.ErrInvalidConfig,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().ValidateConfigFile("/my/app/.ensure.yml").Return(problems, nil)
+			},
+		},
+
+		{
+			Name:           "with valid config file and json output",
+			Args:           []string{"ensure", "--output", "json", "config", "validate", "/my/app/.ensure.yml"},
+			Getwd:          defaultWd,
+			ExpectedOutput: `{"path":"/my/app/.ensure.yml","problems":[]}` + "\n",
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.EnsureFileLoader.EXPECT().ValidateConfigFile("/my/app/.ensure.yml").Return(nil, nil)
+			},
+		},
+
+		{
+			Name:  "with invalid config file and json output",
+			Args:  []string{"ensure", "--output", "json", "config", "validate", "/my/app/.ensure.yml"},
+			Getwd: defaultWd,
+			ExpectedOutput: `{"path":"/my/app/.ensure.yml","problems":[` +
+				`{"line":2,"field":"mocks.primaryDestinaton","message":"is not a known field"},` +
+				`{"line":4,"field":"mocks.packages[0].interfaces","message":"is required"}]}` + "\n",
+			ExpectedError: ensurefile.ErrInvalidConfig,
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.EnsureFileLoader.EXPECT().ValidateConfigFile("/my/app/.ensure.yml").Return(problems, nil)
+			},
+		},
+
+		{
+			Name:          "when error loading working directory",
+			Args:          []string{"ensure", "config", "validate"},
+			Getwd:         func() (string, error) { return "", exampleError },
+			ExpectedError: exampleError,
+		},
+
+		{
+			Name:          "when cannot find config file",
+			Args:          []string{"ensure", "config", "validate"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().FindConfigFile("/test").Return("", exampleError)
+			},
+		},
+
+		{
+			Name:          "when cannot validate config file",
+			Args:          []string{"ensure", "config", "validate"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().FindConfigFile("/test").Return("/my/app/.ensure.yml", nil)
+				m.EnsureFileLoader.EXPECT().ValidateConfigFile("/my/app/.ensure.yml").Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		var stdout bytes.Buffer
+		entry.Subject.Stdout = &stdout
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(stdout.String()).Equals(entry.ExpectedOutput)
+	})
+}
+
+func TestConfigMigrate(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		ConfigMigrator *mock_configmigrate.MockMigratorIface
+		Reporter       *mock_report.MockReporterIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/test", nil
+	}
+
+	migrations := []*configmigrate.Migration{
+		{Path: "/my/app/.ensure.yml", FromVersion: 0, ToVersion: 1},
+		{Path: "/my/app/store/.ensure.yml", FromVersion: 1, ToVersion: 1},
+	}
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput string
+		ExpectedLogs   string
+		ExpectedError  error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:  "with text output",
+			Args:  []string{"ensure", "config", "migrate"},
+			Getwd: defaultWd,
+			ExpectedLogs: "Migrated /my/app/.ensure.yml from version 0 to 1\n" +
+				"/my/app/store/.ensure.yml is already version 1\n",
+			SetupMocks: func(m *Mocks) {
+				m.ConfigMigrator.EXPECT().Migrate("/test").Return(migrations, nil)
+			},
+		},
+
+		{
+			Name:  "with json output",
+			Args:  []string{"ensure", "--output", "json", "config", "migrate"},
+			Getwd: defaultWd,
+			ExpectedOutput: `[{"path":"/my/app/.ensure.yml","fromVersion":0,"toVersion":1},` +
+				`{"path":"/my/app/store/.ensure.yml","fromVersion":1,"toVersion":1}]` + "\n",
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.ConfigMigrator.EXPECT().Migrate("/test").Return(migrations, nil)
+			},
+		},
+
+		{
+			Name:          "when error loading working directory",
+			Args:          []string{"ensure", "config", "migrate"},
+			Getwd:         func() (string, error) { return "", exampleError },
+			ExpectedError: exampleError,
+		},
+
+		{
+			Name:          "when unable to migrate",
+			Args:          []string{"ensure", "config", "migrate"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.ConfigMigrator.EXPECT().Migrate("/test").Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		var stdout, logs bytes.Buffer
+		entry.Subject.Stdout = &stdout
+		entry.Subject.Logger = log.New(&logs, "", 0)
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(stdout.String()).Equals(entry.ExpectedOutput)
+		ensure(logs.String()).Equals(entry.ExpectedLogs)
+	})
+}
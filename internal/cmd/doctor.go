@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+func (a *App) doctorCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "doctor",
+		Usage: "checks that mocks can be generated in the current environment, and suggests fixes for any problems",
+		Description: "Checks that go and mockgen are installed, that mockgen matches the github.com/golang/mock version in go.mod,\n" +
+			"that .ensure.yml is found at the expected module root, and that the mock destinations are writable.\n" +
+			"Within a workspace, each module is checked.",
+
+		Action: func(c *cli.Context) error {
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			return a.Doctor.Diagnose(a.Cleanup.ToContext(c.Context), pwd)
+		},
+	}
+}
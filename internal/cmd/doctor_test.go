@@ -0,0 +1,77 @@
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_context"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_doctor"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_exitcleanup"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestDoctor(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type ContextKey struct{}
+
+	type Mocks struct {
+		Context *mock_context.MockContext `ensure:"ignoreunused"`
+		Doctor  *mock_doctor.MockDoctorIface
+		Cleanup *mock_exitcleanup.MockExitCleaner
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/test", nil
+	}
+
+	table := []struct {
+		Name          string
+		ExpectedError error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:  "with valid execution",
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+				m.Doctor.EXPECT().Diagnose(ctx, "/test").Return(nil)
+			},
+		},
+
+		{
+			Name:          "when error loading working directory",
+			Getwd:         func() (string, error) { return "", exampleError },
+			ExpectedError: exampleError,
+		},
+
+		{
+			Name:          "when checks fail",
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+				m.Doctor.EXPECT().Diagnose(ctx, "/test").Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		err := entry.Subject.Run([]string{"ensure", "doctor"})
+		ensure(err).IsError(entry.ExpectedError)
+	})
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JosiahWitt/ensure-cli/internal/changes"
+	"github.com/JosiahWitt/ensure-cli/internal/doctor"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/exitcode"
+	"github.com/JosiahWitt/ensure-cli/internal/hooks"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mockimport"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/watch"
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/erk/erg"
+	"github.com/urfave/cli/v2"
+)
+
+// ExitCode maps the error returned by Run to an exit code, using its erk kind.
+// See the exitcode package for the full table.
+func ExitCode(err error) int {
+	if err == nil {
+		return exitcode.Success
+	}
+
+	if errors.Is(err, runcmd.ErrProcessTerminated) || errors.Is(err, context.Canceled) {
+		return exitcode.Interrupted
+	}
+
+	// urfave/cli only returns exit coders for unknown commands, since commands never return them
+	var exitCoder cli.ExitCoder
+	if errors.As(err, &exitCoder) {
+		return exitcode.Usage
+	}
+
+	switch erk.GetKind(err).(type) {
+	case ErkInvalidFlag, changes.ErkChangesError:
+		return exitcode.Usage
+	case ensurefile.ErkCannotLoadConfig, mockgen.ErkInvalidConfig, mockgen.ErkMockDestination,
+		modfiles.ErkCannotParseFile, mockimport.ErkCannotImport, watch.ErkWatchError, doctor.ErkChecksFailed:
+		return exitcode.Config
+	case mockgen.ErkMockGenError, mockgen.ErkTemplateError:
+		return exitcode.MockGen
+	case mockgen.ErkFSWriteError, mockgen.ErkFSReadError, mockgen.ErkUnableToTidy, modfiles.ErkCannotFindFiles,
+		hooks.ErkHooksError:
+		return exitcode.FileSystem
+	case mockgen.ErkMocksOutOfDate:
+		return exitcode.OutOfDate
+	case mockgen.ErkMultipleFailures:
+		return groupExitCode(erg.GetErrors(err))
+	default:
+		return exitcode.Unknown
+	}
+}
+
+// groupExitCode returns the exit code shared by all the errors.
+// If they differ, the mocks failed to generate for a variety of reasons.
+func groupExitCode(errs []error) int {
+	if len(errs) == 0 {
+		return exitcode.MockGen
+	}
+
+	code := ExitCode(errs[0])
+	for _, err := range errs[1:] {
+		if ExitCode(err) != code {
+			return exitcode.MockGen
+		}
+	}
+
+	return code
+}
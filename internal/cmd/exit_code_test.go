@@ -0,0 +1,218 @@
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/changes"
+	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/doctor"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/exitcode"
+	"github.com/JosiahWitt/ensure-cli/internal/hooks"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mockimport"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/watch"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/erk/erg"
+	"github.com/urfave/cli/v2"
+)
+
+func TestExitCode(t *testing.T) {
+	ensure := ensure.New(t)
+
+	groupOf := func(errs ...error) error {
+		return erg.Append(erg.NewAs(mockgen.ErrMultipleGenerationFailures), errs...)
+	}
+
+	table := []struct {
+		Name         string
+		Error        error
+		ExpectedCode int
+	}{
+		{
+			Name:         "without error",
+			Error:        nil,
+			ExpectedCode: exitcode.Success,
+		},
+		{
+			Name:         "with unknown error",
+			Error:        errors.New("something went wrong"),
+			ExpectedCode: exitcode.Unknown,
+		},
+		{
+			Name:         "with invalid flag",
+			Error:        cmd.ErrInvalidOutputFormat,
+			ExpectedCode: exitcode.Usage,
+		},
+		{
+			Name:         "with incorrect usage",
+			Error:        cmd.ErrIncorrectUsage,
+			ExpectedCode: exitcode.Usage,
+		},
+		{
+			Name:         "with unknown command",
+			Error:        cli.Exit("No help topic for 'unknown'", 3),
+			ExpectedCode: exitcode.Usage,
+		},
+		{
+			Name:         "when cannot detect changes",
+			Error:        changes.ErrCannotFindMergeBase,
+			ExpectedCode: exitcode.Usage,
+		},
+		{
+			Name:         "when cannot load config",
+			Error:        erk.WrapAs(ensurefile.ErrCannotOpenFile, errors.New("permission denied")),
+			ExpectedCode: exitcode.Config,
+		},
+		{
+			Name:         "with invalid config",
+			Error:        mockgen.ErrMissingPackages,
+			ExpectedCode: exitcode.Config,
+		},
+		{
+			Name:         "with invalid mock destination",
+			Error:        mockgen.ErrInternalPackageOutsideModule,
+			ExpectedCode: exitcode.Config,
+		},
+		{
+			Name:         "when cannot parse file",
+			Error:        modfiles.ErrCannotParseFile,
+			ExpectedCode: exitcode.Config,
+		},
+		{
+			Name:         "when cannot import mocks",
+			Error:        mockimport.ErrMissingMockeryConfig,
+			ExpectedCode: exitcode.Config,
+		},
+		{
+			Name:         "when cannot resolve watched packages",
+			Error:        watch.ErrCannotResolvePackages,
+			ExpectedCode: exitcode.Config,
+		},
+		{
+			Name:         "when doctor checks fail",
+			Error:        doctor.ErrChecksFailed,
+			ExpectedCode: exitcode.Config,
+		},
+		{
+			Name:         "when mockgen fails",
+			Error:        mockgen.ErrMockGenFailed,
+			ExpectedCode: exitcode.MockGen,
+		},
+		{
+			Name:         "when template fails",
+			Error:        mockgen.ErrCannotExecuteTemplate,
+			ExpectedCode: exitcode.MockGen,
+		},
+		{
+			Name:         "when generated mocks do not compile",
+			Error:        erg.Append(erg.NewAs(mockgen.ErrMultipleVerificationFailures), mockgen.ErrMockDoesNotCompile),
+			ExpectedCode: exitcode.MockGen,
+		},
+		{
+			Name:         "when cannot write file",
+			Error:        mockgen.ErrUnableToCreateFile,
+			ExpectedCode: exitcode.FileSystem,
+		},
+		{
+			Name:         "when cannot read mock",
+			Error:        mockgen.ErrCannotReadMock,
+			ExpectedCode: exitcode.FileSystem,
+		},
+		{
+			Name:         "when cannot tidy",
+			Error:        mockgen.ErrTidyUnableToCleanup,
+			ExpectedCode: exitcode.FileSystem,
+		},
+		{
+			Name:         "when mocks are out of date",
+			Error:        mockgen.ErrMocksOutOfDate,
+			ExpectedCode: exitcode.OutOfDate,
+		},
+		{
+			Name:         "when cannot find module files",
+			Error:        modfiles.ErrCannotFindFiles,
+			ExpectedCode: exitcode.FileSystem,
+		},
+		{
+			Name:         "when cannot install hook",
+			Error:        hooks.ErrHookExists,
+			ExpectedCode: exitcode.FileSystem,
+		},
+		{
+			Name:         "when process terminated",
+			Error:        runcmd.ErrProcessTerminated,
+			ExpectedCode: exitcode.Interrupted,
+		},
+		{
+			Name:         "when context canceled",
+			Error:        context.Canceled,
+			ExpectedCode: exitcode.Interrupted,
+		},
+		{
+			Name:         "with grouped errors of the same kind",
+			Error:        groupOf(mockgen.ErrUnableToCreateDir, mockgen.ErrUnableToCreateFile),
+			ExpectedCode: exitcode.FileSystem,
+		},
+		{
+			Name:         "with grouped errors of different kinds",
+			Error:        groupOf(mockgen.ErrMockGenFailed, mockgen.ErrUnableToCreateFile),
+			ExpectedCode: exitcode.MockGen,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		ensure(cmd.ExitCode(entry.Error)).Equals(entry.ExpectedCode)
+	})
+}
+
+func TestExitCodeOfRun(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name         string
+		Args         []string
+		ExpectedCode int
+	}{
+		{
+			Name:         "with unknown global flag",
+			Args:         []string{"--unknown"},
+			ExpectedCode: exitcode.Usage,
+		},
+		{
+			Name:         "with unknown command flag",
+			Args:         []string{"doctor", "--unknown"},
+			ExpectedCode: exitcode.Usage,
+		},
+		{
+			Name:         "with unknown subcommand flag",
+			Args:         []string{"mocks", "generate", "--unknown"},
+			ExpectedCode: exitcode.Usage,
+		},
+		{
+			Name:         "with unknown command",
+			Args:         []string{"help", "unknown"},
+			ExpectedCode: exitcode.Usage,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		app := &cmd.App{
+			Logger: log.New(ioutil.Discard, "", 0),
+			Stdout: ioutil.Discard,
+		}
+
+		err := app.Run(append([]string{"ensure"}, entry.Args...))
+		ensure(cmd.ExitCode(err)).Equals(entry.ExpectedCode)
+	})
+}
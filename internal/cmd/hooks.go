@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/JosiahWitt/ensure-cli/internal/hooks"
+	"github.com/urfave/cli/v2"
+)
+
+func (a *App) hooksCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "hooks",
+		Usage: "commands related to git hooks",
+		Subcommands: []*cli.Command{
+			a.hooksInstallCmd(),
+		},
+	}
+}
+
+func (a *App) hooksInstallCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "install",
+		Usage: "installs a git pre-commit hook that checks the mocks affected by the staged files are up to date",
+		Description: "The hook runs `ensure mocks check --staged`, which only generates the mocks of packages affected by the staged files.\n" +
+			"If any mocks are out of date, the commit fails with the list of stale mocks, and how to regenerate them.\n" +
+			"The mocks are compared using the working tree, so unstaged changes to the affected packages are included in the check.\n\n" +
+			"By default, the hook is written to the pre-commit hook of the git repository, respecting core.hooksPath.\n" +
+			"Use --pre-commit to add it to .pre-commit-config.yaml instead, for use with the pre-commit framework (https://pre-commit.com).",
+
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "pre-commit",
+				Usage: "Adds the hook to .pre-commit-config.yaml, instead of writing a git hook",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "Replaces an existing git pre-commit hook that was not installed by ensure",
+			},
+		},
+
+		Action: func(c *cli.Context) error {
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			var installation *hooks.Installation
+			if c.Bool("pre-commit") {
+				installation, err = a.HookInstaller.InstallPreCommitConfig(c.Context, pwd)
+			} else {
+				installation, err = a.HookInstaller.InstallGitHook(c.Context, pwd, c.Bool("force"))
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if c.String("output") == outputJSON {
+				return json.NewEncoder(a.Stdout).Encode(installation)
+			}
+
+			if installation.Changed {
+				a.Logger.Printf("Installed the pre-commit hook in %s, which runs: %s", installation.Path, installation.Command)
+			} else {
+				a.Logger.Printf("The pre-commit hook is already installed in %s", installation.Path)
+			}
+
+			return nil
+		},
+	}
+}
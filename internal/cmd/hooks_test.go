@@ -0,0 +1,124 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/hooks"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_hooks"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestHooksInstall(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		HookInstaller *mock_hooks.MockInstallerIface
+		Reporter      *mock_report.MockReporterIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/test", nil
+	}
+
+	installation := &hooks.Installation{
+		Path:    "/test/.git/hooks/pre-commit",
+		Command: "ensure mocks check --staged",
+		Changed: true,
+	}
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput string
+		ExpectedLogs   string
+		ExpectedError  error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:         "with git hook",
+			Args:         []string{"ensure", "hooks", "install"},
+			Getwd:        defaultWd,
+			ExpectedLogs: "Installed the pre-commit hook in /test/.git/hooks/pre-commit, which runs: ensure mocks check --staged\n",
+			SetupMocks: func(m *Mocks) {
+				m.HookInstaller.EXPECT().InstallGitHook(gomock.Any(), "/test", false).Return(installation, nil)
+			},
+		},
+
+		{
+			Name:         "with git hook using force",
+			Args:         []string{"ensure", "hooks", "install", "--force"},
+			Getwd:        defaultWd,
+			ExpectedLogs: "Installed the pre-commit hook in /test/.git/hooks/pre-commit, which runs: ensure mocks check --staged\n",
+			SetupMocks: func(m *Mocks) {
+				m.HookInstaller.EXPECT().InstallGitHook(gomock.Any(), "/test", true).Return(installation, nil)
+			},
+		},
+
+		{
+			Name:         "with pre-commit framework",
+			Args:         []string{"ensure", "hooks", "install", "--pre-commit"},
+			Getwd:        defaultWd,
+			ExpectedLogs: "The pre-commit hook is already installed in /test/.pre-commit-config.yaml\n",
+			SetupMocks: func(m *Mocks) {
+				m.HookInstaller.EXPECT().
+					InstallPreCommitConfig(gomock.Any(), "/test").
+					Return(&hooks.Installation{Path: "/test/.pre-commit-config.yaml", Command: "ensure mocks check --staged"}, nil)
+			},
+		},
+
+		{
+			Name:  "with json output",
+			Args:  []string{"ensure", "--output", "json", "hooks", "install"},
+			Getwd: defaultWd,
+			ExpectedOutput: `{"path":"/test/.git/hooks/pre-commit","command":"ensure mocks check --staged","changed":true}` +
+				"\n",
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.HookInstaller.EXPECT().InstallGitHook(gomock.Any(), "/test", false).Return(installation, nil)
+			},
+		},
+
+		{
+			Name:          "when error loading working directory",
+			Args:          []string{"ensure", "hooks", "install"},
+			Getwd:         func() (string, error) { return "", exampleError },
+			ExpectedError: exampleError,
+		},
+
+		{
+			Name:          "when unable to install",
+			Args:          []string{"ensure", "hooks", "install"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.HookInstaller.EXPECT().InstallGitHook(gomock.Any(), "/test", false).Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		var stdout, logs bytes.Buffer
+		entry.Subject.Stdout = &stdout
+		entry.Subject.Logger = log.New(&logs, "", 0)
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(stdout.String()).Equals(entry.ExpectedOutput)
+		ensure(logs.String()).Equals(entry.ExpectedLogs)
+	})
+}
@@ -1,9 +1,19 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+
+	"github.com/JosiahWitt/ensure-cli/internal/changes"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
+	"github.com/JosiahWitt/erk"
 	"github.com/urfave/cli/v2"
 )
 
+var ErrConflictingChangeFlags = erk.New(ErkInvalidFlag{}, "Only one of --changed-since and --staged can be used.")
+
 func (a *App) mocksCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "mocks",
@@ -12,21 +22,52 @@ func (a *App) mocksCmd() *cli.Command {
 		Subcommands: []*cli.Command{
 			a.mocksGenerateCmd(),
 			a.mocksTidyCmd(),
+			a.mocksCheckCmd(),
+			a.mocksWatchCmd(),
+			a.mocksWhyCmd(),
+			a.mocksUnusedCmd(),
+			a.mocksSyncCmd(),
+			a.mocksImportCmd(),
+			a.mocksMoveCmd(),
 		},
 	}
 }
 
 func (a *App) mocksGenerateCmd() *cli.Command {
 	return &cli.Command{
-		Name:  "generate",
-		Usage: "generates GoMocks (https://github.com/golang/mock) for the packages and interfaces listed in .ensure.yml",
+		Name:      "generate",
+		Usage:     "generates GoMocks (https://github.com/golang/mock) for the packages and interfaces listed in .ensure.yml",
+		ArgsUsage: "[package patterns...]",
+		Description: "Generates mocks for every package listed in .ensure.yml, unless package patterns are provided.\n" +
+			"Patterns are matched against the full package path and the path relative to the module, such as 'store/*'.\n" +
+			"Use '<package pattern>:<interface pattern>' to select packages containing a matching interface.\n" +
+			"Use --changed-since to only generate mocks for packages affected by the changes since a git ref,\n" +
+			"or --staged to only generate mocks for packages affected by the files staged for commit.\n" +
+			"With --staged, the packages are selected using git's index, but their mocks are generated from the working tree.\n" +
+			"Tidying is skipped when only some packages are generated.\n" +
+			"Use --verify to type-check the generated mocks, since mockgen can generate mocks that do not compile.\n\n" +
+			"Packages can also be listed in .ensure.yml files within subdirectories of the module. Their package paths can be\n" +
+			"relative to the subdirectory, such as './store', and their destinations only apply to the packages they list.\n\n" +
+			"Within a workspace, the mocks of every module are generated in one run. A workspace is either a go.work file,\n" +
+			"or a .ensure.yml file that lists the directories of its modules using the 'modules' key. Workspaces are only\n" +
+			"searched for up to the root of the repository, and GOWORK is honored like the go tool, including GOWORK=off.",
 
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.BoolFlag{
 				Name:  "disable-parallel",
 				Usage: "Disables generating the mocks in parallel",
 			},
-		},
+			&cli.StringSliceFlag{
+				Name:  "match",
+				Usage: "Only generates mocks for packages matching the pattern; can be repeated",
+			},
+			changedSinceFlag(),
+			stagedFlag(),
+			&cli.BoolFlag{
+				Name:  "verify",
+				Usage: "Type-checks each generated mock package using go build, after generating the mocks",
+			},
+		}, configOverrideFlags()...),
 
 		Action: func(c *cli.Context) error {
 			pwd, err := a.Getwd()
@@ -34,17 +75,58 @@ func (a *App) mocksGenerateCmd() *cli.Command {
 				return err
 			}
 
-			config, err := a.EnsureFileLoader.LoadConfig(pwd)
+			configs, err := a.EnsureFileLoader.LoadConfigs(pwd)
+			if err != nil {
+				return err
+			}
+
+			if err := applyConfigOverrides(c, configs...); err != nil {
+				return err
+			}
+
+			filters := packageFilters(c)
+			for _, config := range configs {
+				config.DisableParallelGeneration = c.Bool("disable-parallel")
+				config.PackageFilters = filters
+			}
+
+			configs, err = a.limitToChanges(c, configs)
 			if err != nil {
 				return err
 			}
 
-			config.DisableParallelGeneration = c.Bool("disable-parallel")
-			if err := a.MockGenerator.GenerateMocks(a.Cleanup.ToContext(c.Context), config); err != nil {
+			if len(configs) == 0 {
+				a.Logger.Printf("No mocks are affected by %s.\n", changesDescription(c))
+				return nil
+			}
+
+			ctx := a.Cleanup.ToContext(c.Context)
+			if err := a.MockGenerator.GenerateAllMocks(ctx, configs); err != nil {
 				return err
 			}
 
-			if config.Mocks.TidyAfterGenerate {
+			if c.Bool("verify") {
+				if err := a.MockGenerator.VerifyMocks(ctx, configs); err != nil {
+					return err
+				}
+			}
+
+			for _, config := range configs {
+				if !config.Mocks.TidyAfterGenerate {
+					continue
+				}
+
+				if len(filters) > 0 {
+					a.Logger.Println("Skipping tidy, since only some of the mocks were generated.")
+					return nil
+				}
+
+				// Only the configs with changed packages were partially generated, so the others can still be tidied
+				if config.ChangedPackages != nil {
+					a.Logger.Printf("Skipping tidy of %s, since only some of its mocks were generated.\n", config.RootPath)
+					continue
+				}
+
 				if err := a.MockGenerator.TidyMocks(config); err != nil {
 					return err
 				}
@@ -57,8 +139,10 @@ func (a *App) mocksGenerateCmd() *cli.Command {
 
 func (a *App) mocksTidyCmd() *cli.Command {
 	return &cli.Command{
-		Name:  "tidy",
-		Usage: "removes any files and directories that would not be generated for the packages and interfaces listed in .ensure.yml",
+		Name:        "tidy",
+		Usage:       "removes any files and directories that would not be generated for the packages and interfaces listed in .ensure.yml",
+		Description: "Within a workspace, the mocks of every module are tidied.",
+		Flags:       configOverrideFlags(),
 
 		Action: func(c *cli.Context) error {
 			pwd, err := a.Getwd()
@@ -66,12 +150,257 @@ func (a *App) mocksTidyCmd() *cli.Command {
 				return err
 			}
 
-			config, err := a.EnsureFileLoader.LoadConfig(pwd)
+			configs, err := a.EnsureFileLoader.LoadConfigs(pwd)
 			if err != nil {
 				return err
 			}
 
-			return a.MockGenerator.TidyMocks(config)
+			if err := applyConfigOverrides(c, configs...); err != nil {
+				return err
+			}
+
+			for _, config := range configs {
+				if err := a.MockGenerator.TidyMocks(config); err != nil {
+					return err
+				}
+			}
+
+			return nil
 		},
 	}
 }
+
+func (a *App) mocksCheckCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "check",
+		Usage:     "checks that the generated mocks are up to date, without changing any files",
+		ArgsUsage: "[package patterns...]",
+		Description: "Generates the mocks in memory, and compares them to the mock files on disk.\n" +
+			"Lists the mocks that are outdated or missing, and the files that would be tidied when tidyAfterGenerate is set.\n" +
+			"Exits with a non-zero code if any mocks are out of date, so it can be used in CI.\n" +
+			"Package patterns, --changed-since, and --staged match like `ensure mocks generate`.\n" +
+			"Use `ensure hooks install` to run `ensure mocks check --staged` before each commit.",
+
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:  "disable-parallel",
+				Usage: "Disables generating the mocks in parallel",
+			},
+			&cli.StringSliceFlag{
+				Name:  "match",
+				Usage: "Only checks mocks for packages matching the pattern; can be repeated",
+			},
+			changedSinceFlag(),
+			stagedFlag(),
+		}, configOverrideFlags()...),
+
+		Action: func(c *cli.Context) error {
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			configs, err := a.EnsureFileLoader.LoadConfigs(pwd)
+			if err != nil {
+				return err
+			}
+
+			if err := applyConfigOverrides(c, configs...); err != nil {
+				return err
+			}
+
+			filters := packageFilters(c)
+			for _, config := range configs {
+				config.DisableParallelGeneration = c.Bool("disable-parallel")
+				config.PackageFilters = filters
+			}
+
+			configs, err = a.limitToChanges(c, configs)
+			if err != nil {
+				return err
+			}
+
+			result := &mockgen.CheckResult{Outdated: []*mockgen.MockFile{}, Missing: []*mockgen.MockFile{}, Extra: []string{}}
+			if len(configs) > 0 {
+				result, err = a.MockGenerator.CheckMocks(a.Cleanup.ToContext(c.Context), configs)
+				if err != nil {
+					return err
+				}
+			}
+
+			if c.String("output") == outputJSON {
+				a.Reporter.Report(&report.Event{Type: report.EventMocksChecked, MocksCheck: newMocksCheck(result)})
+			} else {
+				printCheckResult(a.Stdout, result)
+			}
+
+			return result.Err()
+		},
+	}
+}
+
+// newMocksCheck converts the result, so it can be reported as an event.
+func newMocksCheck(result *mockgen.CheckResult) *report.MocksCheck {
+	newMockFiles := func(mockFiles []*mockgen.MockFile) []*report.MockFile {
+		reportFiles := make([]*report.MockFile, 0, len(mockFiles))
+		for _, mockFile := range mockFiles {
+			reportFiles = append(reportFiles, &report.MockFile{Package: mockFile.Package, Source: mockFile.Source, Path: mockFile.Path})
+		}
+
+		return reportFiles
+	}
+
+	extra := append([]string{}, result.Extra...) // Encoded as an empty list instead of null
+
+	return &report.MocksCheck{Outdated: newMockFiles(result.Outdated), Missing: newMockFiles(result.Missing), Extra: extra}
+}
+
+func printCheckResult(w io.Writer, result *mockgen.CheckResult) {
+	if result.UpToDate() {
+		fmt.Fprintln(w, "All mocks are up to date.")
+		return
+	}
+
+	printMockFiles := func(heading string, mockFiles []*mockgen.MockFile) {
+		if len(mockFiles) == 0 {
+			return
+		}
+
+		fmt.Fprintln(w, heading)
+		for _, mockFile := range mockFiles {
+			fmt.Fprintf(w, " - %s (%s, from %s)\n", mockFile.Path, mockFile.Package, mockFile.Source)
+		}
+	}
+
+	printMockFiles("Outdated mocks:", result.Outdated)
+	printMockFiles("Missing mocks:", result.Missing)
+
+	if len(result.Extra) > 0 {
+		fmt.Fprintln(w, "Files that would be tidied:")
+		for _, path := range result.Extra {
+			fmt.Fprintf(w, " - %s\n", path)
+		}
+	}
+}
+
+func (a *App) mocksWatchCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "watch",
+		Usage: "watches the packages listed in .ensure.yml, and regenerates their mocks when they change",
+		Description: "Generates all mocks, and then watches the Go files of the packages listed in .ensure.yml, as well as .ensure.yml itself.\n" +
+			"When a package changes, only its mocks are regenerated. When a .ensure.yml file changes, all mocks are regenerated.\n" +
+			"Within a workspace, the packages of every module are watched.\n" +
+			"Errors are printed without exiting. If .ensure.yml cannot be loaded, no mocks are generated until it is fixed.\n" +
+			"Press Ctrl+C to stop watching.",
+
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:  "disable-parallel",
+				Usage: "Disables generating the mocks in parallel",
+			},
+		}, configOverrideFlags()...),
+
+		Action: func(c *cli.Context) error {
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			loadConfigs := func() ([]*ensurefile.Config, error) {
+				configs, err := a.EnsureFileLoader.LoadConfigs(pwd)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, config := range configs {
+					if err := applyConfigOverrides(c, config); err != nil {
+						return nil, err
+					}
+
+					config.DisableParallelGeneration = c.Bool("disable-parallel")
+				}
+
+				return configs, nil
+			}
+
+			findConfigFiles := func() ([]string, error) {
+				return a.EnsureFileLoader.FindConfigFiles(pwd)
+			}
+
+			return a.MockWatcher.Watch(a.Cleanup.ToContext(c.Context), loadConfigs, findConfigFiles)
+		},
+	}
+}
+
+// changedSinceFlag limits the mocks to the packages affected by the changes since a git ref.
+func changedSinceFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name: "changed-since",
+		Usage: "Only includes mocks for packages affected by the changes since the git ref, such as origin/main, " +
+			"including packages that import the changed packages",
+	}
+}
+
+// stagedFlag limits the mocks to the packages affected by the files staged for commit.
+func stagedFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name: "staged",
+		Usage: "Only includes mocks for packages affected by the files staged for commit, such as in a pre-commit hook. " +
+			"The mocks are still generated from the working tree, so unstaged changes to those packages are included",
+	}
+}
+
+// limitToChanges sets the changed packages of each config when --changed-since or --staged is provided,
+// and returns the configs with any affected packages.
+func (a *App) limitToChanges(c *cli.Context, configs []*ensurefile.Config) ([]*ensurefile.Config, error) {
+	ref, staged := c.String("changed-since"), c.Bool("staged")
+	if ref != "" && staged {
+		return nil, ErrConflictingChangeFlags
+	}
+
+	if ref == "" && !staged {
+		return configs, nil
+	}
+
+	affectedConfigs := []*ensurefile.Config{}
+	for _, config := range configs {
+		var result *changes.Result
+		var err error
+		if staged {
+			result, err = a.ChangeDetector.AffectedByStaged(c.Context, config)
+		} else {
+			result, err = a.ChangeDetector.Affected(c.Context, config, ref)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if result.All {
+			affectedConfigs = append(affectedConfigs, config)
+			continue
+		}
+
+		if len(result.Packages) > 0 {
+			config.ChangedPackages = result.Packages
+			affectedConfigs = append(affectedConfigs, config)
+		}
+	}
+
+	return affectedConfigs, nil
+}
+
+func changesDescription(c *cli.Context) string {
+	if c.Bool("staged") {
+		return "the staged files"
+	}
+
+	return "the changes since " + c.String("changed-since")
+}
+
+// packageFilters returns the package patterns provided as arguments or using the --match flag.
+func packageFilters(c *cli.Context) []string {
+	var filters []string
+	filters = append(filters, c.Args().Slice()...)
+	return append(filters, c.StringSlice("match")...)
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/mockimport"
+	"github.com/JosiahWitt/erk"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	importFromMockgen = "mockgen"
+	importFromMockery = "mockery"
+)
+
+var ErrInvalidImportSource = erk.New(ErkInvalidFlag{}, "Invalid import source '{{.from}}'. It must be either 'mockgen' or 'mockery'.")
+
+func (a *App) mocksImportCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "import",
+		Usage: "adds the mocks generated by `//go:generate mockgen` directives or mockery to .ensure.yml",
+		Description: "Scans the module's Go files for `//go:generate mockgen` directives, and converts their package, interfaces,\n" +
+			"and flags into packages in .ensure.yml, creating it if needed. Directives that cannot be expressed are listed.\n" +
+			"Use --remove-directives to delete the converted directives.\n\n" +
+			"With --from mockery, the packages config in .mockery.yaml is converted instead, including `all` and `recursive`.\n" +
+			"The old mock import paths are printed next to the new ones, and --rewrite-imports updates the imports to match.\n" +
+			"Since mockery generates testify mocks, the code using them still needs to be updated to use gomock.",
+
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "from",
+				Value: importFromMockgen,
+				Usage: "Where to import the mocks from, either 'mockgen' directives or a 'mockery' config",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Lists the directives without changing any files",
+			},
+			&cli.BoolFlag{
+				Name:  "remove-directives",
+				Usage: "Removes the converted directives from their files",
+			},
+			&cli.BoolFlag{
+				Name:  "rewrite-imports",
+				Usage: "Rewrites the imports of the old mockery mock packages to the new mock packages",
+			},
+		},
+
+		Action: func(c *cli.Context) error {
+			from := c.String("from")
+			if from != importFromMockgen && from != importFromMockery {
+				return erk.WithParams(ErrInvalidImportSource, erk.Params{
+					"from": from,
+				})
+			}
+
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			config, err := a.EnsureFileLoader.LoadModule(pwd)
+			if err != nil {
+				return err
+			}
+
+			find := a.MockImporter.FindDirectives
+			if from == importFromMockery {
+				find = a.MockImporter.FindMockery
+			}
+
+			result, err := find(config)
+			if err != nil {
+				return err
+			}
+
+			if c.String("output") == outputJSON {
+				if err := json.NewEncoder(a.Stdout).Encode(result); err != nil {
+					return err
+				}
+			} else {
+				printImportResult(a.Stdout, from, result)
+			}
+
+			if c.Bool("dry-run") || len(result.Converted) == 0 {
+				return nil
+			}
+
+			if err := a.MockImporter.Apply(config, result.Converted, c.Bool("remove-directives")); err != nil {
+				return err
+			}
+
+			a.Logger.Printf("Added the converted %s to %s. Run 'ensure mocks generate' to generate the mocks.\n", importedKind(from), config.ConfigPath)
+
+			if !c.Bool("rewrite-imports") || len(result.Moves) == 0 {
+				return nil
+			}
+
+			rewrittenFiles, err := a.ImportRewriter.Rewrite(config.RootPath, result.Moves)
+			if err != nil {
+				return err
+			}
+
+			a.Logger.Printf("Rewrote the mock imports in %d files.\n", len(rewrittenFiles))
+			return nil
+		},
+	}
+}
+
+// importedKind describes what is converted when importing from the source.
+func importedKind(from string) string {
+	if from == importFromMockery {
+		return "packages"
+	}
+
+	return "directives"
+}
+
+func printImportResult(w io.Writer, from string, result *mockimport.Result) {
+	if len(result.Converted) == 0 && len(result.Unsupported) == 0 {
+		if from == importFromMockery {
+			fmt.Fprintln(w, "No mockery packages found.")
+		} else {
+			fmt.Fprintln(w, "No mockgen directives found.")
+		}
+
+		return
+	}
+
+	if len(result.Converted) > 0 {
+		fmt.Fprintf(w, "Converted %s:\n", importedKind(from))
+	}
+
+	for _, conversion := range result.Converted {
+		pkg := conversion.PackagePath + ":" + strings.Join(conversion.Interfaces, ",")
+		if directive := conversion.Directive; directive != nil {
+			fmt.Fprintf(w, " - %s:%d: %s\n", directive.File, directive.Line, pkg)
+		} else {
+			fmt.Fprintf(w, " - %s\n", pkg)
+		}
+
+		for _, note := range conversion.Notes {
+			fmt.Fprintf(w, "   Note: %s\n", note)
+		}
+	}
+
+	if len(result.Unsupported) > 0 {
+		fmt.Fprintln(w, "Unable to convert:")
+	}
+
+	for _, unsupported := range result.Unsupported {
+		switch directive := unsupported.Directive; {
+		case directive != nil:
+			fmt.Fprintf(w, " - %s:%d: %s, since %s\n", directive.File, directive.Line, directive.Text, unsupported.Reason)
+		case unsupported.Interface != "":
+			fmt.Fprintf(w, " - %s:%s, since %s\n", unsupported.PackagePath, unsupported.Interface, unsupported.Reason)
+		default:
+			fmt.Fprintf(w, " - %s, since %s\n", unsupported.PackagePath, unsupported.Reason)
+		}
+	}
+
+	if len(result.Moves) > 0 {
+		fmt.Fprintln(w, "Mock import paths:")
+	}
+
+	for _, move := range result.Moves {
+		fmt.Fprintf(w, " - %s -> %s\n", move.OldPath, move.NewPath)
+	}
+}
@@ -0,0 +1,279 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/importrewrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockimport"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_importrewrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_mockimport"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestMocksImport(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		MockImporter     *mock_mockimport.MockImporterIface
+		ImportRewriter   *mock_importrewrite.MockRewriterIface
+		Reporter         *mock_report.MockReporterIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/my/app", nil
+	}
+
+	config := &ensurefile.Config{
+		RootPath:   "/my/app",
+		ModulePath: "github.com/my/app",
+		ConfigPath: "/my/app/.ensure.yml",
+	}
+
+	result := &mockimport.Result{
+		Converted: []*mockimport.Conversion{
+			{
+				Directive:   &mockimport.Directive{File: "/my/app/pkg/pkg.go", Line: 3, Text: "//go:generate mockgen . Iface1,Iface2"},
+				PackagePath: "github.com/my/app/pkg",
+				Interfaces:  []string{"Iface1", "Iface2"},
+				Notes:       []string{"the mock package is named mock_pkg instead of mocks"},
+			},
+		},
+		Unsupported: []*mockimport.Unsupported{
+			{
+				Directive: &mockimport.Directive{File: "/my/app/pkg/pkg.go", Line: 4, Text: "//go:generate mockgen -imports=x . Iface3"},
+				Reason:    "the -imports flag has no equivalent in .ensure.yml",
+			},
+		},
+	}
+
+	const resultOutput = "Converted directives:\n" +
+		" - /my/app/pkg/pkg.go:3: github.com/my/app/pkg:Iface1,Iface2\n" +
+		"   Note: the mock package is named mock_pkg instead of mocks\n" +
+		"Unable to convert:\n" +
+		" - /my/app/pkg/pkg.go:4: //go:generate mockgen -imports=x . Iface3, since the -imports flag has no equivalent in .ensure.yml\n"
+
+	mockeryResult := &mockimport.Result{
+		Converted: []*mockimport.Conversion{
+			{PackagePath: "github.com/my/app/store", Interfaces: []string{"Store"}},
+		},
+		Unsupported: []*mockimport.Unsupported{
+			{PackagePath: "github.com/my/app/store", Interface: "Missing", Reason: "the package does not declare the interface"},
+			{PackagePath: "github.com/other/ext", Reason: "`all: true` requires the package to be within the module"},
+		},
+		Moves: []*importrewrite.Move{
+			{
+				OldPath: "github.com/my/app/mocks/github.com/my/app/store",
+				OldName: "store",
+				NewPath: "github.com/my/app/internal/mocks/github.com/my/app/mock_store",
+			},
+		},
+	}
+
+	const mockeryResultOutput = "Converted packages:\n" +
+		" - github.com/my/app/store:Store\n" +
+		"Unable to convert:\n" +
+		" - github.com/my/app/store:Missing, since the package does not declare the interface\n" +
+		" - github.com/other/ext, since `all: true` requires the package to be within the module\n" +
+		"Mock import paths:\n" +
+		" - github.com/my/app/mocks/github.com/my/app/store -> github.com/my/app/internal/mocks/github.com/my/app/mock_store\n"
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput string
+		ExpectedError  error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:           "with no directives",
+			Args:           []string{"ensure", "mocks", "import"},
+			Getwd:          defaultWd,
+			ExpectedOutput: "No mockgen directives found.\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindDirectives(config).
+					Return(&mockimport.Result{Converted: []*mockimport.Conversion{}, Unsupported: []*mockimport.Unsupported{}}, nil)
+			},
+		},
+
+		{
+			Name:           "with directives",
+			Args:           []string{"ensure", "mocks", "import"},
+			Getwd:          defaultWd,
+			ExpectedOutput: resultOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindDirectives(config).Return(result, nil)
+				m.MockImporter.EXPECT().Apply(config, result.Converted, false).Return(nil)
+			},
+		},
+
+		{
+			Name:           "with directives when removing them",
+			Args:           []string{"ensure", "mocks", "import", "--remove-directives"},
+			Getwd:          defaultWd,
+			ExpectedOutput: resultOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindDirectives(config).Return(result, nil)
+				m.MockImporter.EXPECT().Apply(config, result.Converted, true).Return(nil)
+			},
+		},
+
+		{
+			Name:           "with directives when dry run",
+			Args:           []string{"ensure", "mocks", "import", "--dry-run"},
+			Getwd:          defaultWd,
+			ExpectedOutput: resultOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindDirectives(config).Return(result, nil)
+			},
+		},
+
+		{
+			Name:  "with JSON output",
+			Args:  []string{"ensure", "--output", "json", "mocks", "import", "--dry-run"},
+			Getwd: defaultWd,
+			ExpectedOutput: `{"converted":[{"directive":{"file":"/my/app/pkg/pkg.go","line":3,"text":"//go:generate mockgen . Iface1,Iface2"},` +
+				`"packagePath":"github.com/my/app/pkg","interfaces":["Iface1","Iface2"],"typed":false,` +
+				`"notes":["the mock package is named mock_pkg instead of mocks"]}],` +
+				`"unsupported":[{"directive":{"file":"/my/app/pkg/pkg.go","line":4,"text":"//go:generate mockgen -imports=x . Iface3"},` +
+				`"reason":"the -imports flag has no equivalent in .ensure.yml"}]}` + "\n",
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindDirectives(config).Return(result, nil)
+			},
+		},
+
+		{
+			Name:           "with mockery config",
+			Args:           []string{"ensure", "mocks", "import", "--from", "mockery"},
+			Getwd:          defaultWd,
+			ExpectedOutput: mockeryResultOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindMockery(config).Return(mockeryResult, nil)
+				m.MockImporter.EXPECT().Apply(config, mockeryResult.Converted, false).Return(nil)
+			},
+		},
+
+		{
+			Name:           "with mockery config when rewriting imports",
+			Args:           []string{"ensure", "mocks", "import", "--from", "mockery", "--rewrite-imports"},
+			Getwd:          defaultWd,
+			ExpectedOutput: mockeryResultOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindMockery(config).Return(mockeryResult, nil)
+				m.MockImporter.EXPECT().Apply(config, mockeryResult.Converted, false).Return(nil)
+				m.ImportRewriter.EXPECT().Rewrite("/my/app", mockeryResult.Moves).Return([]string{"/my/app/store/store_test.go"}, nil)
+			},
+		},
+
+		{
+			Name:           "with empty mockery config",
+			Args:           []string{"ensure", "mocks", "import", "--from", "mockery"},
+			Getwd:          defaultWd,
+			ExpectedOutput: "No mockery packages found.\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindMockery(config).
+					Return(&mockimport.Result{Converted: []*mockimport.Conversion{}, Unsupported: []*mockimport.Unsupported{}}, nil)
+			},
+		},
+
+		{
+			Name:           "when unable to rewrite imports",
+			Args:           []string{"ensure", "mocks", "import", "--from", "mockery", "--rewrite-imports"},
+			Getwd:          defaultWd,
+			ExpectedError:  exampleError,
+			ExpectedOutput: mockeryResultOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindMockery(config).Return(mockeryResult, nil)
+				m.MockImporter.EXPECT().Apply(config, mockeryResult.Converted, false).Return(nil)
+				m.ImportRewriter.EXPECT().Rewrite("/my/app", mockeryResult.Moves).Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when import source is invalid",
+			Args:          []string{"ensure", "mocks", "import", "--from", "other"},
+			Getwd:         defaultWd,
+			ExpectedError: cmd.ErrInvalidImportSource,
+		},
+
+		{
+			Name:          "when unable to get working directory",
+			Args:          []string{"ensure", "mocks", "import"},
+			ExpectedError: exampleError,
+			Getwd: func() (string, error) {
+				return "", exampleError
+			},
+		},
+
+		{
+			Name:          "when unable to load module",
+			Args:          []string{"ensure", "mocks", "import"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when unable to find directives",
+			Args:          []string{"ensure", "mocks", "import"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindDirectives(config).Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:           "when unable to apply",
+			Args:           []string{"ensure", "mocks", "import"},
+			Getwd:          defaultWd,
+			ExpectedOutput: resultOutput,
+			ExpectedError:  exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadModule("/my/app").Return(config, nil)
+				m.MockImporter.EXPECT().FindDirectives(config).Return(result, nil)
+				m.MockImporter.EXPECT().Apply(config, result.Converted, false).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		var stdout bytes.Buffer
+		entry.Subject.Stdout = &stdout
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(stdout.String()).Equals(entry.ExpectedOutput)
+	})
+}
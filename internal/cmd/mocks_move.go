@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/JosiahWitt/ensure-cli/internal/mockmove"
+	"github.com/JosiahWitt/erk"
+	"github.com/urfave/cli/v2"
+)
+
+var ErrNothingToMove = erk.New(ErkInvalidFlag{},
+	"The mock destinations are unchanged. Please provide --primary-destination or --internal-destination, "+
+		"or --from-primary-destination or --from-internal-destination if .ensure.yml was already changed.",
+)
+
+func (a *App) mocksMoveCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "move",
+		Usage: "moves the mocks to new destinations, and rewrites the imports of the old mock packages",
+		Description: "Changes primaryDestination or internalDestination in .ensure.yml, generates the mocks at the new destinations,\n" +
+			"and rewrites the imports of the old mock packages in the module's Go files, keeping any import aliases.\n" +
+			"The old destinations are then tidied. If .ensure.yml was already changed, provide the old destinations\n" +
+			"using --from-primary-destination or --from-internal-destination instead.",
+
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "primary-destination",
+				Usage: "New primary destination. Defaults to the one in .ensure.yml",
+			},
+			&cli.StringFlag{
+				Name:  "internal-destination",
+				Usage: "New internal destination. Defaults to the one in .ensure.yml",
+			},
+			&cli.StringFlag{
+				Name:  "from-primary-destination",
+				Usage: "Old primary destination. Defaults to the one in .ensure.yml",
+			},
+			&cli.StringFlag{
+				Name:  "from-internal-destination",
+				Usage: "Old internal destination. Defaults to the one in .ensure.yml",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Lists the mock packages that move without changing any files",
+			},
+			&cli.BoolFlag{
+				Name:  "disable-parallel",
+				Usage: "Disables generating the mocks in parallel",
+			},
+		},
+
+		Action: func(c *cli.Context) error {
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			config, err := a.EnsureFileLoader.LoadConfig(pwd)
+			if err != nil {
+				return err
+			}
+
+			plan, err := mockmove.PlanMove(config,
+				&mockmove.Destinations{Primary: c.String("from-primary-destination"), Internal: c.String("from-internal-destination")},
+				&mockmove.Destinations{Primary: c.String("primary-destination"), Internal: c.String("internal-destination")},
+			)
+			if err != nil {
+				return err
+			}
+
+			if plan.Unchanged() {
+				return ErrNothingToMove
+			}
+
+			if c.String("output") == outputJSON {
+				if err := json.NewEncoder(a.Stdout).Encode(plan); err != nil {
+					return err
+				}
+			} else {
+				printMovePlan(a.Stdout, plan)
+			}
+
+			if c.Bool("dry-run") {
+				return nil
+			}
+
+			if err := a.MockMover.SetDestinations(config, plan); err != nil {
+				return err
+			}
+
+			config = plan.Apply(config)
+			config.DisableParallelGeneration = c.Bool("disable-parallel")
+			if err := a.MockGenerator.GenerateMocks(a.Cleanup.ToContext(c.Context), config); err != nil {
+				return err
+			}
+
+			if len(plan.Moves) > 0 {
+				rewrittenFiles, err := a.ImportRewriter.Rewrite(config.RootPath, plan.Moves)
+				if err != nil {
+					return err
+				}
+
+				a.Logger.Printf("Rewrote the mock imports in %d files.\n", len(rewrittenFiles))
+			}
+
+			if err := a.MockMover.RemoveOld(plan); err != nil {
+				return err
+			}
+
+			if config.Mocks.TidyAfterGenerate {
+				return a.MockGenerator.TidyMocks(config)
+			}
+
+			return nil
+		},
+	}
+}
+
+func printMovePlan(w io.Writer, plan *mockmove.Plan) {
+	fmt.Fprintf(w, "Primary destination: %s -> %s\n", plan.From.Primary, plan.To.Primary)
+	fmt.Fprintf(w, "Internal destination: %s -> %s\n", plan.From.Internal, plan.To.Internal)
+
+	if len(plan.Moves) == 0 {
+		fmt.Fprintln(w, "No mock packages move.")
+	} else {
+		fmt.Fprintln(w, "Mock import paths:")
+	}
+
+	for _, move := range plan.Moves {
+		fmt.Fprintf(w, " - %s -> %s\n", move.OldPath, move.NewPath)
+	}
+
+	if len(plan.Remove) > 0 {
+		fmt.Fprintln(w, "Removing from the old destinations:")
+	}
+
+	for _, path := range plan.Remove {
+		fmt.Fprintf(w, " - %s\n", path)
+	}
+}
@@ -0,0 +1,278 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/importrewrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mockmove"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_context"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_exitcleanup"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_importrewrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_mockmove"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestMocksMove(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type ContextKey struct{}
+
+	type Mocks struct {
+		Context          *mock_context.MockContext `ensure:"ignoreunused"`
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		MockMover        *mock_mockmove.MockMoverIface
+		ImportRewriter   *mock_importrewrite.MockRewriterIface
+		MockGen          *mock_mockgen.MockMockGenerator
+		Cleanup          *mock_exitcleanup.MockExitCleaner
+		Reporter         *mock_report.MockReporterIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/my/app", nil
+	}
+
+	newConfig := func(primaryDestination string, tidy bool) *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			ConfigPath: "/my/app/.ensure.yml",
+			Mocks: &ensurefile.MockConfig{
+				PrimaryDestination: primaryDestination,
+				TidyAfterGenerate:  tidy,
+				Packages: []*ensurefile.Package{
+					{Path: "github.com/my/app/store", Interfaces: []string{"Store"}},
+				},
+			},
+		}
+	}
+
+	// The destinations of the plan are applied to the loaded config, instead of reloading it
+	movedConfig := func(tidy bool) *ensurefile.Config {
+		config := newConfig("mocks", tidy)
+		config.Mocks.InternalDestination = "mocks"
+		return config
+	}
+
+	plan := &mockmove.Plan{
+		From: &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+		To:   &mockmove.Destinations{Primary: "mocks", Internal: "mocks"},
+		Moves: []*importrewrite.Move{
+			{
+				OldPath: "github.com/my/app/internal/mocks/github.com/my/app/mock_store",
+				NewPath: "github.com/my/app/mocks/github.com/my/app/mock_store",
+			},
+		},
+		Remove: []string{"/my/app/internal/mocks"},
+	}
+
+	const planOutput = "Primary destination: internal/mocks -> mocks\n" +
+		"Internal destination: mocks -> mocks\n" +
+		"Mock import paths:\n" +
+		" - github.com/my/app/internal/mocks/github.com/my/app/mock_store -> github.com/my/app/mocks/github.com/my/app/mock_store\n" +
+		"Removing from the old destinations:\n" +
+		" - /my/app/internal/mocks\n"
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput string
+		ExpectedError  error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:           "with new primary destination",
+			Args:           []string{"ensure", "mocks", "move", "--primary-destination", "mocks", "--disable-parallel"},
+			Getwd:          defaultWd,
+			ExpectedOutput: planOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig("", true), nil)
+				m.MockMover.EXPECT().SetDestinations(newConfig("", true), plan).Return(nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				generateConfig := movedConfig(true)
+				generateConfig.DisableParallelGeneration = true
+				m.MockGen.EXPECT().GenerateMocks(ctx, generateConfig).Return(nil)
+				m.ImportRewriter.EXPECT().Rewrite("/my/app", plan.Moves).Return([]string{"/my/app/store/store_test.go"}, nil)
+				m.MockMover.EXPECT().RemoveOld(plan).Return(nil)
+				m.MockGen.EXPECT().TidyMocks(generateConfig).Return(nil)
+			},
+		},
+
+		{
+			Name:           "with old primary destination",
+			Args:           []string{"ensure", "mocks", "move", "--from-primary-destination", "internal/mocks"},
+			Getwd:          defaultWd,
+			ExpectedOutput: planOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig("mocks", false), nil)
+				m.MockMover.EXPECT().SetDestinations(newConfig("mocks", false), plan).Return(nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+				m.MockGen.EXPECT().GenerateMocks(ctx, movedConfig(false)).Return(nil)
+				m.ImportRewriter.EXPECT().Rewrite("/my/app", plan.Moves).Return([]string{}, nil)
+				m.MockMover.EXPECT().RemoveOld(plan).Return(nil)
+			},
+		},
+
+		{
+			Name:           "with dry run",
+			Args:           []string{"ensure", "mocks", "move", "--primary-destination", "mocks", "--dry-run"},
+			Getwd:          defaultWd,
+			ExpectedOutput: planOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig("", false), nil)
+			},
+		},
+
+		{
+			Name:  "with JSON output",
+			Args:  []string{"ensure", "--output", "json", "mocks", "move", "--primary-destination", "mocks", "--dry-run"},
+			Getwd: defaultWd,
+			ExpectedOutput: `{"from":{"primaryDestination":"internal/mocks","internalDestination":"mocks"},` +
+				`"to":{"primaryDestination":"mocks","internalDestination":"mocks"},` +
+				`"moves":[{"oldPath":"github.com/my/app/internal/mocks/github.com/my/app/mock_store",` +
+				`"newPath":"github.com/my/app/mocks/github.com/my/app/mock_store"}],` +
+				`"remove":["/my/app/internal/mocks"]}` + "\n",
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig("", false), nil)
+			},
+		},
+
+		{
+			Name:          "when destinations are unchanged",
+			Args:          []string{"ensure", "mocks", "move", "--primary-destination", "internal/mocks"},
+			Getwd:         defaultWd,
+			ExpectedError: cmd.ErrNothingToMove,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig("", false), nil)
+			},
+		},
+
+		{
+			Name:          "when unable to get working directory",
+			Args:          []string{"ensure", "mocks", "move", "--primary-destination", "mocks"},
+			ExpectedError: exampleError,
+			Getwd: func() (string, error) {
+				return "", exampleError
+			},
+		},
+
+		{
+			Name:          "when unable to load config",
+			Args:          []string{"ensure", "mocks", "move", "--primary-destination", "mocks"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when unable to plan move",
+			Args:          []string{"ensure", "mocks", "move", "--primary-destination", "mocks"},
+			Getwd:         defaultWd,
+			ExpectedError: mockgen.ErrMissingPackages,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(&ensurefile.Config{Mocks: &ensurefile.MockConfig{}}, nil)
+			},
+		},
+
+		{
+			Name:           "when unable to set destinations",
+			Args:           []string{"ensure", "mocks", "move", "--primary-destination", "mocks"},
+			Getwd:          defaultWd,
+			ExpectedOutput: planOutput,
+			ExpectedError:  exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig("", false), nil)
+				m.MockMover.EXPECT().SetDestinations(newConfig("", false), plan).Return(exampleError)
+			},
+		},
+
+		{
+			Name:           "when unable to generate mocks",
+			Args:           []string{"ensure", "mocks", "move", "--primary-destination", "mocks"},
+			Getwd:          defaultWd,
+			ExpectedOutput: planOutput,
+			ExpectedError:  exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig("", false), nil)
+				m.MockMover.EXPECT().SetDestinations(newConfig("", false), plan).Return(nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+				m.MockGen.EXPECT().GenerateMocks(ctx, movedConfig(false)).Return(exampleError)
+			},
+		},
+
+		{
+			Name:           "when unable to rewrite imports",
+			Args:           []string{"ensure", "mocks", "move", "--primary-destination", "mocks"},
+			Getwd:          defaultWd,
+			ExpectedOutput: planOutput,
+			ExpectedError:  exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig("", false), nil)
+				m.MockMover.EXPECT().SetDestinations(newConfig("", false), plan).Return(nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+				m.MockGen.EXPECT().GenerateMocks(ctx, movedConfig(false)).Return(nil)
+				m.ImportRewriter.EXPECT().Rewrite("/my/app", plan.Moves).Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:           "when unable to remove old mocks",
+			Args:           []string{"ensure", "mocks", "move", "--primary-destination", "mocks"},
+			Getwd:          defaultWd,
+			ExpectedOutput: planOutput,
+			ExpectedError:  exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig("", false), nil)
+				m.MockMover.EXPECT().SetDestinations(newConfig("", false), plan).Return(nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+				m.MockGen.EXPECT().GenerateMocks(ctx, movedConfig(false)).Return(nil)
+				m.ImportRewriter.EXPECT().Rewrite("/my/app", plan.Moves).Return([]string{}, nil)
+				m.MockMover.EXPECT().RemoveOld(plan).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		var stdout bytes.Buffer
+		entry.Subject.Stdout = &stdout
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(stdout.String()).Equals(entry.ExpectedOutput)
+	})
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/JosiahWitt/ensure-cli/internal/mocksync"
+	"github.com/urfave/cli/v2"
+)
+
+func (a *App) mocksSyncCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "sync",
+		Usage: "adds mocks that are referenced within the module, but missing from .ensure.yml, and generates them",
+		Description: "Scans the module's Go files, including tests, for references to Mock<Iface> and NewMock<Iface> in mock packages\n" +
+			"that are not generated yet. The package of each mock is found by inverting the destination rules,\n" +
+			"so tests can be written before the mocks are configured.",
+
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Lists the missing mocks without changing .ensure.yml or generating mocks",
+			},
+			&cli.BoolFlag{
+				Name:  "disable-parallel",
+				Usage: "Disables generating the mocks in parallel",
+			},
+		},
+
+		Action: func(c *cli.Context) error {
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			config, err := a.EnsureFileLoader.LoadConfig(pwd)
+			if err != nil {
+				return err
+			}
+
+			result, err := a.MockSyncer.FindMissing(config)
+			if err != nil {
+				return err
+			}
+
+			if c.String("output") == outputJSON {
+				if err := json.NewEncoder(a.Stdout).Encode(result); err != nil {
+					return err
+				}
+			} else {
+				printSyncResult(a.Stdout, result)
+			}
+
+			if c.Bool("dry-run") || len(result.Missing) == 0 {
+				return nil
+			}
+
+			if err := a.MockSyncer.AddMissing(config, result.Missing); err != nil {
+				return err
+			}
+
+			a.Logger.Printf("Added the missing mocks to %s.\n", config.ConfigPath)
+
+			config, err = a.EnsureFileLoader.LoadConfig(pwd)
+			if err != nil {
+				return err
+			}
+
+			config.DisableParallelGeneration = c.Bool("disable-parallel")
+			for _, mock := range result.Missing {
+				config.PackageFilters = append(config.PackageFilters, mock.PackagePath)
+			}
+
+			return a.MockGenerator.GenerateMocks(a.Cleanup.ToContext(c.Context), config)
+		},
+	}
+}
+
+func printSyncResult(w io.Writer, result *mocksync.Result) {
+	if len(result.Missing) == 0 {
+		fmt.Fprintln(w, "No missing mocks.")
+	} else {
+		fmt.Fprintln(w, "Missing mocks:")
+	}
+
+	for _, mock := range result.Missing {
+		fmt.Fprintf(w, " - %s:%s (referenced in %s)\n", mock.PackagePath, mock.Interface, mock.File)
+	}
+
+	if len(result.Unresolved) > 0 {
+		fmt.Fprintln(w, "Unable to resolve:")
+	}
+
+	for _, ref := range result.Unresolved {
+		fmt.Fprintf(w, " - %s.%s (referenced in %s), since %s\n", ref.ImportPath, ref.Name, ref.File, ref.Reason)
+	}
+}
@@ -0,0 +1,248 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_context"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_exitcleanup"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_mocksync"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure-cli/internal/mocksync"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestMocksSync(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type ContextKey struct{}
+
+	type Mocks struct {
+		Context          *mock_context.MockContext `ensure:"ignoreunused"`
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		MockSyncer       *mock_mocksync.MockSyncerIface
+		MockGen          *mock_mockgen.MockMockGenerator
+		Cleanup          *mock_exitcleanup.MockExitCleaner
+		Reporter         *mock_report.MockReporterIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/my/app", nil
+	}
+
+	newConfig := func() *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			ConfigPath: "/my/app/.ensure.yml",
+			Mocks:      &ensurefile.MockConfig{},
+		}
+	}
+
+	missingResult := &mocksync.Result{
+		Missing: []*mocksync.Mock{
+			{
+				PackagePath: "github.com/my/app/internal/store",
+				Interface:   "UserStore",
+				ImportPath:  "github.com/my/app/internal/mocks/mock_store",
+				File:        "/my/app/api/api_test.go",
+			},
+			{
+				PackagePath: "github.com/some/pkg",
+				Interface:   "Iface1",
+				ImportPath:  "github.com/my/app/internal/mocks/github.com/some/mock_pkg",
+				File:        "/my/app/api/api_test.go",
+			},
+		},
+		Unresolved: []*mocksync.Reference{
+			{
+				ImportPath: "github.com/my/app/internal/mocks/mock_other",
+				Name:       "MockThing",
+				File:       "/my/app/other_test.go",
+				Reason:     "no matching interface is declared in github.com/my/app/internal/other",
+			},
+		},
+	}
+
+	const missingOutput = "Missing mocks:\n" +
+		" - github.com/my/app/internal/store:UserStore (referenced in /my/app/api/api_test.go)\n" +
+		" - github.com/some/pkg:Iface1 (referenced in /my/app/api/api_test.go)\n" +
+		"Unable to resolve:\n" +
+		" - github.com/my/app/internal/mocks/mock_other.MockThing (referenced in /my/app/other_test.go), " +
+		"since no matching interface is declared in github.com/my/app/internal/other\n"
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput string
+		ExpectedError  error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:           "with no missing mocks",
+			Args:           []string{"ensure", "mocks", "sync"},
+			Getwd:          defaultWd,
+			ExpectedOutput: "No missing mocks.\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+				m.MockSyncer.EXPECT().FindMissing(newConfig()).
+					Return(&mocksync.Result{Missing: []*mocksync.Mock{}, Unresolved: []*mocksync.Reference{}}, nil)
+			},
+		},
+
+		{
+			Name:           "with missing mocks",
+			Args:           []string{"ensure", "mocks", "sync", "--disable-parallel"},
+			Getwd:          defaultWd,
+			ExpectedOutput: missingOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+				m.MockSyncer.EXPECT().FindMissing(newConfig()).Return(missingResult, nil)
+				m.MockSyncer.EXPECT().AddMissing(newConfig(), missingResult.Missing).Return(nil)
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				expectedConfig := newConfig()
+				expectedConfig.DisableParallelGeneration = true
+				expectedConfig.PackageFilters = []string{"github.com/my/app/internal/store", "github.com/some/pkg"}
+				m.MockGen.EXPECT().GenerateMocks(ctx, expectedConfig).Return(nil)
+			},
+		},
+
+		{
+			Name:           "with missing mocks when dry run",
+			Args:           []string{"ensure", "mocks", "sync", "--dry-run"},
+			Getwd:          defaultWd,
+			ExpectedOutput: missingOutput,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+				m.MockSyncer.EXPECT().FindMissing(newConfig()).Return(missingResult, nil)
+			},
+		},
+
+		{
+			Name:  "with JSON output",
+			Args:  []string{"ensure", "--output", "json", "mocks", "sync", "--dry-run"},
+			Getwd: defaultWd,
+			ExpectedOutput: `{"missing":[` +
+				`{"packagePath":"github.com/my/app/internal/store","interface":"UserStore",` +
+				`"importPath":"github.com/my/app/internal/mocks/mock_store","file":"/my/app/api/api_test.go"},` +
+				`{"packagePath":"github.com/some/pkg","interface":"Iface1",` +
+				`"importPath":"github.com/my/app/internal/mocks/github.com/some/mock_pkg","file":"/my/app/api/api_test.go"}` +
+				`],"unresolved":[` +
+				`{"importPath":"github.com/my/app/internal/mocks/mock_other","name":"MockThing","file":"/my/app/other_test.go",` +
+				`"reason":"no matching interface is declared in github.com/my/app/internal/other"}` +
+				`]}` + "\n",
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+				m.MockSyncer.EXPECT().FindMissing(newConfig()).Return(missingResult, nil)
+			},
+		},
+
+		{
+			Name:          "when unable to get working directory",
+			Args:          []string{"ensure", "mocks", "sync"},
+			ExpectedError: exampleError,
+			Getwd: func() (string, error) {
+				return "", exampleError
+			},
+		},
+
+		{
+			Name:          "when unable to load config",
+			Args:          []string{"ensure", "mocks", "sync"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when unable to find missing mocks",
+			Args:          []string{"ensure", "mocks", "sync"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+				m.MockSyncer.EXPECT().FindMissing(newConfig()).Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:           "when unable to add missing mocks",
+			Args:           []string{"ensure", "mocks", "sync"},
+			Getwd:          defaultWd,
+			ExpectedOutput: missingOutput,
+			ExpectedError:  exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+				m.MockSyncer.EXPECT().FindMissing(newConfig()).Return(missingResult, nil)
+				m.MockSyncer.EXPECT().AddMissing(newConfig(), missingResult.Missing).Return(exampleError)
+			},
+		},
+
+		{
+			Name:           "when unable to reload config",
+			Args:           []string{"ensure", "mocks", "sync"},
+			Getwd:          defaultWd,
+			ExpectedOutput: missingOutput,
+			ExpectedError:  exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+				m.MockSyncer.EXPECT().FindMissing(newConfig()).Return(missingResult, nil)
+				m.MockSyncer.EXPECT().AddMissing(newConfig(), missingResult.Missing).Return(nil)
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:           "when unable to generate mocks",
+			Args:           []string{"ensure", "mocks", "sync"},
+			Getwd:          defaultWd,
+			ExpectedOutput: missingOutput,
+			ExpectedError:  exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+				m.MockSyncer.EXPECT().FindMissing(newConfig()).Return(missingResult, nil)
+				m.MockSyncer.EXPECT().AddMissing(newConfig(), missingResult.Missing).Return(nil)
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+				m.MockGen.EXPECT().GenerateMocks(ctx, gomock.Any()).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		var stdout bytes.Buffer
+		entry.Subject.Stdout = &stdout
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(stdout.String()).Equals(entry.ExpectedOutput)
+	})
+}
@@ -1,17 +1,26 @@
 package cmd_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io/ioutil"
+	"log"
 	"testing"
 
 	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/changes"
 	"github.com/JosiahWitt/ensure-cli/internal/cmd"
 	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_changes"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_context"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_ensurefile"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_exitcleanup"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_watch"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
 	"github.com/JosiahWitt/ensure/ensurepkg"
 	"github.com/golang/mock/gomock"
 )
@@ -26,6 +35,7 @@ func TestMocksGenerate(t *testing.T) {
 		EnsureFileLoader *mock_ensurefile.MockLoaderIface
 		MockGen          *mock_mockgen.MockMockGenerator
 		Cleanup          *mock_exitcleanup.MockExitCleaner
+		ChangeDetector   *mock_changes.MockDetectorIface
 	}
 
 	exampleError := errors.New("something went wrong")
@@ -48,20 +58,20 @@ func TestMocksGenerate(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.EnsureFileLoader.EXPECT().
-					LoadConfig("/test").
-					Return(&ensurefile.Config{
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{
 						RootPath: "/some/root/path",
 						Mocks:    &ensurefile.MockConfig{},
-					}, nil)
+					}}, nil)
 
 				ctx := context.WithValue(m.Context, ContextKey{}, "123")
 				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
 
 				m.MockGen.EXPECT().
-					GenerateMocks(ctx, &ensurefile.Config{
+					GenerateAllMocks(ctx, []*ensurefile.Config{{
 						RootPath: "/some/root/path",
 						Mocks:    &ensurefile.MockConfig{},
-					}).
+					}}).
 					Return(nil)
 			},
 		},
@@ -72,21 +82,21 @@ func TestMocksGenerate(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.EnsureFileLoader.EXPECT().
-					LoadConfig("/test").
-					Return(&ensurefile.Config{
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{
 						RootPath: "/some/root/path",
 						Mocks:    &ensurefile.MockConfig{},
-					}, nil)
+					}}, nil)
 
 				ctx := context.WithValue(m.Context, ContextKey{}, "123")
 				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
 
 				m.MockGen.EXPECT().
-					GenerateMocks(ctx, &ensurefile.Config{
+					GenerateAllMocks(ctx, []*ensurefile.Config{{
 						RootPath:                  "/some/root/path",
 						DisableParallelGeneration: true,
 						Mocks:                     &ensurefile.MockConfig{},
-					}).
+					}}).
 					Return(nil)
 			},
 		},
@@ -96,24 +106,24 @@ func TestMocksGenerate(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.EnsureFileLoader.EXPECT().
-					LoadConfig("/test").
-					Return(&ensurefile.Config{
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{
 						RootPath: "/some/root/path",
 						Mocks: &ensurefile.MockConfig{
 							TidyAfterGenerate: true,
 						},
-					}, nil)
+					}}, nil)
 
 				ctx := context.WithValue(m.Context, ContextKey{}, "123")
 				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
 
 				m.MockGen.EXPECT().
-					GenerateMocks(ctx, &ensurefile.Config{
+					GenerateAllMocks(ctx, []*ensurefile.Config{{
 						RootPath: "/some/root/path",
 						Mocks: &ensurefile.MockConfig{
 							TidyAfterGenerate: true,
 						},
-					}).
+					}}).
 					Return(nil)
 
 				m.MockGen.EXPECT().
@@ -127,6 +137,241 @@ func TestMocksGenerate(t *testing.T) {
 			},
 		},
 
+		{
+			Name:  "with valid execution: multiple modules",
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{
+						{RootPath: "/test/mod1", Mocks: &ensurefile.MockConfig{TidyAfterGenerate: true}},
+						{RootPath: "/test/mod2", Mocks: &ensurefile.MockConfig{}},
+					}, nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				m.MockGen.EXPECT().
+					GenerateAllMocks(ctx, []*ensurefile.Config{
+						{RootPath: "/test/mod1", Mocks: &ensurefile.MockConfig{TidyAfterGenerate: true}},
+						{RootPath: "/test/mod2", Mocks: &ensurefile.MockConfig{}},
+					}).
+					Return(nil)
+
+				m.MockGen.EXPECT().
+					TidyMocks(&ensurefile.Config{RootPath: "/test/mod1", Mocks: &ensurefile.MockConfig{TidyAfterGenerate: true}}).
+					Return(nil)
+			},
+		},
+
+		{
+			Name:  "with valid execution: package filters skip tidy",
+			Flags: []string{"--match", "store/*", "--match", "cache:Iface*", "github.com/my/app/pkg"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{
+						RootPath: "/some/root/path",
+						Mocks: &ensurefile.MockConfig{
+							TidyAfterGenerate: true,
+						},
+					}}, nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				m.MockGen.EXPECT().
+					GenerateAllMocks(ctx, []*ensurefile.Config{{
+						RootPath:       "/some/root/path",
+						PackageFilters: []string{"github.com/my/app/pkg", "store/*", "cache:Iface*"},
+						Mocks: &ensurefile.MockConfig{
+							TidyAfterGenerate: true,
+						},
+					}}).
+					Return(nil)
+			},
+		},
+
+		{
+			Name:  "with valid execution: override flags",
+			Flags: []string{"--primary-destination", "generated", "--tidy-after-generate"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{
+						RootPath: "/some/root/path",
+						Mocks:    &ensurefile.MockConfig{PrimaryDestination: "mocks"},
+					}}, nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				overridden := &ensurefile.Config{
+					RootPath: "/some/root/path",
+					Mocks: &ensurefile.MockConfig{
+						PrimaryDestination: "generated",
+						TidyAfterGenerate:  true,
+					},
+					Sources: map[string]string{
+						"mocks.primaryDestination": "flag --primary-destination",
+						"mocks.tidyAfterGenerate":  "flag --tidy-after-generate",
+					},
+				}
+
+				m.MockGen.EXPECT().GenerateAllMocks(ctx, []*ensurefile.Config{overridden}).Return(nil)
+				m.MockGen.EXPECT().TidyMocks(overridden).Return(nil)
+			},
+		},
+
+		{
+			Name:  "with valid execution: changed since ref",
+			Flags: []string{"--changed-since", "origin/main"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				config := &ensurefile.Config{
+					RootPath: "/some/root/path",
+					Mocks:    &ensurefile.MockConfig{TidyAfterGenerate: true},
+				}
+
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{config}, nil)
+				m.ChangeDetector.EXPECT().
+					Affected(gomock.Any(), config, "origin/main").
+					Return(&changes.Result{Packages: []string{"github.com/my/app/store"}}, nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				// Tidy is skipped, since only some of the mocks are generated
+				m.MockGen.EXPECT().
+					GenerateAllMocks(ctx, []*ensurefile.Config{{
+						RootPath:        "/some/root/path",
+						ChangedPackages: []string{"github.com/my/app/store"},
+						Mocks:           &ensurefile.MockConfig{TidyAfterGenerate: true},
+					}}).
+					Return(nil)
+			},
+		},
+
+		{
+			Name:  "with valid execution: changed since ref affecting all packages",
+			Flags: []string{"--changed-since", "origin/main"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				config := &ensurefile.Config{
+					RootPath: "/some/root/path",
+					Mocks:    &ensurefile.MockConfig{TidyAfterGenerate: true},
+				}
+
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{config}, nil)
+				m.ChangeDetector.EXPECT().Affected(gomock.Any(), config, "origin/main").Return(&changes.Result{All: true}, nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+				m.MockGen.EXPECT().GenerateAllMocks(ctx, []*ensurefile.Config{config}).Return(nil)
+				m.MockGen.EXPECT().TidyMocks(config).Return(nil)
+			},
+		},
+
+		{
+			Name:  "with valid execution: changed since ref in workspace only skips tidy of partially generated modules",
+			Flags: []string{"--changed-since", "origin/main"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				mod1 := &ensurefile.Config{RootPath: "/test/mod1", Mocks: &ensurefile.MockConfig{TidyAfterGenerate: true}}
+				mod2 := &ensurefile.Config{RootPath: "/test/mod2", Mocks: &ensurefile.MockConfig{TidyAfterGenerate: true}}
+
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{mod1, mod2}, nil)
+				m.ChangeDetector.EXPECT().
+					Affected(gomock.Any(), mod1, "origin/main").
+					Return(&changes.Result{Packages: []string{"github.com/my/mod1/store"}}, nil)
+				m.ChangeDetector.EXPECT().Affected(gomock.Any(), mod2, "origin/main").Return(&changes.Result{All: true}, nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+				m.MockGen.EXPECT().GenerateAllMocks(ctx, []*ensurefile.Config{mod1, mod2}).Return(nil)
+				m.MockGen.EXPECT().TidyMocks(mod2).Return(nil)
+			},
+		},
+
+		{
+			Name:  "with valid execution: changed since ref without affected packages",
+			Flags: []string{"--changed-since", "origin/main"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{RootPath: "/test/mod1"}, {RootPath: "/test/mod2"}}, nil)
+
+				m.ChangeDetector.EXPECT().
+					Affected(gomock.Any(), &ensurefile.Config{RootPath: "/test/mod1"}, "origin/main").
+					Return(&changes.Result{Packages: []string{}}, nil)
+
+				m.ChangeDetector.EXPECT().
+					Affected(gomock.Any(), &ensurefile.Config{RootPath: "/test/mod2"}, "origin/main").
+					Return(&changes.Result{Packages: []string{}}, nil)
+			},
+		},
+
+		{
+			Name:          "when cannot detect changes",
+			Flags:         []string{"--changed-since", "origin/main"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{RootPath: "/some/root/path"}}, nil)
+
+				m.ChangeDetector.EXPECT().Affected(gomock.Any(), gomock.Any(), "origin/main").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:  "with valid execution: verify mocks",
+			Flags: []string{"--verify"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				configs := []*ensurefile.Config{{
+					RootPath: "/some/root/path",
+					Mocks:    &ensurefile.MockConfig{},
+				}}
+
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return(configs, nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				gomock.InOrder(
+					m.MockGen.EXPECT().GenerateAllMocks(ctx, configs).Return(nil),
+					m.MockGen.EXPECT().VerifyMocks(ctx, configs).Return(nil),
+				)
+			},
+		},
+
+		{
+			Name:          "when generated mocks do not compile",
+			Flags:         []string{"--verify"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				configs := []*ensurefile.Config{{
+					RootPath: "/some/root/path",
+					Mocks:    &ensurefile.MockConfig{TidyAfterGenerate: true},
+				}}
+
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return(configs, nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				// Mocks are not tidied when verification fails
+				m.MockGen.EXPECT().GenerateAllMocks(ctx, configs).Return(nil)
+				m.MockGen.EXPECT().VerifyMocks(ctx, configs).Return(exampleError)
+			},
+		},
+
 		{
 			Name:          "when error loading working directory",
 			Getwd:         func() (string, error) { return "", exampleError },
@@ -138,7 +383,7 @@ func TestMocksGenerate(t *testing.T) {
 			Getwd:         defaultWd,
 			ExpectedError: exampleError,
 			SetupMocks: func(m *Mocks) {
-				m.EnsureFileLoader.EXPECT().LoadConfig("/test").Return(nil, exampleError)
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return(nil, exampleError)
 			},
 		},
 
@@ -148,20 +393,20 @@ func TestMocksGenerate(t *testing.T) {
 			ExpectedError: exampleError,
 			SetupMocks: func(m *Mocks) {
 				m.EnsureFileLoader.EXPECT().
-					LoadConfig("/test").
-					Return(&ensurefile.Config{
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{
 						RootPath: "/some/root/path",
 						Mocks:    &ensurefile.MockConfig{},
-					}, nil)
+					}}, nil)
 
 				ctx := context.WithValue(m.Context, ContextKey{}, "123")
 				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
 
 				m.MockGen.EXPECT().
-					GenerateMocks(ctx, &ensurefile.Config{
+					GenerateAllMocks(ctx, []*ensurefile.Config{{
 						RootPath: "/some/root/path",
 						Mocks:    &ensurefile.MockConfig{},
-					}).
+					}}).
 					Return(exampleError) // Generate fails
 			},
 		},
@@ -172,24 +417,24 @@ func TestMocksGenerate(t *testing.T) {
 			ExpectedError: exampleError,
 			SetupMocks: func(m *Mocks) {
 				m.EnsureFileLoader.EXPECT().
-					LoadConfig("/test").
-					Return(&ensurefile.Config{
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{
 						RootPath: "/some/root/path",
 						Mocks: &ensurefile.MockConfig{
 							TidyAfterGenerate: true,
 						},
-					}, nil)
+					}}, nil)
 
 				ctx := context.WithValue(m.Context, ContextKey{}, "123")
 				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
 
 				m.MockGen.EXPECT().
-					GenerateMocks(ctx, &ensurefile.Config{
+					GenerateAllMocks(ctx, []*ensurefile.Config{{
 						RootPath: "/some/root/path",
 						Mocks: &ensurefile.MockConfig{
 							TidyAfterGenerate: true,
 						},
-					}).
+					}}).
 					Return(nil)
 
 				m.MockGen.EXPECT().
@@ -207,6 +452,7 @@ func TestMocksGenerate(t *testing.T) {
 	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
 		entry := table[i]
 		entry.Subject.Getwd = entry.Getwd
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
 
 		err := entry.Subject.Run(append([]string{"ensure", "mocks", "generate"}, entry.Flags...))
 		ensure(err).IsError(entry.ExpectedError)
@@ -243,10 +489,10 @@ func TestMocksTidy(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.EnsureFileLoader.EXPECT().
-					LoadConfig("/test").
-					Return(&ensurefile.Config{
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{
 						RootPath: "/some/root/path",
-					}, nil)
+					}}, nil)
 
 				m.MockGen.EXPECT().
 					TidyMocks(&ensurefile.Config{
@@ -256,6 +502,19 @@ func TestMocksTidy(t *testing.T) {
 			},
 		},
 
+		{
+			Name:  "with valid execution: multiple modules",
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{RootPath: "/test/mod1"}, {RootPath: "/test/mod2"}}, nil)
+
+				m.MockGen.EXPECT().TidyMocks(&ensurefile.Config{RootPath: "/test/mod1"}).Return(nil)
+				m.MockGen.EXPECT().TidyMocks(&ensurefile.Config{RootPath: "/test/mod2"}).Return(nil)
+			},
+		},
+
 		{
 			Name:          "when error loading working directory",
 			Getwd:         func() (string, error) { return "", exampleError },
@@ -267,7 +526,7 @@ func TestMocksTidy(t *testing.T) {
 			Getwd:         defaultWd,
 			ExpectedError: exampleError,
 			SetupMocks: func(m *Mocks) {
-				m.EnsureFileLoader.EXPECT().LoadConfig("/test").Return(nil, exampleError)
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return(nil, exampleError)
 			},
 		},
 
@@ -277,10 +536,10 @@ func TestMocksTidy(t *testing.T) {
 			ExpectedError: exampleError,
 			SetupMocks: func(m *Mocks) {
 				m.EnsureFileLoader.EXPECT().
-					LoadConfig("/test").
-					Return(&ensurefile.Config{
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{
 						RootPath: "/some/root/path",
-					}, nil)
+					}}, nil)
 
 				m.MockGen.EXPECT().
 					TidyMocks(&ensurefile.Config{
@@ -299,3 +558,374 @@ func TestMocksTidy(t *testing.T) {
 		ensure(err).IsError(entry.ExpectedError)
 	})
 }
+
+func TestMocksWatch(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type ContextKey struct{}
+
+	type Mocks struct {
+		Context          *mock_context.MockContext `ensure:"ignoreunused"`
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		MockWatcher      *mock_watch.MockWatcherIface
+		Cleanup          *mock_exitcleanup.MockExitCleaner
+	}
+
+	exampleError := errors.New("something went wrong")
+	errUnexpectedConfig := errors.New("unexpected config")
+	defaultWd := func() (string, error) {
+		return "/test", nil
+	}
+
+	// expectWatch loads the configs and config files using the functions passed to the watcher,
+	// and ensures they match the expected configs and config files
+	expectWatch := func(m *Mocks, ctx context.Context, expectedConfigs []*ensurefile.Config, expectedFiles []string) {
+		m.MockWatcher.EXPECT().
+			Watch(ctx, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(
+				ctx context.Context,
+				loadConfigs func() ([]*ensurefile.Config, error),
+				findConfigFiles func() ([]string, error),
+			) error {
+				configFiles, err := findConfigFiles()
+				if err != nil {
+					return err
+				}
+
+				if !gomock.Eq(expectedFiles).Matches(configFiles) {
+					return errUnexpectedConfig
+				}
+
+				configs, err := loadConfigs()
+				if err != nil {
+					return err
+				}
+
+				if !gomock.Eq(expectedConfigs).Matches(configs) {
+					return errUnexpectedConfig
+				}
+
+				return nil
+			})
+	}
+
+	expectFindConfigFiles := func(m *Mocks) {
+		m.EnsureFileLoader.EXPECT().
+			FindConfigFiles("/test").
+			Return([]string{"/some/root/path/.ensure.yml"}, nil)
+	}
+
+	table := []struct {
+		Name          string
+		ExpectedError error
+		Flags         []string
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:  "with valid execution",
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectFindConfigFiles(m)
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{
+						{RootPath: "/some/root/path", Mocks: &ensurefile.MockConfig{}},
+						{RootPath: "/some/other/path", Mocks: &ensurefile.MockConfig{}},
+					}, nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				expectWatch(m, ctx, []*ensurefile.Config{
+					{RootPath: "/some/root/path", Mocks: &ensurefile.MockConfig{}},
+					{RootPath: "/some/other/path", Mocks: &ensurefile.MockConfig{}},
+				}, []string{"/some/root/path/.ensure.yml"})
+			},
+		},
+
+		{
+			Name:  "with valid execution: disabled parallel generation",
+			Flags: []string{"--disable-parallel"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectFindConfigFiles(m)
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{
+						{RootPath: "/some/root/path", Mocks: &ensurefile.MockConfig{}},
+						{RootPath: "/some/other/path", Mocks: &ensurefile.MockConfig{}},
+					}, nil)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				expectWatch(m, ctx, []*ensurefile.Config{
+					{RootPath: "/some/root/path", DisableParallelGeneration: true, Mocks: &ensurefile.MockConfig{}},
+					{RootPath: "/some/other/path", DisableParallelGeneration: true, Mocks: &ensurefile.MockConfig{}},
+				}, []string{"/some/root/path/.ensure.yml"})
+			},
+		},
+
+		{
+			Name:          "when error loading working directory",
+			Getwd:         func() (string, error) { return "", exampleError },
+			ExpectedError: exampleError,
+		},
+
+		{
+			Name:          "when cannot load config",
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				expectFindConfigFiles(m)
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return(nil, exampleError)
+
+				ctx := context.WithValue(m.Context, ContextKey{}, "123")
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+
+				expectWatch(m, ctx, nil, []string{"/some/root/path/.ensure.yml"})
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		err := entry.Subject.Run(append([]string{"ensure", "mocks", "watch"}, entry.Flags...))
+		ensure(err).IsError(entry.ExpectedError)
+	})
+}
+
+func TestMocksCheck(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type ContextKey struct{}
+
+	type Mocks struct {
+		Context          *mock_context.MockContext `ensure:"ignoreunused"`
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		MockGen          *mock_mockgen.MockMockGenerator
+		Cleanup          *mock_exitcleanup.MockExitCleaner
+		Reporter         *mock_report.MockReporterIface `ensure:"ignoreunused"`
+		ChangeDetector   *mock_changes.MockDetectorIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/test", nil
+	}
+
+	outOfDate := &mockgen.CheckResult{
+		Outdated: []*mockgen.MockFile{{
+			Package: "github.com/my/app/abc:Iface",
+			Source:  "/test/.ensure.yml:4",
+			Path:    "/test/internal/mocks/mock_abc/mock_abc.go",
+		}},
+		Missing: []*mockgen.MockFile{{
+			Package: "github.com/my/app/xyz:Iface",
+			Source:  "/test/.ensure.yml:5",
+			Path:    "/test/internal/mocks/mock_xyz/mock_xyz.go",
+		}},
+		Extra: []string{"/test/internal/mocks/mock_old"},
+	}
+
+	table := []struct {
+		Name           string
+		Args           []string
+		Result         *mockgen.CheckResult
+		ExpectedConfig *ensurefile.Config
+		ExpectedOutput string
+		ExpectedError  error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:           "when mocks are up to date",
+			Args:           []string{"ensure", "mocks", "check"},
+			Getwd:          defaultWd,
+			Result:         &mockgen.CheckResult{},
+			ExpectedConfig: &ensurefile.Config{RootPath: "/test", Mocks: &ensurefile.MockConfig{}},
+			ExpectedOutput: "All mocks are up to date.\n",
+		},
+
+		{
+			Name:   "when mocks are out of date",
+			Args:   []string{"ensure", "mocks", "check", "--disable-parallel", "--match", "internal/*"},
+			Getwd:  defaultWd,
+			Result: outOfDate,
+			ExpectedConfig: &ensurefile.Config{
+				RootPath:                  "/test",
+				Mocks:                     &ensurefile.MockConfig{},
+				DisableParallelGeneration: true,
+				PackageFilters:            []string{"internal/*"},
+			},
+			ExpectedOutput: "Outdated mocks:\n" +
+				" - /test/internal/mocks/mock_abc/mock_abc.go (github.com/my/app/abc:Iface, from /test/.ensure.yml:4)\n" +
+				"Missing mocks:\n" +
+				" - /test/internal/mocks/mock_xyz/mock_xyz.go (github.com/my/app/xyz:Iface, from /test/.ensure.yml:5)\n" +
+				"Files that would be tidied:\n" +
+				" - /test/internal/mocks/mock_old\n",
+			ExpectedError: mockgen.ErrMocksOutOfDate,
+		},
+
+		{
+			Name:           "with json output",
+			Getwd:          defaultWd,
+			Result:         &mockgen.CheckResult{Outdated: outOfDate.Outdated, Missing: []*mockgen.MockFile{}, Extra: []string{}},
+			ExpectedConfig: &ensurefile.Config{RootPath: "/test", Mocks: &ensurefile.MockConfig{}},
+			Args:           []string{"ensure", "--output", "json", "mocks", "check"},
+			ExpectedError:  mockgen.ErrMocksOutOfDate,
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Report(&report.Event{
+					Type: report.EventMocksChecked,
+					MocksCheck: &report.MocksCheck{
+						Outdated: []*report.MockFile{{
+							Package: "github.com/my/app/abc:Iface",
+							Source:  "/test/.ensure.yml:4",
+							Path:    "/test/internal/mocks/mock_abc/mock_abc.go",
+						}},
+						Missing: []*report.MockFile{},
+						Extra:   []string{},
+					},
+				})
+			},
+		},
+
+		{
+			Name:           "with changed since ref without affected packages",
+			Args:           []string{"ensure", "mocks", "check", "--changed-since", "origin/main"},
+			Getwd:          defaultWd,
+			ExpectedOutput: "All mocks are up to date.\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{RootPath: "/test", Mocks: &ensurefile.MockConfig{}}}, nil)
+
+				m.ChangeDetector.EXPECT().
+					Affected(gomock.Any(), &ensurefile.Config{RootPath: "/test", Mocks: &ensurefile.MockConfig{}}, "origin/main").
+					Return(&changes.Result{Packages: []string{}}, nil)
+			},
+		},
+
+		{
+			Name:   "with changed since ref",
+			Args:   []string{"ensure", "mocks", "check", "--changed-since", "origin/main"},
+			Getwd:  defaultWd,
+			Result: &mockgen.CheckResult{},
+			ExpectedConfig: &ensurefile.Config{
+				RootPath:        "/test",
+				Mocks:           &ensurefile.MockConfig{},
+				ChangedPackages: []string{"github.com/my/app/abc"},
+			},
+			ExpectedOutput: "All mocks are up to date.\n",
+			SetupMocks: func(m *Mocks) {
+				m.ChangeDetector.EXPECT().
+					Affected(gomock.Any(), gomock.Any(), "origin/main").
+					Return(&changes.Result{Packages: []string{"github.com/my/app/abc"}}, nil)
+			},
+		},
+
+		{
+			Name:   "with staged files",
+			Args:   []string{"ensure", "mocks", "check", "--staged"},
+			Getwd:  defaultWd,
+			Result: outOfDate,
+			ExpectedConfig: &ensurefile.Config{
+				RootPath:        "/test",
+				Mocks:           &ensurefile.MockConfig{},
+				ChangedPackages: []string{"github.com/my/app/abc", "github.com/my/app/xyz"},
+			},
+			ExpectedOutput: "Outdated mocks:\n" +
+				" - /test/internal/mocks/mock_abc/mock_abc.go (github.com/my/app/abc:Iface, from /test/.ensure.yml:4)\n" +
+				"Missing mocks:\n" +
+				" - /test/internal/mocks/mock_xyz/mock_xyz.go (github.com/my/app/xyz:Iface, from /test/.ensure.yml:5)\n" +
+				"Files that would be tidied:\n" +
+				" - /test/internal/mocks/mock_old\n",
+			ExpectedError: mockgen.ErrMocksOutOfDate,
+			SetupMocks: func(m *Mocks) {
+				m.ChangeDetector.EXPECT().
+					AffectedByStaged(gomock.Any(), gomock.Any()).
+					Return(&changes.Result{Packages: []string{"github.com/my/app/abc", "github.com/my/app/xyz"}}, nil)
+			},
+		},
+
+		{
+			Name:          "with both changed since ref and staged files",
+			Args:          []string{"ensure", "mocks", "check", "--changed-since", "origin/main", "--staged"},
+			Getwd:         defaultWd,
+			ExpectedError: cmd.ErrConflictingChangeFlags,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{RootPath: "/test", Mocks: &ensurefile.MockConfig{}}}, nil)
+			},
+		},
+
+		{
+			Name:          "when error loading working directory",
+			Args:          []string{"ensure", "mocks", "check"},
+			Getwd:         func() (string, error) { return "", exampleError },
+			ExpectedError: exampleError,
+		},
+
+		{
+			Name:          "when cannot load config",
+			Args:          []string{"ensure", "mocks", "check"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when cannot check mocks",
+			Args:          []string{"ensure", "mocks", "check"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().
+					LoadConfigs("/test").
+					Return([]*ensurefile.Config{{RootPath: "/test", Mocks: &ensurefile.MockConfig{}}}, nil)
+
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(m.Context)
+				m.MockGen.EXPECT().CheckMocks(gomock.Any(), gomock.Any()).Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		if entry.ExpectedConfig != nil {
+			entry.Mocks.EnsureFileLoader.EXPECT().
+				LoadConfigs("/test").
+				Return([]*ensurefile.Config{{RootPath: "/test", Mocks: &ensurefile.MockConfig{}}}, nil)
+
+			ctx := context.WithValue(entry.Mocks.Context, ContextKey{}, "123")
+			entry.Mocks.Cleanup.EXPECT().ToContext(gomock.Any()).Return(ctx)
+			entry.Mocks.MockGen.EXPECT().
+				CheckMocks(ctx, []*ensurefile.Config{entry.ExpectedConfig}).
+				Return(entry.Result, nil)
+		}
+
+		var stdout bytes.Buffer
+		entry.Subject.Stdout = &stdout
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+
+		entry.Mocks.Reporter.EXPECT().Enable().AnyTimes()
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(errors.Is(err, entry.ExpectedError)).IsTrue()
+		ensure(stdout.String()).Equals(entry.ExpectedOutput)
+	})
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/JosiahWitt/ensure-cli/internal/unused"
+	"github.com/urfave/cli/v2"
+)
+
+type unusedOutput struct {
+	Unused  []*unused.Mock `json:"unused"`
+	Removed bool           `json:"removed"`
+}
+
+func (a *App) mocksUnusedCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "unused",
+		Usage: "lists the interfaces in .ensure.yml whose mocks are never referenced within the module",
+		Description: "Scans the module's Go files, including tests, for references to the Mock<Iface> and NewMock<Iface> identifiers\n" +
+			"of each generated mock package. Use --remove to delete the unused interfaces from .ensure.yml.",
+
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "remove",
+				Usage: "Removes the unused interfaces from .ensure.yml, along with any packages left without interfaces",
+			},
+		},
+
+		Action: func(c *cli.Context) error {
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			config, err := a.EnsureFileLoader.LoadConfig(pwd)
+			if err != nil {
+				return err
+			}
+
+			unusedMocks, err := a.UnusedDetector.FindUnused(config)
+			if err != nil {
+				return err
+			}
+
+			remove := c.Bool("remove") && len(unusedMocks) > 0
+			if remove {
+				if err := a.UnusedDetector.RemoveUnused(config, unusedMocks); err != nil {
+					return err
+				}
+			}
+
+			if c.String("output") == outputJSON {
+				return json.NewEncoder(a.Stdout).Encode(&unusedOutput{Unused: unusedMocks, Removed: remove})
+			}
+
+			if len(unusedMocks) == 0 {
+				fmt.Fprintln(a.Stdout, "All configured mocks are used.")
+				return nil
+			}
+
+			skipped := false
+			fmt.Fprintln(a.Stdout, "Unused mocks:")
+			for _, mock := range unusedMocks {
+				fmt.Fprintf(a.Stdout, " - %s:%d: %s:%s (%s)\n", mock.File, mock.Line, mock.PackagePath, mock.Interface, mock.MockName)
+				skipped = skipped || mock.File != config.ConfigPath
+			}
+
+			if remove {
+				fmt.Fprintf(a.Stdout, "Removed them from %s. Run 'ensure mocks tidy' to delete their mock files.\n", config.ConfigPath)
+			}
+
+			if remove && skipped {
+				fmt.Fprintf(a.Stdout, "Mocks configured outside of %s were not removed. "+
+					"Please remove them from their nested .ensure.yml files or //ensure:mock annotations.\n", config.ConfigPath)
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,220 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_unused"
+	"github.com/JosiahWitt/ensure-cli/internal/unused"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestMocksUnused(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		UnusedDetector   *mock_unused.MockDetectorIface
+		Reporter         *mock_report.MockReporterIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/my/app", nil
+	}
+
+	config := &ensurefile.Config{
+		RootPath:   "/my/app",
+		ModulePath: "github.com/my/app",
+		ConfigPath: "/my/app/.ensure.yml",
+	}
+
+	unusedMocks := []*unused.Mock{
+		{
+			PackagePath: "github.com/some/pkg",
+			Interface:   "Iface1",
+			MockName:    "MockIface1",
+			ImportPath:  "github.com/my/app/internal/mocks/github.com/some/mock_pkg",
+			File:        "/my/app/.ensure.yml",
+			Line:        4,
+		},
+		{
+			PackagePath: "github.com/my/app/internal/pkg",
+			Interface:   "Iface2",
+			MockName:    "MockIface2",
+			ImportPath:  "github.com/my/app/internal/mocks/mock_pkg",
+			File:        "/my/app/.ensure.yml",
+			Line:        7,
+		},
+	}
+
+	annotatedMocks := append(unusedMocks[:2:2], &unused.Mock{
+		PackagePath: "github.com/my/app/internal/pkg",
+		Interface:   "Iface3",
+		MockName:    "FakeIface3",
+		ImportPath:  "github.com/my/app/internal/mocks/mock_pkg",
+		File:        "/my/app/internal/pkg/pkg.go",
+		Line:        12,
+	})
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput string
+		ExpectedError  error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:           "with no unused mocks",
+			Args:           []string{"ensure", "mocks", "unused"},
+			Getwd:          defaultWd,
+			ExpectedOutput: "All configured mocks are used.\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(config, nil)
+				m.UnusedDetector.EXPECT().FindUnused(config).Return([]*unused.Mock{}, nil)
+			},
+		},
+
+		{
+			Name:  "with unused mocks",
+			Args:  []string{"ensure", "mocks", "unused"},
+			Getwd: defaultWd,
+			ExpectedOutput: "Unused mocks:\n" +
+				" - /my/app/.ensure.yml:4: github.com/some/pkg:Iface1 (MockIface1)\n" +
+				" - /my/app/.ensure.yml:7: github.com/my/app/internal/pkg:Iface2 (MockIface2)\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(config, nil)
+				m.UnusedDetector.EXPECT().FindUnused(config).Return(unusedMocks, nil)
+			},
+		},
+
+		{
+			Name:  "with unused mocks when removing",
+			Args:  []string{"ensure", "mocks", "unused", "--remove"},
+			Getwd: defaultWd,
+			ExpectedOutput: "Unused mocks:\n" +
+				" - /my/app/.ensure.yml:4: github.com/some/pkg:Iface1 (MockIface1)\n" +
+				" - /my/app/.ensure.yml:7: github.com/my/app/internal/pkg:Iface2 (MockIface2)\n" +
+				"Removed them from /my/app/.ensure.yml. Run 'ensure mocks tidy' to delete their mock files.\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(config, nil)
+				m.UnusedDetector.EXPECT().FindUnused(config).Return(unusedMocks, nil)
+				m.UnusedDetector.EXPECT().RemoveUnused(config, unusedMocks).Return(nil)
+			},
+		},
+
+		{
+			Name:  "with unused annotated or nested mocks when removing",
+			Args:  []string{"ensure", "mocks", "unused", "--remove"},
+			Getwd: defaultWd,
+			ExpectedOutput: "Unused mocks:\n" +
+				" - /my/app/.ensure.yml:4: github.com/some/pkg:Iface1 (MockIface1)\n" +
+				" - /my/app/.ensure.yml:7: github.com/my/app/internal/pkg:Iface2 (MockIface2)\n" +
+				" - /my/app/internal/pkg/pkg.go:12: github.com/my/app/internal/pkg:Iface3 (FakeIface3)\n" +
+				"Removed them from /my/app/.ensure.yml. Run 'ensure mocks tidy' to delete their mock files.\n" +
+				"Mocks configured outside of /my/app/.ensure.yml were not removed. " +
+				"Please remove them from their nested .ensure.yml files or //ensure:mock annotations.\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(config, nil)
+				m.UnusedDetector.EXPECT().FindUnused(config).Return(annotatedMocks, nil)
+				m.UnusedDetector.EXPECT().RemoveUnused(config, annotatedMocks).Return(nil)
+			},
+		},
+
+		{
+			Name:           "with no unused mocks when removing",
+			Args:           []string{"ensure", "mocks", "unused", "--remove"},
+			Getwd:          defaultWd,
+			ExpectedOutput: "All configured mocks are used.\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(config, nil)
+				m.UnusedDetector.EXPECT().FindUnused(config).Return([]*unused.Mock{}, nil)
+			},
+		},
+
+		{
+			Name:  "with JSON output",
+			Args:  []string{"ensure", "--output", "json", "mocks", "unused", "--remove"},
+			Getwd: defaultWd,
+			ExpectedOutput: `{"unused":[` +
+				`{"packagePath":"github.com/some/pkg","interface":"Iface1","mockName":"MockIface1",` +
+				`"importPath":"github.com/my/app/internal/mocks/github.com/some/mock_pkg","file":"/my/app/.ensure.yml","line":4},` +
+				`{"packagePath":"github.com/my/app/internal/pkg","interface":"Iface2","mockName":"MockIface2",` +
+				`"importPath":"github.com/my/app/internal/mocks/mock_pkg","file":"/my/app/.ensure.yml","line":7}` +
+				`],"removed":true}` + "\n",
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(config, nil)
+				m.UnusedDetector.EXPECT().FindUnused(config).Return(unusedMocks, nil)
+				m.UnusedDetector.EXPECT().RemoveUnused(config, unusedMocks).Return(nil)
+			},
+		},
+
+		{
+			Name:          "when unable to get working directory",
+			Args:          []string{"ensure", "mocks", "unused"},
+			ExpectedError: exampleError,
+			Getwd: func() (string, error) {
+				return "", exampleError
+			},
+		},
+
+		{
+			Name:          "when unable to load config",
+			Args:          []string{"ensure", "mocks", "unused"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when unable to find unused mocks",
+			Args:          []string{"ensure", "mocks", "unused"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(config, nil)
+				m.UnusedDetector.EXPECT().FindUnused(config).Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when unable to remove unused mocks",
+			Args:          []string{"ensure", "mocks", "unused", "--remove"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(config, nil)
+				m.UnusedDetector.EXPECT().FindUnused(config).Return(unusedMocks, nil)
+				m.UnusedDetector.EXPECT().RemoveUnused(config, unusedMocks).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		var stdout bytes.Buffer
+		entry.Subject.Stdout = &stdout
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(stdout.String()).Equals(entry.ExpectedOutput)
+	})
+}
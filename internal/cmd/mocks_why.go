@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/erk"
+	"github.com/urfave/cli/v2"
+)
+
+var ErrMissingWhyPath = erk.New(ErkInvalidFlag{}, "Please provide exactly one path to explain, such as: ensure mocks why internal/mocks/mock_pkg/mock_pkg.go")
+
+func (a *App) mocksWhyCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "why",
+		Usage:     "explains which package in .ensure.yml generates a file or directory in a mock directory",
+		ArgsUsage: "<path>",
+		Description: "Prints the .ensure.yml entry (including its line number) that generates the path, and which destination rule applied.\n" +
+			"If no entry generates the path, explains whether 'ensure mocks tidy' would delete it.",
+
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return ErrMissingWhyPath
+			}
+
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			config, err := a.EnsureFileLoader.LoadConfig(pwd)
+			if err != nil {
+				return err
+			}
+
+			path := c.Args().First()
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(pwd, path)
+			}
+
+			explanation, err := mockgen.ExplainPath(config, path)
+			if err != nil {
+				return err
+			}
+
+			if c.String("output") == outputJSON {
+				return json.NewEncoder(a.Stdout).Encode(explanation)
+			}
+
+			printExplanation(a.Stdout, explanation)
+			return nil
+		},
+	}
+}
+
+func printExplanation(w io.Writer, explanation *mockgen.PathExplanation) {
+	fmt.Fprintln(w, explanation.Path)
+
+	if len(explanation.Entries) > 0 {
+		fmt.Fprintln(w, "Generated by:")
+	} else {
+		fmt.Fprintln(w, "Not generated by any package in .ensure.yml.")
+	}
+
+	for _, entry := range explanation.Entries {
+		fmt.Fprintf(w, " - %s:%d: %s (%s)\n", entry.File, entry.Line, entry.PackagePath, strings.Join(entry.Interfaces, ", "))
+		fmt.Fprintf(w, "   File: %s\n", entry.FilePath)
+		fmt.Fprintf(w, "   Destination: %s\n", describeDestination(entry))
+	}
+
+	switch {
+	case explanation.TidyRemoves:
+		fmt.Fprintf(w, "Tidy would delete it, since it is within the mock directory %s, "+
+			"and tidy deletes anything in mock directories that would not be generated.\n", explanation.MockDir)
+	case explanation.MockDir == "" && len(explanation.Entries) == 0:
+		fmt.Fprintln(w, "Tidy would not delete it, since it is not within a mock directory.")
+	}
+}
+
+func describeDestination(entry *mockgen.PathEntry) string {
+	if entry.Destination == mockgen.DestinationInternal {
+		return fmt.Sprintf("internal, since the package path contains 'internal/', "+
+			"its mocks are generated in %s next to the last internal directory, so they can import the package",
+			entry.DestinationDir,
+		)
+	}
+
+	return fmt.Sprintf("primary, since the package is not internal, its mocks are generated in %s at the module root",
+		entry.DestinationDir,
+	)
+}
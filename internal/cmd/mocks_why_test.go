@@ -0,0 +1,201 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestMocksWhy(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		Reporter         *mock_report.MockReporterIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/my/app", nil
+	}
+
+	newConfig := func() *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			ConfigPath: "/my/app/.ensure.yml",
+			Mocks: &ensurefile.MockConfig{
+				Packages: []*ensurefile.Package{
+					{
+						Path:       "github.com/some/pkg",
+						Interfaces: []string{"Iface1", "Iface2"},
+						Line:       4,
+					},
+					{
+						Path:       "github.com/my/app/layer1/internal/pkg",
+						Interfaces: []string{"Iface3"},
+						Line:       7,
+					},
+				},
+			},
+		}
+	}
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput string
+		ExpectedError  error
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:  "with file generated by primary destination",
+			Args:  []string{"ensure", "mocks", "why", "internal/mocks/github.com/some/mock_pkg/mock_pkg.go"},
+			Getwd: defaultWd,
+			ExpectedOutput: "/my/app/internal/mocks/github.com/some/mock_pkg/mock_pkg.go\n" +
+				"Generated by:\n" +
+				" - /my/app/.ensure.yml:4: github.com/some/pkg (Iface1, Iface2)\n" +
+				"   File: /my/app/internal/mocks/github.com/some/mock_pkg/mock_pkg.go\n" +
+				"   Destination: primary, since the package is not internal, its mocks are generated in internal/mocks at the module root\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+			},
+		},
+
+		{
+			Name:  "with file generated by internal destination",
+			Args:  []string{"ensure", "mocks", "why", "/my/app/layer1/internal/mocks/mock_pkg/mock_pkg.go"},
+			Getwd: defaultWd,
+			ExpectedOutput: "/my/app/layer1/internal/mocks/mock_pkg/mock_pkg.go\n" +
+				"Generated by:\n" +
+				" - /my/app/.ensure.yml:7: github.com/my/app/layer1/internal/pkg (Iface3)\n" +
+				"   File: /my/app/layer1/internal/mocks/mock_pkg/mock_pkg.go\n" +
+				"   Destination: internal, since the package path contains 'internal/', " +
+				"its mocks are generated in internal/mocks next to the last internal directory, so they can import the package\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+			},
+		},
+
+		{
+			Name:  "with file generated by nested config",
+			Args:  []string{"ensure", "mocks", "why", "team/fakes/github.com/my/app/team/mock_store/mock_store.go"},
+			Getwd: defaultWd,
+			ExpectedOutput: "/my/app/team/fakes/github.com/my/app/team/mock_store/mock_store.go\n" +
+				"Generated by:\n" +
+				" - /my/app/team/.ensure.yml:3: github.com/my/app/team/store (Store)\n" +
+				"   File: /my/app/team/fakes/github.com/my/app/team/mock_store/mock_store.go\n" +
+				"   Destination: primary, since the package is not internal, its mocks are generated in team/fakes at the module root\n",
+			SetupMocks: func(m *Mocks) {
+				config := newConfig()
+				config.Mocks.Packages = append(config.Mocks.Packages, &ensurefile.Package{
+					Path:               "github.com/my/app/team/store",
+					Interfaces:         []string{"Store"},
+					File:               "/my/app/team/.ensure.yml",
+					Line:               3,
+					PrimaryDestination: "team/fakes",
+				})
+
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(config, nil)
+			},
+		},
+
+		{
+			Name:  "with file that tidy would delete",
+			Args:  []string{"ensure", "mocks", "why", "internal/mocks/extra.go"},
+			Getwd: defaultWd,
+			ExpectedOutput: "/my/app/internal/mocks/extra.go\n" +
+				"Not generated by any package in .ensure.yml.\n" +
+				"Tidy would delete it, since it is within the mock directory /my/app/internal/mocks, " +
+				"and tidy deletes anything in mock directories that would not be generated.\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+			},
+		},
+
+		{
+			Name:  "with file outside mock directories",
+			Args:  []string{"ensure", "mocks", "why", "main.go"},
+			Getwd: defaultWd,
+			ExpectedOutput: "/my/app/main.go\n" +
+				"Not generated by any package in .ensure.yml.\n" +
+				"Tidy would not delete it, since it is not within a mock directory.\n",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+			},
+		},
+
+		{
+			Name:  "with json output",
+			Args:  []string{"ensure", "--output", "json", "mocks", "why", "internal/mocks/extra.go"},
+			Getwd: defaultWd,
+			ExpectedOutput: `{"path":"/my/app/internal/mocks/extra.go","entries":[],` +
+				`"mockDir":"/my/app/internal/mocks","tidyRemoves":true}` + "\n",
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(newConfig(), nil)
+			},
+		},
+
+		{
+			Name:          "when missing path",
+			Args:          []string{"ensure", "mocks", "why"},
+			Getwd:         defaultWd,
+			ExpectedError: cmd.ErrMissingWhyPath,
+		},
+
+		{
+			Name:          "when error loading working directory",
+			Args:          []string{"ensure", "mocks", "why", "main.go"},
+			Getwd:         func() (string, error) { return "", exampleError },
+			ExpectedError: exampleError,
+		},
+
+		{
+			Name:          "when cannot load config",
+			Args:          []string{"ensure", "mocks", "why", "main.go"},
+			Getwd:         defaultWd,
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when config is invalid",
+			Args:          []string{"ensure", "mocks", "why", "main.go"},
+			Getwd:         defaultWd,
+			ExpectedError: mockgen.ErrMissingMockConfig,
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfig("/my/app").Return(&ensurefile.Config{}, nil)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		var stdout bytes.Buffer
+		entry.Subject.Stdout = &stdout
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(stdout.String()).Equals(entry.ExpectedOutput)
+	})
+}
@@ -1,23 +1,64 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
+	"path/filepath"
 
+	"github.com/JosiahWitt/ensure-cli/internal/changes"
+	"github.com/JosiahWitt/ensure-cli/internal/configmigrate"
+	"github.com/JosiahWitt/ensure-cli/internal/doctor"
 	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
 	"github.com/JosiahWitt/ensure-cli/internal/exitcleanup"
+	"github.com/JosiahWitt/ensure-cli/internal/exitcode"
+	"github.com/JosiahWitt/ensure-cli/internal/hooks"
+	"github.com/JosiahWitt/ensure-cli/internal/importrewrite"
 	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mockimport"
+	"github.com/JosiahWitt/ensure-cli/internal/mockmove"
+	"github.com/JosiahWitt/ensure-cli/internal/mocksync"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
+	"github.com/JosiahWitt/ensure-cli/internal/unused"
+	"github.com/JosiahWitt/ensure-cli/internal/watch"
+	"github.com/JosiahWitt/erk"
 	"github.com/urfave/cli/v2"
 )
 
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type ErkInvalidFlag struct{ erk.DefaultKind }
+
+var (
+	ErrInvalidOutputFormat = erk.New(ErkInvalidFlag{}, "Invalid output format '{{.format}}'. It must be either 'text' or 'json'.")
+	ErrIncorrectUsage      = erk.New(ErkInvalidFlag{}, "Incorrect usage: {{.err}}")
+)
+
 // App is the CLI application for ensure.
 type App struct {
 	Version string
 
 	Logger           *log.Logger
+	Stdout           io.Writer
 	Getwd            func() (string, error)
 	EnsureFileLoader ensurefile.LoaderIface
 	MockGenerator    mockgen.MockGenerator
+	MockWatcher      watch.WatcherIface
+	MockSyncer       mocksync.SyncerIface
+	MockImporter     mockimport.ImporterIface
+	ImportRewriter   importrewrite.RewriterIface
+	MockMover        mockmove.MoverIface
+	Doctor           doctor.DoctorIface
+	UnusedDetector   unused.DetectorIface
+	ConfigMigrator   configmigrate.MigratorIface
+	ChangeDetector   changes.DetectorIface
+	HookInstaller    hooks.InstallerIface
 	Cleanup          exitcleanup.ExitCleaner
+	Reporter         report.ReporterIface
 }
 
 // Run the application given the os.Args array.
@@ -27,13 +68,121 @@ func (a *App) Run(args []string) error {
 		Usage:   "A balanced test framework for Go 1.14+.",
 		Version: a.Version,
 
+		Description: exitcode.Table,
+
 		ExitErrHandler: func(context *cli.Context, err error) {}, // Bubble up error
+		OnUsageError:   usageError,
+
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "output",
+				Value: outputText,
+				Usage: "Output format, either 'text' or 'json'. JSON writes one event per line, for use by other tools",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				EnvVars: []string{"ENSURE_CONFIG"},
+				Usage:   "Path to the config file to use, instead of the .ensure.yml file next to go.mod. Relative paths are relative to --chdir",
+			},
+			&cli.StringFlag{
+				Name:    "chdir",
+				Aliases: []string{"C"},
+				Usage:   "Runs as if ensure was started in the directory, such as the root of another module",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			if err := a.setupOutput(c); err != nil {
+				return err
+			}
+
+			return a.setupPaths(c)
+		},
 
 		Commands: []*cli.Command{
 			a.generateCmd(),
 			a.mocksCmd(),
+			a.configCmd(),
+			a.hooksCmd(),
+			a.doctorCmd(),
 		},
 	}
 
+	handleUsageErrors(cliApp.Commands)
 	return cliApp.Run(args)
 }
+
+// handleUsageErrors sets the usage error handler on each command and subcommand, since urfave/cli does not inherit it.
+func handleUsageErrors(commands []*cli.Command) {
+	for _, command := range commands {
+		command.OnUsageError = usageError
+		handleUsageErrors(command.Subcommands)
+	}
+}
+
+// usageError shows the help like urfave/cli does by default, and wraps the error, so it exits with the usage exit code.
+func usageError(c *cli.Context, err error, isSubcommand bool) error {
+	fmt.Fprintf(c.App.Writer, "Incorrect Usage: %v\n\n", err)
+
+	switch {
+	case isSubcommand:
+		_ = cli.ShowSubcommandHelp(c)
+	case c.Command.Name != "":
+		_ = cli.ShowCommandHelp(c, c.Command.Name)
+	default:
+		_ = cli.ShowAppHelp(c)
+	}
+
+	return erk.WrapWith(ErrIncorrectUsage, err, erk.Params{
+		"err": err,
+	})
+}
+
+// setupOutput switches from log lines to JSON events when JSON output is requested.
+func (a *App) setupOutput(c *cli.Context) error {
+	switch format := c.String("output"); format {
+	case outputText:
+		return nil
+	case outputJSON:
+		a.Logger.SetOutput(ioutil.Discard)
+		a.Reporter.Enable()
+		return nil
+	default:
+		return erk.WithParams(ErrInvalidOutputFormat, erk.Params{
+			"format": format,
+		})
+	}
+}
+
+// setupPaths applies the --chdir and --config flags, so commands run in the directory using the config file.
+func (a *App) setupPaths(c *cli.Context) error {
+	if dir := c.String("chdir"); dir != "" {
+		getwd := a.Getwd
+		a.Getwd = func() (string, error) {
+			if filepath.IsAbs(dir) {
+				return filepath.Clean(dir), nil
+			}
+
+			pwd, err := getwd()
+			if err != nil {
+				return "", err
+			}
+
+			return filepath.Join(pwd, dir), nil
+		}
+	}
+
+	if configPath := c.String("config"); configPath != "" {
+		if !filepath.IsAbs(configPath) {
+			pwd, err := a.Getwd()
+			if err != nil {
+				return err
+			}
+
+			configPath = filepath.Join(pwd, configPath)
+		}
+
+		a.EnsureFileLoader.UseConfigFile(filepath.Clean(configPath))
+	}
+
+	return nil
+}
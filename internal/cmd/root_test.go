@@ -0,0 +1,204 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/cmd"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_context"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_exitcleanup"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestOutputFlag(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Context          *mock_context.MockContext `ensure:"ignoreunused"`
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		MockGen          *mock_mockgen.MockMockGenerator
+		Cleanup          *mock_exitcleanup.MockExitCleaner
+		Reporter         *mock_report.MockReporterIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	table := []struct {
+		Name           string
+		ExpectedError  error
+		ExpectedOutput string
+		Flags          []string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:           "with default output",
+			ExpectedOutput: "Skipping tidy, since only some of the mocks were generated.\n",
+			SetupMocks: func(m *Mocks) {
+				config := &ensurefile.Config{Mocks: &ensurefile.MockConfig{TidyAfterGenerate: true}}
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{config}, nil)
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(m.Context)
+				m.MockGen.EXPECT().GenerateAllMocks(m.Context, []*ensurefile.Config{config}).Return(nil)
+			},
+		},
+
+		{
+			Name:           "with text output",
+			Flags:          []string{"--output", "text"},
+			ExpectedOutput: "Skipping tidy, since only some of the mocks were generated.\n",
+			SetupMocks: func(m *Mocks) {
+				config := &ensurefile.Config{Mocks: &ensurefile.MockConfig{TidyAfterGenerate: true}}
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{config}, nil)
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(m.Context)
+				m.MockGen.EXPECT().GenerateAllMocks(m.Context, []*ensurefile.Config{config}).Return(nil)
+			},
+		},
+
+		{
+			Name:           "with json output",
+			Flags:          []string{"--output", "json"},
+			ExpectedOutput: "",
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+
+				config := &ensurefile.Config{Mocks: &ensurefile.MockConfig{TidyAfterGenerate: true}}
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return([]*ensurefile.Config{config}, nil)
+				m.Cleanup.EXPECT().ToContext(gomock.Any()).Return(m.Context)
+				m.MockGen.EXPECT().GenerateAllMocks(m.Context, []*ensurefile.Config{config}).Return(nil)
+			},
+		},
+
+		{
+			Name:          "with json output when command fails",
+			Flags:         []string{"--output", "json"},
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.Reporter.EXPECT().Enable()
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/test").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "with invalid output",
+			Flags:         []string{"--output", "xml"},
+			ExpectedError: cmd.ErrInvalidOutputFormat,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+
+		var output bytes.Buffer
+		entry.Subject.Logger = log.New(&output, "", 0)
+
+		args := append(append([]string{"ensure"}, entry.Flags...), "mocks", "generate", "--match", "pkg")
+		err := entry.Subject.Run(args)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
+
+func TestPathFlags(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Context          *mock_context.MockContext `ensure:"ignoreunused"`
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		MockGen          *mock_mockgen.MockMockGenerator
+		Cleanup          *mock_exitcleanup.MockExitCleaner
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	table := []struct {
+		Name          string
+		Flags         []string
+		ConfigEnv     string
+		Getwd         func() (string, error)
+		ExpectedPWD   string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:        "with relative chdir",
+			Flags:       []string{"-C", "services/api"},
+			ExpectedPWD: "/test/services/api",
+		},
+		{
+			Name:        "with absolute chdir",
+			Flags:       []string{"--chdir", "/other/module/"},
+			ExpectedPWD: "/other/module",
+		},
+		{
+			Name:        "with absolute config",
+			Flags:       []string{"--config", "/build/ensure.yml"},
+			ExpectedPWD: "/test",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().UseConfigFile("/build/ensure.yml")
+			},
+		},
+		{
+			Name:        "with relative config and chdir",
+			Flags:       []string{"-C", "services/api", "--config", "../../build/api.json"},
+			ExpectedPWD: "/test/services/api",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().UseConfigFile("/test/build/api.json")
+			},
+		},
+		{
+			Name:        "with config environment variable",
+			ConfigEnv:   "build/ensure.yaml",
+			ExpectedPWD: "/test",
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().UseConfigFile("/test/build/ensure.yaml")
+			},
+		},
+		{
+			Name:          "when unable to get working directory for config",
+			Flags:         []string{"--config", "ensure.yml"},
+			Getwd:         func() (string, error) { return "", exampleError },
+			ExpectedError: exampleError,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+		entry.Subject.Getwd = entry.Getwd
+		if entry.Getwd == nil {
+			entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+		}
+
+		if entry.ConfigEnv != "" {
+			os.Setenv("ENSURE_CONFIG", entry.ConfigEnv)
+			defer os.Unsetenv("ENSURE_CONFIG")
+		}
+
+		if entry.ExpectedError == nil {
+			config := &ensurefile.Config{Mocks: &ensurefile.MockConfig{}}
+			entry.Mocks.EnsureFileLoader.EXPECT().LoadConfigs(entry.ExpectedPWD).Return([]*ensurefile.Config{config}, nil)
+			entry.Mocks.Cleanup.EXPECT().ToContext(gomock.Any()).Return(entry.Mocks.Context)
+			entry.Mocks.MockGen.EXPECT().GenerateAllMocks(entry.Mocks.Context, []*ensurefile.Config{config}).Return(nil)
+		}
+
+		args := append(append([]string{"ensure"}, entry.Flags...), "mocks", "generate")
+		err := entry.Subject.Run(args)
+		ensure(err).IsError(entry.ExpectedError)
+	})
+}
@@ -0,0 +1,77 @@
+// Package configmigrate upgrades .ensure.yml files to the current version of the config format.
+package configmigrate
+
+import (
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/erk"
+)
+
+var ErrCannotWriteFile = erk.New(mockgen.ErkFSWriteError{}, "Could not write '{{.path}}': {{.err}}")
+
+// Migration of a config file between versions of the config format.
+type Migration struct {
+	Path        string `json:"path"`
+	FromVersion int    `json:"fromVersion"`
+	ToVersion   int    `json:"toVersion"`
+}
+
+// Migrated is true if the config file was rewritten.
+func (m *Migration) Migrated() bool {
+	return m.FromVersion != m.ToVersion
+}
+
+type MigratorIface interface {
+	Migrate(pwd string) ([]*Migration, error)
+}
+
+// Migrator rewrites config files in place, preserving their comments.
+type Migrator struct {
+	EnsureFileLoader ensurefile.LoaderIface
+	FSWrite          fswrite.FSWriteIface
+}
+
+var _ MigratorIface = &Migrator{}
+
+// Migrate upgrades the config file of the module containing pwd, and its nested config files.
+// Config files that are already using the current version are not rewritten.
+func (m *Migrator) Migrate(pwd string) ([]*Migration, error) {
+	configFilePaths, err := m.EnsureFileLoader.FindConfigFiles(pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []*Migration{}
+	for _, configFilePath := range configFilePaths {
+		doc, err := m.EnsureFileLoader.LoadDocument(configFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		fromVersion, err := doc.Migrate()
+		if err != nil {
+			return nil, err
+		}
+
+		migration := &Migration{Path: configFilePath, FromVersion: fromVersion, ToVersion: ensurefile.CurrentVersion}
+		migrations = append(migrations, migration)
+
+		if !migration.Migrated() {
+			continue
+		}
+
+		data, err := doc.Bytes()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := m.FSWrite.WriteFile(configFilePath, string(data), 0664); err != nil {
+			return nil, erk.WrapWith(ErrCannotWriteFile, err, erk.Params{
+				"path": configFilePath,
+			})
+		}
+	}
+
+	return migrations, nil
+}
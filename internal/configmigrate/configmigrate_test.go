@@ -0,0 +1,96 @@
+package configmigrate_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/configmigrate"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestMigrate(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS      *mock_fs.MockReadFileFS
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	const filePerm = os.FileMode(0664)
+
+	const oldConfigFile = "mocks:\n  # Mocks of the store\n  packages:\n    - path: github.com/my/app/store\n"
+
+	table := []struct {
+		Name string
+
+		ExpectedMigrations []*configmigrate.Migration
+		ExpectedError      error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *configmigrate.Migrator
+	}{
+		{
+			Name: "rewrites unversioned config file",
+			ExpectedMigrations: []*configmigrate.Migration{
+				{Path: "/my/app/.ensure.yml", FromVersion: 0, ToVersion: ensurefile.CurrentVersion},
+			},
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(oldConfigFile), nil).Times(2)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml",
+					"version: 1\nmocks:\n  # Mocks of the store\n  packages:\n    - path: github.com/my/app/store\n",
+					filePerm,
+				).Return(nil)
+			},
+		},
+		{
+			Name: "skips current config file",
+			ExpectedMigrations: []*configmigrate.Migration{
+				{Path: "/my/app/.ensure.yml", FromVersion: ensurefile.CurrentVersion, ToVersion: ensurefile.CurrentVersion},
+			},
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte("version: 1\n"), nil).Times(2)
+			},
+		},
+		{
+			Name:          "when config file is from a newer version",
+			ExpectedError: ensurefile.ErrUnsupportedVersion,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte("version: 2\n"), nil).Times(2)
+			},
+		},
+		{
+			Name:          "when unable to read config file",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, exampleError)
+			},
+		},
+		{
+			Name:          "when unable to write config file",
+			ExpectedError: configmigrate.ErrCannotWriteFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(oldConfigFile), nil).Times(2)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml", gomock.Any(), filePerm).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+		entry.Subject.EnsureFileLoader = &ensurefile.Loader{FS: entry.Mocks.FS}
+
+		migrations, err := entry.Subject.Migrate("/my/app")
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(migrations).Equals(entry.ExpectedMigrations)
+	})
+}
@@ -0,0 +1,378 @@
+// Package doctor diagnoses problems with the environment that mocks are generated in.
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/erk"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	gomockModulePath = "github.com/golang/mock"
+	probeFileName    = ".ensure_doctor_probe"
+)
+
+type ErkChecksFailed struct{ erk.DefaultKind }
+
+var ErrChecksFailed = erk.New(ErkChecksFailed{}, "{{.count}} of the doctor checks failed")
+
+type DoctorIface interface {
+	Diagnose(ctx context.Context, pwd string) error
+}
+
+// Doctor checks that mocks can be generated, and suggests fixes for any problems.
+type Doctor struct {
+	CmdRun           runcmd.RunnerIface
+	EnsureFileLoader ensurefile.LoaderIface
+	FS               fs.FS
+	FSWrite          fswrite.FSWriteIface
+	Logger           *log.Logger
+	Reporter         report.ReporterIface // Optional
+}
+
+var _ DoctorIface = &Doctor{}
+
+// Diagnose the environment for pwd, logging and reporting the result of each check.
+// Within a workspace, the config, gomock version, and destinations of each module are checked.
+// An error is returned if any of the checks failed.
+func (d *Doctor) Diagnose(ctx context.Context, pwd string) error {
+	d.Logger.Println("Checking environment:")
+
+	configs, configChecks := d.checkConfigs(pwd)
+
+	requiredVersions := make([]string, len(configs))
+	requiredVersionErrs := make([]error, len(configs))
+	mockgenRequiredVersion := ""
+	for i, config := range configs {
+		requiredVersions[i], requiredVersionErrs[i] = d.requiredGoMockVersion(config)
+		if mockgenRequiredVersion == "" {
+			mockgenRequiredVersion = requiredVersions[i]
+		}
+	}
+
+	mockgenVersion, mockgenCheck := d.checkMockgen(ctx, pwd, mockgenRequiredVersion)
+
+	checks := append(configChecks, d.checkGo(ctx, pwd), mockgenCheck)
+	for i, config := range configs {
+		checks = append(checks, checkGoMockVersion(config, requiredVersions[i], requiredVersionErrs[i], mockgenVersion))
+		checks = append(checks, d.checkDestinations(config)...)
+	}
+
+	failed := 0
+	for _, check := range checks {
+		d.logCheck(check)
+
+		if d.Reporter != nil {
+			d.Reporter.Report(&report.Event{Type: report.EventCheck, Check: check})
+		}
+
+		if check.Status == report.CheckFailed {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return erk.WithParams(ErrChecksFailed, erk.Params{
+			"count": failed,
+		})
+	}
+
+	return nil
+}
+
+// checkConfigs loads the config of each module in the workspace containing pwd.
+// If they cannot be loaded, a single nil config is returned, so the checks that depend on it are skipped.
+func (d *Doctor) checkConfigs(pwd string) ([]*ensurefile.Config, []*report.Check) {
+	configs, err := d.EnsureFileLoader.LoadConfigs(pwd)
+	if err != nil {
+		return []*ensurefile.Config{nil}, []*report.Check{
+			{
+				Name:    "config",
+				Status:  report.CheckFailed,
+				Message: err.Error(),
+				Fix:     "Run ensure within a Go module that has a .ensure.yml file next to its go.mod file, or pass the config file using --config.",
+			},
+		}
+	}
+
+	checks := make([]*report.Check, 0, len(configs))
+	for _, config := range configs {
+		message := fmt.Sprintf("Loaded %s for module %s rooted at %s", config.ConfigPath, config.ModulePath, config.RootPath)
+		if nestedConfigPaths := nestedConfigPaths(config); len(nestedConfigPaths) > 0 {
+			message += ", including the packages listed in " + strings.Join(nestedConfigPaths, ", ")
+		}
+
+		checks = append(checks, &report.Check{
+			Name:    "config",
+			Status:  report.CheckPassed,
+			Message: message,
+		})
+	}
+
+	return configs, checks
+}
+
+// nestedConfigPaths returns the nested .ensure.yml files that list packages of the config, in the order they are listed.
+func nestedConfigPaths(config *ensurefile.Config) []string {
+	if config.Mocks == nil {
+		return nil
+	}
+
+	paths := []string{}
+	seen := map[string]bool{}
+	for _, pkg := range config.Mocks.Packages {
+		if pkg.File == "" || pkg.File == config.ConfigPath || seen[pkg.File] {
+			continue
+		}
+
+		seen[pkg.File] = true
+		paths = append(paths, pkg.File)
+	}
+
+	return paths
+}
+
+func (d *Doctor) checkGo(ctx context.Context, pwd string) *report.Check {
+	out, err := d.CmdRun.Exec(ctx, &runcmd.ExecParams{
+		PWD:  pwd,
+		CMD:  "go",
+		Args: []string{"version"},
+	})
+	if err != nil {
+		return &report.Check{
+			Name:    "go",
+			Status:  report.CheckFailed,
+			Message: fmt.Sprintf("Could not run go: %v", err),
+			Fix:     "Install Go from https://golang.org/dl/, and ensure it is on your PATH.",
+		}
+	}
+
+	return &report.Check{
+		Name:    "go",
+		Status:  report.CheckPassed,
+		Message: strings.TrimSpace(out),
+	}
+}
+
+func (d *Doctor) checkMockgen(ctx context.Context, pwd, requiredVersion string) (string, *report.Check) {
+	out, err := d.CmdRun.Exec(ctx, &runcmd.ExecParams{
+		PWD:  pwd,
+		CMD:  "mockgen",
+		Args: []string{"-version"},
+	})
+	if err != nil {
+		return "", &report.Check{
+			Name:    "mockgen",
+			Status:  report.CheckFailed,
+			Message: fmt.Sprintf("Could not run mockgen: %v", err),
+			Fix:     installMockgenFix(requiredVersion),
+		}
+	}
+
+	version := strings.TrimSpace(out)
+	return version, &report.Check{
+		Name:    "mockgen",
+		Status:  report.CheckPassed,
+		Message: "mockgen " + version,
+	}
+}
+
+// requiredGoMockVersion returns the version of github.com/golang/mock required by the module's go.mod file.
+// It returns an empty string if it is not required.
+func (d *Doctor) requiredGoMockVersion(config *ensurefile.Config) (string, error) {
+	if config == nil {
+		return "", nil
+	}
+
+	gomodFilePath := strings.TrimPrefix(filepath.Join(config.RootPath, "go.mod"), "/")
+	gomodFileData, err := fs.ReadFile(d.FS, gomodFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	gomodFile, err := modfile.Parse(gomodFilePath, gomodFileData, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for _, require := range gomodFile.Require {
+		if require.Mod.Path == gomockModulePath {
+			return require.Mod.Version, nil
+		}
+	}
+
+	return "", nil
+}
+
+func checkGoMockVersion(config *ensurefile.Config, requiredVersion string, requiredVersionErr error, mockgenVersion string) *report.Check {
+	const name = "gomock version"
+
+	switch {
+	case config == nil:
+		return &report.Check{
+			Name:    name,
+			Status:  report.CheckSkipped,
+			Message: "Skipped, since the config could not be loaded",
+		}
+
+	case requiredVersionErr != nil:
+		return &report.Check{
+			Name:    name,
+			Status:  report.CheckFailed,
+			Message: fmt.Sprintf("Could not read go.mod: %v", requiredVersionErr),
+			Fix:     "Ensure go.mod is readable and valid by running: go mod tidy",
+		}
+
+	case requiredVersion == "":
+		version := mockgenVersion
+		if !semver.IsValid(version) {
+			version = "latest"
+		}
+
+		return &report.Check{
+			Name:    name,
+			Status:  report.CheckFailed,
+			Message: fmt.Sprintf("go.mod does not require %s, which is imported by the generated mocks", gomockModulePath),
+			Fix:     fmt.Sprintf("Add the dependency by running: go get %s@%s", gomockModulePath, version),
+		}
+
+	case mockgenVersion == "":
+		return &report.Check{
+			Name:    name,
+			Status:  report.CheckSkipped,
+			Message: fmt.Sprintf("go.mod requires %s %s, but mockgen could not be run to compare its version", gomockModulePath, requiredVersion),
+		}
+
+	case !semver.IsValid(mockgenVersion):
+		return &report.Check{
+			Name:    name,
+			Status:  report.CheckWarning,
+			Message: fmt.Sprintf("go.mod requires %s %s, but the mockgen version '%s' cannot be compared", gomockModulePath, requiredVersion, mockgenVersion),
+			Fix:     installMockgenFix(requiredVersion),
+		}
+
+	case semver.Compare(mockgenVersion, requiredVersion) != 0:
+		return &report.Check{
+			Name:    name,
+			Status:  report.CheckFailed,
+			Message: fmt.Sprintf("mockgen %s does not match %s %s required by go.mod", mockgenVersion, gomockModulePath, requiredVersion),
+			Fix:     installMockgenFix(requiredVersion),
+		}
+
+	default:
+		return &report.Check{
+			Name:    name,
+			Status:  report.CheckPassed,
+			Message: fmt.Sprintf("mockgen matches %s %s required by go.mod", gomockModulePath, requiredVersion),
+		}
+	}
+}
+
+// checkDestinations ensures each mock directory can be written to, by creating it and writing a probe file.
+func (d *Doctor) checkDestinations(config *ensurefile.Config) []*report.Check {
+	const name = "destination"
+
+	if config == nil {
+		return []*report.Check{
+			{
+				Name:    name,
+				Status:  report.CheckSkipped,
+				Message: "Skipped, since the config could not be loaded",
+			},
+		}
+	}
+
+	mockDirs, err := mockgen.MockDirs(config)
+	if err != nil {
+		return []*report.Check{
+			{
+				Name:    name,
+				Status:  report.CheckFailed,
+				Message: err.Error(),
+				Fix:     "Fix the mocks config in .ensure.yml.",
+			},
+		}
+	}
+
+	checks := make([]*report.Check, 0, len(mockDirs))
+	for _, mockDir := range mockDirs {
+		checks = append(checks, d.checkDestination(name, mockDir))
+	}
+
+	return checks
+}
+
+// checkDestination checks that the mocks can be written to mockDir, without creating it.
+// If mockDir does not exist yet, its nearest existing parent is probed instead, since mockgen creates the rest.
+func (d *Doctor) checkDestination(name, mockDir string) *report.Check {
+	dir := mockDir
+	probeFilePath := filepath.Join(dir, probeFileName)
+	err := d.FSWrite.WriteFile(probeFilePath, "", 0664)
+
+	for errors.Is(err, os.ErrNotExist) && filepath.Dir(dir) != dir {
+		dir = filepath.Dir(dir)
+		probeFilePath = filepath.Join(dir, probeFileName)
+		err = d.FSWrite.WriteFile(probeFilePath, "", 0664)
+	}
+
+	fix := fmt.Sprintf("Ensure the current user can write to: %s", dir)
+
+	if err != nil {
+		return &report.Check{
+			Name:    name,
+			Status:  report.CheckFailed,
+			Message: fmt.Sprintf("Could not write to %s: %v", dir, err),
+			Fix:     fix,
+		}
+	}
+
+	if err := d.FSWrite.RemoveAll(probeFilePath); err != nil {
+		return &report.Check{
+			Name:    name,
+			Status:  report.CheckFailed,
+			Message: fmt.Sprintf("Could not delete %s: %v", probeFilePath, err),
+			Fix:     fix,
+		}
+	}
+
+	message := mockDir + " is writable"
+	if dir != mockDir {
+		message = fmt.Sprintf("%s can be created, since %s is writable", mockDir, dir)
+	}
+
+	return &report.Check{
+		Name:    name,
+		Status:  report.CheckPassed,
+		Message: message,
+	}
+}
+
+func (d *Doctor) logCheck(check *report.Check) {
+	d.Logger.Printf(" - [%s] %s: %s\n", strings.ToUpper(check.Status), check.Name, check.Message)
+
+	if check.Fix != "" {
+		d.Logger.Printf("          Fix: %s\n", check.Fix)
+	}
+}
+
+func installMockgenFix(version string) string {
+	if version == "" {
+		version = "latest"
+	}
+
+	return fmt.Sprintf("Install mockgen by running: go install %s/mockgen@%s", gomockModulePath, version)
+}
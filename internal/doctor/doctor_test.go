@@ -0,0 +1,451 @@
+package doctor_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/doctor"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestDiagnose(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const (
+		goModWithGoMock    = "module github.com/my/app\n\nrequire github.com/golang/mock v1.5.0\n"
+		goModWithoutGoMock = "module github.com/my/app\n"
+	)
+
+	type Mocks struct {
+		CmdRun           *mock_runcmd.MockRunnerIface
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+		FS               *mock_fs.MockReadFileFS
+		FSWrite          *mock_fswrite.MockFSWriteIface
+		Reporter         *mock_report.MockReporterIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	newConfig := func() *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			ConfigPath: "/my/app/.ensure.yml",
+			Mocks: &ensurefile.MockConfig{
+				Packages: []*ensurefile.Package{
+					{
+						Path:       "github.com/some/pkg",
+						Interfaces: []string{"Iface1"},
+					},
+					{
+						Path:       "github.com/my/app/layer1/internal/pkg",
+						Interfaces: []string{"Iface2"},
+					},
+				},
+			},
+		}
+	}
+
+	expectConfig := func(m *Mocks, goMod string) {
+		m.EnsureFileLoader.EXPECT().LoadConfigs("/my/app/pkg").Return([]*ensurefile.Config{newConfig()}, nil)
+		m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte(goMod), nil)
+	}
+
+	expectCommands := func(m *Mocks, goErr error, mockgenVersion string, mockgenErr error) {
+		m.CmdRun.EXPECT().
+			Exec(gomock.Any(), &runcmd.ExecParams{PWD: "/my/app/pkg", CMD: "mockgen", Args: []string{"-version"}}).
+			Return(mockgenVersion+"\n", mockgenErr)
+
+		m.CmdRun.EXPECT().
+			Exec(gomock.Any(), &runcmd.ExecParams{PWD: "/my/app/pkg", CMD: "go", Args: []string{"version"}}).
+			Return("go version go1.15 linux/amd64\n", goErr)
+	}
+
+	expectWritable := func(m *Mocks, dirs ...string) {
+		for _, dir := range dirs {
+			m.FSWrite.EXPECT().WriteFile(dir+"/.ensure_doctor_probe", "", gomock.Any()).Return(nil)
+			m.FSWrite.EXPECT().RemoveAll(dir + "/.ensure_doctor_probe").Return(nil)
+		}
+	}
+
+	newInvalidConfig := func() *ensurefile.Config {
+		config := newConfig()
+		config.Mocks.Packages = []*ensurefile.Package{
+			{
+				Path:       "github.com/other/internal/pkg",
+				Interfaces: []string{"Iface1"},
+			},
+		}
+
+		return config
+	}
+
+	_, invalidConfigErr := mockgen.MockDirs(newInvalidConfig())
+	ensure(invalidConfigErr).IsError(mockgen.ErrInternalPackageOutsideModule)
+
+	passedConfig := &report.Check{
+		Name:    "config",
+		Status:  report.CheckPassed,
+		Message: "Loaded /my/app/.ensure.yml for module github.com/my/app rooted at /my/app",
+	}
+	passedGo := &report.Check{Name: "go", Status: report.CheckPassed, Message: "go version go1.15 linux/amd64"}
+	passedMockgen := &report.Check{Name: "mockgen", Status: report.CheckPassed, Message: "mockgen v1.5.0"}
+	passedGoMockVersion := &report.Check{
+		Name:    "gomock version",
+		Status:  report.CheckPassed,
+		Message: "mockgen matches github.com/golang/mock v1.5.0 required by go.mod",
+	}
+	passedDestinations := []*report.Check{
+		{Name: "destination", Status: report.CheckPassed, Message: "/my/app/internal/mocks is writable"},
+		{Name: "destination", Status: report.CheckPassed, Message: "/my/app/layer1/internal/mocks is writable"},
+	}
+
+	table := []struct {
+		Name           string
+		ExpectedChecks []*report.Check
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *doctor.Doctor
+	}{
+		{
+			Name: "when all checks pass",
+			ExpectedChecks: append([]*report.Check{
+				passedConfig,
+				passedGo,
+				passedMockgen,
+				passedGoMockVersion,
+			}, passedDestinations...),
+			SetupMocks: func(m *Mocks) {
+				expectConfig(m, goModWithGoMock)
+				expectCommands(m, nil, "v1.5.0", nil)
+				expectWritable(m, "/my/app/internal/mocks", "/my/app/layer1/internal/mocks")
+			},
+		},
+
+		{
+			Name: "with nested config",
+			ExpectedChecks: append([]*report.Check{
+				{
+					Name:   "config",
+					Status: report.CheckPassed,
+					Message: "Loaded /my/app/.ensure.yml for module github.com/my/app rooted at /my/app, " +
+						"including the packages listed in /my/app/layer1/.ensure.yml",
+				},
+				passedGo,
+				passedMockgen,
+				passedGoMockVersion,
+			}, passedDestinations...),
+			SetupMocks: func(m *Mocks) {
+				config := newConfig()
+				config.Mocks.Packages[0].File = "/my/app/.ensure.yml"
+				config.Mocks.Packages[1].File = "/my/app/layer1/.ensure.yml"
+
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/my/app/pkg").Return([]*ensurefile.Config{config}, nil)
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte(goModWithGoMock), nil)
+				expectCommands(m, nil, "v1.5.0", nil)
+				expectWritable(m, "/my/app/internal/mocks", "/my/app/layer1/internal/mocks")
+			},
+		},
+
+		{
+			Name:          "within a workspace",
+			ExpectedError: doctor.ErrChecksFailed,
+			ExpectedChecks: append(append([]*report.Check{
+				passedConfig,
+				{
+					Name:    "config",
+					Status:  report.CheckPassed,
+					Message: "Loaded /my/lib/.ensure.yml for module github.com/my/lib rooted at /my/lib",
+				},
+				passedGo,
+				passedMockgen,
+				passedGoMockVersion,
+			}, passedDestinations...),
+				&report.Check{
+					Name:    "gomock version",
+					Status:  report.CheckFailed,
+					Message: "go.mod does not require github.com/golang/mock, which is imported by the generated mocks",
+					Fix:     "Add the dependency by running: go get github.com/golang/mock@v1.5.0",
+				},
+				&report.Check{Name: "destination", Status: report.CheckPassed, Message: "/my/lib/internal/mocks is writable"},
+			),
+			SetupMocks: func(m *Mocks) {
+				libConfig := &ensurefile.Config{
+					RootPath:   "/my/lib",
+					ModulePath: "github.com/my/lib",
+					ConfigPath: "/my/lib/.ensure.yml",
+					Mocks: &ensurefile.MockConfig{
+						Packages: []*ensurefile.Package{{Path: "github.com/my/lib/store", Interfaces: []string{"Store"}}},
+					},
+				}
+
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/my/app/pkg").Return([]*ensurefile.Config{newConfig(), libConfig}, nil)
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte(goModWithGoMock), nil)
+				m.FS.EXPECT().ReadFile("my/lib/go.mod").Return([]byte("module github.com/my/lib\n"), nil)
+				expectCommands(m, nil, "v1.5.0", nil)
+				expectWritable(m, "/my/app/internal/mocks", "/my/app/layer1/internal/mocks", "/my/lib/internal/mocks")
+			},
+		},
+
+		{
+			Name: "when destination does not exist yet",
+			ExpectedChecks: []*report.Check{
+				passedConfig,
+				passedGo,
+				passedMockgen,
+				passedGoMockVersion,
+				passedDestinations[0],
+				{
+					Name:    "destination",
+					Status:  report.CheckPassed,
+					Message: "/my/app/layer1/internal/mocks can be created, since /my/app/layer1 is writable",
+				},
+			},
+			SetupMocks: func(m *Mocks) {
+				expectConfig(m, goModWithGoMock)
+				expectCommands(m, nil, "v1.5.0", nil)
+				expectWritable(m, "/my/app/internal/mocks")
+
+				m.FSWrite.EXPECT().WriteFile("/my/app/layer1/internal/mocks/.ensure_doctor_probe", "", gomock.Any()).Return(os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile("/my/app/layer1/internal/.ensure_doctor_probe", "", gomock.Any()).Return(os.ErrNotExist)
+				expectWritable(m, "/my/app/layer1")
+			},
+		},
+
+		{
+			Name:          "when config cannot be loaded",
+			ExpectedError: doctor.ErrChecksFailed,
+			ExpectedChecks: []*report.Check{
+				{
+					Name:    "config",
+					Status:  report.CheckFailed,
+					Message: exampleError.Error(),
+					Fix:     "Run ensure within a Go module that has a .ensure.yml file next to its go.mod file, or pass the config file using --config.",
+				},
+				passedGo,
+				passedMockgen,
+				{Name: "gomock version", Status: report.CheckSkipped, Message: "Skipped, since the config could not be loaded"},
+				{Name: "destination", Status: report.CheckSkipped, Message: "Skipped, since the config could not be loaded"},
+			},
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/my/app/pkg").Return(nil, exampleError)
+				expectCommands(m, nil, "v1.5.0", nil)
+			},
+		},
+
+		{
+			Name:          "when go and mockgen cannot be run",
+			ExpectedError: doctor.ErrChecksFailed,
+			ExpectedChecks: append([]*report.Check{
+				passedConfig,
+				{
+					Name:    "go",
+					Status:  report.CheckFailed,
+					Message: "Could not run go: something went wrong",
+					Fix:     "Install Go from https://golang.org/dl/, and ensure it is on your PATH.",
+				},
+				{
+					Name:    "mockgen",
+					Status:  report.CheckFailed,
+					Message: "Could not run mockgen: something went wrong",
+					Fix:     "Install mockgen by running: go install github.com/golang/mock/mockgen@v1.5.0",
+				},
+				{
+					Name:    "gomock version",
+					Status:  report.CheckSkipped,
+					Message: "go.mod requires github.com/golang/mock v1.5.0, but mockgen could not be run to compare its version",
+				},
+			}, passedDestinations...),
+			SetupMocks: func(m *Mocks) {
+				expectConfig(m, goModWithGoMock)
+				expectCommands(m, exampleError, "", exampleError)
+				expectWritable(m, "/my/app/internal/mocks", "/my/app/layer1/internal/mocks")
+			},
+		},
+
+		{
+			Name:          "when go.mod does not require gomock",
+			ExpectedError: doctor.ErrChecksFailed,
+			ExpectedChecks: append([]*report.Check{
+				passedConfig,
+				passedGo,
+				{
+					Name:    "mockgen",
+					Status:  report.CheckPassed,
+					Message: "mockgen v1.6.0",
+				},
+				{
+					Name:    "gomock version",
+					Status:  report.CheckFailed,
+					Message: "go.mod does not require github.com/golang/mock, which is imported by the generated mocks",
+					Fix:     "Add the dependency by running: go get github.com/golang/mock@v1.6.0",
+				},
+			}, passedDestinations...),
+			SetupMocks: func(m *Mocks) {
+				expectConfig(m, goModWithoutGoMock)
+				expectCommands(m, nil, "v1.6.0", nil)
+				expectWritable(m, "/my/app/internal/mocks", "/my/app/layer1/internal/mocks")
+			},
+		},
+
+		{
+			Name:          "when go.mod cannot be read",
+			ExpectedError: doctor.ErrChecksFailed,
+			ExpectedChecks: append([]*report.Check{
+				passedConfig,
+				passedGo,
+				passedMockgen,
+				{
+					Name:    "gomock version",
+					Status:  report.CheckFailed,
+					Message: "Could not read go.mod: permission denied",
+					Fix:     "Ensure go.mod is readable and valid by running: go mod tidy",
+				},
+			}, passedDestinations...),
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/my/app/pkg").Return([]*ensurefile.Config{newConfig()}, nil)
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return(nil, fs.ErrPermission)
+				expectCommands(m, nil, "v1.5.0", nil)
+				expectWritable(m, "/my/app/internal/mocks", "/my/app/layer1/internal/mocks")
+			},
+		},
+
+		{
+			Name:          "when mockgen version does not match go.mod",
+			ExpectedError: doctor.ErrChecksFailed,
+			ExpectedChecks: append([]*report.Check{
+				passedConfig,
+				passedGo,
+				{
+					Name:    "mockgen",
+					Status:  report.CheckPassed,
+					Message: "mockgen v1.4.4",
+				},
+				{
+					Name:    "gomock version",
+					Status:  report.CheckFailed,
+					Message: "mockgen v1.4.4 does not match github.com/golang/mock v1.5.0 required by go.mod",
+					Fix:     "Install mockgen by running: go install github.com/golang/mock/mockgen@v1.5.0",
+				},
+			}, passedDestinations...),
+			SetupMocks: func(m *Mocks) {
+				expectConfig(m, goModWithGoMock)
+				expectCommands(m, nil, "v1.4.4", nil)
+				expectWritable(m, "/my/app/internal/mocks", "/my/app/layer1/internal/mocks")
+			},
+		},
+
+		{
+			Name: "when mockgen version cannot be compared",
+			ExpectedChecks: append([]*report.Check{
+				passedConfig,
+				passedGo,
+				{
+					Name:    "mockgen",
+					Status:  report.CheckPassed,
+					Message: "mockgen (devel)",
+				},
+				{
+					Name:    "gomock version",
+					Status:  report.CheckWarning,
+					Message: "go.mod requires github.com/golang/mock v1.5.0, but the mockgen version '(devel)' cannot be compared",
+					Fix:     "Install mockgen by running: go install github.com/golang/mock/mockgen@v1.5.0",
+				},
+			}, passedDestinations...),
+			SetupMocks: func(m *Mocks) {
+				expectConfig(m, goModWithGoMock)
+				expectCommands(m, nil, "(devel)", nil)
+				expectWritable(m, "/my/app/internal/mocks", "/my/app/layer1/internal/mocks")
+			},
+		},
+
+		{
+			Name:          "when destination is not writable",
+			ExpectedError: doctor.ErrChecksFailed,
+			ExpectedChecks: []*report.Check{
+				passedConfig,
+				passedGo,
+				passedMockgen,
+				passedGoMockVersion,
+				{
+					Name:    "destination",
+					Status:  report.CheckFailed,
+					Message: "Could not write to /my/app/internal/mocks: something went wrong",
+					Fix:     "Ensure the current user can write to: /my/app/internal/mocks",
+				},
+				{
+					Name:    "destination",
+					Status:  report.CheckFailed,
+					Message: "Could not write to /my/app/layer1: something went wrong",
+					Fix:     "Ensure the current user can write to: /my/app/layer1",
+				},
+			},
+			SetupMocks: func(m *Mocks) {
+				expectConfig(m, goModWithGoMock)
+				expectCommands(m, nil, "v1.5.0", nil)
+
+				m.FSWrite.EXPECT().WriteFile("/my/app/internal/mocks/.ensure_doctor_probe", "", gomock.Any()).Return(exampleError)
+
+				// Missing destinations are not created, so their nearest existing parent is probed
+				m.FSWrite.EXPECT().WriteFile("/my/app/layer1/internal/mocks/.ensure_doctor_probe", "", gomock.Any()).Return(os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile("/my/app/layer1/internal/.ensure_doctor_probe", "", gomock.Any()).Return(os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile("/my/app/layer1/.ensure_doctor_probe", "", gomock.Any()).Return(exampleError)
+			},
+		},
+
+		{
+			Name:          "when mocks config is invalid",
+			ExpectedError: doctor.ErrChecksFailed,
+			ExpectedChecks: []*report.Check{
+				passedConfig,
+				passedGo,
+				passedMockgen,
+				passedGoMockVersion,
+				{
+					Name:    "destination",
+					Status:  report.CheckFailed,
+					Message: invalidConfigErr.Error(),
+					Fix:     "Fix the mocks config in .ensure.yml.",
+				},
+			},
+			SetupMocks: func(m *Mocks) {
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/my/app/pkg").Return([]*ensurefile.Config{newInvalidConfig()}, nil)
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte(goModWithGoMock), nil)
+				expectCommands(m, nil, "v1.5.0", nil)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+
+		checks := []*report.Check{}
+		entry.Mocks.Reporter.EXPECT().Report(gomock.Any()).AnyTimes().Do(func(event *report.Event) {
+			ensure(event.Type).Equals(report.EventCheck)
+			checks = append(checks, event.Check)
+		})
+
+		err := entry.Subject.Diagnose(context.Background(), "/my/app/pkg")
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(checks).Equals(entry.ExpectedChecks)
+	})
+}
@@ -0,0 +1,173 @@
+package ensurefile
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/erk"
+)
+
+const annotationDirective = "//ensure:mock"
+
+var ErrInvalidAnnotation = erk.New(ErkCannotLoadConfig{}, "Invalid //ensure:mock annotation at {{.path}}:{{.line}}, since {{.reason}}")
+
+// Annotation is an interface marked for mocking by an `//ensure:mock` comment on its type declaration.
+type Annotation struct {
+	PackagePath string
+	Interface   string
+	MockName    string // Optional, set using `//ensure:mock name=MockName`
+	File        string
+	Line        int
+}
+
+// loadAnnotations finds the annotated interfaces in the module's Go files, excluding tests.
+func (l *Loader) loadAnnotations(config *Config) error {
+	if l.Finder == nil {
+		return nil
+	}
+
+	// Only files containing the directive are parsed, since every config load searches for annotations
+	goFiles, err := l.Finder.GoFilesContaining(config.RootPath, annotationDirective)
+	if err != nil {
+		return err
+	}
+
+	for _, goFile := range goFiles {
+		if strings.HasSuffix(goFile.Path, "_test.go") {
+			continue
+		}
+
+		annotations, err := fileAnnotations(config, goFile)
+		if err != nil {
+			return err
+		}
+
+		config.Annotations = append(config.Annotations, annotations...)
+	}
+
+	return nil
+}
+
+func fileAnnotations(config *Config, goFile *modfiles.GoFile) ([]*Annotation, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, goFile.Path, goFile.Contents, parser.ParseComments)
+	if err != nil {
+		return nil, erk.WrapWith(modfiles.ErrCannotParseFile, err, erk.Params{
+			"path": goFile.Path,
+		})
+	}
+
+	relativeDir, err := filepath.Rel(config.RootPath, filepath.Dir(goFile.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	pkgPath := path.Join(config.ModulePath, filepath.ToSlash(relativeDir))
+	used := map[*ast.Comment]bool{}
+	annotations := []*Annotation{}
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			doc := typeSpec.Doc
+			if doc == nil && len(genDecl.Specs) == 1 {
+				doc = genDecl.Doc
+			}
+
+			comment := findDirective(doc)
+			if comment == nil {
+				continue
+			}
+
+			used[comment] = true
+			line := fset.Position(comment.Pos()).Line
+			if _, ok := typeSpec.Type.(*ast.InterfaceType); !ok {
+				return nil, annotationError(goFile.Path, line, "only interfaces can be mocked")
+			}
+
+			mockName, reason := parseDirective(comment.Text)
+			if reason != "" {
+				return nil, annotationError(goFile.Path, line, reason)
+			}
+
+			annotations = append(annotations, &Annotation{
+				PackagePath: pkgPath,
+				Interface:   typeSpec.Name.Name,
+				MockName:    mockName,
+				File:        goFile.Path,
+				Line:        line,
+			})
+		}
+	}
+
+	// Annotations that are not on a type declaration would otherwise be silently ignored
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if isDirective(comment.Text) && !used[comment] {
+				return nil, annotationError(goFile.Path, fset.Position(comment.Pos()).Line, "it is not on a type declaration")
+			}
+		}
+	}
+
+	return annotations, nil
+}
+
+func findDirective(doc *ast.CommentGroup) *ast.Comment {
+	if doc == nil {
+		return nil
+	}
+
+	for _, comment := range doc.List {
+		if isDirective(comment.Text) {
+			return comment
+		}
+	}
+
+	return nil
+}
+
+func isDirective(text string) bool {
+	return text == annotationDirective || strings.HasPrefix(text, annotationDirective+" ")
+}
+
+// parseDirective returns the mock name in the directive, or the reason it is invalid.
+func parseDirective(text string) (string, string) {
+	mockName := ""
+
+	for _, option := range strings.Fields(strings.TrimPrefix(text, annotationDirective)) {
+		parts := strings.SplitN(option, "=", 2)
+		if len(parts) != 2 || parts[0] != "name" {
+			return "", "the option '" + option + "' is unknown, and only name=MockName is supported"
+		}
+
+		if !token.IsIdentifier(parts[1]) || !ast.IsExported(parts[1]) {
+			return "", "the mock name '" + parts[1] + "' is not an exported identifier"
+		}
+
+		mockName = parts[1]
+	}
+
+	return mockName, ""
+}
+
+func annotationError(filePath string, line int, reason string) error {
+	return erk.WithParams(ErrInvalidAnnotation, erk.Params{
+		"path":   filePath,
+		"line":   line,
+		"reason": reason,
+	})
+}
@@ -0,0 +1,148 @@
+package ensurefile_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_modfiles"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestLoadConfigAnnotations(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const configFile = "mocks:\n  packages:\n    - path: github.com/some/pkg\n      interfaces: [Iface]\n"
+
+	type Mocks struct {
+		FS     *mock_fs.MockReadFileFS
+		Finder *mock_modfiles.MockFinderIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	table := []struct {
+		Name                string
+		GoFiles             []*modfiles.GoFile
+		ExpectedAnnotations []*ensurefile.Annotation
+		ExpectedError       error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name: "with annotated interfaces",
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/store/store.go",
+					Contents: `package store
+
+// UserStore stores users.
+//ensure:mock
+type UserStore interface{}
+
+type (
+	//ensure:mock name=FakeOrderStore
+	OrderStore interface{}
+
+	Other interface{}
+)
+
+type NotAnnotated interface{}
+`,
+				},
+				{
+					Path:     "/my/app/store/store_test.go",
+					Contents: "package store\n\n//ensure:mock\ntype TestOnly interface{}\n",
+				},
+				{
+					Path:     "/my/app/main.go",
+					Contents: "package main\n",
+				},
+			},
+			ExpectedAnnotations: []*ensurefile.Annotation{
+				{
+					PackagePath: "github.com/my/app/store",
+					Interface:   "UserStore",
+					File:        "/my/app/store/store.go",
+					Line:        4,
+				},
+				{
+					PackagePath: "github.com/my/app/store",
+					Interface:   "OrderStore",
+					MockName:    "FakeOrderStore",
+					File:        "/my/app/store/store.go",
+					Line:        8,
+				},
+			},
+		},
+		{
+			Name:    "with no annotations",
+			GoFiles: []*modfiles.GoFile{{Path: "/my/app/main.go", Contents: "package main\n"}},
+		},
+		{
+			Name: "when annotating a struct",
+			GoFiles: []*modfiles.GoFile{
+				{Path: "/my/app/store/store.go", Contents: "package store\n\n//ensure:mock\ntype Store struct{}\n"},
+			},
+			ExpectedError: ensurefile.ErrInvalidAnnotation,
+		},
+		{
+			Name: "when annotation has an unknown option",
+			GoFiles: []*modfiles.GoFile{
+				{Path: "/my/app/store/store.go", Contents: "package store\n\n//ensure:mock typed\ntype Store interface{}\n"},
+			},
+			ExpectedError: ensurefile.ErrInvalidAnnotation,
+		},
+		{
+			Name: "when annotation has an unexported mock name",
+			GoFiles: []*modfiles.GoFile{
+				{Path: "/my/app/store/store.go", Contents: "package store\n\n//ensure:mock name=fakeStore\ntype Store interface{}\n"},
+			},
+			ExpectedError: ensurefile.ErrInvalidAnnotation,
+		},
+		{
+			Name: "when annotation is not on a type declaration",
+			GoFiles: []*modfiles.GoFile{
+				{Path: "/my/app/store/store.go", Contents: "package store\n\n//ensure:mock\nfunc New() {}\n"},
+			},
+			ExpectedError: ensurefile.ErrInvalidAnnotation,
+		},
+		{
+			Name: "when unable to parse Go file",
+			GoFiles: []*modfiles.GoFile{
+				{Path: "/my/app/store/store.go", Contents: "package store\n\n//ensure:mock\ntype"},
+			},
+			ExpectedError: modfiles.ErrCannotParseFile,
+		},
+		{
+			Name:          "when unable to find Go files",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.Finder.EXPECT().GoFilesContaining("/my/app", "//ensure:mock").Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(configFile), nil)
+		entry.Mocks.Finder.EXPECT().Files("/my/app", ".ensure.yml", ".ensure.yaml", ".ensure.json").Return([]string{"/my/app/.ensure.yml"}, nil)
+		if entry.GoFiles != nil {
+			entry.Mocks.Finder.EXPECT().GoFilesContaining("/my/app", "//ensure:mock").Return(entry.GoFiles, nil)
+		}
+
+		config, err := entry.Subject.LoadConfig("/my/app")
+		ensure(err).IsError(entry.ExpectedError)
+
+		if entry.ExpectedError == nil {
+			ensure(config.Annotations).Equals(entry.ExpectedAnnotations)
+		}
+	})
+}
@@ -0,0 +1,405 @@
+package ensurefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/erk"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrCannotEditFile = erk.New(ErkCannotLoadConfig{}, "Cannot edit the file '{{.path}}', since {{.reason}}")
+
+var errUnexpectedKind = errors.New("unexpected node kind")
+
+// Document is a .ensure.yml file that can be edited, while preserving its comments.
+type Document struct {
+	Path string
+	root yaml.Node
+}
+
+// LoadDocument reads the .ensure.yml file at the absolute configPath, so it can be edited.
+// If the file does not exist, an empty document is returned, so the file can be created.
+func (l *Loader) LoadDocument(configPath string) (*Document, error) {
+	relativeConfigPath := strings.TrimPrefix(configPath, "/")
+	configFileData, err := fs.ReadFile(l.FS, relativeConfigPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Document{Path: configPath}, nil
+	}
+
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+			"path": relativeConfigPath,
+		})
+	}
+
+	doc := &Document{Path: configPath}
+	if err := yaml.Unmarshal(configFileData, &doc.root); err != nil {
+		return nil, erk.WrapWith(ErrCannotUnmarshalFile, err, erk.Params{
+			"path": relativeConfigPath,
+		})
+	}
+
+	return doc, nil
+}
+
+// RemoveInterfaces from the package with the provided path.
+// If the package has no remaining interfaces, the package is removed.
+func (d *Document) RemoveInterfaces(pkgPath string, interfaces []string) error {
+	packages, err := d.packagesNode()
+	if err != nil {
+		return err
+	}
+
+	toRemove := map[string]bool{}
+	for _, iface := range interfaces {
+		toRemove[iface] = true
+	}
+
+	remainingPackages := []*yaml.Node{}
+	for _, pkg := range packages.Content {
+		pathNode := mappingValue(pkg, "path")
+		interfacesNode := mappingValue(pkg, "interfaces")
+		if pathNode == nil || pathNode.Value != pkgPath || interfacesNode == nil {
+			remainingPackages = append(remainingPackages, pkg)
+			continue
+		}
+
+		remainingInterfaces := []*yaml.Node{}
+		for _, iface := range interfacesNode.Content {
+			if !toRemove[iface.Value] {
+				remainingInterfaces = append(remainingInterfaces, iface)
+			}
+		}
+
+		interfacesNode.Content = remainingInterfaces
+		if len(remainingInterfaces) > 0 {
+			remainingPackages = append(remainingPackages, pkg)
+		}
+	}
+
+	packages.Content = remainingPackages
+	return nil
+}
+
+// AddInterfaces to the package with the provided path, skipping any it already has.
+// If the package does not exist, it is appended to the list of packages.
+func (d *Document) AddInterfaces(pkgPath string, interfaces []string) error {
+	packages, err := d.ensurePackagesNode()
+	if err != nil {
+		return err
+	}
+
+	for _, pkg := range packages.Content {
+		pathNode := mappingValue(pkg, "path")
+		if pathNode == nil || pathNode.Value != pkgPath {
+			continue
+		}
+
+		interfacesNode := mappingValue(pkg, "interfaces")
+		if interfacesNode == nil {
+			interfacesNode = &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+			pkg.Content = append(pkg.Content, scalarNode("interfaces"), interfacesNode)
+		}
+
+		existing := map[string]bool{}
+		for _, iface := range interfacesNode.Content {
+			existing[iface.Value] = true
+		}
+
+		for _, iface := range interfaces {
+			if !existing[iface] {
+				interfacesNode.Content = append(interfacesNode.Content, scalarNode(iface))
+				existing[iface] = true
+			}
+		}
+
+		return nil
+	}
+
+	interfacesNode := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, iface := range interfaces {
+		interfacesNode.Content = append(interfacesNode.Content, scalarNode(iface))
+	}
+
+	packages.Content = append(packages.Content, &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			scalarNode("path"), scalarNode(pkgPath),
+			scalarNode("interfaces"), interfacesNode,
+		},
+	})
+
+	return nil
+}
+
+// SetTyped sets the typed key of the package with the provided path.
+func (d *Document) SetTyped(pkgPath string, typed bool) error {
+	packages, err := d.ensurePackagesNode()
+	if err != nil {
+		return err
+	}
+
+	for _, pkg := range packages.Content {
+		pathNode := mappingValue(pkg, "path")
+		if pathNode == nil || pathNode.Value != pkgPath {
+			continue
+		}
+
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(typed)}
+		if typedNode := mappingValue(pkg, "typed"); typedNode != nil {
+			*typedNode = *value
+		} else {
+			pkg.Content = append(pkg.Content, scalarNode("typed"), value)
+		}
+
+		return nil
+	}
+
+	return d.editError("package `" + pkgPath + "` does not exist")
+}
+
+// SetMockName sets the name of the mock for the interface, within the mockNames of the package with the provided path.
+func (d *Document) SetMockName(pkgPath, iface, mockName string) error {
+	packages, err := d.ensurePackagesNode()
+	if err != nil {
+		return err
+	}
+
+	for _, pkg := range packages.Content {
+		pathNode := mappingValue(pkg, "path")
+		if pathNode == nil || pathNode.Value != pkgPath {
+			continue
+		}
+
+		mockNames, err := ensureMappingValue(pkg, "mockNames", yaml.MappingNode)
+		if err != nil {
+			return d.editError("`mockNames` of package `" + pkgPath + "` is not a map")
+		}
+
+		if nameNode := mappingValue(mockNames, iface); nameNode != nil {
+			*nameNode = *scalarNode(mockName)
+		} else {
+			mockNames.Content = append(mockNames.Content, scalarNode(iface), scalarNode(mockName))
+		}
+
+		return nil
+	}
+
+	return d.editError("package `" + pkgPath + "` does not exist")
+}
+
+// SetMockOption sets the string value of the key within mocks, such as primaryDestination.
+func (d *Document) SetMockOption(key, value string) error {
+	if len(d.root.Content) == 0 {
+		d.root = newDocumentRoot()
+	}
+
+	mocks, err := ensureMappingValue(d.root.Content[0], "mocks", yaml.MappingNode)
+	if err != nil {
+		return d.editError("`mocks` is not a map")
+	}
+
+	if valueNode := mappingValue(mocks, key); valueNode != nil {
+		*valueNode = *scalarNode(value)
+		return nil
+	}
+
+	// Keep packages last, since it is usually the longest
+	keyNodes := []*yaml.Node{scalarNode(key), scalarNode(value)}
+	for i := 0; i+1 < len(mocks.Content); i += 2 {
+		if mocks.Content[i].Value == "packages" {
+			mocks.Content = append(mocks.Content[:i], append(keyNodes, mocks.Content[i:]...)...)
+			return nil
+		}
+	}
+
+	mocks.Content = append(mocks.Content, keyNodes...)
+	return nil
+}
+
+// Bytes encodes the edited document.
+// Documents loaded from .ensure.json files are encoded as JSON, keeping the order of their keys.
+func (d *Document) Bytes() ([]byte, error) {
+	if filepath.Ext(d.Path) == ".json" {
+		return d.jsonBytes()
+	}
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&d.root); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (d *Document) jsonBytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if len(d.root.Content) == 0 {
+		buf.WriteString("{}")
+	} else if err := encodeJSON(&buf, d.root.Content[0]); err != nil {
+		return nil, err
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+
+	indented.WriteString("\n")
+	return indented.Bytes(), nil
+}
+
+// encodeJSON writes the node as compact JSON, since encoding/json does not preserve the order of mapping keys.
+func encodeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf.WriteString("{")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+
+			key, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+
+			buf.Write(key)
+			buf.WriteString(":")
+			if err := encodeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case yaml.SequenceNode:
+		buf.WriteString("[")
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+
+			if err := encodeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	case yaml.AliasNode:
+		return encodeJSON(buf, node.Alias)
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		buf.Write(data)
+	}
+
+	return nil
+}
+
+// packagesNode returns the mocks.packages sequence.
+func (d *Document) packagesNode() (*yaml.Node, error) {
+	if len(d.root.Content) == 0 {
+		return nil, d.editError("it is empty")
+	}
+
+	mocks := mappingValue(d.root.Content[0], "mocks")
+	if mocks == nil {
+		return nil, d.editError("it is missing the `mocks` key")
+	}
+
+	packages := mappingValue(mocks, "packages")
+	if packages == nil || packages.Kind != yaml.SequenceNode {
+		return nil, d.editError("`mocks.packages` is not a list")
+	}
+
+	return packages, nil
+}
+
+// ensurePackagesNode returns the mocks.packages sequence, creating any missing keys.
+func (d *Document) ensurePackagesNode() (*yaml.Node, error) {
+	if len(d.root.Content) == 0 {
+		d.root = newDocumentRoot()
+	}
+
+	mocks, err := ensureMappingValue(d.root.Content[0], "mocks", yaml.MappingNode)
+	if err != nil {
+		return nil, d.editError("`mocks` is not a map")
+	}
+
+	packages, err := ensureMappingValue(mocks, "packages", yaml.SequenceNode)
+	if err != nil {
+		return nil, d.editError("`mocks.packages` is not a list")
+	}
+
+	return packages, nil
+}
+
+func (d *Document) editError(reason string) error {
+	return erk.WithParams(ErrCannotEditFile, erk.Params{
+		"path":   d.Path,
+		"reason": reason,
+	})
+}
+
+// mappingValue returns the value of the key in the mapping node, or nil if it does not exist.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// ensureMappingValue returns the value of the key in the mapping node, adding it if it does not exist or is null.
+// It returns an error if the node is not a mapping, or the value is not of the provided kind.
+func ensureMappingValue(node *yaml.Node, key string, kind yaml.Kind) (*yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, errUnexpectedKind
+	}
+
+	value := mappingValue(node, key)
+	if value == nil {
+		value = &yaml.Node{Kind: kind}
+		node.Content = append(node.Content, scalarNode(key), value)
+		return value, nil
+	}
+
+	if value.Kind == yaml.ScalarNode && value.Tag == "!!null" { // Such as `packages:` without a value
+		*value = yaml.Node{Kind: kind}
+	}
+
+	if value.Kind != kind {
+		return nil, errUnexpectedKind
+	}
+
+	return value, nil
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
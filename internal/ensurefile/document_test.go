@@ -0,0 +1,596 @@
+package ensurefile_test
+
+import (
+	"errors"
+	"testing"
+
+	"bursavich.dev/fs-shim/io/fs"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestDocumentRemoveInterfaces(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const configFile = `mocks:
+  # Keep this comment
+  tidyAfterGenerate: true
+  packages:
+    - path: github.com/my/app/pkg1
+      interfaces: [Iface1, Iface2]
+    # Pkg2 comment
+    - path: github.com/my/app/pkg2
+      interfaces: [Iface3]
+    - path: github.com/my/app/pkg3
+      interfaces:
+        - Iface4
+        - Iface5
+`
+
+	type Removal struct {
+		PackagePath string
+		Interfaces  []string
+	}
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name     string
+		File     string
+		Removals []Removal
+
+		ExpectedFile  string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name: "removes interfaces and empty packages",
+			File: configFile,
+			Removals: []Removal{
+				{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Iface2"}},
+				{PackagePath: "github.com/my/app/pkg2", Interfaces: []string{"Iface3"}},
+				{PackagePath: "github.com/my/app/pkg3", Interfaces: []string{"Iface4"}},
+			},
+			ExpectedFile: `mocks:
+  # Keep this comment
+  tidyAfterGenerate: true
+  packages:
+    - path: github.com/my/app/pkg1
+      interfaces: [Iface1]
+    - path: github.com/my/app/pkg3
+      interfaces:
+        - Iface5
+`,
+		},
+		{
+			Name: "ignores unknown packages and interfaces",
+			File: configFile,
+			Removals: []Removal{
+				{PackagePath: "github.com/my/app/unknown", Interfaces: []string{"Iface1"}},
+				{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Unknown"}},
+			},
+			ExpectedFile: configFile,
+		},
+		{
+			Name:          "when mocks key is missing",
+			File:          "something: else\n",
+			Removals:      []Removal{{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Iface1"}}},
+			ExpectedError: ensurefile.ErrCannotEditFile,
+		},
+		{
+			Name:          "when packages is not a list",
+			File:          "mocks:\n  packages: nope\n",
+			Removals:      []Removal{{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Iface1"}}},
+			ExpectedError: ensurefile.ErrCannotEditFile,
+		},
+		{
+			Name:          "when file is empty",
+			File:          "",
+			Removals:      []Removal{{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Iface1"}}},
+			ExpectedError: ensurefile.ErrCannotEditFile,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(entry.File), nil)
+
+		doc, err := entry.Subject.LoadDocument("/my/app/.ensure.yml")
+		ensure(err).IsNotError()
+		ensure(doc.Path).Equals("/my/app/.ensure.yml")
+
+		for _, removal := range entry.Removals {
+			if err = doc.RemoveInterfaces(removal.PackagePath, removal.Interfaces); err != nil {
+				break
+			}
+		}
+
+		ensure(err).IsError(entry.ExpectedError)
+		if entry.ExpectedError != nil {
+			return
+		}
+
+		data, err := doc.Bytes()
+		ensure(err).IsNotError()
+		ensure(string(data)).Equals(entry.ExpectedFile)
+	})
+}
+
+func TestLoadDocument(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name          string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name:          "when cannot open file",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, errors.New("boom"))
+			},
+		},
+		{
+			Name:          "when cannot parse file",
+			ExpectedError: ensurefile.ErrCannotUnmarshalFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte("mocks: ["), nil)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		doc, err := entry.Subject.LoadDocument("/my/app/.ensure.yml")
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(doc == nil).IsTrue()
+	})
+}
+
+func TestDocumentAddInterfaces(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const configFile = `mocks:
+  # Keep this comment
+  packages:
+    - path: github.com/my/app/pkg1
+      interfaces: [Iface1]
+`
+
+	type Addition struct {
+		PackagePath string
+		Interfaces  []string
+	}
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name      string
+		File      string
+		Additions []Addition
+
+		ExpectedFile  string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name: "adds interfaces to existing and new packages",
+			File: configFile,
+			Additions: []Addition{
+				{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Iface1", "Iface2"}},
+				{PackagePath: "github.com/my/app/pkg2", Interfaces: []string{"Iface3", "Iface4"}},
+			},
+			ExpectedFile: `mocks:
+  # Keep this comment
+  packages:
+    - path: github.com/my/app/pkg1
+      interfaces: [Iface1, Iface2]
+    - path: github.com/my/app/pkg2
+      interfaces: [Iface3, Iface4]
+`,
+		},
+		{
+			Name: "adds interfaces key when missing",
+			File: "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n",
+			Additions: []Addition{
+				{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Iface1"}},
+			},
+			ExpectedFile: "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      interfaces: [Iface1]\n",
+		},
+		{
+			Name: "when packages is empty",
+			File: "mocks:\n  tidyAfterGenerate: true\n  packages:\n",
+			Additions: []Addition{
+				{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Iface1"}},
+			},
+			ExpectedFile: "mocks:\n  tidyAfterGenerate: true\n  packages:\n    - path: github.com/my/app/pkg1\n      interfaces: [Iface1]\n",
+		},
+		{
+			Name: "when file is empty",
+			File: "",
+			Additions: []Addition{
+				{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Iface1"}},
+			},
+			ExpectedFile: "version: 1\nmocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      interfaces: [Iface1]\n",
+		},
+		{
+			Name:          "when mocks is not a map",
+			File:          "mocks: nope\n",
+			Additions:     []Addition{{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Iface1"}}},
+			ExpectedError: ensurefile.ErrCannotEditFile,
+		},
+		{
+			Name:          "when packages is not a list",
+			File:          "mocks:\n  packages: nope\n",
+			Additions:     []Addition{{PackagePath: "github.com/my/app/pkg1", Interfaces: []string{"Iface1"}}},
+			ExpectedError: ensurefile.ErrCannotEditFile,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(entry.File), nil)
+
+		doc, err := entry.Subject.LoadDocument("/my/app/.ensure.yml")
+		ensure(err).IsNotError()
+
+		for _, addition := range entry.Additions {
+			if err = doc.AddInterfaces(addition.PackagePath, addition.Interfaces); err != nil {
+				break
+			}
+		}
+
+		ensure(err).IsError(entry.ExpectedError)
+		if entry.ExpectedError != nil {
+			return
+		}
+
+		data, err := doc.Bytes()
+		ensure(err).IsNotError()
+		ensure(string(data)).Equals(entry.ExpectedFile)
+	})
+}
+
+func TestLoadDocumentWhenFileDoesNotExist(t *testing.T) {
+	ensure := ensure.New(t)
+	ctrl := gomock.NewController(t)
+
+	fsMock := mock_fs.NewMockReadFileFS(ctrl)
+	fsMock.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, fs.ErrNotExist)
+
+	doc, err := (&ensurefile.Loader{FS: fsMock}).LoadDocument("/my/app/.ensure.yml")
+	ensure(err).IsNotError()
+	ensure(doc.Path).Equals("/my/app/.ensure.yml")
+
+	err = doc.AddInterfaces("github.com/my/app/pkg1", []string{"Iface1"})
+	ensure(err).IsNotError()
+
+	data, err := doc.Bytes()
+	ensure(err).IsNotError()
+	ensure(string(data)).Equals("version: 1\nmocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      interfaces: [Iface1]\n")
+}
+
+func TestDocumentSetTyped(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name        string
+		File        string
+		PackagePath string
+		Typed       bool
+
+		ExpectedFile  string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name:         "adds typed key",
+			File:         "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      interfaces: [Iface1]\n",
+			PackagePath:  "github.com/my/app/pkg1",
+			Typed:        true,
+			ExpectedFile: "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      interfaces: [Iface1]\n      typed: true\n",
+		},
+		{
+			Name:         "replaces typed key",
+			File:         "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      typed: true\n",
+			PackagePath:  "github.com/my/app/pkg1",
+			Typed:        false,
+			ExpectedFile: "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      typed: false\n",
+		},
+		{
+			Name:          "when package does not exist",
+			File:          "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n",
+			PackagePath:   "github.com/my/app/pkg2",
+			ExpectedError: ensurefile.ErrCannotEditFile,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(entry.File), nil)
+
+		doc, err := entry.Subject.LoadDocument("/my/app/.ensure.yml")
+		ensure(err).IsNotError()
+
+		err = doc.SetTyped(entry.PackagePath, entry.Typed)
+		ensure(err).IsError(entry.ExpectedError)
+		if entry.ExpectedError != nil {
+			return
+		}
+
+		data, err := doc.Bytes()
+		ensure(err).IsNotError()
+		ensure(string(data)).Equals(entry.ExpectedFile)
+	})
+}
+
+func TestDocumentSetMockName(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name        string
+		File        string
+		PackagePath string
+		Interface   string
+		MockName    string
+
+		ExpectedFile  string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name:         "adds mockNames key",
+			File:         "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      interfaces: [Iface1]\n",
+			PackagePath:  "github.com/my/app/pkg1",
+			Interface:    "Iface1",
+			MockName:     "FakeIface1",
+			ExpectedFile: "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      interfaces: [Iface1]\n      mockNames:\n        Iface1: FakeIface1\n",
+		},
+		{
+			Name:         "replaces mock name",
+			File:         "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      mockNames:\n        Iface1: OldIface1\n        Iface2: FakeIface2\n",
+			PackagePath:  "github.com/my/app/pkg1",
+			Interface:    "Iface1",
+			MockName:     "FakeIface1",
+			ExpectedFile: "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      mockNames:\n        Iface1: FakeIface1\n        Iface2: FakeIface2\n",
+		},
+		{
+			Name:          "when mockNames is not a map",
+			File:          "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n      mockNames: [Iface1]\n",
+			PackagePath:   "github.com/my/app/pkg1",
+			Interface:     "Iface1",
+			MockName:      "FakeIface1",
+			ExpectedError: ensurefile.ErrCannotEditFile,
+		},
+		{
+			Name:          "when package does not exist",
+			File:          "mocks:\n  packages:\n    - path: github.com/my/app/pkg1\n",
+			PackagePath:   "github.com/my/app/pkg2",
+			Interface:     "Iface1",
+			MockName:      "FakeIface1",
+			ExpectedError: ensurefile.ErrCannotEditFile,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(entry.File), nil)
+
+		doc, err := entry.Subject.LoadDocument("/my/app/.ensure.yml")
+		ensure(err).IsNotError()
+
+		err = doc.SetMockName(entry.PackagePath, entry.Interface, entry.MockName)
+		ensure(err).IsError(entry.ExpectedError)
+		if entry.ExpectedError != nil {
+			return
+		}
+
+		data, err := doc.Bytes()
+		ensure(err).IsNotError()
+		ensure(string(data)).Equals(entry.ExpectedFile)
+	})
+}
+
+func TestDocumentSetMockOption(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name  string
+		File  string
+		Key   string
+		Value string
+
+		ExpectedFile  string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name:         "adds key before packages",
+			File:         "mocks:\n  tidyAfterGenerate: true\n  packages:\n    - path: github.com/my/app/pkg1\n",
+			Key:          "primaryDestination",
+			Value:        "mocks",
+			ExpectedFile: "mocks:\n  tidyAfterGenerate: true\n  primaryDestination: mocks\n  packages:\n    - path: github.com/my/app/pkg1\n",
+		},
+		{
+			Name:         "adds key without packages",
+			File:         "mocks:\n  tidyAfterGenerate: true\n",
+			Key:          "internalDestination",
+			Value:        "fakes",
+			ExpectedFile: "mocks:\n  tidyAfterGenerate: true\n  internalDestination: fakes\n",
+		},
+		{
+			Name:         "replaces key",
+			File:         "mocks:\n  # Where the mocks go\n  primaryDestination: internal/mocks\n",
+			Key:          "primaryDestination",
+			Value:        "mocks",
+			ExpectedFile: "mocks:\n  # Where the mocks go\n  primaryDestination: mocks\n",
+		},
+		{
+			Name:         "adds mocks key",
+			File:         "",
+			Key:          "primaryDestination",
+			Value:        "mocks",
+			ExpectedFile: "version: 1\nmocks:\n  primaryDestination: mocks\n",
+		},
+		{
+			Name:          "when mocks is not a map",
+			File:          "mocks: [not, a, map]\n",
+			Key:           "primaryDestination",
+			Value:         "mocks",
+			ExpectedError: ensurefile.ErrCannotEditFile,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(entry.File), nil)
+
+		doc, err := entry.Subject.LoadDocument("/my/app/.ensure.yml")
+		ensure(err).IsNotError()
+
+		err = doc.SetMockOption(entry.Key, entry.Value)
+		ensure(err).IsError(entry.ExpectedError)
+		if entry.ExpectedError != nil {
+			return
+		}
+
+		data, err := doc.Bytes()
+		ensure(err).IsNotError()
+		ensure(string(data)).Equals(entry.ExpectedFile)
+	})
+}
+
+func TestDocumentJSON(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name string
+		File string
+
+		ExpectedFile string
+
+		Mocks   *Mocks
+		Subject *ensurefile.Loader
+	}{
+		{
+			Name: "keeps the order of keys",
+			File: `{"mocks": {"typed": true, "packages": [{"path": "github.com/my/app/pkg1", "interfaces": ["Iface1"], "mockNames": {"Iface1": "FakeIface1"}}]}}`,
+			ExpectedFile: `{
+  "mocks": {
+    "typed": true,
+    "packages": [
+      {
+        "path": "github.com/my/app/pkg1",
+        "interfaces": [
+          "Iface1",
+          "Iface2"
+        ],
+        "mockNames": {
+          "Iface1": "FakeIface1"
+        }
+      },
+      {
+        "path": "github.com/my/app/pkg2",
+        "interfaces": [
+          "Iface3"
+        ]
+      }
+    ]
+  }
+}
+`,
+		},
+		{
+			Name: "when file is empty",
+			File: "",
+			ExpectedFile: `{
+  "version": 1,
+  "mocks": {
+    "packages": [
+      {
+        "path": "github.com/my/app/pkg1",
+        "interfaces": [
+          "Iface2"
+        ]
+      },
+      {
+        "path": "github.com/my/app/pkg2",
+        "interfaces": [
+          "Iface3"
+        ]
+      }
+    ]
+  }
+}
+`,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.json").Return([]byte(entry.File), nil)
+
+		doc, err := entry.Subject.LoadDocument("/my/app/.ensure.json")
+		ensure(err).IsNotError()
+
+		ensure(doc.AddInterfaces("github.com/my/app/pkg1", []string{"Iface2"})).IsNotError()
+		ensure(doc.AddInterfaces("github.com/my/app/pkg2", []string{"Iface3"})).IsNotError()
+
+		data, err := doc.Bytes()
+		ensure(err).IsNotError()
+		ensure(string(data)).Equals(entry.ExpectedFile)
+	})
+}
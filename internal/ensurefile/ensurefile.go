@@ -8,13 +8,18 @@ import (
 	"strings"
 
 	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
 	"github.com/JosiahWitt/erk"
 	"golang.org/x/mod/modfile"
 	"gopkg.in/yaml.v3"
 )
 
 // ExampleFile for use in error messages and CLI help menus.
-const ExampleFile = `mocks:
+const ExampleFile = `# Version of the config format, which allows older config files to keep working.
+# Run 'ensure config migrate' to upgrade config files to the latest version.
+version: 1
+
+mocks:
   # Used as the directory path relative to the root of the module
   # for any interfaces that are not within internal directories.
   # Optional, defaults to "internal/mocks".
@@ -30,16 +35,43 @@ const ExampleFile = `mocks:
   # Optional, defaults to false.
   tidyAfterGenerate: true
 
-  # Packages with interfaces for which to generate mocks
+  # Generate strongly-typed wrappers for the calls returned by EXPECT(),
+  # so the arguments to Return, Do, and DoAndReturn are checked by the compiler.
+  # Packages can override this using their own 'typed' key.
+  # Optional, defaults to false.
+  typed: true
+
+  # Go text/template files that are executed and appended to each generated mock file.
+  # Paths are relative to the root of the module.
+  # The scope is either "package" (executed once per mock file) or "interface" (executed once per interface).
+  # Optional, defaults to no templates. Scope defaults to "package".
+  templates:
+    - path: mock_helpers.tmpl
+      scope: interface
+
+  # Packages with interfaces for which to generate mocks.
+  # Interfaces can also be marked in code, by adding an '//ensure:mock' comment to their type declaration.
+  # Use '//ensure:mock name=MockName' to override the name of the mock.
   packages:
     - path: github.com/my/app/some/pkg
       interfaces: [Iface1, Iface2]
+
+      # Overrides the names of the mocks, which default to Mock<Iface>.
+      # Optional, defaults to no overrides.
+      mockNames:
+        Iface2: FakeIface2
 `
 
-const (
-	gomodFileName  = "go.mod"
-	configFileName = ".ensure.yml"
-)
+const gomodFileName = "go.mod"
+
+// configFileNames are searched in order, so .ensure.yml takes precedence over the alternatives.
+// JSON config files are parsed like YAML files, since YAML is a superset of JSON.
+var configFileNames = []string{".ensure.yml", ".ensure.yaml", ".ensure.json"} //nolint:gochecknoglobals // Constant
+
+// IsConfigFileName is true if the file name is one of the accepted config file names, such as .ensure.yml.
+func IsConfigFileName(name string) bool {
+	return containsString(configFileNames, name)
+}
 
 type ErkCannotLoadConfig struct{ erk.DefaultKind }
 
@@ -52,84 +84,341 @@ var (
 
 type LoaderIface interface {
 	LoadConfig(pwd string) (*Config, error)
+	LoadModule(pwd string) (*Config, error)
+	LoadConfigs(pwd string) ([]*Config, error)
+	LoadDocument(configPath string) (*Document, error)
+	UseConfigFile(configPath string)
+	FindConfigFile(pwd string) (string, error)
+	FindConfigFiles(pwd string) ([]string, error)
+	ValidateConfigFile(configPath string) ([]*SchemaError, error)
 }
 
 // Loader allows loading the project's .ensure.yml file.
 type Loader struct {
-	FS fs.FS
+	FS     fs.FS
+	Finder modfiles.FinderIface // Optional: nested .ensure.yml files and `//ensure:mock` annotations are only loaded when set
+
+	// Optional: ENSURE_* environment variables only override the config when set, such as to os.LookupEnv
+	LookupEnv func(key string) (string, bool)
+
+	configPath string // Set by UseConfigFile
 }
 
 var _ LoaderIface = &Loader{}
 
 // Config is the root of the .ensure.yml file.
 type Config struct {
-	DisableParallelGeneration bool   `yaml:"-"`
-	RootPath                  string `yaml:"-"`
-	ModulePath                string `yaml:"-"`
+	DisableParallelGeneration bool     `yaml:"-"`
+	PackageFilters            []string `yaml:"-"`
+	ChangedPackages           []string `yaml:"-"` // When not nil, only the mocks of these package paths are generated
+	RootPath                  string   `yaml:"-"`
+	ModulePath                string   `yaml:"-"`
+	ConfigPath                string   `yaml:"-"`
 
-	Mocks *MockConfig `yaml:"mocks"`
+	Version     int               `yaml:"version"` // Version of the config format the file was written in, which is 0 for files without one
+	Mocks       *MockConfig       `yaml:"mocks"`
+	Modules     []string          `yaml:"modules"` // Directories of the modules in the workspace, relative to .ensure.yml
+	Annotations []*Annotation     `yaml:"-"`
+	Sources     map[string]string `yaml:"-"` // Source of the overridable fields that are set, keyed like mocks.typed
 }
 
 type MockConfig struct {
-	PrimaryDestination  string     `yaml:"primaryDestination"`
-	InternalDestination string     `yaml:"internalDestination"`
-	TidyAfterGenerate   bool       `yaml:"tidyAfterGenerate"`
-	Packages            []*Package `yaml:"packages"`
+	PrimaryDestination  string      `yaml:"primaryDestination"`
+	InternalDestination string      `yaml:"internalDestination"`
+	TidyAfterGenerate   bool        `yaml:"tidyAfterGenerate"`
+	Typed               bool        `yaml:"typed"`
+	Templates           []*Template `yaml:"templates"`
+	Packages            []*Package  `yaml:"packages"`
+}
+
+// Template is a user-defined text/template that is appended to each generated mock file.
+type Template struct {
+	Path     string `yaml:"path" schema:"required"`
+	Scope    string `yaml:"scope" schema:"enum=package|interface"`
+	Contents string `yaml:"-"`
 }
 
 type Package struct {
-	Path       string   `yaml:"path"`
-	Interfaces []string `yaml:"interfaces"`
+	Path       string            `yaml:"path" schema:"required"`
+	Interfaces []string          `yaml:"interfaces" schema:"required"`
+	Typed      *bool             `yaml:"typed"`
+	MockNames  map[string]string `yaml:"mockNames"`
+	Line       int               `yaml:"-"` // Line of the package in .ensure.yml
+	File       string            `yaml:"-"` // The .ensure.yml file listing the package
+
+	// Set by nested .ensure.yml files, overriding the destinations of the root .ensure.yml file
+	PrimaryDestination  string `yaml:"-"` // Relative to the root of the module
+	InternalDestination string `yaml:"-"`
+}
+
+// UseConfigFile loads the config from the file at the absolute configPath,
+// instead of the .ensure.yml file next to go.mod. The module is still found using pwd.
+func (l *Loader) UseConfigFile(configPath string) {
+	l.configPath = configPath
 }
 
 // LoadConfig from the .ensure.yml file that is located in pwd or a parent of pwd.
+// The .ensure.yaml and .ensure.json file names are also accepted.
 func (l *Loader) LoadConfig(pwd string) (*Config, error) {
-	pwd = strings.TrimPrefix(pwd, "/")
+	return l.load(pwd, true)
+}
+
+// LoadModule finds the module containing pwd, and loads its .ensure.yml file if it exists.
+// Unlike LoadConfig, a missing .ensure.yml file is not an error, and Mocks is left nil.
+func (l *Loader) LoadModule(pwd string) (*Config, error) {
+	return l.load(pwd, false)
+}
+
+// FindConfigFile returns the absolute path of the config file that LoadConfig loads, without parsing it.
+func (l *Loader) FindConfigFile(pwd string) (string, error) {
+	dir, _, err := l.findModule(strings.TrimPrefix(pwd, "/"))
+	if err != nil {
+		return "", err
+	}
+
+	configFilePath, _, err := l.readConfigFile(dir)
+	if err != nil {
+		return "", erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+			"path": configFilePath,
+		})
+	}
+
+	return "/" + configFilePath, nil
+}
+
+// FindConfigFiles returns the absolute paths of the module's config file and nested config files.
+// Nested config files are only found when Finder is set.
+func (l *Loader) FindConfigFiles(pwd string) ([]string, error) {
+	dir, _, err := l.findModule(strings.TrimPrefix(pwd, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	configFilePaths := []string{}
+	configFilePath, _, err := l.readConfigFile(dir)
+	if err != nil && (l.configPath != "" || !errors.Is(err, fs.ErrNotExist)) {
+		return nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+			"path": configFilePath,
+		})
+	}
+
+	if err == nil {
+		configFilePaths = append(configFilePaths, "/"+configFilePath)
+	}
+
+	if l.Finder != nil {
+		nestedFilePaths, err := l.Finder.Files("/"+dir, configFileNames...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, nestedFilePath := range preferredConfigFiles(nestedFilePaths) {
+			if filepath.Dir(nestedFilePath) != "/"+dir {
+				configFilePaths = append(configFilePaths, nestedFilePath)
+			}
+		}
+	}
+
+	if len(configFilePaths) == 0 {
+		return nil, erk.WrapWith(ErrCannotOpenFile, fs.ErrNotExist, erk.Params{
+			"path": configFilePath,
+		})
+	}
+
+	return configFilePaths, nil
+}
+
+func (l *Loader) load(pwd string, requireConfigFile bool) (*Config, error) {
+	dir, gomodFileData, err := l.findModule(strings.TrimPrefix(pwd, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	return l.loadModuleDir(dir, gomodFileData, requireConfigFile)
+}
+
+// findModule searches pwd and its parents for the go.mod file, returning the root of the module and the file's contents.
+func (l *Loader) findModule(pwd string) (string, []byte, error) {
 	gomodFilePath := filepath.Join(pwd, gomodFileName)
 
 	gomodFileData, err := fs.ReadFile(l.FS, gomodFilePath)
 	if errors.Is(err, fs.ErrNotExist) {
 		newPWD := filepath.Dir(pwd)
 		if pwd == newPWD {
-			return nil, ErrCannotFindGoModule
+			return "", nil, ErrCannotFindGoModule
 		}
 
-		return l.LoadConfig(newPWD)
+		return l.findModule(newPWD)
 	}
 
 	if err != nil {
-		return nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+		return "", nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
 			"path": gomodFilePath,
 		})
 	}
 
+	return pwd, gomodFileData, nil
+}
+
+// loadModuleDir loads the module rooted at dir, given its go.mod file.
+func (l *Loader) loadModuleDir(dir string, gomodFileData []byte, requireConfigFile bool) (*Config, error) {
+	gomodFilePath := filepath.Join(dir, gomodFileName)
 	modulePath := modfile.ModulePath(gomodFileData)
 	if modulePath == "" {
-		return nil, erk.WrapWith(ErrCannotParseGoModule, err, erk.Params{
+		return nil, erk.WithParams(ErrCannotParseGoModule, erk.Params{
 			"path": gomodFilePath,
 		})
 	}
 
-	configFilePath := filepath.Join(pwd, configFileName)
-	configFileData, err := fs.ReadFile(l.FS, configFilePath)
-	if err != nil {
+	config := Config{}
+	configFilePath, configFileData, err := l.readConfigFile(dir)
+	if err != nil && (requireConfigFile || !errors.Is(err, fs.ErrNotExist)) {
 		return nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
 			"path": configFilePath,
 		})
 	}
 
-	config := Config{}
-	if err := yaml.Unmarshal(configFileData, &config); err != nil {
-		return nil, erk.WrapWith(ErrCannotUnmarshalFile, err, erk.Params{
-			"path": configFilePath,
-		})
+	if err == nil {
+		version, err := decodeConfigFile(configFilePath, configFileData, &config)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Version = version // Instead of the migrated version
+
+		if err := l.loadTemplates(dir, &config); err != nil {
+			return nil, err
+		}
+
+		if config.Mocks != nil {
+			for _, pkg := range config.Mocks.Packages {
+				pkg.File = "/" + configFilePath
+			}
+		}
 	}
 
-	config.RootPath = "/" + pwd
+	config.RootPath = "/" + dir
 	config.ModulePath = modulePath
+	config.ConfigPath = "/" + configFilePath
+
+	if err == nil {
+		if err := config.setFileSources(configFileData); err != nil {
+			return nil, erk.WrapWith(ErrCannotUnmarshalFile, err, erk.Params{
+				"path": configFilePath,
+			})
+		}
+	}
+
+	if err := l.loadNestedConfigs(&config); err != nil {
+		return nil, err
+	}
+
+	if err := l.loadAnnotations(&config); err != nil {
+		return nil, err
+	}
+
+	if err := config.ApplyOverrides(l.envOverrides()); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
+// readConfigFile reads the config file of the module rooted at dir, returning its path relative to the root of the file system.
+func (l *Loader) readConfigFile(dir string) (string, []byte, error) {
+	if l.configPath != "" {
+		configFilePath := strings.TrimPrefix(l.configPath, "/")
+		configFileData, err := fs.ReadFile(l.FS, configFilePath)
+		return configFilePath, configFileData, err
+	}
+
+	return l.findConfigFile(dir)
+}
+
+// findConfigFile reads the first of the configFileNames that exists in dir.
+// If none of them exist, the error from reading .ensure.yml is returned.
+func (l *Loader) findConfigFile(dir string) (string, []byte, error) {
+	var firstErr error
+	for _, name := range configFileNames {
+		configFilePath := filepath.Join(dir, name)
+		configFileData, err := fs.ReadFile(l.FS, configFilePath)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return configFilePath, configFileData, err
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return filepath.Join(dir, configFileNames[0]), nil, firstErr
+}
+
+// loadTemplates reads the contents of any templates, which are relative to the root of the module.
+func (l *Loader) loadTemplates(pwd string, config *Config) error {
+	if config.Mocks == nil {
+		return nil
+	}
+
+	for _, template := range config.Mocks.Templates {
+		templatePath := strings.TrimPrefix(template.Path, "/")
+		if !filepath.IsAbs(template.Path) {
+			templatePath = filepath.Join(pwd, template.Path)
+		}
+
+		templateData, err := fs.ReadFile(l.FS, templatePath)
+		if err != nil {
+			return erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+				"path": templatePath,
+			})
+		}
+
+		template.Contents = string(templateData)
+	}
+
+	return nil
+}
+
+// TemplatePaths returns the absolute paths of the templates, resolving relative paths against the root of the module.
+func (c *Config) TemplatePaths() []string {
+	if c.Mocks == nil {
+		return nil
+	}
+
+	paths := make([]string, 0, len(c.Mocks.Templates))
+	for _, template := range c.Mocks.Templates {
+		path := template.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.RootPath, path)
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+// UnmarshalYAML decodes the package, and records the line it is defined on.
+func (pkg *Package) UnmarshalYAML(node *yaml.Node) error {
+	type rawPackage Package // Prevents recursively calling UnmarshalYAML
+
+	if err := node.Decode((*rawPackage)(pkg)); err != nil {
+		return err
+	}
+
+	pkg.Line = node.Line
+	return nil
+}
+
+// MockName returns the name of the mock generated for the interface.
+func (pkg *Package) MockName(iface string) string {
+	if mockName, ok := pkg.MockNames[iface]; ok {
+		return mockName
+	}
+
+	return "Mock" + iface
+}
+
 // String exposes the Package as `<Path>:<Interfaces[0]>,<Interfaces[1]>,...`.
 func (pkg *Package) String() string {
 	return fmt.Sprintf("%s:%s", pkg.Path, strings.Join(pkg.Interfaces, ","))
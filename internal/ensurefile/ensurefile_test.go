@@ -8,6 +8,7 @@ import (
 	"github.com/JosiahWitt/ensure"
 	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_modfiles"
 	"github.com/JosiahWitt/ensure/ensurepkg"
 	"github.com/golang/mock/gomock"
 )
@@ -16,6 +17,7 @@ func TestLoadConfig(t *testing.T) {
 	ensure := ensure.New(t)
 
 	const defaultGoModFile = "module github.com/my/app"
+	const defaultTemplateFile = "{{.PackagePath}}"
 
 	type Mocks struct {
 		FS *mock_fs.MockReadFileFS
@@ -44,8 +46,9 @@ func TestLoadConfig(t *testing.T) {
 	}
 
 	table := []struct {
-		Name string
-		PWD  string
+		Name       string
+		PWD        string
+		ConfigFile string
 
 		ExpectedConfig *ensurefile.Config
 		ExpectedError  error
@@ -60,10 +63,26 @@ func TestLoadConfig(t *testing.T) {
 			ExpectedConfig: &ensurefile.Config{
 				RootPath:   "/my/app",
 				ModulePath: "github.com/my/app",
+				ConfigPath: "/my/app/.ensure.yml",
+				Version:    1,
+				Sources: map[string]string{
+					"mocks.primaryDestination":  "/my/app/.ensure.yml",
+					"mocks.internalDestination": "/my/app/.ensure.yml",
+					"mocks.tidyAfterGenerate":   "/my/app/.ensure.yml",
+					"mocks.typed":               "/my/app/.ensure.yml",
+				},
 				Mocks: &ensurefile.MockConfig{
 					PrimaryDestination:  "internal/mocks",
 					InternalDestination: "mocks",
 					TidyAfterGenerate:   true,
+					Typed:               true,
+					Templates: []*ensurefile.Template{
+						{
+							Path:     "mock_helpers.tmpl",
+							Scope:    "interface",
+							Contents: defaultTemplateFile,
+						},
+					},
 					Packages: []*ensurefile.Package{
 						{
 							Path: "github.com/my/app/some/pkg",
@@ -71,14 +90,20 @@ func TestLoadConfig(t *testing.T) {
 								"Iface1",
 								"Iface2",
 							},
+							MockNames: map[string]string{
+								"Iface2": "FakeIface2",
+							},
+							Line: 39,
+							File: "/my/app/.ensure.yml",
 						},
 					},
 				},
 			},
 
 			SetupMocks: setupMapFS(mapFS{
-				"my/app/go.mod":      defaultGoModFile,
-				"my/app/.ensure.yml": ensurefile.ExampleFile,
+				"my/app/go.mod":            defaultGoModFile,
+				"my/app/.ensure.yml":       ensurefile.ExampleFile,
+				"my/app/mock_helpers.tmpl": defaultTemplateFile,
 			}),
 		},
 
@@ -88,10 +113,26 @@ func TestLoadConfig(t *testing.T) {
 			ExpectedConfig: &ensurefile.Config{
 				RootPath:   "/my/app",
 				ModulePath: "github.com/my/app",
+				ConfigPath: "/my/app/.ensure.yml",
+				Version:    1,
+				Sources: map[string]string{
+					"mocks.primaryDestination":  "/my/app/.ensure.yml",
+					"mocks.internalDestination": "/my/app/.ensure.yml",
+					"mocks.tidyAfterGenerate":   "/my/app/.ensure.yml",
+					"mocks.typed":               "/my/app/.ensure.yml",
+				},
 				Mocks: &ensurefile.MockConfig{
 					PrimaryDestination:  "internal/mocks",
 					InternalDestination: "mocks",
 					TidyAfterGenerate:   true,
+					Typed:               true,
+					Templates: []*ensurefile.Template{
+						{
+							Path:     "mock_helpers.tmpl",
+							Scope:    "interface",
+							Contents: defaultTemplateFile,
+						},
+					},
 					Packages: []*ensurefile.Package{
 						{
 							Path: "github.com/my/app/some/pkg",
@@ -99,11 +140,116 @@ func TestLoadConfig(t *testing.T) {
 								"Iface1",
 								"Iface2",
 							},
+							MockNames: map[string]string{
+								"Iface2": "FakeIface2",
+							},
+							Line: 39,
+							File: "/my/app/.ensure.yml",
+						},
+					},
+				},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":            defaultGoModFile,
+				"my/app/.ensure.yml":       ensurefile.ExampleFile,
+				"my/app/mock_helpers.tmpl": defaultTemplateFile,
+			}),
+		},
+
+		{
+			Name: "with valid config with absolute template path",
+			PWD:  "/my/app",
+			ExpectedConfig: &ensurefile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				ConfigPath: "/my/app/.ensure.yml",
+				Mocks: &ensurefile.MockConfig{
+					Templates: []*ensurefile.Template{
+						{
+							Path:     "/shared/templates/mock_helpers.tmpl",
+							Contents: defaultTemplateFile,
 						},
 					},
 				},
 			},
 
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":                      defaultGoModFile,
+				"my/app/.ensure.yml":                 "mocks:\n  templates:\n    - path: /shared/templates/mock_helpers.tmpl\n",
+				"shared/templates/mock_helpers.tmpl": defaultTemplateFile,
+			}),
+		},
+
+		{
+			Name: "with .ensure.yaml config",
+			PWD:  "/my/app",
+			ExpectedConfig: &ensurefile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				ConfigPath: "/my/app/.ensure.yaml",
+				Mocks:      &ensurefile.MockConfig{PrimaryDestination: "mocks"},
+				Sources:    map[string]string{"mocks.primaryDestination": "/my/app/.ensure.yaml"},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":       defaultGoModFile,
+				"my/app/.ensure.yaml": "mocks:\n  primaryDestination: mocks\n",
+				"my/app/.ensure.json": `{"mocks": {"primaryDestination": "ignored"}}`,
+			}),
+		},
+
+		{
+			Name: "with .ensure.json config",
+			PWD:  "/my/app",
+			ExpectedConfig: &ensurefile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				ConfigPath: "/my/app/.ensure.json",
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/my/app/some/pkg",
+							Interfaces: []string{"Iface1"},
+							Line:       1,
+							File:       "/my/app/.ensure.json",
+						},
+					},
+				},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":       defaultGoModFile,
+				"my/app/.ensure.json": `{"mocks": {"packages": [{"path": "github.com/my/app/some/pkg", "interfaces": ["Iface1"]}]}}`,
+			}),
+		},
+
+		{
+			Name:       "with explicit config file",
+			PWD:        "/my/app/some/pkg",
+			ConfigFile: "/build/configs/app.yml",
+			ExpectedConfig: &ensurefile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				ConfigPath: "/build/configs/app.yml",
+				Mocks:      &ensurefile.MockConfig{PrimaryDestination: "generated"},
+				Sources:    map[string]string{"mocks.primaryDestination": "/build/configs/app.yml"},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":          defaultGoModFile,
+				"my/app/.ensure.yml":     ensurefile.ExampleFile,
+				"build/configs/app.yml":  "mocks:\n  primaryDestination: generated\n",
+				"build/configs/app.json": `{"mocks": {}}`,
+			}),
+		},
+
+		{
+			Name:          "when explicit config file is missing",
+			PWD:           "/my/app",
+			ConfigFile:    "/build/configs/app.yml",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod":      defaultGoModFile,
 				"my/app/.ensure.yml": ensurefile.ExampleFile,
@@ -173,17 +319,272 @@ func TestLoadConfig(t *testing.T) {
 				"my/app/.ensure.yml": "{{{{{{ Not YAML",
 			}),
 		},
+
+		{
+			Name:          "when cannot parse package in .ensure.yml file",
+			PWD:           "/my/app",
+			ExpectedError: ensurefile.ErrCannotUnmarshalFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.ensure.yml": "mocks:\n  packages:\n    - path: [not, a, string]\n",
+			}),
+		},
+
+		{
+			Name:          "when cannot open template file",
+			PWD:           "/my/app",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.ensure.yml": ensurefile.ExampleFile,
+			}),
+		},
 	}
 
 	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
 		entry := table[i]
 
+		if entry.ConfigFile != "" {
+			entry.Subject.UseConfigFile(entry.ConfigFile)
+		}
+
 		config, err := entry.Subject.LoadConfig(entry.PWD)
 		ensure(err).IsError(entry.ExpectedError)
 		ensure(config).Equals(entry.ExpectedConfig)
 	})
 }
 
+func TestLoadModule(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	table := []struct {
+		Name string
+		PWD  string
+
+		ExpectedConfig *ensurefile.Config
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name: "with existing config",
+			PWD:  "/my/app/pkg",
+			ExpectedConfig: &ensurefile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				ConfigPath: "/my/app/.ensure.yml",
+				Sources:    map[string]string{"mocks.primaryDestination": "/my/app/.ensure.yml"},
+				Mocks: &ensurefile.MockConfig{
+					PrimaryDestination: "mocks",
+				},
+			},
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/pkg/go.mod").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte("mocks:\n  primaryDestination: mocks\n"), nil)
+			},
+		},
+		{
+			Name: "without config",
+			PWD:  "/my/app",
+			ExpectedConfig: &ensurefile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				ConfigPath: "/my/app/.ensure.yml",
+			},
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yaml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.json").Return(nil, fs.ErrNotExist)
+			},
+		},
+		{
+			Name:          "when cannot find go.mod",
+			PWD:           "/my",
+			ExpectedError: ensurefile.ErrCannotFindGoModule,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/go.mod").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("go.mod").Return(nil, fs.ErrNotExist)
+			},
+		},
+		{
+			Name:          "when cannot open config",
+			PWD:           "/my/app",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, exampleError)
+			},
+		},
+		{
+			Name:          "when cannot parse config",
+			PWD:           "/my/app",
+			ExpectedError: ensurefile.ErrCannotUnmarshalFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte("mocks: ["), nil)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		config, err := entry.Subject.LoadModule(entry.PWD)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(config).Equals(entry.ExpectedConfig)
+	})
+}
+
+func TestFindConfigFile(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name       string
+		PWD        string
+		ConfigFile string
+
+		ExpectedPath  string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name:         "with config file in parent directory",
+			PWD:          "/my/app/pkg",
+			ExpectedPath: "/my/app/.ensure.json",
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/pkg/go.mod").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yaml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.json").Return([]byte("{}"), nil)
+			},
+		},
+		{
+			Name:         "with explicit config file",
+			PWD:          "/my/app",
+			ConfigFile:   "/my/configs/ensure.yml",
+			ExpectedPath: "/my/configs/ensure.yml",
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+				m.FS.EXPECT().ReadFile("my/configs/ensure.yml").Return([]byte("{}"), nil)
+			},
+		},
+		{
+			Name:          "when cannot find go.mod",
+			PWD:           "/my",
+			ExpectedError: ensurefile.ErrCannotFindGoModule,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/go.mod").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("go.mod").Return(nil, fs.ErrNotExist)
+			},
+		},
+		{
+			Name:          "without config file",
+			PWD:           "/my/app",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yaml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.json").Return(nil, fs.ErrNotExist)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		if entry.ConfigFile != "" {
+			entry.Subject.UseConfigFile(entry.ConfigFile)
+		}
+
+		path, err := entry.Subject.FindConfigFile(entry.PWD)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(path).Equals(entry.ExpectedPath)
+	})
+}
+
+func TestFindConfigFiles(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS     *mock_fs.MockReadFileFS
+		Finder *mock_modfiles.MockFinderIface
+	}
+
+	nestedFiles := []string{"/my/app/.ensure.yml", "/my/app/store/.ensure.json", "/my/app/store/.ensure.yml"}
+
+	table := []struct {
+		Name string
+
+		ExpectedPaths []string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name:          "with root and nested config files",
+			ExpectedPaths: []string{"/my/app/.ensure.yml", "/my/app/store/.ensure.yml"},
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte("{}"), nil)
+				m.Finder.EXPECT().Files("/my/app", ".ensure.yml", ".ensure.yaml", ".ensure.json").Return(nestedFiles, nil)
+			},
+		},
+		{
+			Name:          "with only nested config files",
+			ExpectedPaths: []string{"/my/app/store/.ensure.yml"},
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yaml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.json").Return(nil, fs.ErrNotExist)
+				m.Finder.EXPECT().Files("/my/app", ".ensure.yml", ".ensure.yaml", ".ensure.json").
+					Return([]string{"/my/app/store/.ensure.yml"}, nil)
+			},
+		},
+		{
+			Name:          "without config files",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yaml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.ensure.json").Return(nil, fs.ErrNotExist)
+				m.Finder.EXPECT().Files("/my/app", ".ensure.yml", ".ensure.yaml", ".ensure.json").Return(nil, nil)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+
+		paths, err := entry.Subject.FindConfigFiles("/my/app")
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(paths).Equals(entry.ExpectedPaths)
+	})
+}
+
 func TestPackageString(t *testing.T) {
 	ensure := ensure.New(t)
 
@@ -193,3 +594,17 @@ func TestPackageString(t *testing.T) {
 	}
 	ensure(pkg.String()).Equals("github.com/my/pkg:Iface1,Iface2")
 }
+
+func TestConfigTemplatePaths(t *testing.T) {
+	ensure := ensure.New(t)
+
+	config := ensurefile.Config{
+		RootPath: "/my/app",
+		Mocks: &ensurefile.MockConfig{
+			Templates: []*ensurefile.Template{{Path: "templates/mock.tmpl"}, {Path: "/shared/mock.tmpl"}},
+		},
+	}
+	ensure(config.TemplatePaths()).Equals([]string{"/my/app/templates/mock.tmpl", "/shared/mock.tmpl"})
+
+	ensure((&ensurefile.Config{RootPath: "/my/app"}).TemplatePaths()).IsEmpty()
+}
@@ -0,0 +1,167 @@
+package ensurefile
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/erk"
+)
+
+var ErrRootOnlyKey = erk.New(ErkCannotLoadConfig{},
+	"The `{{.key}}` key can only be set in the .ensure.yml file at the root of the module, but it is set in '{{.path}}'",
+)
+
+// nestedConfig is a .ensure.yml file within a subdirectory of the module, which configures the mocks of that subtree.
+// Keys that only apply to the whole module are decoded so they can be rejected, instead of being silently ignored.
+type nestedConfig struct {
+	Modules []string `yaml:"modules"`
+	Mocks   *struct {
+		PrimaryDestination  string      `yaml:"primaryDestination"` // Relative to the nested .ensure.yml file
+		InternalDestination string      `yaml:"internalDestination"`
+		TidyAfterGenerate   *bool       `yaml:"tidyAfterGenerate"`
+		Typed               *bool       `yaml:"typed"`
+		Templates           []*Template `yaml:"templates"`
+		Packages            []*Package  `yaml:"packages"`
+	} `yaml:"mocks"`
+}
+
+// loadNestedConfigs merges the packages of the .ensure.yml files within subdirectories of the module into the config.
+func (l *Loader) loadNestedConfigs(config *Config) error {
+	if l.Finder == nil {
+		return nil
+	}
+
+	configFilePaths, err := l.Finder.Files(config.RootPath, configFileNames...)
+	if err != nil {
+		return err
+	}
+
+	for _, configFilePath := range preferredConfigFiles(configFilePaths) {
+		if filepath.Dir(configFilePath) == config.RootPath { // Either the root config file, or an alternative to it
+			continue
+		}
+
+		packages, err := l.loadNestedConfig(config, configFilePath)
+		if err != nil {
+			return err
+		}
+
+		if len(packages) == 0 {
+			continue
+		}
+
+		if config.Mocks == nil {
+			config.Mocks = &MockConfig{}
+		}
+
+		config.Mocks.Packages = append(config.Mocks.Packages, packages...)
+	}
+
+	return nil
+}
+
+// loadNestedConfig returns the packages listed in the nested .ensure.yml file.
+// Package paths starting with ./ or ../ are relative to the directory of the file,
+// and the file's destinations and typed setting become the defaults of its packages.
+func (l *Loader) loadNestedConfig(config *Config, configFilePath string) ([]*Package, error) {
+	fsPath := strings.TrimPrefix(configFilePath, "/")
+	configFileData, err := fs.ReadFile(l.FS, fsPath)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+			"path": fsPath,
+		})
+	}
+
+	nested := nestedConfig{}
+	if _, err := decodeConfigFile(fsPath, configFileData, &nested); err != nil {
+		return nil, err
+	}
+
+	if len(nested.Modules) > 0 {
+		return nil, rootOnlyKeyError("modules", configFilePath)
+	}
+
+	if nested.Mocks == nil {
+		return nil, nil
+	}
+
+	if nested.Mocks.TidyAfterGenerate != nil {
+		return nil, rootOnlyKeyError("mocks.tidyAfterGenerate", configFilePath)
+	}
+
+	if len(nested.Mocks.Templates) > 0 {
+		return nil, rootOnlyKeyError("mocks.templates", configFilePath)
+	}
+
+	relativeDir, err := filepath.Rel(config.RootPath, filepath.Dir(configFilePath))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pkg := range nested.Mocks.Packages {
+		pkg.File = configFilePath
+
+		if isRelativePackagePath(pkg.Path) {
+			pkg.Path = path.Join(config.ModulePath, filepath.ToSlash(relativeDir), pkg.Path)
+		}
+
+		if nested.Mocks.PrimaryDestination != "" {
+			pkg.PrimaryDestination = filepath.Join(relativeDir, nested.Mocks.PrimaryDestination)
+		}
+
+		pkg.InternalDestination = nested.Mocks.InternalDestination
+
+		if pkg.Typed == nil {
+			pkg.Typed = nested.Mocks.Typed
+		}
+	}
+
+	return nested.Mocks.Packages, nil
+}
+
+// preferredConfigFiles keeps one config file per directory, using the same precedence as the root config file.
+func preferredConfigFiles(configFilePaths []string) []string {
+	preferred := map[string]string{}
+	dirs := []string{}
+	for _, configFilePath := range configFilePaths {
+		dir := filepath.Dir(configFilePath)
+		existing, ok := preferred[dir]
+		if !ok {
+			dirs = append(dirs, dir)
+		}
+
+		if !ok || configFileRank(filepath.Base(configFilePath)) < configFileRank(filepath.Base(existing)) {
+			preferred[dir] = configFilePath
+		}
+	}
+
+	paths := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		paths = append(paths, preferred[dir])
+	}
+
+	return paths
+}
+
+func configFileRank(name string) int {
+	for i, configFileName := range configFileNames {
+		if name == configFileName {
+			return i
+		}
+	}
+
+	return len(configFileNames)
+}
+
+func isRelativePackagePath(pkgPath string) bool {
+	return pkgPath == "." || pkgPath == ".." || strings.HasPrefix(pkgPath, "./") || strings.HasPrefix(pkgPath, "../")
+}
+
+func rootOnlyKeyError(key, configFilePath string) error {
+	return erk.WithParams(ErrRootOnlyKey, erk.Params{
+		"key":  key,
+		"path": configFilePath,
+	})
+}
@@ -0,0 +1,226 @@
+package ensurefile_test
+
+import (
+	"errors"
+	"testing"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_modfiles"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestLoadConfigNested(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const rootConfigFile = "mocks:\n  typed: true\n  packages:\n    - path: github.com/some/pkg\n      interfaces: [Iface]\n"
+
+	type Mocks struct {
+		FS     *mock_fs.MockReadFileFS
+		Finder *mock_modfiles.MockFinderIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	boolPtr := func(b bool) *bool { return &b }
+
+	type mapFS map[string]interface{}
+
+	table := []struct {
+		Name        string
+		ConfigFiles []string
+		Files       mapFS
+
+		ExpectedMocks *ensurefile.MockConfig
+		ExpectedError error
+
+		Mocks   *Mocks
+		Subject *ensurefile.Loader
+	}{
+		{
+			Name:        "with nested config files",
+			ConfigFiles: []string{"/my/app/.ensure.yml", "/my/app/store/.ensure.yml", "/my/app/api/v1/.ensure.yml"},
+			Files: mapFS{
+				"my/app/.ensure.yml": rootConfigFile,
+				"my/app/store/.ensure.yml": "mocks:\n" +
+					"  primaryDestination: mocks\n" +
+					"  internalDestination: fakes\n" +
+					"  typed: false\n" +
+					"  packages:\n" +
+					"    - path: .\n" +
+					"      interfaces: [Store]\n" +
+					"    - path: ./internal/cache\n" +
+					"      interfaces: [Cache]\n" +
+					"      typed: true\n" +
+					"    - path: github.com/other/pkg\n" +
+					"      interfaces: [Other]\n",
+				"my/app/api/v1/.ensure.yml": "mocks:\n  packages:\n    - path: ../shared\n      interfaces: [Handler]\n",
+			},
+			ExpectedMocks: &ensurefile.MockConfig{
+				Typed: true,
+				Packages: []*ensurefile.Package{
+					{
+						Path:       "github.com/some/pkg",
+						Interfaces: []string{"Iface"},
+						Line:       4,
+						File:       "/my/app/.ensure.yml",
+					},
+					{
+						Path:                "github.com/my/app/store",
+						Interfaces:          []string{"Store"},
+						Typed:               boolPtr(false),
+						Line:                6,
+						File:                "/my/app/store/.ensure.yml",
+						PrimaryDestination:  "store/mocks",
+						InternalDestination: "fakes",
+					},
+					{
+						Path:                "github.com/my/app/store/internal/cache",
+						Interfaces:          []string{"Cache"},
+						Typed:               boolPtr(true),
+						Line:                8,
+						File:                "/my/app/store/.ensure.yml",
+						PrimaryDestination:  "store/mocks",
+						InternalDestination: "fakes",
+					},
+					{
+						Path:                "github.com/other/pkg",
+						Interfaces:          []string{"Other"},
+						Typed:               boolPtr(false),
+						Line:                11,
+						File:                "/my/app/store/.ensure.yml",
+						PrimaryDestination:  "store/mocks",
+						InternalDestination: "fakes",
+					},
+					{
+						Path:       "github.com/my/app/api/shared",
+						Interfaces: []string{"Handler"},
+						Line:       3,
+						File:       "/my/app/api/v1/.ensure.yml",
+					},
+				},
+			},
+		},
+		{
+			Name:        "with nested config file without mocks",
+			ConfigFiles: []string{"/my/app/.ensure.yml", "/my/app/store/.ensure.yml"},
+			Files: mapFS{
+				"my/app/.ensure.yml":       rootConfigFile,
+				"my/app/store/.ensure.yml": "# Nothing to mock\n",
+			},
+			ExpectedMocks: &ensurefile.MockConfig{
+				Typed: true,
+				Packages: []*ensurefile.Package{
+					{Path: "github.com/some/pkg", Interfaces: []string{"Iface"}, Line: 4, File: "/my/app/.ensure.yml"},
+				},
+			},
+		},
+		{
+			Name: "with alternative config file names",
+			ConfigFiles: []string{
+				"/my/app/.ensure.json",
+				"/my/app/.ensure.yml",
+				"/my/app/api/.ensure.json",
+				"/my/app/store/.ensure.json",
+				"/my/app/store/.ensure.yml",
+			},
+			Files: mapFS{
+				"my/app/.ensure.yml":        rootConfigFile,
+				"my/app/.ensure.json":       `{"mocks": {"packages": [{"path": "github.com/ignored/pkg", "interfaces": ["Iface"]}]}}`,
+				"my/app/api/.ensure.json":   `{"mocks": {"packages": [{"path": ".", "interfaces": ["Handler"]}]}}`,
+				"my/app/store/.ensure.json": `{"mocks": {"packages": [{"path": ".", "interfaces": ["Ignored"]}]}}`,
+				"my/app/store/.ensure.yml":  "mocks:\n  packages:\n    - path: .\n      interfaces: [Store]\n",
+			},
+			ExpectedMocks: &ensurefile.MockConfig{
+				Typed: true,
+				Packages: []*ensurefile.Package{
+					{Path: "github.com/some/pkg", Interfaces: []string{"Iface"}, Line: 4, File: "/my/app/.ensure.yml"},
+					{Path: "github.com/my/app/api", Interfaces: []string{"Handler"}, Line: 1, File: "/my/app/api/.ensure.json"},
+					{Path: "github.com/my/app/store", Interfaces: []string{"Store"}, Line: 3, File: "/my/app/store/.ensure.yml"},
+				},
+			},
+		},
+		{
+			Name:        "when nested config file lists modules",
+			ConfigFiles: []string{"/my/app/.ensure.yml", "/my/app/store/.ensure.yml"},
+			Files: mapFS{
+				"my/app/.ensure.yml":       rootConfigFile,
+				"my/app/store/.ensure.yml": "modules: [other]\n",
+			},
+			ExpectedError: ensurefile.ErrRootOnlyKey,
+		},
+		{
+			Name:        "when nested config file sets tidyAfterGenerate",
+			ConfigFiles: []string{"/my/app/.ensure.yml", "/my/app/store/.ensure.yml"},
+			Files: mapFS{
+				"my/app/.ensure.yml":       rootConfigFile,
+				"my/app/store/.ensure.yml": "mocks:\n  tidyAfterGenerate: false\n",
+			},
+			ExpectedError: ensurefile.ErrRootOnlyKey,
+		},
+		{
+			Name:        "when nested config file sets templates",
+			ConfigFiles: []string{"/my/app/.ensure.yml", "/my/app/store/.ensure.yml"},
+			Files: mapFS{
+				"my/app/.ensure.yml":       rootConfigFile,
+				"my/app/store/.ensure.yml": "mocks:\n  templates:\n    - path: header.tmpl\n",
+			},
+			ExpectedError: ensurefile.ErrRootOnlyKey,
+		},
+		{
+			Name:        "when unable to read nested config file",
+			ConfigFiles: []string{"/my/app/.ensure.yml", "/my/app/store/.ensure.yml"},
+			Files: mapFS{
+				"my/app/.ensure.yml":       rootConfigFile,
+				"my/app/store/.ensure.yml": exampleError,
+			},
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+		},
+		{
+			Name:        "when unable to parse nested config file",
+			ConfigFiles: []string{"/my/app/.ensure.yml", "/my/app/store/.ensure.yml"},
+			Files: mapFS{
+				"my/app/.ensure.yml":       rootConfigFile,
+				"my/app/store/.ensure.yml": "mocks: {",
+			},
+			ExpectedError: ensurefile.ErrCannotUnmarshalFile,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile(gomock.Any()).AnyTimes().
+			DoAndReturn(func(name string) ([]byte, error) {
+				if name == "my/app/go.mod" {
+					return []byte("module github.com/my/app"), nil
+				}
+
+				rawData, ok := entry.Files[name]
+				if !ok {
+					return nil, fs.ErrNotExist
+				}
+
+				switch data := rawData.(type) {
+				case string:
+					return []byte(data), nil
+				case error:
+					return nil, data
+				default:
+					return nil, errors.New("unknown type")
+				}
+			})
+
+		entry.Mocks.Finder.EXPECT().Files("/my/app", ".ensure.yml", ".ensure.yaml", ".ensure.json").Return(entry.ConfigFiles, nil)
+		entry.Mocks.Finder.EXPECT().GoFilesContaining("/my/app", "//ensure:mock").Return(nil, nil).AnyTimes()
+
+		config, err := entry.Subject.LoadConfig("/my/app")
+		ensure(err).IsError(entry.ExpectedError)
+
+		if entry.ExpectedError == nil {
+			ensure(config.Mocks).Equals(entry.ExpectedMocks)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package ensurefile
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"gopkg.in/yaml.v3"
+)
+
+// SourceDefault is the source of config fields that are not set.
+const SourceDefault = "default"
+
+var ErrInvalidOverride = erk.New(ErkCannotLoadConfig{}, "Invalid value '{{.value}}' for {{.key}} from {{.source}}: {{.err}}")
+
+// Override sets a config field from outside of the config file.
+type Override struct {
+	Key    string // Such as mocks.primaryDestination
+	Value  string
+	Source string // Such as "env ENSURE_MOCKS_PRIMARY_DESTINATION" or "flag --primary-destination"
+}
+
+// OverridableField is a config field that can be set using an environment variable or flag.
+type OverridableField struct {
+	Key    string
+	EnvVar string
+	Flag   string
+	Usage  string
+	IsBool bool
+
+	set func(mocks *MockConfig, value string) error
+}
+
+// OverridableFields returns the config fields that can be overridden, in the order they are applied.
+// Only the scalar mock options can be overridden, since lists such as mocks.packages and mocks.templates
+// cannot be expressed as a single environment variable or flag, and are only read from the config file.
+func OverridableFields() []*OverridableField {
+	return []*OverridableField{
+		{
+			Key:    "mocks.primaryDestination",
+			EnvVar: "ENSURE_MOCKS_PRIMARY_DESTINATION",
+			Flag:   "primary-destination",
+			Usage:  "Overrides mocks.primaryDestination",
+			set: func(mocks *MockConfig, value string) error {
+				mocks.PrimaryDestination = value
+				return nil
+			},
+		},
+		{
+			Key:    "mocks.internalDestination",
+			EnvVar: "ENSURE_MOCKS_INTERNAL_DESTINATION",
+			Flag:   "internal-destination",
+			Usage:  "Overrides mocks.internalDestination",
+			set: func(mocks *MockConfig, value string) error {
+				mocks.InternalDestination = value
+				return nil
+			},
+		},
+		{
+			Key:    "mocks.tidyAfterGenerate",
+			EnvVar: "ENSURE_MOCKS_TIDY_AFTER_GENERATE",
+			Flag:   "tidy-after-generate",
+			Usage:  "Overrides mocks.tidyAfterGenerate",
+			IsBool: true,
+			set: func(mocks *MockConfig, value string) error {
+				tidyAfterGenerate, err := strconv.ParseBool(value)
+				mocks.TidyAfterGenerate = tidyAfterGenerate
+				return err
+			},
+		},
+		{
+			Key:    "mocks.typed",
+			EnvVar: "ENSURE_MOCKS_TYPED",
+			Flag:   "typed",
+			Usage:  "Overrides mocks.typed",
+			IsBool: true,
+			set: func(mocks *MockConfig, value string) error {
+				typed, err := strconv.ParseBool(value)
+				mocks.Typed = typed
+				return err
+			},
+		},
+	}
+}
+
+// ApplyOverrides sets the fields of the config in order, so later overrides take precedence,
+// and records the source of each field. When the config has neither a mocks section nor annotations,
+// the values are still checked, but the mocks section is not created, so generating reports it as missing.
+func (c *Config) ApplyOverrides(overrides []*Override) error {
+	fields := map[string]*OverridableField{}
+	for _, field := range OverridableFields() {
+		fields[field.Key] = field
+	}
+
+	for _, override := range overrides {
+		field, ok := fields[override.Key]
+		if !ok {
+			return erk.WithParams(ErrInvalidOverride, erk.Params{
+				"key":    override.Key,
+				"value":  override.Value,
+				"source": override.Source,
+				"err":    "the field cannot be overridden",
+			})
+		}
+
+		mocks := c.Mocks
+		if mocks == nil {
+			mocks = &MockConfig{}
+		}
+
+		if err := field.set(mocks, override.Value); err != nil {
+			return erk.WrapWith(ErrInvalidOverride, err, erk.Params{
+				"key":    override.Key,
+				"value":  override.Value,
+				"source": override.Source,
+			})
+		}
+
+		if c.Mocks == nil {
+			if len(c.Annotations) == 0 {
+				continue
+			}
+
+			c.Mocks = mocks // Annotations can be used without a mocks section
+		}
+
+		c.setSource(override.Key, override.Source)
+	}
+
+	return nil
+}
+
+// Source returns where the value of the field came from, which is either the config file,
+// an environment variable, a flag, or SourceDefault.
+func (c *Config) Source(key string) string {
+	if source, ok := c.Sources[key]; ok {
+		return source
+	}
+
+	return SourceDefault
+}
+
+func (c *Config) setSource(key, source string) {
+	if c.Sources == nil {
+		c.Sources = map[string]string{}
+	}
+
+	c.Sources[key] = source
+}
+
+// envOverrides returns the overrides from the ENSURE_* environment variables that are set.
+func (l *Loader) envOverrides() []*Override {
+	if l.LookupEnv == nil {
+		return nil
+	}
+
+	overrides := []*Override{}
+	for _, field := range OverridableFields() {
+		if value, ok := l.LookupEnv(field.EnvVar); ok {
+			overrides = append(overrides, &Override{
+				Key:    field.Key,
+				Value:  value,
+				Source: "env " + field.EnvVar,
+			})
+		}
+	}
+
+	return overrides
+}
+
+// setFileSources records the overridable fields that are set in the config file.
+func (c *Config) setFileSources(configFileData []byte) error {
+	var raw struct {
+		Mocks map[string]yaml.Node `yaml:"mocks"`
+	}
+
+	if err := yaml.Unmarshal(configFileData, &raw); err != nil {
+		return err
+	}
+
+	for _, field := range OverridableFields() {
+		if _, ok := raw.Mocks[strings.TrimPrefix(field.Key, "mocks.")]; ok {
+			c.setSource(field.Key, c.ConfigPath)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package ensurefile_test
+
+import (
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const configFile = "mocks:\n  primaryDestination: mocks\n  typed: true\n"
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name string
+		Env  map[string]string
+
+		ExpectedMocks   *ensurefile.MockConfig
+		ExpectedSources map[string]string
+		ExpectedError   error
+
+		Mocks   *Mocks
+		Subject *ensurefile.Loader
+	}{
+		{
+			Name:          "without environment variables",
+			ExpectedMocks: &ensurefile.MockConfig{PrimaryDestination: "mocks", Typed: true},
+			ExpectedSources: map[string]string{
+				"mocks.primaryDestination": "/my/app/.ensure.yml",
+				"mocks.typed":              "/my/app/.ensure.yml",
+			},
+		},
+		{
+			Name: "with environment variables",
+			Env: map[string]string{
+				"ENSURE_MOCKS_PRIMARY_DESTINATION":  "generated",
+				"ENSURE_MOCKS_INTERNAL_DESTINATION": "fakes",
+				"ENSURE_MOCKS_TIDY_AFTER_GENERATE":  "true",
+				"ENSURE_MOCKS_TYPED":                "false",
+			},
+			ExpectedMocks: &ensurefile.MockConfig{
+				PrimaryDestination:  "generated",
+				InternalDestination: "fakes",
+				TidyAfterGenerate:   true,
+			},
+			ExpectedSources: map[string]string{
+				"mocks.primaryDestination":  "env ENSURE_MOCKS_PRIMARY_DESTINATION",
+				"mocks.internalDestination": "env ENSURE_MOCKS_INTERNAL_DESTINATION",
+				"mocks.tidyAfterGenerate":   "env ENSURE_MOCKS_TIDY_AFTER_GENERATE",
+				"mocks.typed":               "env ENSURE_MOCKS_TYPED",
+			},
+		},
+		{
+			Name:          "with invalid boolean environment variable",
+			Env:           map[string]string{"ENSURE_MOCKS_TYPED": "sometimes"},
+			ExpectedError: ensurefile.ErrInvalidOverride,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(configFile), nil)
+		entry.Subject.LookupEnv = func(key string) (string, bool) {
+			value, ok := entry.Env[key]
+			return value, ok
+		}
+
+		config, err := entry.Subject.LoadConfig("/my/app")
+		ensure(err).IsError(entry.ExpectedError)
+
+		if entry.ExpectedError == nil {
+			ensure(config.Mocks).Equals(entry.ExpectedMocks)
+			ensure(config.Sources).Equals(entry.ExpectedSources)
+		}
+	})
+}
+
+func TestConfigApplyOverrides(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name      string
+		Config    *ensurefile.Config
+		Overrides []*ensurefile.Override
+
+		ExpectedConfig *ensurefile.Config
+		ExpectedError  error
+	}{
+		{
+			Name: "later overrides take precedence",
+			Config: &ensurefile.Config{
+				Mocks:   &ensurefile.MockConfig{PrimaryDestination: "mocks"},
+				Sources: map[string]string{"mocks.primaryDestination": "/my/app/.ensure.yml"},
+			},
+			Overrides: []*ensurefile.Override{
+				{Key: "mocks.primaryDestination", Value: "from/env", Source: "env ENSURE_MOCKS_PRIMARY_DESTINATION"},
+				{Key: "mocks.primaryDestination", Value: "from/flag", Source: "flag --primary-destination"},
+			},
+			ExpectedConfig: &ensurefile.Config{
+				Mocks:   &ensurefile.MockConfig{PrimaryDestination: "from/flag"},
+				Sources: map[string]string{"mocks.primaryDestination": "flag --primary-destination"},
+			},
+		},
+		{
+			Name: "creates mocks config when missing and using annotations",
+			Config: &ensurefile.Config{
+				Annotations: []*ensurefile.Annotation{{PackagePath: "github.com/my/app/pkg", Interface: "Iface"}},
+			},
+			Overrides: []*ensurefile.Override{
+				{Key: "mocks.typed", Value: "true", Source: "flag --typed"},
+			},
+			ExpectedConfig: &ensurefile.Config{
+				Mocks:       &ensurefile.MockConfig{Typed: true},
+				Annotations: []*ensurefile.Annotation{{PackagePath: "github.com/my/app/pkg", Interface: "Iface"}},
+				Sources:     map[string]string{"mocks.typed": "flag --typed"},
+			},
+		},
+		{
+			Name:   "leaves mocks config missing when not using annotations",
+			Config: &ensurefile.Config{},
+			Overrides: []*ensurefile.Override{
+				{Key: "mocks.typed", Value: "true", Source: "flag --typed"},
+			},
+			ExpectedConfig: &ensurefile.Config{},
+		},
+		{
+			Name:   "when boolean is invalid and mocks config is missing",
+			Config: &ensurefile.Config{},
+			Overrides: []*ensurefile.Override{
+				{Key: "mocks.typed", Value: "maybe", Source: "env ENSURE_MOCKS_TYPED"},
+			},
+			ExpectedError: ensurefile.ErrInvalidOverride,
+		},
+		{
+			Name:   "when field cannot be overridden",
+			Config: &ensurefile.Config{},
+			Overrides: []*ensurefile.Override{
+				{Key: "mocks.packages", Value: "github.com/my/app/pkg", Source: "flag --packages"},
+			},
+			ExpectedError: ensurefile.ErrInvalidOverride,
+		},
+		{
+			Name:   "when boolean is invalid",
+			Config: &ensurefile.Config{},
+			Overrides: []*ensurefile.Override{
+				{Key: "mocks.tidyAfterGenerate", Value: "maybe", Source: "flag --tidy-after-generate"},
+			},
+			ExpectedError: ensurefile.ErrInvalidOverride,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		err := entry.Config.ApplyOverrides(entry.Overrides)
+		ensure(err).IsError(entry.ExpectedError)
+
+		if entry.ExpectedError == nil {
+			ensure(entry.Config).Equals(entry.ExpectedConfig)
+			ensure(entry.Config.Source("mocks.internalDestination")).Equals(ensurefile.SourceDefault)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package ensurefile
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema is the subset of JSON Schema used to describe the config file.
+type JSONSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type"`
+
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"` // Either false or a *JSONSchema
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Maximum              *int                   `json:"maximum,omitempty"`
+}
+
+// Schema returns the JSON Schema of the config file, which is generated from the Config type.
+// Descriptions are taken from the comments in ExampleFile.
+func Schema() *JSONSchema {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	schema.Schema = jsonSchemaDraft
+	schema.Title = ".ensure.yml"
+	schema.Description = "Config file for the ensure CLI."
+
+	minVersion, maxVersion := 1, CurrentVersion
+	schema.Properties["version"].Minimum = &minVersion
+	schema.Properties["version"].Maximum = &maxVersion
+
+	var example yaml.Node
+	if err := yaml.Unmarshal([]byte(ExampleFile), &example); err == nil && len(example.Content) > 0 {
+		describe(schema, example.Content[0])
+	}
+
+	return schema
+}
+
+// typeSchema converts the type to a schema, using the yaml tags of structs as the property names.
+// Struct fields can be tagged with `schema:"required"` or `schema:"enum=a|b"`.
+func typeSchema(t reflect.Type) *JSONSchema {
+	switch t.Kind() { //nolint:exhaustive // Only the kinds used by Config are supported
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Slice:
+		return &JSONSchema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return &JSONSchema{Type: "string"}
+	}
+}
+
+func structSchema(t reflect.Type) *JSONSchema {
+	schema := &JSONSchema{
+		Type:                 "object",
+		Properties:           map[string]*JSONSchema{},
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		property := typeSchema(field.Type)
+		for _, option := range strings.Split(field.Tag.Get("schema"), ",") {
+			switch {
+			case option == "required":
+				schema.Required = append(schema.Required, name)
+			case strings.HasPrefix(option, "enum="):
+				property.Enum = strings.Split(strings.TrimPrefix(option, "enum="), "|")
+			}
+		}
+
+		schema.Properties[name] = property
+	}
+
+	return schema
+}
+
+// describe sets the descriptions of the schema's properties to the comments above the matching keys in the node.
+func describe(schema *JSONSchema, node *yaml.Node) {
+	switch node.Kind { //nolint:exhaustive // Other kinds have no properties to describe
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			property, ok := schema.Properties[key.Value]
+			if !ok {
+				continue
+			}
+
+			if key.HeadComment != "" {
+				property.Description = commentText(key.HeadComment)
+			}
+
+			describe(property, value)
+		}
+	case yaml.SequenceNode:
+		if schema.Items != nil && len(node.Content) > 0 {
+			describe(schema.Items, node.Content[0])
+		}
+	}
+}
+
+// commentText joins the lines of a YAML comment into a single sentence.
+func commentText(comment string) string {
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+	}
+
+	return strings.Join(lines, " ")
+}
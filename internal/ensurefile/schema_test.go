@@ -0,0 +1,42 @@
+package ensurefile_test
+
+import (
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+)
+
+func TestSchema(t *testing.T) {
+	ensure := ensure.New(t)
+
+	schema := ensurefile.Schema()
+	ensure(schema.Schema).Equals("http://json-schema.org/draft-07/schema#")
+	ensure(schema.Type).Equals("object")
+	ensure(schema.AdditionalProperties).Equals(false)
+
+	mocks := schema.Properties["mocks"]
+	ensure(mocks.Properties["tidyAfterGenerate"].Type).Equals("boolean")
+	ensure(mocks.Properties["primaryDestination"].Description).Equals(
+		"Used as the directory path relative to the root of the module " +
+			"for any interfaces that are not within internal directories. " +
+			"Optional, defaults to \"internal/mocks\".",
+	)
+
+	templates := mocks.Properties["templates"]
+	ensure(templates.Type).Equals("array")
+	ensure(templates.Items.Required).Equals([]string{"path"})
+	ensure(templates.Items.Properties["scope"].Enum).Equals([]string{"package", "interface"})
+
+	packages := mocks.Properties["packages"]
+	ensure(packages.Items.Required).Equals([]string{"path", "interfaces"})
+	ensure(packages.Items.Properties["interfaces"].Items.Type).Equals("string")
+	ensure(packages.Items.Properties["mockNames"].AdditionalProperties).Equals(&ensurefile.JSONSchema{Type: "string"})
+	ensure(packages.Items.Properties["mockNames"].Description).Equals(
+		"Overrides the names of the mocks, which default to Mock<Iface>. Optional, defaults to no overrides.",
+	)
+
+	ensure(schema.Properties["modules"].Items.Type).Equals("string")
+	ensure(schema.Properties["version"].Type).Equals("integer")
+	ensure(*schema.Properties["version"].Maximum).Equals(ensurefile.CurrentVersion)
+}
@@ -0,0 +1,174 @@
+package ensurefile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/erk"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidConfig = erk.New(ErkCannotLoadConfig{}, "The config file '{{.path}}' has {{.count}} problem(s)")
+
+// SchemaError is a problem with a value in the config file, found by validating it against the Schema.
+type SchemaError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field"` // Such as mocks.packages[0].path
+	Message string `json:"message"`
+}
+
+// ValidateConfigFile checks the config file against the Schema, returning every problem that is found.
+func (l *Loader) ValidateConfigFile(configPath string) ([]*SchemaError, error) {
+	configFileData, err := fs.ReadFile(l.FS, strings.TrimPrefix(configPath, "/"))
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+			"path": configPath,
+		})
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(configFileData, &document); err != nil {
+		return nil, erk.WrapWith(ErrCannotUnmarshalFile, err, erk.Params{
+			"path": configPath,
+		})
+	}
+
+	if len(document.Content) == 0 {
+		return nil, nil // Empty files are valid
+	}
+
+	if problems := validateNode(Schema(), document.Content[0], ""); len(problems) > 0 {
+		return problems, nil
+	}
+
+	return nil, nil
+}
+
+func validateNode(schema *JSONSchema, node *yaml.Node, field string) []*SchemaError {
+	if node.Kind == yaml.AliasNode {
+		return validateNode(schema, node.Alias, field)
+	}
+
+	problem := func(format string, args ...interface{}) []*SchemaError {
+		return []*SchemaError{{Line: node.Line, Field: fieldName(field), Message: fmt.Sprintf(format, args...)}}
+	}
+
+	switch schema.Type {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			return problem("must be an object")
+		}
+
+		return validateMapping(schema, node, field)
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			return problem("must be an array")
+		}
+
+		errs := []*SchemaError{}
+		for i, item := range node.Content {
+			errs = append(errs, validateNode(schema.Items, item, fmt.Sprintf("%s[%d]", field, i))...)
+		}
+
+		return errs
+	case "boolean":
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!bool" {
+			return problem("must be a boolean")
+		}
+	case "integer":
+		var value int
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!int" || node.Decode(&value) != nil {
+			return problem("must be an integer")
+		}
+
+		if schema.Minimum != nil && value < *schema.Minimum {
+			return problem("must be at least %d", *schema.Minimum)
+		}
+
+		if schema.Maximum != nil && value > *schema.Maximum {
+			return problem("must be at most %d", *schema.Maximum)
+		}
+	default:
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" {
+			return problem("must be a string")
+		}
+	}
+
+	if len(schema.Enum) > 0 && !containsString(schema.Enum, node.Value) {
+		return problem("must be one of: %s", strings.Join(schema.Enum, ", "))
+	}
+
+	return nil
+}
+
+func validateMapping(schema *JSONSchema, node *yaml.Node, field string) []*SchemaError {
+	errs := []*SchemaError{}
+	seen := map[string]bool{}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		seen[key.Value] = true
+
+		property, ok := schema.Properties[key.Value]
+		if !ok {
+			if additional, ok := schema.AdditionalProperties.(*JSONSchema); ok {
+				property = additional
+			}
+		}
+
+		if property == nil {
+			errs = append(errs, &SchemaError{
+				Line:    key.Line,
+				Field:   fieldName(joinField(field, key.Value)),
+				Message: "is not a known field",
+			})
+
+			continue
+		}
+
+		errs = append(errs, validateNode(property, value, joinField(field, key.Value))...)
+	}
+
+	required := append([]string{}, schema.Required...)
+	sort.Strings(required)
+
+	for _, name := range required {
+		if !seen[name] {
+			errs = append(errs, &SchemaError{
+				Line:    node.Line,
+				Field:   fieldName(joinField(field, name)),
+				Message: "is required",
+			})
+		}
+	}
+
+	return errs
+}
+
+func joinField(field, name string) string {
+	if field == "" {
+		return name
+	}
+
+	return field + "." + name
+}
+
+func fieldName(field string) string {
+	if field == "" {
+		return "(root)"
+	}
+
+	return field
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+package ensurefile_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	table := []struct {
+		Name       string
+		ConfigFile string
+		ReadError  error
+
+		ExpectedProblems []*ensurefile.SchemaError
+		ExpectedError    error
+
+		Mocks   *Mocks
+		Subject *ensurefile.Loader
+	}{
+		{
+			Name:       "with valid config file",
+			ConfigFile: ensurefile.ExampleFile,
+		},
+		{
+			Name:       "with valid JSON config file",
+			ConfigFile: `{"mocks": {"typed": true, "packages": [{"path": "github.com/my/app/pkg", "interfaces": ["Iface"]}]}}`,
+		},
+		{
+			Name:       "with empty config file",
+			ConfigFile: "",
+		},
+		{
+			Name: "with invalid config file",
+			ConfigFile: "mocks:\n" +
+				"  primaryDestinaton: mocks\n" +
+				"  tidyAfterGenerate: yes please\n" +
+				"  templates:\n" +
+				"    - path: helpers.tmpl\n" +
+				"      scope: file\n" +
+				"  packages:\n" +
+				"    - path: github.com/my/app/pkg\n" +
+				"      mockNames:\n" +
+				"        Iface: [FakeIface]\n" +
+				"    - interfaces: Iface\n" +
+				"modules: other\n" +
+				"version: 2\n",
+			ExpectedProblems: []*ensurefile.SchemaError{
+				{Line: 2, Field: "mocks.primaryDestinaton", Message: "is not a known field"},
+				{Line: 3, Field: "mocks.tidyAfterGenerate", Message: "must be a boolean"},
+				{Line: 6, Field: "mocks.templates[0].scope", Message: "must be one of: package, interface"},
+				{Line: 10, Field: "mocks.packages[0].mockNames.Iface", Message: "must be a string"},
+				{Line: 8, Field: "mocks.packages[0].interfaces", Message: "is required"},
+				{Line: 11, Field: "mocks.packages[1].interfaces", Message: "must be an array"},
+				{Line: 11, Field: "mocks.packages[1].path", Message: "is required"},
+				{Line: 12, Field: "modules", Message: "must be an array"},
+				{Line: 13, Field: "version", Message: "must be at most 1"},
+			},
+		},
+		{
+			Name:          "when unable to read config file",
+			ReadError:     exampleError,
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+		},
+		{
+			Name:          "when unable to parse config file",
+			ConfigFile:    "mocks: {",
+			ExpectedError: ensurefile.ErrCannotUnmarshalFile,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(entry.ConfigFile), entry.ReadError)
+
+		problems, err := entry.Subject.ValidateConfigFile("/my/app/.ensure.yml")
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(problems).Equals(entry.ExpectedProblems)
+	})
+}
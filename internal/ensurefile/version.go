@@ -0,0 +1,131 @@
+package ensurefile
+
+import (
+	"strconv"
+
+	"github.com/JosiahWitt/erk"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion of the config format, which is written by `ensure config migrate`.
+const CurrentVersion = 1
+
+var (
+	ErrInvalidVersion     = erk.New(ErkCannotLoadConfig{}, "Invalid version '{{.version}}' in '{{.path}}'. It must be a number from 1 to {{.current}}.")
+	ErrUnsupportedVersion = erk.New(ErkCannotLoadConfig{},
+		"The config file '{{.path}}' uses version {{.version}} of the config format, "+
+			"but this version of ensure only supports up to version {{.current}}. Please upgrade ensure to load it.",
+	)
+)
+
+// migrations upgrade the root mapping of a config file from the version at their index to the next version.
+// Version 0 is the format from before versions were introduced, which is version 1 without the version key.
+var migrations = []func(root *yaml.Node) error{ //nolint:gochecknoglobals // Constant
+	func(root *yaml.Node) error { return nil }, // Version 1 only adds the version key
+}
+
+// decodeConfigFile decodes the config file into out, after migrating it to the current version.
+// It returns the version the config file was written in.
+func decodeConfigFile(configFilePath string, configFileData []byte, out interface{}) (int, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(configFileData, &document); err != nil {
+		return 0, erk.WrapWith(ErrCannotUnmarshalFile, err, erk.Params{
+			"path": configFilePath,
+		})
+	}
+
+	if len(document.Content) == 0 {
+		return 0, nil
+	}
+
+	version, err := migrate(configFilePath, &document)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := document.Decode(out); err != nil {
+		return 0, erk.WrapWith(ErrCannotUnmarshalFile, err, erk.Params{
+			"path": configFilePath,
+		})
+	}
+
+	return version, nil
+}
+
+// migrate upgrades the document to the current version, returning the version it had.
+// Documents from newer versions of ensure are rejected, since they cannot be understood.
+func migrate(configFilePath string, document *yaml.Node) (int, error) {
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return 0, nil // Left for decoding to reject
+	}
+
+	version := 0
+	if versionNode := mappingValue(root, "version"); versionNode != nil {
+		if err := versionNode.Decode(&version); err != nil || version < 1 {
+			return 0, erk.WithParams(ErrInvalidVersion, erk.Params{
+				"path":    configFilePath,
+				"version": versionNode.Value,
+				"current": CurrentVersion,
+			})
+		}
+	}
+
+	if version > CurrentVersion {
+		return 0, erk.WithParams(ErrUnsupportedVersion, erk.Params{
+			"path":    configFilePath,
+			"version": version,
+			"current": CurrentVersion,
+		})
+	}
+
+	if version == CurrentVersion {
+		return version, nil
+	}
+
+	for _, migration := range migrations[version:] {
+		if err := migration(root); err != nil {
+			return 0, err
+		}
+	}
+
+	setVersion(root, CurrentVersion)
+	return version, nil
+}
+
+// Migrate upgrades the document to the current version of the config format, preserving its comments.
+// It returns the version the document had, which is CurrentVersion if it was already up to date.
+func (d *Document) Migrate() (int, error) {
+	if len(d.root.Content) == 0 {
+		d.root = newDocumentRoot()
+		return 0, nil
+	}
+
+	return migrate(d.Path, &d.root)
+}
+
+// setVersion sets the version key of the root mapping, adding it as the first key if it does not exist.
+func setVersion(root *yaml.Node, version int) {
+	value := versionNode(version)
+	if versionNode := mappingValue(root, "version"); versionNode != nil {
+		*versionNode = *value
+		return
+	}
+
+	root.Content = append([]*yaml.Node{scalarNode("version"), value}, root.Content...)
+}
+
+// newDocumentRoot returns an empty config file using the current version.
+func newDocumentRoot() yaml.Node {
+	return yaml.Node{
+		Kind: yaml.DocumentNode,
+		Content: []*yaml.Node{{
+			Kind:    yaml.MappingNode,
+			Content: []*yaml.Node{scalarNode("version"), versionNode(CurrentVersion)},
+		}},
+	}
+}
+
+func versionNode(version int) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+}
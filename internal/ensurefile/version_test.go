@@ -0,0 +1,143 @@
+package ensurefile_test
+
+import (
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestLoadConfigVersions(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name       string
+		ConfigFile string
+
+		ExpectedVersion int
+		ExpectedMocks   *ensurefile.MockConfig
+		ExpectedError   error
+
+		Mocks   *Mocks
+		Subject *ensurefile.Loader
+	}{
+		{
+			Name:            "without version",
+			ConfigFile:      "mocks:\n  primaryDestination: mocks\n",
+			ExpectedVersion: 0,
+			ExpectedMocks:   &ensurefile.MockConfig{PrimaryDestination: "mocks"},
+		},
+		{
+			Name:            "with current version",
+			ConfigFile:      "version: 1\nmocks:\n  primaryDestination: mocks\n",
+			ExpectedVersion: 1,
+			ExpectedMocks:   &ensurefile.MockConfig{PrimaryDestination: "mocks"},
+		},
+		{
+			Name:          "with future version",
+			ConfigFile:    "version: 2\nmocks:\n  primaryDestination: mocks\n  somethingNew: true\n",
+			ExpectedError: ensurefile.ErrUnsupportedVersion,
+		},
+		{
+			Name:          "with version that is not a number",
+			ConfigFile:    "version: latest\n",
+			ExpectedError: ensurefile.ErrInvalidVersion,
+		},
+		{
+			Name:          "with version below 1",
+			ConfigFile:    "version: 0\n",
+			ExpectedError: ensurefile.ErrInvalidVersion,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/go.mod").Return([]byte("module github.com/my/app"), nil)
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(entry.ConfigFile), nil)
+
+		config, err := entry.Subject.LoadConfig("/my/app")
+		ensure(err).IsError(entry.ExpectedError)
+
+		if entry.ExpectedError == nil {
+			ensure(config.Version).Equals(entry.ExpectedVersion)
+			ensure(config.Mocks).Equals(entry.ExpectedMocks)
+		}
+	})
+}
+
+func TestDocumentMigrate(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	table := []struct {
+		Name string
+		File string
+
+		ExpectedFromVersion int
+		ExpectedFile        string
+		ExpectedError       error
+
+		Mocks   *Mocks
+		Subject *ensurefile.Loader
+	}{
+		{
+			Name: "without version",
+			File: "mocks:\n" +
+				"  # Where the mocks go\n" +
+				"  primaryDestination: mocks\n",
+			ExpectedFromVersion: 0,
+			ExpectedFile: "version: 1\n" +
+				"mocks:\n" +
+				"  # Where the mocks go\n" +
+				"  primaryDestination: mocks\n",
+		},
+		{
+			Name:                "with current version",
+			File:                "version: 1\nmocks:\n  primaryDestination: mocks\n",
+			ExpectedFromVersion: 1,
+			ExpectedFile:        "version: 1\nmocks:\n  primaryDestination: mocks\n",
+		},
+		{
+			Name:                "when file is empty",
+			File:                "",
+			ExpectedFromVersion: 0,
+			ExpectedFile:        "version: 1\n",
+		},
+		{
+			Name:          "with future version",
+			File:          "version: 2\n",
+			ExpectedError: ensurefile.ErrUnsupportedVersion,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Mocks.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(entry.File), nil)
+
+		doc, err := entry.Subject.LoadDocument("/my/app/.ensure.yml")
+		ensure(err).IsNotError()
+
+		fromVersion, err := doc.Migrate()
+		ensure(err).IsError(entry.ExpectedError)
+		if entry.ExpectedError != nil {
+			return
+		}
+
+		ensure(fromVersion).Equals(entry.ExpectedFromVersion)
+
+		data, err := doc.Bytes()
+		ensure(err).IsNotError()
+		ensure(string(data)).Equals(entry.ExpectedFile)
+	})
+}
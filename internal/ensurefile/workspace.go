@@ -0,0 +1,257 @@
+package ensurefile
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/erk"
+)
+
+const workFileName = "go.work"
+
+var (
+	ErrCannotParseWorkFile = erk.New(ErkCannotLoadConfig{}, "Cannot parse the file '{{.path}}' on line {{.line}}: {{.err}}")
+	ErrNoModuleConfigs     = erk.New(ErkCannotLoadConfig{},
+		"None of the modules listed in '{{.path}}' have a .ensure.yml file or //ensure:mock annotations. For example:\n\n"+ExampleFile,
+	)
+)
+
+// LoadConfigs loads the config of every module in the workspace containing pwd.
+// A workspace is either a go.work file, or a .ensure.yml file that lists `modules`, in pwd or a parent of pwd
+// within the same repository.
+// Modules without a .ensure.yml file or annotations are skipped.
+// Outside of a workspace, only the config of the module containing pwd is loaded, like LoadConfig.
+func (l *Loader) LoadConfigs(pwd string) ([]*Config, error) {
+	workspacePath, moduleDirs, err := l.findWorkspace(strings.TrimPrefix(pwd, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if workspacePath == "" {
+		config, err := l.LoadConfig(pwd)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*Config{config}, nil
+	}
+
+	moduleLoader := *l
+	moduleLoader.configPath = "" // Modules use their own config files, even when the workspace config file is explicit
+
+	configs := []*Config{}
+	for _, moduleDir := range moduleDirs {
+		gomodFilePath := filepath.Join(moduleDir, gomodFileName)
+		gomodFileData, err := fs.ReadFile(l.FS, gomodFilePath)
+		if err != nil {
+			return nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+				"path": gomodFilePath,
+			})
+		}
+
+		config, err := moduleLoader.loadModuleDir(moduleDir, gomodFileData, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if config.Mocks == nil && len(config.Annotations) == 0 {
+			continue
+		}
+
+		configs = append(configs, config)
+	}
+
+	if len(configs) == 0 {
+		return nil, erk.WithParams(ErrNoModuleConfigs, erk.Params{
+			"path": "/" + workspacePath,
+		})
+	}
+
+	return configs, nil
+}
+
+// findWorkspace searches dir and its parents for a workspace, returning its path and the directories of its modules.
+// A .ensure.yml file listing modules takes precedence over a go.work file in the same directory.
+// The search stops at the root of the repository, so files in parents of the repository are not used.
+// Like the go tool, GOWORK=off disables go.work files, and GOWORK=<path> uses that go.work file instead of searching.
+// When a config file is explicitly used, it is only a workspace if it lists modules, which are relative to it.
+func (l *Loader) findWorkspace(dir string) (string, []string, error) {
+	gowork := l.lookupEnv("GOWORK")
+
+	for {
+		configFilePath, moduleDirs, err := l.configModules(dir)
+		if err != nil || len(moduleDirs) > 0 {
+			return configFilePath, joinModuleDirs(filepath.Dir(configFilePath), moduleDirs), err
+		}
+
+		if l.configPath != "" {
+			return "", nil, nil
+		}
+
+		if gowork == "" {
+			workFilePath, moduleDirs, err := l.readWorkFile(filepath.Join(dir, workFileName))
+			if err != nil || workFilePath != "" {
+				return workFilePath, moduleDirs, err
+			}
+		}
+
+		parentDir := filepath.Dir(dir)
+		if parentDir == dir || l.isRepositoryRoot(dir) {
+			break
+		}
+
+		dir = parentDir
+	}
+
+	if gowork == "" || gowork == "off" {
+		return "", nil, nil
+	}
+
+	workFilePath, moduleDirs, err := l.readWorkFile(strings.TrimPrefix(filepath.Clean(gowork), "/"))
+	if err == nil && workFilePath == "" {
+		return "", nil, erk.WrapWith(ErrCannotOpenFile, fs.ErrNotExist, erk.Params{
+			"path": gowork,
+		})
+	}
+
+	return workFilePath, moduleDirs, err
+}
+
+// readWorkFile returns the path of the go.work file and the directories of its modules, if it exists.
+func (l *Loader) readWorkFile(workFilePath string) (string, []string, error) {
+	workFileData, err := fs.ReadFile(l.FS, workFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, nil
+	}
+
+	if err != nil {
+		return "", nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+			"path": workFilePath,
+		})
+	}
+
+	moduleDirs, err := parseWorkFile(workFilePath, workFileData)
+	return workFilePath, joinModuleDirs(filepath.Dir(workFilePath), moduleDirs), err
+}
+
+// isRepositoryRoot is true if dir contains a .git directory or file.
+// Reading a directory fails with an error other than fs.ErrNotExist, so only missing entries are not roots.
+func (l *Loader) isRepositoryRoot(dir string) bool {
+	_, err := fs.ReadFile(l.FS, filepath.Join(dir, ".git"))
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// configModules returns the path of the config file in dir, and the modules it lists, if it exists.
+func (l *Loader) configModules(dir string) (string, []string, error) {
+	configFilePath, configFileData, err := l.readConfigFile(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return configFilePath, nil, nil
+	}
+
+	if err != nil {
+		return configFilePath, nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+			"path": configFilePath,
+		})
+	}
+
+	var config struct {
+		Modules []string `yaml:"modules"`
+	}
+
+	if _, err := decodeConfigFile(configFilePath, configFileData, &config); err != nil {
+		return configFilePath, nil, err
+	}
+
+	return configFilePath, config.Modules, nil
+}
+
+// parseWorkFile returns the directories in the use directives of the go.work file.
+// Other directives are skipped, since only the modules in the workspace are needed.
+func parseWorkFile(workFilePath string, data []byte) ([]string, error) {
+	moduleDirs := []string{}
+	block := ""
+
+	for idx, line := range strings.Split(string(data), "\n") {
+		if comment := strings.Index(line, "//"); comment >= 0 {
+			line = line[:comment]
+		}
+
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+
+		var useDir string
+		switch {
+		case block != "":
+			if words[0] == ")" {
+				block = ""
+				continue
+			}
+
+			if block == "use" {
+				useDir = words[0]
+			}
+		case len(words) == 2 && words[1] == "(":
+			block = words[0]
+			continue
+		case words[0] == "use" && len(words) == 2:
+			useDir = words[1]
+		}
+
+		if useDir == "" {
+			continue
+		}
+
+		if strings.HasPrefix(useDir, `"`) || strings.HasPrefix(useDir, "`") {
+			unquoted, err := strconv.Unquote(useDir)
+			if err != nil {
+				return nil, erk.WrapWith(ErrCannotParseWorkFile, err, erk.Params{
+					"path": "/" + workFilePath,
+					"line": idx + 1,
+				})
+			}
+
+			useDir = unquoted
+		}
+
+		moduleDirs = append(moduleDirs, useDir)
+	}
+
+	if block != "" {
+		return nil, erk.WrapWith(ErrCannotParseWorkFile, errors.New("unterminated block"), erk.Params{
+			"path": "/" + workFilePath,
+			"line": len(strings.Split(string(data), "\n")),
+		})
+	}
+
+	return moduleDirs, nil
+}
+
+// joinModuleDirs makes the module directories relative to the root of the file system, like the other paths read by Loader.
+func joinModuleDirs(dir string, moduleDirs []string) []string {
+	joined := make([]string, 0, len(moduleDirs))
+	for _, moduleDir := range moduleDirs {
+		if filepath.IsAbs(moduleDir) {
+			joined = append(joined, strings.TrimPrefix(filepath.Clean(moduleDir), "/"))
+			continue
+		}
+
+		joined = append(joined, filepath.Join(dir, moduleDir))
+	}
+
+	return joined
+}
+
+// lookupEnv returns the value of the environment variable, or an empty string if it is not set or LookupEnv is nil.
+func (l *Loader) lookupEnv(key string) string {
+	if l.LookupEnv == nil {
+		return ""
+	}
+
+	value, _ := l.LookupEnv(key)
+	return value
+}
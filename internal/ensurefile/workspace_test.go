@@ -0,0 +1,367 @@
+package ensurefile_test
+
+import (
+	"errors"
+	"testing"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestLoadConfigs(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const apiConfigFile = "mocks:\n  packages:\n    - path: github.com/my/api/store\n      interfaces: [Store]\n"
+	const workerConfigFile = "mocks:\n  packages:\n    - path: github.com/my/worker/queue\n      interfaces: [Queue]\n"
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	type mapFS map[string]interface{}
+	setupMapFS := func(mapFS mapFS) func(*Mocks) {
+		return func(m *Mocks) {
+			m.FS.EXPECT().ReadFile(gomock.Any()).AnyTimes().
+				DoAndReturn(func(name string) ([]byte, error) {
+					rawData, ok := mapFS[name]
+					if !ok {
+						return nil, fs.ErrNotExist
+					}
+
+					switch data := rawData.(type) {
+					case string:
+						return []byte(data), nil
+					case error:
+						return nil, data
+					default:
+						return nil, errors.New("unknown type")
+					}
+				})
+		}
+	}
+
+	apiConfig := &ensurefile.Config{
+		RootPath:   "/repo/api",
+		ModulePath: "github.com/my/api",
+		ConfigPath: "/repo/api/.ensure.yml",
+		Mocks: &ensurefile.MockConfig{
+			Packages: []*ensurefile.Package{
+				{Path: "github.com/my/api/store", Interfaces: []string{"Store"}, Line: 3, File: "/repo/api/.ensure.yml"},
+			},
+		},
+	}
+
+	apiConfigIn := func(rootPath string) *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   rootPath,
+			ModulePath: "github.com/my/api",
+			ConfigPath: rootPath + "/.ensure.yml",
+			Mocks: &ensurefile.MockConfig{
+				Packages: []*ensurefile.Package{
+					{Path: "github.com/my/api/store", Interfaces: []string{"Store"}, Line: 3, File: rootPath + "/.ensure.yml"},
+				},
+			},
+		}
+	}
+
+	workerConfig := &ensurefile.Config{
+		RootPath:   "/repo/services/worker",
+		ModulePath: "github.com/my/worker",
+		ConfigPath: "/repo/services/worker/.ensure.yml",
+		Mocks: &ensurefile.MockConfig{
+			Packages: []*ensurefile.Package{
+				{Path: "github.com/my/worker/queue", Interfaces: []string{"Queue"}, Line: 3, File: "/repo/services/worker/.ensure.yml"},
+			},
+		},
+	}
+
+	table := []struct {
+		Name       string
+		PWD        string
+		ConfigFile string
+		Env        map[string]string
+
+		ExpectedConfigs []*ensurefile.Config
+		ExpectedError   error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *ensurefile.Loader
+	}{
+		{
+			Name:            "with go.work file",
+			PWD:             "/repo/api/store",
+			ExpectedConfigs: []*ensurefile.Config{apiConfig, workerConfig},
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/go.work": "go 1.18\n\n" +
+					"// The modules in the workspace\n" +
+					"use (\n\t./api\n\t\"./services/worker\" // Quoted\n\t./tools\n)\n\n" +
+					"replace (\n\texample.com/dep => ./dep\n)\n",
+				"repo/api/go.mod":                  defaultGoModFile("github.com/my/api"),
+				"repo/api/.ensure.yml":             apiConfigFile,
+				"repo/services/worker/go.mod":      defaultGoModFile("github.com/my/worker"),
+				"repo/services/worker/.ensure.yml": workerConfigFile,
+				"repo/tools/go.mod":                defaultGoModFile("github.com/my/tools"),
+			}),
+		},
+
+		{
+			Name:            "with go.work file using single use directives",
+			PWD:             "/repo",
+			ExpectedConfigs: []*ensurefile.Config{workerConfig},
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/go.work":                     "go 1.18\n\nuse ./services/worker\n",
+				"repo/services/worker/go.mod":      defaultGoModFile("github.com/my/worker"),
+				"repo/services/worker/.ensure.yml": workerConfigFile,
+			}),
+		},
+
+		{
+			Name: "with .ensure.yml file listing modules",
+			PWD:  "/repo/services",
+			ExpectedConfigs: []*ensurefile.Config{
+				workerConfig,
+				apiConfig,
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/.ensure.yml":                 "modules: [services/worker, api]\n",
+				"repo/go.work":                     "go 1.18\n\nuse ./api\n",
+				"repo/api/go.mod":                  defaultGoModFile("github.com/my/api"),
+				"repo/api/.ensure.yml":             apiConfigFile,
+				"repo/services/worker/go.mod":      defaultGoModFile("github.com/my/worker"),
+				"repo/services/worker/.ensure.yml": workerConfigFile,
+			}),
+		},
+
+		{
+			Name:            "without a workspace",
+			PWD:             "/repo/api/store",
+			ExpectedConfigs: []*ensurefile.Config{apiConfig},
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/api/go.mod":      defaultGoModFile("github.com/my/api"),
+				"repo/api/.ensure.yml": apiConfigFile,
+			}),
+		},
+
+		{
+			Name:            "with .ensure.json file listing modules",
+			PWD:             "/repo",
+			ExpectedConfigs: []*ensurefile.Config{apiConfig},
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/.ensure.json":    `{"modules": ["api"]}`,
+				"repo/api/go.mod":      defaultGoModFile("github.com/my/api"),
+				"repo/api/.ensure.yml": apiConfigFile,
+			}),
+		},
+
+		{
+			Name:            "with explicit config file listing modules",
+			PWD:             "/repo",
+			ConfigFile:      "/build/workspace.yml",
+			ExpectedConfigs: []*ensurefile.Config{workerConfig},
+
+			SetupMocks: setupMapFS(mapFS{
+				"build/workspace.yml":              "modules: [../repo/services/worker]\n",
+				"repo/go.work":                     "go 1.18\n\nuse ./api\n",
+				"repo/services/worker/go.mod":      defaultGoModFile("github.com/my/worker"),
+				"repo/services/worker/.ensure.yml": workerConfigFile,
+			}),
+		},
+
+		{
+			Name:       "with explicit config file within go.work",
+			PWD:        "/repo/api",
+			ConfigFile: "/build/api.yml",
+			ExpectedConfigs: []*ensurefile.Config{
+				{
+					RootPath:   "/repo/api",
+					ModulePath: "github.com/my/api",
+					ConfigPath: "/build/api.yml",
+					Mocks: &ensurefile.MockConfig{
+						Packages: []*ensurefile.Package{
+							{Path: "github.com/my/api/store", Interfaces: []string{"Store"}, Line: 3, File: "/build/api.yml"},
+						},
+					},
+				},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"build/api.yml":                    apiConfigFile,
+				"repo/go.work":                     "go 1.18\n\nuse (\n\t./api\n\t./services/worker\n)\n",
+				"repo/api/go.mod":                  defaultGoModFile("github.com/my/api"),
+				"repo/services/worker/go.mod":      defaultGoModFile("github.com/my/worker"),
+				"repo/services/worker/.ensure.yml": workerConfigFile,
+			}),
+		},
+
+		{
+			Name:            "with go.work file outside the repository",
+			PWD:             "/home/user/repo/api",
+			ExpectedConfigs: []*ensurefile.Config{apiConfigIn("/home/user/repo/api")},
+
+			SetupMocks: setupMapFS(mapFS{
+				"home/user/go.work":              "go 1.18\n\nuse ./other\n",
+				"home/user/repo/.git":            errors.New("is a directory"),
+				"home/user/repo/api/go.mod":      defaultGoModFile("github.com/my/api"),
+				"home/user/repo/api/.ensure.yml": apiConfigFile,
+			}),
+		},
+
+		{
+			Name:            "with .ensure.yml file listing modules outside the repository",
+			PWD:             "/home/user/repo/api",
+			ExpectedConfigs: []*ensurefile.Config{apiConfigIn("/home/user/repo/api")},
+
+			SetupMocks: setupMapFS(mapFS{
+				"home/user/.ensure.yml":          "modules: [other]\n",
+				"home/user/repo/.git":            "gitdir: /home/user/main/.git/worktrees/repo\n",
+				"home/user/repo/api/go.mod":      defaultGoModFile("github.com/my/api"),
+				"home/user/repo/api/.ensure.yml": apiConfigFile,
+			}),
+		},
+
+		{
+			Name:            "with GOWORK=off",
+			PWD:             "/repo/api/store",
+			Env:             map[string]string{"GOWORK": "off"},
+			ExpectedConfigs: []*ensurefile.Config{apiConfig},
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/go.work":         "go 1.18\n\nuse (\n\t./api\n\t./services/worker\n)\n",
+				"repo/api/go.mod":      defaultGoModFile("github.com/my/api"),
+				"repo/api/.ensure.yml": apiConfigFile,
+			}),
+		},
+
+		{
+			Name:            "with GOWORK path",
+			PWD:             "/repo/api/store",
+			Env:             map[string]string{"GOWORK": "/repo/build/go.work"},
+			ExpectedConfigs: []*ensurefile.Config{workerConfig},
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/go.work":                     "go 1.18\n\nuse ./api\n",
+				"repo/build/go.work":               "go 1.18\n\nuse ../services/worker\n",
+				"repo/api/go.mod":                  defaultGoModFile("github.com/my/api"),
+				"repo/api/.ensure.yml":             apiConfigFile,
+				"repo/services/worker/go.mod":      defaultGoModFile("github.com/my/worker"),
+				"repo/services/worker/.ensure.yml": workerConfigFile,
+			}),
+		},
+
+		{
+			Name:          "when GOWORK path does not exist",
+			PWD:           "/repo/api/store",
+			Env:           map[string]string{"GOWORK": "/repo/missing/go.work"},
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/api/go.mod":      defaultGoModFile("github.com/my/api"),
+				"repo/api/.ensure.yml": apiConfigFile,
+			}),
+		},
+
+		{
+			Name:          "without a workspace when config is missing",
+			PWD:           "/repo/api",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/api/go.mod": defaultGoModFile("github.com/my/api"),
+			}),
+		},
+
+		{
+			Name:          "when no modules have configs",
+			PWD:           "/repo",
+			ExpectedError: ensurefile.ErrNoModuleConfigs,
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/go.work":      "use ./tools\n",
+				"repo/tools/go.mod": defaultGoModFile("github.com/my/tools"),
+			}),
+		},
+
+		{
+			Name:          "when module is missing go.mod",
+			PWD:           "/repo",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/go.work": "use ./missing\n",
+			}),
+		},
+
+		{
+			Name:          "when go.work has an invalid quoted path",
+			PWD:           "/repo",
+			ExpectedError: ensurefile.ErrCannotParseWorkFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/go.work": "use \"./api\n",
+			}),
+		},
+
+		{
+			Name:          "when go.work has an unterminated block",
+			PWD:           "/repo",
+			ExpectedError: ensurefile.ErrCannotParseWorkFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/go.work": "use (\n\t./api\n",
+			}),
+		},
+
+		{
+			Name:          "when cannot read go.work",
+			PWD:           "/repo",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/go.work": exampleError,
+			}),
+		},
+
+		{
+			Name:          "when cannot parse .ensure.yml",
+			PWD:           "/repo",
+			ExpectedError: ensurefile.ErrCannotUnmarshalFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"repo/.ensure.yml": "modules: {",
+			}),
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		if entry.ConfigFile != "" {
+			entry.Subject.UseConfigFile(entry.ConfigFile)
+		}
+
+		entry.Subject.LookupEnv = func(key string) (string, bool) {
+			value, ok := entry.Env[key]
+			return value, ok
+		}
+
+		configs, err := entry.Subject.LoadConfigs(entry.PWD)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(configs).Equals(entry.ExpectedConfigs)
+	})
+}
+
+func defaultGoModFile(modulePath string) string {
+	return "module " + modulePath + "\n\ngo 1.18\n"
+}
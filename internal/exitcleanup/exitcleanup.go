@@ -9,6 +9,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"syscall"
+
+	"github.com/JosiahWitt/ensure-cli/internal/exitcode"
 )
 
 type ExitCleaner interface {
@@ -92,5 +94,5 @@ func (cleanup *ExitCleanup) cleanup() {
 		}
 	}
 
-	cleanup.osExit(1)
+	cleanup.osExit(exitcode.Interrupted)
 }
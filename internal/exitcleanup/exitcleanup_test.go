@@ -57,7 +57,7 @@ func TestExitCleanup(t *testing.T) {
 		ensure(ctx1.Err()).IsError(context.Canceled)
 		ensure(ctx2.Err()).IsError(context.Canceled)
 		ensure(atomic.AddInt64(&count, 0)).Equals(int64(3))
-		ensure(atomic.AddInt64(&exitCode, 0)).Equals(int64(1))
+		ensure(atomic.AddInt64(&exitCode, 0)).Equals(int64(130))
 	})
 
 	ensure.Run("with no termination", func(ensure ensurepkg.Ensure) {
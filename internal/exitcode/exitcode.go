@@ -0,0 +1,25 @@
+// Package exitcode defines the exit codes of the CLI, so wrapping scripts can tell failures apart.
+// The codes are documented by Table, which is shown in the CLI help.
+package exitcode
+
+const (
+	Success     = 0
+	Unknown     = 1
+	Usage       = 2
+	Config      = 3
+	MockGen     = 4
+	FileSystem  = 5
+	OutOfDate   = 6
+	Interrupted = 130
+)
+
+// Table documents the exit codes, and is shown in the CLI help.
+const Table = `Exit codes:
+  0    Success
+  1    Unknown error
+  2    Invalid command line usage, such as an unknown flag or git ref
+  3    Config error, such as a missing or invalid .ensure.yml or go.mod file, or failing doctor checks
+  4    Mock generation error, such as mockgen failing or a template failing to render
+  5    Filesystem error, such as being unable to write or tidy the mocks, or install a git hook
+  6    Mocks are out of date, found by 'ensure mocks check'
+  130  Interrupted by a signal`
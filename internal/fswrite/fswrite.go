@@ -12,7 +12,9 @@ type FSWriteIface interface {
 	MkdirAll(path string, perm os.FileMode) error
 	GlobRemoveAll(pattern string) error
 	ListRecursive(dir string) ([]string, error)
+	ReadFile(filename string) (string, error)
 	RemoveAll(paths string) error
+	Chmod(name string, perm os.FileMode) error
 }
 
 type FSWrite struct{}
@@ -59,7 +61,18 @@ func (*FSWrite) ListRecursive(dir string) ([]string, error) {
 	return paths, nil
 }
 
+// ReadFile wraps ioutil.ReadFile.
+func (*FSWrite) ReadFile(filename string) (string, error) {
+	data, err := ioutil.ReadFile(filename)
+	return string(data), err
+}
+
 // RemoveAll deletes the path and any sub paths.
 func (*FSWrite) RemoveAll(path string) error {
 	return os.RemoveAll(path)
 }
+
+// Chmod wraps os.Chmod.
+func (*FSWrite) Chmod(name string, perm os.FileMode) error {
+	return os.Chmod(name, perm)
+}
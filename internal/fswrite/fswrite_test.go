@@ -119,6 +119,21 @@ func TestListRecursive(t *testing.T) {
 	})
 }
 
+func TestReadFile(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const contents = "testing"
+	fileName := filepath.Join(t.TempDir(), "file.txt")
+
+	err := ioutil.WriteFile(fileName, []byte(contents), 0600)
+	ensure(err).IsNotError()
+
+	fsWrite := fswrite.FSWrite{}
+	actualContents, err := fsWrite.ReadFile(fileName)
+	ensure(err).IsNotError()
+	ensure(actualContents).Equals(contents)
+}
+
 func TestRemoveAll(t *testing.T) {
 	ensure := ensure.New(t)
 	dirName := t.TempDir()
@@ -136,3 +151,19 @@ func TestRemoveAll(t *testing.T) {
 	_, err = os.Stat(dirName + "/abc")
 	ensure(err).IsError(os.ErrNotExist)
 }
+
+func TestChmod(t *testing.T) {
+	ensure := ensure.New(t)
+
+	fileName := filepath.Join(t.TempDir(), "file.sh")
+	err := ioutil.WriteFile(fileName, []byte("#!/bin/sh\n"), 0600)
+	ensure(err).IsNotError()
+
+	fsWrite := fswrite.FSWrite{}
+	err = fsWrite.Chmod(fileName, 0755)
+	ensure(err).IsNotError()
+
+	info, err := os.Stat(fileName)
+	ensure(err).IsNotError()
+	ensure(info.Mode().Perm()).Equals(os.FileMode(0755))
+}
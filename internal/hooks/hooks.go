@@ -0,0 +1,371 @@
+// Package hooks installs git pre-commit hooks that check the mocks affected by the staged files.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/erk"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	hookFilePerm = 0755
+	yamlFilePerm = 0664
+
+	// hookMarker identifies git hooks installed by ensure, so they can be replaced when reinstalling.
+	hookMarker = "# Installed by `ensure hooks install`."
+
+	// PreCommitHookID is the id of the hook in .pre-commit-config.yaml.
+	PreCommitHookID = "ensure-mocks"
+
+	preCommitConfigFileName = ".pre-commit-config.yaml"
+
+	checkArgs      = "mocks check --staged"
+	generateArgs   = "mocks generate --staged"
+	shellSafeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./"
+)
+
+type ErkHooksError struct{ erk.DefaultKind }
+
+var (
+	ErrCannotFindRepo = erk.New(ErkHooksError{}, "Could not find the git repository containing '{{.path}}': {{.err}}")
+	ErrHookExists     = erk.New(ErkHooksError{},
+		"A pre-commit hook that was not installed by ensure already exists at '{{.path}}'. "+
+			"Use --force to replace it, or add '{{.command}}' to it.",
+	)
+	ErrCannotParsePreCommitConfig = erk.New(ErkHooksError{}, "Could not parse '{{.path}}': {{.err}}")
+	ErrCannotReadFile             = erk.New(mockgen.ErkFSReadError{}, "Could not read '{{.path}}': {{.err}}")
+	ErrCannotWriteFile            = erk.New(mockgen.ErkFSWriteError{}, "Could not write '{{.path}}': {{.err}}")
+)
+
+var (
+	errNotMapping   = errors.New("expected a mapping at the root")
+	errReposNotList = errors.New("expected repos to be a list")
+)
+
+// Installation is a hook that was installed.
+type Installation struct {
+	Path    string `json:"path"`
+	Command string `json:"command"`
+	Changed bool   `json:"changed"` // False if the hook was already installed
+}
+
+type InstallerIface interface {
+	InstallGitHook(ctx context.Context, pwd string, force bool) (*Installation, error)
+	InstallPreCommitConfig(ctx context.Context, pwd string) (*Installation, error)
+}
+
+// Installer writes hooks that run `ensure mocks check --staged` before each commit.
+type Installer struct {
+	CmdRun           runcmd.RunnerIface
+	FSWrite          fswrite.FSWriteIface
+	EnsureFileLoader ensurefile.LoaderIface
+}
+
+var _ InstallerIface = &Installer{}
+
+// InstallGitHook writes the pre-commit hook of the git repository containing pwd, respecting core.hooksPath.
+// An existing hook is only replaced if it was installed by ensure, unless force is set.
+func (i *Installer) InstallGitHook(ctx context.Context, pwd string, force bool) (*Installation, error) {
+	ensureCommand, err := i.ensureCommand(ctx, pwd)
+	if err != nil {
+		return nil, err
+	}
+	command := ensureCommand + " " + checkArgs
+
+	hookPath, err := i.git(ctx, pwd, "rev-parse", "--git-path", "hooks/pre-commit")
+	if err != nil {
+		return nil, err
+	}
+
+	if !filepath.IsAbs(hookPath) {
+		hookPath = filepath.Join(pwd, hookPath)
+	}
+
+	script := gitHookScript(ensureCommand)
+	existing, err := i.readFile(hookPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == script {
+		return &Installation{Path: hookPath, Command: command, Changed: false}, nil
+	}
+
+	if existing != "" && !strings.Contains(existing, hookMarker) && !force {
+		return nil, erk.WithParams(ErrHookExists, erk.Params{
+			"path":    hookPath,
+			"command": command,
+		})
+	}
+
+	if err := i.FSWrite.MkdirAll(filepath.Dir(hookPath), hookFilePerm); err != nil {
+		return nil, erk.WrapWith(ErrCannotWriteFile, err, erk.Params{
+			"path": filepath.Dir(hookPath),
+		})
+	}
+
+	if err := i.FSWrite.WriteFile(hookPath, script, hookFilePerm); err != nil {
+		return nil, erk.WrapWith(ErrCannotWriteFile, err, erk.Params{
+			"path": hookPath,
+		})
+	}
+
+	// Writing an existing file keeps its mode, and git ignores hooks that are not executable
+	if err := i.FSWrite.Chmod(hookPath, hookFilePerm); err != nil {
+		return nil, erk.WrapWith(ErrCannotWriteFile, err, erk.Params{
+			"path": hookPath,
+		})
+	}
+
+	return &Installation{Path: hookPath, Command: command, Changed: true}, nil
+}
+
+// InstallPreCommitConfig adds a local hook to the .pre-commit-config.yaml file at the root of the git repository,
+// for use with the pre-commit framework (https://pre-commit.com). The file is created if it does not exist.
+func (i *Installer) InstallPreCommitConfig(ctx context.Context, pwd string) (*Installation, error) {
+	ensureCommand, err := i.ensureCommand(ctx, pwd)
+	if err != nil {
+		return nil, err
+	}
+	command := ensureCommand + " " + checkArgs
+
+	topLevel, err := i.git(ctx, pwd, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+
+	// Templates are appended to every mock, so changing them must also run the hook
+	configs, err := i.EnsureFileLoader.LoadConfigs(pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	configPath := filepath.Join(topLevel, preCommitConfigFileName)
+	existing, err := i.readFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, changed, err := addPreCommitHook(existing, command, preCommitFilesPattern(topLevel, configs))
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotParsePreCommitConfig, err, erk.Params{
+			"path": configPath,
+		})
+	}
+
+	if changed {
+		if err := i.FSWrite.WriteFile(configPath, updated, yamlFilePerm); err != nil {
+			return nil, erk.WrapWith(ErrCannotWriteFile, err, erk.Params{
+				"path": configPath,
+			})
+		}
+	}
+
+	return &Installation{Path: configPath, Command: command, Changed: changed}, nil
+}
+
+// ensureCommand returns the command used by the hook to run ensure.
+// Hooks run from the root of the repository, so it changes to pwd when it is a subdirectory.
+func (i *Installer) ensureCommand(ctx context.Context, pwd string) (string, error) {
+	prefix, err := i.git(ctx, pwd, "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", err
+	}
+
+	if prefix = strings.TrimSuffix(prefix, "/"); prefix == "" {
+		return "ensure", nil
+	}
+
+	return "ensure --chdir " + shellQuote(prefix), nil
+}
+
+func (i *Installer) git(ctx context.Context, pwd string, args ...string) (string, error) {
+	out, err := i.CmdRun.Exec(ctx, &runcmd.ExecParams{PWD: pwd, CMD: "git", Args: args})
+	if err != nil {
+		return "", erk.WrapWith(ErrCannotFindRepo, err, erk.Params{
+			"path": pwd,
+		})
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+// readFile returns the contents of the file, or an empty string if it does not exist.
+func (i *Installer) readFile(path string) (string, error) {
+	contents, err := i.FSWrite.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", erk.WrapWith(ErrCannotReadFile, err, erk.Params{
+			"path": path,
+		})
+	}
+
+	return contents, nil
+}
+
+func gitHookScript(ensureCommand string) string {
+	return "#!/bin/sh\n" +
+		hookMarker + "\n" +
+		"# Checks that the mocks affected by the staged files are up to date.\n" +
+		"if ! " + ensureCommand + " " + checkArgs + "; then\n" +
+		"\techo \"Regenerate the mocks using '" + ensureCommand + " " + generateArgs + "',\" >&2\n" +
+		"\techo \"and stage the changes. Use 'git commit --no-verify' to skip this check.\" >&2\n" +
+		"\texit 1\n" +
+		"fi\n"
+}
+
+// shellQuote quotes the argument for sh, unless it only contains safe characters.
+func shellQuote(arg string) string {
+	for _, r := range arg {
+		if !strings.ContainsRune(shellSafeChars, r) {
+			return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+
+	return arg
+}
+
+// addPreCommitHook adds the hook to the repos of the pre-commit config, preserving its comments.
+// It returns false if a hook with the same id already exists.
+func addPreCommitHook(config, command, files string) (string, bool, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(config), &document); err != nil {
+		return "", false, err
+	}
+
+	if len(document.Content) == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return "", false, errNotMapping
+	}
+
+	repos := mappingValue(root, "repos")
+	if repos == nil {
+		repos = &yaml.Node{Kind: yaml.SequenceNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "repos"}, repos)
+	}
+
+	if repos.Kind != yaml.SequenceNode {
+		return "", false, errReposNotList
+	}
+
+	for _, repo := range repos.Content {
+		hooks := mappingValue(repo, "hooks")
+		if hooks == nil {
+			continue
+		}
+
+		for _, hook := range hooks.Content {
+			if id := mappingValue(hook, "id"); id != nil && id.Value == PreCommitHookID {
+				return config, false, nil
+			}
+		}
+	}
+
+	var repo yaml.Node
+	if err := repo.Encode(&preCommitRepo{
+		Repo: "local",
+		Hooks: []*preCommitHook{{
+			ID:            PreCommitHookID,
+			Name:          "ensure mocks check",
+			Entry:         command,
+			Language:      "system",
+			PassFilenames: false,
+			Files:         files,
+		}},
+	}); err != nil {
+		return "", false, err
+	}
+	repos.Content = append(repos.Content, &repo)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return "", false, err
+	}
+
+	return buf.String(), true, nil
+}
+
+// preCommitFilesPattern returns the pattern of the files that can affect the mocks, which are the Go files,
+// module files, config files, and the templates of the configs within the repository.
+func preCommitFilesPattern(topLevel string, configs []*ensurefile.Config) string {
+	pattern := `(\.go|go\.mod|go\.sum|go\.work|\.ensure\.(yml|yaml|json))$`
+
+	templatePatterns := []string{}
+	for _, config := range configs {
+		for _, templatePath := range config.TemplatePaths() {
+			relativePath, err := filepath.Rel(topLevel, templatePath)
+			if err != nil || strings.HasPrefix(relativePath, "..") {
+				continue // pre-commit only passes files within the repository
+			}
+
+			templatePattern := regexp.QuoteMeta(filepath.ToSlash(relativePath))
+			if !containsString(templatePatterns, templatePattern) {
+				templatePatterns = append(templatePatterns, templatePattern)
+			}
+		}
+	}
+
+	if len(templatePatterns) == 0 {
+		return pattern
+	}
+
+	return "^(" + strings.Join(templatePatterns, "|") + ")$|" + pattern
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+type preCommitRepo struct {
+	Repo  string           `yaml:"repo"`
+	Hooks []*preCommitHook `yaml:"hooks"`
+}
+
+type preCommitHook struct {
+	ID            string `yaml:"id"`
+	Name          string `yaml:"name"`
+	Entry         string `yaml:"entry"`
+	Language      string `yaml:"language"`
+	PassFilenames bool   `yaml:"pass_filenames"`
+	Files         string `yaml:"files"`
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,355 @@
+package hooks_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/hooks"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+const rootHookScript = `#!/bin/sh
+# Installed by ` + "`ensure hooks install`" + `.
+# Checks that the mocks affected by the staged files are up to date.
+if ! ensure mocks check --staged; then
+	echo "Regenerate the mocks using 'ensure mocks generate --staged'," >&2
+	echo "and stage the changes. Use 'git commit --no-verify' to skip this check." >&2
+	exit 1
+fi
+`
+
+func gitParams(pwd string, args ...string) *runcmd.ExecParams {
+	return &runcmd.ExecParams{PWD: pwd, CMD: "git", Args: args}
+}
+
+func TestInstallGitHook(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		CmdRun  *mock_runcmd.MockRunnerIface
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	hookPath := "/repo/.git/hooks/pre-commit"
+
+	expectGit := func(m *Mocks, pwd, prefix string) {
+		m.CmdRun.EXPECT().Exec(context.Background(), gitParams(pwd, "rev-parse", "--show-prefix")).Return(prefix+"\n", nil)
+		m.CmdRun.EXPECT().
+			Exec(context.Background(), gitParams(pwd, "rev-parse", "--git-path", "hooks/pre-commit")).
+			Return(".git/hooks/pre-commit\n", nil)
+	}
+
+	table := []struct {
+		Name                 string
+		PWD                  string
+		Force                bool
+		ExpectedInstallation *hooks.Installation
+		ExpectedError        error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *hooks.Installer
+	}{
+		{
+			Name: "without existing hook",
+			PWD:  "/repo",
+			ExpectedInstallation: &hooks.Installation{
+				Path:    hookPath,
+				Command: "ensure mocks check --staged",
+				Changed: true,
+			},
+			SetupMocks: func(m *Mocks) {
+				expectGit(m, "/repo", "")
+				m.FSWrite.EXPECT().ReadFile(hookPath).Return("", os.ErrNotExist)
+				m.FSWrite.EXPECT().MkdirAll("/repo/.git/hooks", os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().WriteFile(hookPath, rootHookScript, os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().Chmod(hookPath, os.FileMode(0755)).Return(nil)
+			},
+		},
+		{
+			Name: "within a subdirectory of the repository",
+			PWD:  "/repo/my dir",
+			ExpectedInstallation: &hooks.Installation{
+				Path:    hookPath,
+				Command: "ensure --chdir 'my dir' mocks check --staged",
+				Changed: true,
+			},
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), gitParams("/repo/my dir", "rev-parse", "--show-prefix")).Return("my dir/\n", nil)
+				m.CmdRun.EXPECT().
+					Exec(context.Background(), gitParams("/repo/my dir", "rev-parse", "--git-path", "hooks/pre-commit")).
+					Return(hookPath+"\n", nil)
+
+				m.FSWrite.EXPECT().ReadFile(hookPath).Return("", os.ErrNotExist)
+				m.FSWrite.EXPECT().MkdirAll("/repo/.git/hooks", os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().WriteFile(hookPath, `#!/bin/sh
+# Installed by `+"`ensure hooks install`"+`.
+# Checks that the mocks affected by the staged files are up to date.
+if ! ensure --chdir 'my dir' mocks check --staged; then
+	echo "Regenerate the mocks using 'ensure --chdir 'my dir' mocks generate --staged'," >&2
+	echo "and stage the changes. Use 'git commit --no-verify' to skip this check." >&2
+	exit 1
+fi
+`, os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().Chmod(hookPath, os.FileMode(0755)).Return(nil)
+			},
+		},
+		{
+			Name: "when already installed",
+			PWD:  "/repo",
+			ExpectedInstallation: &hooks.Installation{
+				Path:    hookPath,
+				Command: "ensure mocks check --staged",
+				Changed: false,
+			},
+			SetupMocks: func(m *Mocks) {
+				expectGit(m, "/repo", "")
+				m.FSWrite.EXPECT().ReadFile(hookPath).Return(rootHookScript, nil)
+			},
+		},
+		{
+			Name: "when replacing a hook installed by ensure",
+			PWD:  "/repo",
+			ExpectedInstallation: &hooks.Installation{
+				Path:    hookPath,
+				Command: "ensure mocks check --staged",
+				Changed: true,
+			},
+			SetupMocks: func(m *Mocks) {
+				expectGit(m, "/repo", "")
+				m.FSWrite.EXPECT().ReadFile(hookPath).Return("#!/bin/sh\n# Installed by `ensure hooks install`.\nensure mocks check\n", nil)
+				m.FSWrite.EXPECT().MkdirAll("/repo/.git/hooks", os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().WriteFile(hookPath, rootHookScript, os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().Chmod(hookPath, os.FileMode(0755)).Return(nil)
+			},
+		},
+		{
+			Name:  "when replacing another hook using force",
+			PWD:   "/repo",
+			Force: true,
+			ExpectedInstallation: &hooks.Installation{
+				Path:    hookPath,
+				Command: "ensure mocks check --staged",
+				Changed: true,
+			},
+			SetupMocks: func(m *Mocks) {
+				expectGit(m, "/repo", "")
+				m.FSWrite.EXPECT().ReadFile(hookPath).Return("#!/bin/sh\nmake lint\n", nil)
+				m.FSWrite.EXPECT().MkdirAll("/repo/.git/hooks", os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().WriteFile(hookPath, rootHookScript, os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().Chmod(hookPath, os.FileMode(0755)).Return(nil)
+			},
+		},
+		{
+			Name:          "when another hook exists",
+			PWD:           "/repo",
+			ExpectedError: hooks.ErrHookExists,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m, "/repo", "")
+				m.FSWrite.EXPECT().ReadFile(hookPath).Return("#!/bin/sh\nmake lint\n", nil)
+			},
+		},
+		{
+			Name:          "when not in a git repository",
+			PWD:           "/repo",
+			ExpectedError: hooks.ErrCannotFindRepo,
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(context.Background(), gitParams("/repo", "rev-parse", "--show-prefix")).Return("", exampleError)
+			},
+		},
+		{
+			Name:          "when unable to read the existing hook",
+			PWD:           "/repo",
+			ExpectedError: hooks.ErrCannotReadFile,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m, "/repo", "")
+				m.FSWrite.EXPECT().ReadFile(hookPath).Return("", exampleError)
+			},
+		},
+		{
+			Name:          "when unable to write the hook",
+			PWD:           "/repo",
+			ExpectedError: hooks.ErrCannotWriteFile,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m, "/repo", "")
+				m.FSWrite.EXPECT().ReadFile(hookPath).Return("", os.ErrNotExist)
+				m.FSWrite.EXPECT().MkdirAll("/repo/.git/hooks", os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().WriteFile(hookPath, rootHookScript, os.FileMode(0755)).Return(exampleError)
+			},
+		},
+		{
+			Name:          "when unable to make the hook executable",
+			PWD:           "/repo",
+			Force:         true,
+			ExpectedError: hooks.ErrCannotWriteFile,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m, "/repo", "")
+				m.FSWrite.EXPECT().ReadFile(hookPath).Return("#!/bin/sh\nmake lint\n", nil)
+				m.FSWrite.EXPECT().MkdirAll("/repo/.git/hooks", os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().WriteFile(hookPath, rootHookScript, os.FileMode(0755)).Return(nil)
+				m.FSWrite.EXPECT().Chmod(hookPath, os.FileMode(0755)).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		installation, err := entry.Subject.InstallGitHook(context.Background(), entry.PWD, entry.Force)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(installation).Equals(entry.ExpectedInstallation)
+	})
+}
+
+func TestInstallPreCommitConfig(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		CmdRun           *mock_runcmd.MockRunnerIface
+		FSWrite          *mock_fswrite.MockFSWriteIface
+		EnsureFileLoader *mock_ensurefile.MockLoaderIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	configPath := "/repo/.pre-commit-config.yaml"
+
+	expectGit := func(m *Mocks) {
+		m.CmdRun.EXPECT().Exec(context.Background(), gitParams("/repo/api", "rev-parse", "--show-prefix")).Return("api/\n", nil)
+		m.CmdRun.EXPECT().Exec(context.Background(), gitParams("/repo/api", "rev-parse", "--show-toplevel")).Return("/repo\n", nil)
+	}
+
+	expectConfigs := func(m *Mocks, templatePaths ...string) {
+		config := &ensurefile.Config{RootPath: "/repo/api", Mocks: &ensurefile.MockConfig{}}
+		for _, templatePath := range templatePaths {
+			config.Mocks.Templates = append(config.Mocks.Templates, &ensurefile.Template{Path: templatePath})
+		}
+
+		m.EnsureFileLoader.EXPECT().LoadConfigs("/repo/api").Return([]*ensurefile.Config{config}, nil)
+	}
+
+	ensureHook := `  - repo: local
+    hooks:
+      - id: ensure-mocks
+        name: ensure mocks check
+        entry: ensure --chdir api mocks check --staged
+        language: system
+        pass_filenames: false
+        files: (\.go|go\.mod|go\.sum|go\.work|\.ensure\.(yml|yaml|json))$
+`
+
+	existingConfig := `# Hooks for the repository
+repos:
+  - repo: https://github.com/pre-commit/pre-commit-hooks
+    rev: v4.0.1
+    hooks:
+      - id: trailing-whitespace
+`
+
+	installation := func(changed bool) *hooks.Installation {
+		return &hooks.Installation{
+			Path:    configPath,
+			Command: "ensure --chdir api mocks check --staged",
+			Changed: changed,
+		}
+	}
+
+	table := []struct {
+		Name                 string
+		ExpectedInstallation *hooks.Installation
+		ExpectedError        error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *hooks.Installer
+	}{
+		{
+			Name:                 "without existing config",
+			ExpectedInstallation: installation(true),
+			SetupMocks: func(m *Mocks) {
+				expectGit(m)
+				expectConfigs(m)
+				m.FSWrite.EXPECT().ReadFile(configPath).Return("", os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile(configPath, "repos:\n"+ensureHook, os.FileMode(0664)).Return(nil)
+			},
+		},
+		{
+			Name:                 "with templates",
+			ExpectedInstallation: installation(true),
+			SetupMocks: func(m *Mocks) {
+				expectGit(m)
+				expectConfigs(m, "templates/mock.tmpl", "/repo/shared/mock.tmpl", "/outside/mock.tmpl")
+				m.FSWrite.EXPECT().ReadFile(configPath).Return("", os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile(configPath, "repos:\n"+strings.Replace(
+					ensureHook,
+					"files: (",
+					`files: ^(api/templates/mock\.tmpl|shared/mock\.tmpl)$|(`,
+					1,
+				), os.FileMode(0664)).Return(nil)
+			},
+		},
+		{
+			Name:          "when unable to load the configs",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m)
+				m.EnsureFileLoader.EXPECT().LoadConfigs("/repo/api").Return(nil, exampleError)
+			},
+		},
+		{
+			Name:                 "with existing config",
+			ExpectedInstallation: installation(true),
+			SetupMocks: func(m *Mocks) {
+				expectGit(m)
+				expectConfigs(m)
+				m.FSWrite.EXPECT().ReadFile(configPath).Return(existingConfig, nil)
+				m.FSWrite.EXPECT().WriteFile(configPath, existingConfig+ensureHook, os.FileMode(0664)).Return(nil)
+			},
+		},
+		{
+			Name:                 "when already installed",
+			ExpectedInstallation: installation(false),
+			SetupMocks: func(m *Mocks) {
+				expectGit(m)
+				expectConfigs(m)
+				m.FSWrite.EXPECT().ReadFile(configPath).Return(existingConfig+ensureHook, nil)
+			},
+		},
+		{
+			Name:          "with invalid config",
+			ExpectedError: hooks.ErrCannotParsePreCommitConfig,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m)
+				expectConfigs(m)
+				m.FSWrite.EXPECT().ReadFile(configPath).Return("repos: abc\n", nil)
+			},
+		},
+		{
+			Name:          "when unable to write the config",
+			ExpectedError: hooks.ErrCannotWriteFile,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m)
+				expectConfigs(m)
+				m.FSWrite.EXPECT().ReadFile(configPath).Return("", os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile(configPath, "repos:\n"+ensureHook, os.FileMode(0664)).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		installation, err := entry.Subject.InstallPreCommitConfig(context.Background(), "/repo/api")
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(installation).Equals(entry.ExpectedInstallation)
+	})
+}
@@ -0,0 +1,140 @@
+// Package importrewrite rewrites imports of mock packages that moved.
+package importrewrite
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/erk"
+)
+
+var ErrCannotWriteFile = erk.New(mockgen.ErkFSWriteError{}, "Could not write '{{.path}}': {{.err}}")
+
+// Move of a package to a new import path.
+type Move struct {
+	OldPath string `json:"oldPath"`
+	OldName string `json:"oldName,omitempty"` // Defaults to the last element of OldPath
+	NewPath string `json:"newPath"`
+}
+
+type RewriterIface interface {
+	Rewrite(rootPath string, moves []*Move) ([]string, error)
+}
+
+// Rewriter rewrites the imports in the Go files of a module.
+type Rewriter struct {
+	Finder  modfiles.FinderIface
+	FSWrite fswrite.FSWriteIface
+}
+
+var _ RewriterIface = &Rewriter{}
+
+// Rewrite the imports of the moved packages in every Go file within the module rooted at rootPath,
+// returning the paths of the rewritten files. Import aliases are kept. If an import is not aliased,
+// and the package name changes, the old name is added as an alias, so references to the package remain valid.
+func (r *Rewriter) Rewrite(rootPath string, moves []*Move) ([]string, error) {
+	movesByOldPath := map[string]*Move{}
+	for _, move := range moves {
+		movesByOldPath[move.OldPath] = move
+	}
+
+	goFiles, err := r.Finder.GoFiles(rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rewrittenFiles := []string{}
+	for _, goFile := range goFiles {
+		contents, rewritten, err := rewriteFile(goFile, movesByOldPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !rewritten {
+			continue
+		}
+
+		if err := r.FSWrite.WriteFile(goFile.Path, contents, 0664); err != nil {
+			return nil, erk.WrapWith(ErrCannotWriteFile, err, erk.Params{
+				"path": goFile.Path,
+			})
+		}
+
+		rewrittenFiles = append(rewrittenFiles, goFile.Path)
+	}
+
+	return rewrittenFiles, nil
+}
+
+type edit struct {
+	start       int
+	end         int
+	replacement string
+}
+
+func rewriteFile(goFile *modfiles.GoFile, movesByOldPath map[string]*Move) (string, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, goFile.Path, goFile.Contents, parser.ImportsOnly)
+	if err != nil {
+		return "", false, erk.WrapWith(modfiles.ErrCannotParseFile, err, erk.Params{
+			"path": goFile.Path,
+		})
+	}
+
+	edits := []*edit{}
+	for _, imp := range file.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		move, ok := movesByOldPath[importPath]
+		if !ok {
+			continue
+		}
+
+		edits = append(edits, &edit{
+			start:       fset.Position(imp.Path.Pos()).Offset,
+			end:         fset.Position(imp.Path.End()).Offset,
+			replacement: aliasFor(imp, move) + strconv.Quote(move.NewPath),
+		})
+	}
+
+	if len(edits) == 0 {
+		return goFile.Contents, false, nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+
+	contents := goFile.Contents
+	for _, e := range edits {
+		contents = contents[:e.start] + e.replacement + contents[e.end:]
+	}
+
+	return contents, true, nil
+}
+
+// aliasFor returns the alias to insert before the new import path, if any.
+func aliasFor(imp *ast.ImportSpec, move *Move) string {
+	if imp.Name != nil {
+		return "" // Existing aliases are kept
+	}
+
+	oldName := move.OldName
+	if oldName == "" {
+		oldName = path.Base(move.OldPath)
+	}
+
+	if oldName == path.Base(move.NewPath) {
+		return ""
+	}
+
+	return oldName + " "
+}
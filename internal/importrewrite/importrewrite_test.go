@@ -0,0 +1,120 @@
+package importrewrite_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/importrewrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_modfiles"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRewrite(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Finder  *mock_modfiles.MockFinderIface
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	const filePerm = os.FileMode(0664)
+
+	moves := []*importrewrite.Move{
+		{
+			OldPath: "github.com/my/app/internal/mocks/mock_store",
+			NewPath: "github.com/my/app/mocks/mock_store",
+		},
+		{
+			OldPath: "github.com/my/app/mocks/github.com/my/app/store",
+			OldName: "mocks",
+			NewPath: "github.com/my/app/internal/mocks/mock_store",
+		},
+	}
+
+	table := []struct {
+		Name          string
+		ExpectedFiles []string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *importrewrite.Rewriter
+	}{
+		{
+			Name:          "rewrites the imports",
+			ExpectedFiles: []string{"/my/app/a_test.go", "/my/app/b_test.go"},
+			SetupMocks: func(m *Mocks) {
+				m.Finder.EXPECT().GoFiles("/my/app").Return([]*modfiles.GoFile{
+					{
+						Path: "/my/app/a_test.go",
+						Contents: "package a_test\n\nimport (\n" +
+							"\t\"testing\"\n\n" +
+							"\t\"github.com/my/app/internal/mocks/mock_store\"\n" +
+							"\tstoremocks \"github.com/my/app/mocks/github.com/my/app/store\"\n" +
+							")\n",
+					},
+					{
+						Path:     "/my/app/b_test.go",
+						Contents: "package b_test\n\nimport \"github.com/my/app/mocks/github.com/my/app/store\"\n\nvar _ = mocks.NewStore\n",
+					},
+					{
+						Path:     "/my/app/c.go",
+						Contents: "package c\n\nimport \"fmt\"\n",
+					},
+				}, nil)
+
+				m.FSWrite.EXPECT().WriteFile("/my/app/a_test.go",
+					"package a_test\n\nimport (\n"+
+						"\t\"testing\"\n\n"+
+						"\t\"github.com/my/app/mocks/mock_store\"\n"+
+						"\tstoremocks \"github.com/my/app/internal/mocks/mock_store\"\n"+
+						")\n",
+					filePerm,
+				).Return(nil)
+
+				m.FSWrite.EXPECT().WriteFile("/my/app/b_test.go",
+					"package b_test\n\nimport mocks \"github.com/my/app/internal/mocks/mock_store\"\n\nvar _ = mocks.NewStore\n",
+					filePerm,
+				).Return(nil)
+			},
+		},
+		{
+			Name:          "when unable to find Go files",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.Finder.EXPECT().GoFiles("/my/app").Return(nil, exampleError)
+			},
+		},
+		{
+			Name:          "when unable to parse Go file",
+			ExpectedError: modfiles.ErrCannotParseFile,
+			SetupMocks: func(m *Mocks) {
+				m.Finder.EXPECT().GoFiles("/my/app").Return([]*modfiles.GoFile{{Path: "/my/app/a.go", Contents: "package"}}, nil)
+			},
+		},
+		{
+			Name:          "when unable to write Go file",
+			ExpectedError: importrewrite.ErrCannotWriteFile,
+			SetupMocks: func(m *Mocks) {
+				m.Finder.EXPECT().GoFiles("/my/app").Return([]*modfiles.GoFile{
+					{Path: "/my/app/a_test.go", Contents: "package a_test\n\nimport \"github.com/my/app/internal/mocks/mock_store\"\n"},
+				}, nil)
+				m.FSWrite.EXPECT().WriteFile("/my/app/a_test.go", gomock.Any(), filePerm).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		files, err := entry.Subject.Rewrite("/my/app", moves)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(files).Equals(entry.ExpectedFiles)
+	})
+}
@@ -0,0 +1,97 @@
+package mockgen
+
+import (
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/erk"
+)
+
+var (
+	ErrConflictingMockName = erk.New(ErkInvalidConfig{},
+		"The //ensure:mock annotation at {{.path}}:{{.line}} names the mock of '{{.packagePath}}:{{.interface}}' {{.annotationName}}, "+
+			"but {{.source}} names it {{.configName}}. Please remove one of the names.",
+	)
+	ErrDuplicateMockName = erk.New(ErkInvalidConfig{},
+		"Package '{{.packagePath}}' in {{.source}} has more than one mock named {{.mockName}}. Please rename one of them using `mockNames`.",
+	)
+)
+
+// mergeAnnotations adds the annotated interfaces to the packages in the config.
+// Annotations for packages that are already listed are merged into them, so the package is only generated once.
+// Merging is idempotent, since the config is validated more than once.
+func mergeAnnotations(config *ensurefile.Config) error {
+	for _, annotation := range config.Annotations {
+		pkg := findPackage(config.Mocks.Packages, annotation.PackagePath)
+		if pkg == nil {
+			pkg = &ensurefile.Package{Path: annotation.PackagePath, File: annotation.File, Line: annotation.Line}
+			config.Mocks.Packages = append(config.Mocks.Packages, pkg)
+		}
+
+		if !containsString(pkg.Interfaces, annotation.Interface) {
+			pkg.Interfaces = append(pkg.Interfaces, annotation.Interface)
+		}
+
+		if annotation.MockName == "" {
+			continue
+		}
+
+		if configName, ok := pkg.MockNames[annotation.Interface]; ok && configName != annotation.MockName {
+			return erk.WithParams(ErrConflictingMockName, erk.Params{
+				"path":           annotation.File,
+				"line":           annotation.Line,
+				"packagePath":    pkg.Path,
+				"interface":      annotation.Interface,
+				"source":         packageSource(config, pkg),
+				"annotationName": annotation.MockName,
+				"configName":     configName,
+			})
+		}
+
+		if pkg.MockNames == nil {
+			pkg.MockNames = map[string]string{}
+		}
+		pkg.MockNames[annotation.Interface] = annotation.MockName
+	}
+
+	return nil
+}
+
+// checkMockNames ensures the mocks generated for each package have unique names.
+func checkMockNames(config *ensurefile.Config) error {
+	for _, pkg := range config.Mocks.Packages {
+		mockNames := map[string]bool{}
+
+		for _, iface := range pkg.Interfaces {
+			mockName := pkg.MockName(iface)
+			if mockNames[mockName] {
+				return erk.WithParams(ErrDuplicateMockName, erk.Params{
+					"packagePath": pkg.Path,
+					"mockName":    mockName,
+				})
+			}
+
+			mockNames[mockName] = true
+		}
+	}
+
+	return nil
+}
+
+func findPackage(packages []*ensurefile.Package, pkgPath string) *ensurefile.Package {
+	for _, pkg := range packages {
+		if pkg.Path == pkgPath {
+			return pkg
+		}
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,162 @@
+package mockgen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/erk/erg"
+)
+
+type ErkMocksOutOfDate struct{ erk.DefaultKind }
+
+var (
+	ErrMocksOutOfDate = erk.New(ErkMocksOutOfDate{},
+		"{{.count}} mock file(s) are out of date. Please run 'ensure mocks generate' to update them.",
+	)
+	ErrCannotReadMock = erk.New(ErkFSReadError{}, "Could not read the existing mock '{{.path}}': {{.err}}")
+)
+
+// MockFile is a mock file that would be generated for a package.
+type MockFile struct {
+	Package string `json:"package"` // Such as github.com/my/app/pkg:Iface1,Iface2
+	Source  string `json:"source"`  // Where the package is configured, such as /my/app/.ensure.yml:4
+	Path    string `json:"path"`
+}
+
+// CheckResult lists the differences between the mocks that would be generated and the files on disk.
+type CheckResult struct {
+	Outdated []*MockFile `json:"outdated"` // Mock files with different contents
+	Missing  []*MockFile `json:"missing"`  // Mock files that do not exist
+	Extra    []string    `json:"extra"`    // Paths that would be tidied, when tidyAfterGenerate is set
+}
+
+// UpToDate is true if generating the mocks would not change any files.
+func (r *CheckResult) UpToDate() bool {
+	return len(r.Outdated) == 0 && len(r.Missing) == 0 && len(r.Extra) == 0
+}
+
+// Count of the files that would change.
+func (r *CheckResult) Count() int {
+	return len(r.Outdated) + len(r.Missing) + len(r.Extra)
+}
+
+// Err returns ErrMocksOutOfDate if any files would change.
+func (r *CheckResult) Err() error {
+	if r.UpToDate() {
+		return nil
+	}
+
+	return erk.WithParams(ErrMocksOutOfDate, erk.Params{
+		"count": r.Count(),
+	})
+}
+
+// CheckMocks generates the mocks for the configs in memory, and compares them to the files on disk, without changing any files.
+// Like GenerateAllMocks, package filters only need to match packages in one of the configs.
+// Paths that would be tidied are only included for configs with tidyAfterGenerate set, and without package filters or changed packages.
+func (g *MockGen) CheckMocks(ctx context.Context, configs []*ensurefile.Config) (*CheckResult, error) {
+	jobs, disableParallel, err := prepareAllJobs(configs)
+	if err != nil {
+		return nil, err
+	}
+
+	g.registerCleanups(jobs)
+
+	result := &CheckResult{Outdated: []*MockFile{}, Missing: []*MockFile{}, Extra: []string{}}
+	asyncParams := &generateMockAsyncParams{
+		errors: erg.NewAs(ErrMultipleGenerationFailures),
+	}
+
+	var resultMu sync.Mutex
+	checkJob := func(job *generateJob) {
+		mockFile, missing, err := g.checkMock(ctx, job)
+		if err != nil {
+			asyncParams.addError(err)
+			return
+		}
+
+		if mockFile == nil {
+			return
+		}
+
+		resultMu.Lock()
+		defer resultMu.Unlock()
+
+		if missing {
+			result.Missing = append(result.Missing, mockFile)
+		} else {
+			result.Outdated = append(result.Outdated, mockFile)
+		}
+	}
+
+	for _, job := range jobs {
+		if disableParallel {
+			checkJob(job)
+			continue
+		}
+
+		asyncParams.wg.Add(1)
+		go func(job *generateJob) {
+			defer asyncParams.wg.Done()
+			checkJob(job)
+		}(job)
+	}
+
+	asyncParams.wg.Wait()
+	if erg.Any(asyncParams.errors) {
+		return nil, asyncParams.errors
+	}
+
+	for _, config := range configs {
+		if !config.Mocks.TidyAfterGenerate || len(config.PackageFilters) > 0 || config.ChangedPackages != nil {
+			continue
+		}
+
+		extra, err := g.TidyPaths(config)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Extra = append(result.Extra, extra...)
+	}
+
+	sort.Slice(result.Outdated, func(i, j int) bool { return result.Outdated[i].Path < result.Outdated[j].Path })
+	sort.Slice(result.Missing, func(i, j int) bool { return result.Missing[i].Path < result.Missing[j].Path })
+	return result, nil
+}
+
+// checkMock renders the mock, and returns it if it differs from the file on disk, along with whether the file is missing.
+func (g *MockGen) checkMock(ctx context.Context, job *generateJob) (*MockFile, bool, error) {
+	rendered, err := g.renderMock(ctx, job.destination, job.templates)
+	if err != nil {
+		return nil, false, err
+	}
+
+	mockFile := &MockFile{
+		Package: job.destination.Package.String(),
+		Source:  job.destination.Source,
+		Path:    job.destination.fullPath(),
+	}
+
+	existing, err := g.FSWrite.ReadFile(mockFile.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return mockFile, true, nil
+	}
+
+	if err != nil {
+		return nil, false, erk.WrapWith(ErrCannotReadMock, err, erk.Params{
+			"path": mockFile.Path,
+		})
+	}
+
+	if existing != rendered {
+		return mockFile, false, nil
+	}
+
+	return nil, false, nil
+}
@@ -0,0 +1,194 @@
+package mockgen_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_exitcleanup"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestCheckMocks(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		CmdRun  *mock_runcmd.MockRunnerIface
+		FSWrite *mock_fswrite.MockFSWriteIface
+		Cleanup *mock_exitcleanup.MockExitCleaner
+	}
+
+	const (
+		mockFilePath   = "/root/path/primary_mocks/github.com/some/pkg/mock_abc/mock_abc.go"
+		primaryMockDir = "/root/path/primary_mocks"
+	)
+
+	exampleError := errors.New("something went wrong")
+	expectedMockFile := exampleMockSource +
+		"\n// NEW creates a MockIface1.\n" +
+		"func (*MockIface1) NEW(ctrl *gomock.Controller) *MockIface1 {\n" +
+		"\treturn NewMockIface1(ctrl)\n" +
+		"}\n"
+
+	newConfig := func(tidyAfterGenerate bool) *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/root/path",
+			ModulePath: "github.com/my/mod",
+			ConfigPath: "/root/path/.ensure.yml",
+			Mocks: &ensurefile.MockConfig{
+				PrimaryDestination: "primary_mocks",
+				TidyAfterGenerate:  tidyAfterGenerate,
+				Packages: []*ensurefile.Package{
+					{Path: "github.com/some/pkg/abc", Interfaces: []string{"Iface1"}, Line: 4},
+				},
+			},
+		}
+	}
+
+	abcMockFile := &mockgen.MockFile{
+		Package: "github.com/some/pkg/abc:Iface1",
+		Source:  "/root/path/.ensure.yml:4",
+		Path:    mockFilePath,
+	}
+
+	expectMockgen := func(m *Mocks) *gomock.Call {
+		return m.CmdRun.EXPECT().
+			Exec(gomock.Any(), &runcmd.ExecParams{
+				PWD:  "/root/path",
+				CMD:  "mockgen",
+				Args: []string{"github.com/some/pkg/abc", "Iface1"},
+			}).
+			Return(exampleMockSource, nil)
+	}
+
+	table := []struct {
+		Name           string
+		Config         *ensurefile.Config
+		ExpectedResult *mockgen.CheckResult
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *mockgen.MockGen
+	}{
+		{
+			Name:   "when mocks are up to date",
+			Config: newConfig(false),
+			ExpectedResult: &mockgen.CheckResult{
+				Outdated: []*mockgen.MockFile{},
+				Missing:  []*mockgen.MockFile{},
+				Extra:    []string{},
+			},
+			SetupMocks: func(m *Mocks) {
+				expectMockgen(m)
+				m.FSWrite.EXPECT().ReadFile(mockFilePath).Return(expectedMockFile, nil)
+			},
+		},
+		{
+			Name:   "when mock is outdated",
+			Config: newConfig(false),
+			ExpectedResult: &mockgen.CheckResult{
+				Outdated: []*mockgen.MockFile{abcMockFile},
+				Missing:  []*mockgen.MockFile{},
+				Extra:    []string{},
+			},
+			SetupMocks: func(m *Mocks) {
+				expectMockgen(m)
+				m.FSWrite.EXPECT().ReadFile(mockFilePath).Return("package mock_abc\n", nil)
+			},
+		},
+		{
+			Name:   "when mock is missing",
+			Config: newConfig(false),
+			ExpectedResult: &mockgen.CheckResult{
+				Outdated: []*mockgen.MockFile{},
+				Missing:  []*mockgen.MockFile{abcMockFile},
+				Extra:    []string{},
+			},
+			SetupMocks: func(m *Mocks) {
+				expectMockgen(m)
+				m.FSWrite.EXPECT().ReadFile(mockFilePath).Return("", os.ErrNotExist)
+			},
+		},
+		{
+			Name:   "when files would be tidied",
+			Config: newConfig(true),
+			ExpectedResult: &mockgen.CheckResult{
+				Outdated: []*mockgen.MockFile{},
+				Missing:  []*mockgen.MockFile{},
+				Extra:    []string{primaryMockDir + "/github.com/some/pkg/mock_old"},
+			},
+			SetupMocks: func(m *Mocks) {
+				expectMockgen(m)
+				m.FSWrite.EXPECT().ReadFile(mockFilePath).Return(expectedMockFile, nil)
+				m.FSWrite.EXPECT().ListRecursive(primaryMockDir).Return([]string{
+					primaryMockDir + "/github.com/some/pkg/mock_abc",
+					mockFilePath,
+					primaryMockDir + "/github.com/some/pkg/mock_old",
+				}, nil)
+			},
+		},
+		{
+			Name:          "when mockgen fails",
+			Config:        newConfig(false),
+			ExpectedError: mockgen.ErrMockGenFailed,
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(gomock.Any(), gomock.Any()).Return("", exampleError)
+			},
+		},
+		{
+			Name:          "when unable to read existing mock",
+			Config:        newConfig(false),
+			ExpectedError: mockgen.ErrCannotReadMock,
+			SetupMocks: func(m *Mocks) {
+				expectMockgen(m)
+				m.FSWrite.EXPECT().ReadFile(mockFilePath).Return("", exampleError)
+			},
+		},
+		{
+			Name:          "with invalid config",
+			Config:        &ensurefile.Config{},
+			ExpectedError: mockgen.ErrMissingMockConfig,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+		entry.Config.DisableParallelGeneration = true
+		entry.Mocks.Cleanup.EXPECT().Register(gomock.Any()).AnyTimes()
+
+		result, err := entry.Subject.CheckMocks(context.Background(), []*ensurefile.Config{entry.Config})
+		if entry.ExpectedError != nil {
+			ensure(errors.Is(err, entry.ExpectedError)).IsTrue()
+			return
+		}
+
+		ensure(err).IsNotError()
+		ensure(result).Equals(entry.ExpectedResult)
+	})
+}
+
+func TestCheckResultErr(t *testing.T) {
+	ensure := ensure.New(t)
+
+	result := &mockgen.CheckResult{}
+	ensure(result.UpToDate()).IsTrue()
+	ensure(result.Err()).IsNotError()
+
+	result.Missing = []*mockgen.MockFile{{Path: "/root/path/mocks/mock_abc/mock_abc.go"}}
+	result.Extra = []string{"/root/path/mocks/mock_old"}
+	ensure(result.UpToDate()).IsFalse()
+	ensure(result.Count()).Equals(2)
+	ensure(result.Err()).IsError(mockgen.ErrMocksOutOfDate)
+}
@@ -0,0 +1,106 @@
+package mockgen
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+)
+
+const (
+	// DestinationPrimary is used for packages that are not internal, and places mocks in mocks.primaryDestination at the module root.
+	DestinationPrimary = "primary"
+
+	// DestinationInternal is used for internal packages, and places mocks in internal/<mocks.internalDestination>
+	// next to the last internal directory, so the mocks can import the package.
+	DestinationInternal = "internal"
+)
+
+// PathExplanation describes why a path exists in a mock directory.
+type PathExplanation struct {
+	Path string `json:"path"`
+
+	// Entries that generate the path, or files within the path if it is a directory.
+	Entries []*PathEntry `json:"entries"`
+
+	// MockDir containing the path, if any.
+	MockDir string `json:"mockDir,omitempty"`
+
+	// TidyRemoves is true if tidying would delete the path.
+	TidyRemoves bool `json:"tidyRemoves"`
+}
+
+// PathEntry is a package in .ensure.yml that generates a mock file.
+type PathEntry struct {
+	PackagePath    string   `json:"packagePath"`
+	Interfaces     []string `json:"interfaces"`
+	File           string   `json:"file"` // The .ensure.yml file or annotated Go file that lists the package
+	Line           int      `json:"line"`
+	Destination    string   `json:"destination"`
+	DestinationDir string   `json:"destinationDir"` // Resolved for the package, such as internal/mocks
+	FilePath       string   `json:"filePath"`
+}
+
+// ExplainPath finds the packages in the config that generate the provided absolute path.
+func ExplainPath(config *ensurefile.Config, path string) (*PathExplanation, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	mockDestinations, err := computeMockDestinations(config)
+	if err != nil {
+		return nil, err
+	}
+
+	path = filepath.Clean(path)
+	explanation := &PathExplanation{
+		Path:    path,
+		Entries: []*PathEntry{},
+	}
+
+	for _, mockDestination := range mockDestinations {
+		fullPath := mockDestination.fullPath()
+		if fullPath != path && !strings.HasPrefix(fullPath, path+string(filepath.Separator)) {
+			continue
+		}
+
+		destination := DestinationPrimary
+		if mockDestination.Internal {
+			destination = DestinationInternal
+		}
+
+		file := mockDestination.Package.File
+		if file == "" {
+			file = config.ConfigPath
+		}
+
+		explanation.Entries = append(explanation.Entries, &PathEntry{
+			PackagePath:    mockDestination.Package.Path,
+			Interfaces:     mockDestination.Package.Interfaces,
+			File:           file,
+			Line:           mockDestination.Package.Line,
+			Destination:    destination,
+			DestinationDir: mockDestination.MockDir,
+			FilePath:       fullPath,
+		})
+	}
+
+	sort.SliceStable(explanation.Entries, func(i, j int) bool {
+		return explanation.Entries[i].FilePath < explanation.Entries[j].FilePath
+	})
+
+	// Mirror the behavior of TidyMocks, which only deletes paths within a mock directory.
+	// If mock directories are nested, the innermost one is used.
+	for mockDir, mockDests := range mockDestinations.byFullMockDir() {
+		isWithin := path == mockDir || strings.HasPrefix(path, mockDir+string(filepath.Separator))
+		if !isWithin || len(mockDir) < len(explanation.MockDir) {
+			continue
+		}
+
+		explanation.MockDir = mockDir
+		explanation.TidyRemoves = path != mockDir && !mockDests.hasFullPathPrefix(path)
+	}
+
+	return explanation, nil
+}
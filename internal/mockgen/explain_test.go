@@ -0,0 +1,218 @@
+package mockgen_test
+
+import (
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestExplainPath(t *testing.T) {
+	ensure := ensure.New(t)
+
+	newConfig := func() *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/root/path",
+			ModulePath: "github.com/my/mod",
+			ConfigPath: "/root/path/.ensure.yml",
+			Mocks: &ensurefile.MockConfig{
+				Packages: []*ensurefile.Package{
+					{
+						Path:       "github.com/some/pkg/abc",
+						Interfaces: []string{"Iface1", "Iface2"},
+						Line:       4,
+					},
+					{
+						Path:       "github.com/some/pkg/xyz",
+						Interfaces: []string{"Iface3"},
+						Line:       7,
+					},
+					{
+						Path:       "github.com/my/mod/layer1/internal/layer2/qwerty",
+						Interfaces: []string{"Iface4"},
+						Line:       10,
+					},
+				},
+			},
+		}
+	}
+
+	abcEntry := &mockgen.PathEntry{
+		PackagePath:    "github.com/some/pkg/abc",
+		Interfaces:     []string{"Iface1", "Iface2"},
+		File:           "/root/path/.ensure.yml",
+		Line:           4,
+		Destination:    mockgen.DestinationPrimary,
+		DestinationDir: "internal/mocks",
+		FilePath:       "/root/path/internal/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+	}
+
+	xyzEntry := &mockgen.PathEntry{
+		PackagePath:    "github.com/some/pkg/xyz",
+		Interfaces:     []string{"Iface3"},
+		File:           "/root/path/.ensure.yml",
+		Line:           7,
+		Destination:    mockgen.DestinationPrimary,
+		DestinationDir: "internal/mocks",
+		FilePath:       "/root/path/internal/mocks/github.com/some/pkg/mock_xyz/mock_xyz.go",
+	}
+
+	table := []struct {
+		Name                string
+		Config              *ensurefile.Config
+		Path                string
+		ExpectedExplanation *mockgen.PathExplanation
+		ExpectedError       error
+	}{
+		{
+			Name:   "with file generated by primary destination",
+			Config: newConfig(),
+			Path:   "/root/path/internal/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+			ExpectedExplanation: &mockgen.PathExplanation{
+				Path:    "/root/path/internal/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+				Entries: []*mockgen.PathEntry{abcEntry},
+				MockDir: "/root/path/internal/mocks",
+			},
+		},
+
+		{
+			Name:   "with file generated by internal destination",
+			Config: newConfig(),
+			Path:   "/root/path/layer1/internal/mocks/layer2/mock_qwerty/mock_qwerty.go",
+			ExpectedExplanation: &mockgen.PathExplanation{
+				Path: "/root/path/layer1/internal/mocks/layer2/mock_qwerty/mock_qwerty.go",
+				Entries: []*mockgen.PathEntry{
+					{
+						PackagePath:    "github.com/my/mod/layer1/internal/layer2/qwerty",
+						Interfaces:     []string{"Iface4"},
+						File:           "/root/path/.ensure.yml",
+						Line:           10,
+						Destination:    mockgen.DestinationInternal,
+						DestinationDir: "internal/mocks",
+						FilePath:       "/root/path/layer1/internal/mocks/layer2/mock_qwerty/mock_qwerty.go",
+					},
+				},
+				MockDir: "/root/path/layer1/internal/mocks",
+			},
+		},
+
+		{
+			Name: "with file generated by nested config",
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				ConfigPath: "/root/path/.ensure.yml",
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:                "github.com/my/mod/team/store",
+							Interfaces:          []string{"Store"},
+							File:                "/root/path/team/.ensure.yml",
+							Line:                3,
+							PrimaryDestination:  "team/fakes",
+							InternalDestination: "fakes",
+						},
+						{
+							Path:                "github.com/my/mod/team/internal/cache",
+							Interfaces:          []string{"Cache"},
+							File:                "/root/path/team/cache.go",
+							Line:                8,
+							InternalDestination: "fakes",
+						},
+					},
+				},
+			},
+			Path: "/root/path/team",
+			ExpectedExplanation: &mockgen.PathExplanation{
+				Path: "/root/path/team",
+				Entries: []*mockgen.PathEntry{
+					{
+						PackagePath:    "github.com/my/mod/team/store",
+						Interfaces:     []string{"Store"},
+						File:           "/root/path/team/.ensure.yml",
+						Line:           3,
+						Destination:    mockgen.DestinationPrimary,
+						DestinationDir: "team/fakes",
+						FilePath:       "/root/path/team/fakes/github.com/my/mod/team/mock_store/mock_store.go",
+					},
+					{
+						PackagePath:    "github.com/my/mod/team/internal/cache",
+						Interfaces:     []string{"Cache"},
+						File:           "/root/path/team/cache.go",
+						Line:           8,
+						Destination:    mockgen.DestinationInternal,
+						DestinationDir: "internal/fakes",
+						FilePath:       "/root/path/team/internal/fakes/mock_cache/mock_cache.go",
+					},
+				},
+			},
+		},
+
+		{
+			Name:   "with directory containing generated files",
+			Config: newConfig(),
+			Path:   "/root/path/internal/mocks/github.com/some/pkg/",
+			ExpectedExplanation: &mockgen.PathExplanation{
+				Path:    "/root/path/internal/mocks/github.com/some/pkg",
+				Entries: []*mockgen.PathEntry{abcEntry, xyzEntry},
+				MockDir: "/root/path/internal/mocks",
+			},
+		},
+
+		{
+			Name:   "with extra file in mock directory",
+			Config: newConfig(),
+			Path:   "/root/path/internal/mocks/github.com/some/pkg/mock_abc/extra.go",
+			ExpectedExplanation: &mockgen.PathExplanation{
+				Path:        "/root/path/internal/mocks/github.com/some/pkg/mock_abc/extra.go",
+				Entries:     []*mockgen.PathEntry{},
+				MockDir:     "/root/path/internal/mocks",
+				TidyRemoves: true,
+			},
+		},
+
+		{
+			Name:   "with path outside mock directories",
+			Config: newConfig(),
+			Path:   "/root/path/some/file.go",
+			ExpectedExplanation: &mockgen.PathExplanation{
+				Path:    "/root/path/some/file.go",
+				Entries: []*mockgen.PathEntry{},
+			},
+		},
+
+		{
+			Name:          "when missing mocks",
+			Config:        &ensurefile.Config{},
+			Path:          "/root/path/internal/mocks",
+			ExpectedError: mockgen.ErrMissingMockConfig,
+		},
+
+		{
+			Name: "when internal package is outside module",
+			Config: &ensurefile.Config{
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/other/internal/pkg",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+			Path:          "/root/path/internal/mocks",
+			ExpectedError: mockgen.ErrInternalPackageOutsideModule,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		explanation, err := mockgen.ExplainPath(entry.Config, entry.Path)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(explanation).Equals(entry.ExpectedExplanation)
+	})
+}
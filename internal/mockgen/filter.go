@@ -0,0 +1,124 @@
+package mockgen
+
+import (
+	"path"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/erk"
+)
+
+var (
+	ErrInvalidPackageFilter   = erk.New(ErkInvalidConfig{}, "Invalid package filter '{{.filter}}': {{.err}}")
+	ErrNoPackagesMatchFilters = erk.New(ErkInvalidConfig{}, "No packages in .ensure.yml match the provided filters: {{.filters}}")
+)
+
+// filter returns the destinations with packages matching at least one of the config's package filters.
+// Filters are path.Match patterns, which are compared against both the full package path and the path relative to the module.
+// Filters of the form <package pattern>:<interface pattern> match packages that contain a matching interface.
+// Since each package is generated into a single file, matching an interface selects the entire package.
+//
+// When the config has ChangedPackages, only those packages are kept. Unlike package filters,
+// it is not an error if none of the packages changed.
+func (dests mockDestinations) filter(config *ensurefile.Config) (mockDestinations, error) {
+	dests, err := dests.filterByPatterns(config)
+	if err != nil || config.ChangedPackages == nil {
+		return dests, err
+	}
+
+	changed := map[string]bool{}
+	for _, pkgPath := range config.ChangedPackages {
+		changed[pkgPath] = true
+	}
+
+	filtered := mockDestinations{}
+	for _, dest := range dests {
+		if changed[dest.Package.Path] {
+			filtered = append(filtered, dest)
+		}
+	}
+
+	return filtered, nil
+}
+
+func (dests mockDestinations) filterByPatterns(config *ensurefile.Config) (mockDestinations, error) {
+	if len(config.PackageFilters) == 0 {
+		return dests, nil
+	}
+
+	filtered := mockDestinations{}
+	for _, dest := range dests {
+		matches, err := matchesAnyFilter(config, dest.Package, config.PackageFilters)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches {
+			filtered = append(filtered, dest)
+		}
+	}
+
+	if len(filtered) == 0 {
+		return nil, erk.WithParams(ErrNoPackagesMatchFilters, erk.Params{
+			"filters": strings.Join(config.PackageFilters, ", "),
+		})
+	}
+
+	return filtered, nil
+}
+
+func matchesAnyFilter(config *ensurefile.Config, pkg *ensurefile.Package, filters []string) (bool, error) {
+	for _, filter := range filters {
+		matches, err := matchesFilter(config, pkg, filter)
+		if err != nil {
+			return false, erk.WrapWith(ErrInvalidPackageFilter, err, erk.Params{
+				"filter": filter,
+			})
+		}
+
+		if matches {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func matchesFilter(config *ensurefile.Config, pkg *ensurefile.Package, filter string) (bool, error) {
+	pkgPattern, ifacePattern := filter, ""
+	if idx := strings.LastIndex(filter, ":"); idx >= 0 {
+		pkgPattern, ifacePattern = filter[:idx], filter[idx+1:]
+	}
+
+	pkgMatches, err := matchesPackagePath(config, pkg.Path, pkgPattern)
+	if err != nil || !pkgMatches || ifacePattern == "" {
+		return pkgMatches, err
+	}
+
+	for _, iface := range pkg.Interfaces {
+		ifaceMatches, err := path.Match(ifacePattern, iface)
+		if err != nil {
+			return false, err
+		}
+
+		if ifaceMatches {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func matchesPackagePath(config *ensurefile.Config, pkgPath, pattern string) (bool, error) {
+	matches, err := path.Match(pattern, pkgPath)
+	if err != nil || matches {
+		return matches, err
+	}
+
+	relativePath := strings.TrimPrefix(pkgPath, config.ModulePath+"/")
+	if relativePath == pkgPath {
+		return false, nil
+	}
+
+	return path.Match(pattern, relativePath)
+}
@@ -1,7 +1,9 @@
 package mockgen
 
 import (
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
@@ -12,7 +14,7 @@ import (
 type ErkMockDestination struct{ erk.DefaultKind }
 
 var ErrInternalPackageOutsideModule = erk.New(ErkMockDestination{},
-	"Cannot generate mock of internal package, since package '{{.packagePath}}' is not in the current module '{{.modulePath}}'",
+	"Cannot generate mock of internal package, since package '{{.packagePath}}' in {{.source}} is not in the current module '{{.modulePath}}'",
 )
 
 type mockDestinations []*mockDestination
@@ -21,9 +23,32 @@ type mockDestination struct {
 	Package        *ensurefile.Package
 	PWD            string
 	MockDir        string
+	Typed          bool
+	Internal       bool
+	Source         string // Where the package is configured
 	rawPackagePath string
 }
 
+// MockDirs returns the directories that the mocks for the config are generated within.
+func MockDirs(config *ensurefile.Config) ([]string, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	mockDestinations, err := computeMockDestinations(config)
+	if err != nil {
+		return nil, err
+	}
+
+	mockDirs := []string{}
+	for mockDir := range mockDestinations.byFullMockDir() {
+		mockDirs = append(mockDirs, mockDir)
+	}
+	sort.Strings(mockDirs)
+
+	return mockDirs, nil
+}
+
 func computeMockDestinations(config *ensurefile.Config) (mockDestinations, error) {
 	errGroup := erg.NewAs(ErrMultipleGenerationFailures)
 
@@ -48,13 +73,30 @@ func computeMockDestinations(config *ensurefile.Config) (mockDestinations, error
 func computeMockDestination(config *ensurefile.Config, pkg *ensurefile.Package) (*mockDestination, error) {
 	const internalPart = "internal/"
 
+	typed := config.Mocks.Typed
+	if pkg.Typed != nil {
+		typed = *pkg.Typed
+	}
+
+	primaryDestination := config.Mocks.PrimaryDestination
+	if pkg.PrimaryDestination != "" {
+		primaryDestination = pkg.PrimaryDestination
+	}
+
+	internalDestination := config.Mocks.InternalDestination
+	if pkg.InternalDestination != "" {
+		internalDestination = pkg.InternalDestination
+	}
+
 	// Check if package is internal
 	idx := strings.LastIndex(pkg.Path, internalPart)
 	if idx < 0 {
 		return &mockDestination{
 			Package:        pkg,
 			PWD:            config.RootPath,
-			MockDir:        config.Mocks.PrimaryDestination,
+			MockDir:        primaryDestination,
+			Typed:          typed,
+			Source:         packageSource(config, pkg),
 			rawPackagePath: pkg.Path,
 		}, nil
 	}
@@ -62,6 +104,7 @@ func computeMockDestination(config *ensurefile.Config, pkg *ensurefile.Package)
 	if !strings.HasPrefix(pkg.Path, config.ModulePath) {
 		return nil, erk.WithParams(ErrInternalPackageOutsideModule, erk.Params{
 			"packagePath": pkg.Path,
+			"source":      packageSource(config, pkg),
 			"modulePath":  config.ModulePath,
 		})
 	}
@@ -75,19 +118,31 @@ func computeMockDestination(config *ensurefile.Config, pkg *ensurefile.Package)
 	return &mockDestination{
 		Package:        pkg,
 		PWD:            filepath.Join(config.RootPath, pkgPathPrefix),
-		MockDir:        filepath.Join(internalPart, config.Mocks.InternalDestination),
+		MockDir:        filepath.Join(internalPart, internalDestination),
+		Typed:          typed,
+		Internal:       true,
+		Source:         packageSource(config, pkg),
 		rawPackagePath: pkgPathSuffix,
 	}, nil
 }
 
+func (dest *mockDestination) mockPackageName() string {
+	return "mock_" + filepath.Base(dest.rawPackagePath)
+}
+
 func (dest *mockDestination) fullPath() string {
-	originalPackageName := filepath.Base(dest.rawPackagePath)
-	mockPackageName := "mock_" + originalPackageName
+	mockPackageName := dest.mockPackageName()
 	destPkgFile := filepath.Join(filepath.Dir(dest.rawPackagePath), mockPackageName, mockPackageName+".go")
 
 	return filepath.Join(dest.PWD, dest.MockDir, destPkgFile)
 }
 
+// importPath of the generated mock package, which is within the config's module.
+func (dest *mockDestination) importPath(config *ensurefile.Config) string {
+	relativeDir := strings.TrimPrefix(filepath.Dir(dest.fullPath()), config.RootPath)
+	return path.Join(config.ModulePath, filepath.ToSlash(relativeDir))
+}
+
 func (dests mockDestinations) uniquePWDs() []string {
 	uniquePWDMap := map[string]bool{}
 	for _, dest := range dests {
@@ -0,0 +1,156 @@
+package mockgen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+)
+
+// TemplateInterface describes a mocked interface, and is exposed to user-defined templates.
+type TemplateInterface struct {
+	Name     string
+	MockName string
+	Methods  []*TemplateMethod
+}
+
+// TemplateMethod describes a method of a mocked interface, and is exposed to user-defined templates.
+type TemplateMethod struct {
+	Name     string
+	Params   []*TemplateParam
+	Results  []string
+	Variadic bool
+}
+
+// TemplateParam describes a parameter of a mocked method.
+type TemplateParam struct {
+	Name string
+	Type string
+}
+
+// parseMockSource extracts the method sets of the package's interfaces from the source generated by mockgen.
+func parseMockSource(source string, pkg *ensurefile.Package) ([]*TemplateInterface, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	methodsByMockName := map[string][]*TemplateMethod{}
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 || funcDecl.Name.Name == "EXPECT" {
+			continue
+		}
+
+		mockName := receiverTypeName(funcDecl.Recv.List[0].Type)
+		methodsByMockName[mockName] = append(methodsByMockName[mockName], parseMockMethod(fset, funcDecl))
+	}
+
+	templateInterfaces := make([]*TemplateInterface, 0, len(pkg.Interfaces))
+	for _, iface := range pkg.Interfaces {
+		mockName := pkg.MockName(iface)
+
+		templateInterfaces = append(templateInterfaces, &TemplateInterface{
+			Name:     iface,
+			MockName: mockName,
+			Methods:  methodsByMockName[mockName],
+		})
+	}
+
+	return templateInterfaces, nil
+}
+
+func parseMockMethod(fset *token.FileSet, funcDecl *ast.FuncDecl) *TemplateMethod {
+	method := &TemplateMethod{
+		Name:    funcDecl.Name.Name,
+		Params:  []*TemplateParam{},
+		Results: []string{},
+	}
+
+	for _, field := range funcDecl.Type.Params.List {
+		fieldType := exprString(fset, field.Type)
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			method.Variadic = true
+		}
+
+		if len(field.Names) == 0 {
+			method.Params = append(method.Params, &TemplateParam{Type: fieldType})
+		}
+
+		for _, name := range field.Names {
+			method.Params = append(method.Params, &TemplateParam{Name: name.Name, Type: fieldType})
+		}
+	}
+
+	if funcDecl.Type.Results != nil {
+		for _, field := range funcDecl.Type.Results.List {
+			fieldType := exprString(fset, field.Type)
+			method.Results = append(method.Results, fieldType)
+
+			for i := 1; i < len(field.Names); i++ {
+				method.Results = append(method.Results, fieldType)
+			}
+		}
+	}
+
+	return method
+}
+
+// Signature of the method, such as `(arg0 string, arg1 ...int) (bool, error)`.
+func (m *TemplateMethod) Signature() string {
+	params := make([]string, 0, len(m.Params))
+	for _, param := range m.Params {
+		params = append(params, strings.TrimSpace(param.Name+" "+param.Type))
+	}
+
+	return "(" + strings.Join(params, ", ") + ")" + m.resultsString()
+}
+
+// Args that forward the parameters to another call, such as `arg0, arg1...`.
+func (m *TemplateMethod) Args() string {
+	args := make([]string, 0, len(m.Params))
+	for _, param := range m.Params {
+		args = append(args, param.Name)
+	}
+
+	str := strings.Join(args, ", ")
+	if m.Variadic {
+		str += "..."
+	}
+
+	return str
+}
+
+func (m *TemplateMethod) resultsString() string {
+	switch len(m.Results) {
+	case 0:
+		return ""
+	case 1:
+		return " " + m.Results[0]
+	default:
+		return " (" + strings.Join(m.Results, ", ") + ")"
+	}
+}
+
+func receiverTypeName(expr ast.Expr) string {
+	if starExpr, ok := expr.(*ast.StarExpr); ok {
+		expr = starExpr.X
+	}
+
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+
+	return ""
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, fset, expr) // Printing a parsed expression to a buffer cannot fail
+	return buf.String()
+}
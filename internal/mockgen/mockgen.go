@@ -12,6 +12,7 @@ import (
 	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
 	"github.com/JosiahWitt/ensure-cli/internal/exitcleanup"
 	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
 	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
 	"github.com/JosiahWitt/erk"
 	"github.com/JosiahWitt/erk/erg"
@@ -28,6 +29,7 @@ type (
 	ErkMultipleFailures struct{ erk.DefaultKind }
 	ErkMockGenError     struct{ erk.DefaultKind }
 	ErkFSWriteError     struct{ erk.DefaultKind }
+	ErkFSReadError      struct{ erk.DefaultKind }
 )
 
 var (
@@ -35,15 +37,18 @@ var (
 	ErrMissingPackages   = erk.New(ErkInvalidConfig{},
 		"No mocks to generate. Please add some to `mocks.packages` in .ensure.yml file. For example:\n\n"+ensurefile.ExampleFile,
 	)
-	ErrDuplicatePackagePath = erk.New(ErkInvalidConfig{}, "Found duplicate package path: {{.packagePath}}. Package paths must be unique.")
+	ErrDuplicatePackagePath = erk.New(ErkInvalidConfig{},
+		"Found duplicate package path: {{.packagePath}}, listed in {{.source}} and {{.otherSource}}. Package paths must be unique.",
+	)
 
-	ErrMissingPackagePath       = erk.New(ErkInvalidConfig{}, "Missing `path` key for package.")
+	ErrMissingPackagePath       = erk.New(ErkInvalidConfig{}, "Missing `path` key for package in {{.source}}.")
 	ErrMissingPackageInterfaces = erk.New(ErkInvalidConfig{},
-		"Package '{{.packagePath}}' has no interfaces to generate. Please add them using the `interfaces` key.",
+		"Package '{{.packagePath}}' in {{.source}} has no interfaces to generate. Please add them using the `interfaces` key.",
 	)
 
 	ErrMultipleGenerationFailures = erk.New(ErkMultipleFailures{}, "Unable to generate at least one mock")
 	ErrMockGenFailed              = erk.New(ErkMockGenError{}, "Could not run mockgen successfully for '{{.packageDescription}}': {{.err}}")
+	ErrCannotParseMock            = erk.New(ErkMockGenError{}, "Could not parse the mock generated for '{{.packageDescription}}': {{.err}}")
 
 	ErrUnableToCreateDir  = erk.New(ErkFSWriteError{}, "Could not create directory '{{.path}}': {{.err}}")
 	ErrUnableToCreateFile = erk.New(ErkFSWriteError{}, "Could not create file '{{.path}}': {{.err}}")
@@ -51,58 +56,62 @@ var (
 
 type MockGenerator interface {
 	GenerateMocks(ctx context.Context, config *ensurefile.Config) error
+	GenerateAllMocks(ctx context.Context, configs []*ensurefile.Config) error
+	CheckMocks(ctx context.Context, configs []*ensurefile.Config) (*CheckResult, error)
+	VerifyMocks(ctx context.Context, configs []*ensurefile.Config) error
 	TidyMocks(config *ensurefile.Config) error
 }
 
 type MockGen struct {
-	CmdRun  runcmd.RunnerIface
-	FSWrite fswrite.FSWriteIface
-	Logger  *log.Logger
-	Cleanup exitcleanup.ExitCleaner
+	CmdRun   runcmd.RunnerIface
+	FSWrite  fswrite.FSWriteIface
+	Logger   *log.Logger
+	Cleanup  exitcleanup.ExitCleaner
+	Reporter report.ReporterIface // Optional
 }
 
 var _ MockGenerator = &MockGen{}
 
 // GenerateMocks for the provided configuration.
 func (g *MockGen) GenerateMocks(ctx context.Context, config *ensurefile.Config) error {
-	if err := validateConfig(config); err != nil {
-		return err
-	}
+	return g.GenerateAllMocks(ctx, []*ensurefile.Config{config})
+}
 
-	mockDestinations, err := computeMockDestinations(config)
+// GenerateAllMocks for the configurations of multiple modules in a single run, sharing the parallel generation.
+// Each module keeps its own root and module path, so internal packages are routed within their own module.
+// When generating multiple modules, package filters only need to match packages in one of the modules.
+func (g *MockGen) GenerateAllMocks(ctx context.Context, configs []*ensurefile.Config) error {
+	jobs, disableParallel, err := prepareAllJobs(configs)
 	if err != nil {
 		return err
 	}
 
-	for _, pwd := range mockDestinations.uniquePWDs() {
-		pwd := pwd // Pin range variable
-
-		g.Cleanup.Register(func() error {
-			pattern := filepath.Join(pwd, gomockReflectDirPattern)
-			g.Logger.Printf("Cleaning up: %s", pattern)
-			return g.FSWrite.GlobRemoveAll(pattern)
-		})
-	}
+	g.registerCleanups(jobs)
 
 	asyncParams := &generateMockAsyncParams{
 		errors: erg.NewAs(ErrMultipleGenerationFailures),
 	}
 
 	g.Logger.Println("Generating mocks:")
-	for _, mockDestination := range mockDestinations {
-		if !config.DisableParallelGeneration {
+	for _, job := range jobs {
+		if !disableParallel {
 			asyncParams.wg.Add(1)
-			go g.generateMockAsync(ctx, mockDestination, asyncParams)
+			go g.generateMockAsync(ctx, job, asyncParams)
 		} else {
-			g.Logger.Printf(" - Generating: %s\n", mockDestination.Package.String())
-
-			if err := g.generateMock(ctx, mockDestination); err != nil {
-				asyncParams.addError(err)
-			}
+			g.Logger.Printf(" - Generating: %s\n", job.destination.Package.String())
+			g.generateAndReportMock(ctx, job, asyncParams)
 		}
 	}
 
 	asyncParams.wg.Wait()
+	g.report(&report.Event{
+		Type: report.EventSummary,
+		Summary: &report.Summary{
+			Generated: len(jobs) - asyncParams.failed,
+			Failed:    asyncParams.failed,
+		},
+	})
+
 	if erg.Any(asyncParams.errors) {
 		return asyncParams.errors
 	}
@@ -110,52 +119,130 @@ func (g *MockGen) GenerateMocks(ctx context.Context, config *ensurefile.Config)
 	return nil
 }
 
+// generateJob is a mock to generate, along with the templates of the module it belongs to.
+type generateJob struct {
+	destination *mockDestination
+	templates   []*mockTemplate
+}
+
+// prepareAllJobs returns the mocks to generate for the configs, and whether they should be generated sequentially.
+// When there are multiple configs, package filters only need to match packages in one of them.
+func prepareAllJobs(configs []*ensurefile.Config) ([]*generateJob, bool, error) {
+	jobs := []*generateJob{}
+	disableParallel := false
+	anyMatched := false
+
+	for _, config := range configs {
+		configJobs, err := prepareJobs(config)
+		if len(configs) > 1 && errors.Is(err, ErrNoPackagesMatchFilters) {
+			continue
+		}
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		jobs = append(jobs, configJobs...)
+		disableParallel = disableParallel || config.DisableParallelGeneration
+		anyMatched = true
+	}
+
+	if !anyMatched && len(configs) > 1 {
+		return nil, false, erk.WithParams(ErrNoPackagesMatchFilters, erk.Params{
+			"filters": strings.Join(configs[0].PackageFilters, ", "),
+		})
+	}
+
+	return jobs, disableParallel, nil
+}
+
+// registerCleanups removes the directories mockgen creates in reflect mode, in case it is interrupted.
+func (g *MockGen) registerCleanups(jobs []*generateJob) {
+	for _, pwd := range uniqueJobPWDs(jobs) {
+		pwd := pwd // Pin range variable
+
+		g.Cleanup.Register(func() error {
+			pattern := filepath.Join(pwd, gomockReflectDirPattern)
+			g.Logger.Printf("Cleaning up: %s", pattern)
+			return g.FSWrite.GlobRemoveAll(pattern)
+		})
+	}
+}
+
+// prepareJobs validates the config, and returns the mocks to generate for it.
+func prepareJobs(config *ensurefile.Config) ([]*generateJob, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	mockDestinations, err := computeMockDestinations(config)
+	if err != nil {
+		return nil, err
+	}
+
+	mockDestinations, err = mockDestinations.filter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := parseTemplates(config.Mocks.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*generateJob, 0, len(mockDestinations))
+	for _, mockDestination := range mockDestinations {
+		jobs = append(jobs, &generateJob{destination: mockDestination, templates: templates})
+	}
+
+	return jobs, nil
+}
+
+func uniqueJobPWDs(jobs []*generateJob) []string {
+	destinations := make(mockDestinations, 0, len(jobs))
+	for _, job := range jobs {
+		destinations = append(destinations, job.destination)
+	}
+
+	return destinations.uniquePWDs()
+}
+
 type generateMockAsyncParams struct {
 	wg       sync.WaitGroup
 	errors   error
 	errorsMu sync.Mutex
+	failed   int
 }
 
-func (g *MockGen) generateMockAsync(ctx context.Context, mockDestination *mockDestination, asyncParams *generateMockAsyncParams) {
+func (g *MockGen) generateMockAsync(ctx context.Context, job *generateJob, asyncParams *generateMockAsyncParams) {
 	defer asyncParams.wg.Done()
 
-	if err := g.generateMock(ctx, mockDestination); err != nil {
-		asyncParams.addError(err)
-		return
+	if ok := g.generateAndReportMock(ctx, job, asyncParams); ok {
+		g.Logger.Printf(" - Generated: %s\n", job.destination.Package.String())
 	}
-
-	g.Logger.Printf(" - Generated: %s\n", mockDestination.Package.String())
 }
 
-func (g *MockGen) generateMock(ctx context.Context, mockDestination *mockDestination) error {
-	pkg := mockDestination.Package
+// generateAndReportMock generates the mock, reporting its progress. It returns true if the mock was generated.
+func (g *MockGen) generateAndReportMock(ctx context.Context, job *generateJob, asyncParams *generateMockAsyncParams) bool {
+	pkg := job.destination.Package.String()
+	g.report(&report.Event{Type: report.EventPackageStarted, Package: pkg})
 
-	if pkg.Path == "" {
-		return ErrMissingPackagePath
+	if err := g.generateMock(ctx, job.destination, job.templates); err != nil {
+		asyncParams.addError(err)
+		g.report(&report.Event{Type: report.EventPackageFailed, Package: pkg, Error: report.NewError(err)})
+		return false
 	}
 
-	if len(pkg.Interfaces) < 1 {
-		return erk.WithParams(ErrMissingPackageInterfaces, erk.Params{
-			"packagePath": pkg.Path,
-		})
-	}
+	g.report(&report.Event{Type: report.EventPackageGenerated, Package: pkg, Path: job.destination.fullPath()})
+	return true
+}
 
-	result, err := g.CmdRun.Exec(ctx, &runcmd.ExecParams{
-		PWD: mockDestination.PWD,
-		CMD: "mockgen", // TODO: Allow overriding
-		Args: []string{
-			pkg.Path,
-			strings.Join(pkg.Interfaces, ","),
-		},
-	})
+func (g *MockGen) generateMock(ctx context.Context, mockDestination *mockDestination, templates []*mockTemplate) error {
+	result, err := g.renderMock(ctx, mockDestination, templates)
 	if err != nil {
-		return erk.WrapWith(ErrMockGenFailed, err, erk.Params{
-			"packageDescription": pkg.String(),
-		})
+		return err
 	}
 
-	result += createNEWMethods(pkg.Interfaces)
-
 	mockFilePath := mockDestination.fullPath()
 	mockDirPath := filepath.Dir(mockFilePath)
 
@@ -174,28 +261,83 @@ func (g *MockGen) generateMock(ctx context.Context, mockDestination *mockDestina
 	return nil
 }
 
+// renderMock runs mockgen for the package, and returns the contents of the mock file.
+func (g *MockGen) renderMock(ctx context.Context, mockDestination *mockDestination, templates []*mockTemplate) (string, error) {
+	pkg := mockDestination.Package
+
+	if pkg.Path == "" {
+		return "", erk.WithParams(ErrMissingPackagePath, erk.Params{
+			"source": mockDestination.Source,
+		})
+	}
+
+	if len(pkg.Interfaces) < 1 {
+		return "", erk.WithParams(ErrMissingPackageInterfaces, erk.Params{
+			"packagePath": pkg.Path,
+			"source":      mockDestination.Source,
+		})
+	}
+
+	result, err := g.CmdRun.Exec(ctx, &runcmd.ExecParams{
+		PWD:  mockDestination.PWD,
+		CMD:  "mockgen", // TODO: Allow overriding
+		Args: mockgenArgs(pkg),
+	})
+	if err != nil {
+		return "", erk.WrapWith(ErrMockGenFailed, err, erk.Params{
+			"packageDescription": pkg.String(),
+		})
+	}
+
+	if mockDestination.Typed {
+		result, err = typeMockSource(result, pkg)
+		if err != nil {
+			return "", erk.WrapWith(ErrCannotParseMock, err, erk.Params{
+				"packageDescription": pkg.String(),
+			})
+		}
+	}
+
+	renderedTemplates, err := renderTemplates(templates, mockDestination, result)
+	if err != nil {
+		return "", err
+	}
+
+	return result + createNEWMethods(pkg) + renderedTemplates, nil
+}
+
 func (asyncParams *generateMockAsyncParams) addError(err error) {
+	asyncParams.errorsMu.Lock()
+	defer asyncParams.errorsMu.Unlock()
+	asyncParams.failed++
+
 	if errors.Is(err, runcmd.ErrProcessTerminated) {
 		// If the process was terminated, ignore the error
 		return
 	}
 
-	asyncParams.errorsMu.Lock()
-	defer asyncParams.errorsMu.Unlock()
 	asyncParams.errors = erg.Append(asyncParams.errors, err)
 }
 
+func (g *MockGen) report(event *report.Event) {
+	if g.Reporter != nil {
+		g.Reporter.Report(event)
+	}
+}
+
 func validateConfig(config *ensurefile.Config) error {
+	if config.Mocks == nil && len(config.Annotations) > 0 {
+		config.Mocks = &ensurefile.MockConfig{} // Annotations can be used without listing any packages
+	}
+
 	if config.Mocks == nil {
 		return ErrMissingMockConfig
 	}
 
-	if config.Mocks.PrimaryDestination == "" {
-		config.Mocks.PrimaryDestination = defaultPrimaryDestination
-	}
+	applyDefaults(config.Mocks)
 
-	if config.Mocks.InternalDestination == "" {
-		config.Mocks.InternalDestination = defaultInternalDestination
+	if err := mergeAnnotations(config); err != nil {
+		return err
 	}
 
 	packages := config.Mocks.Packages
@@ -204,30 +346,75 @@ func validateConfig(config *ensurefile.Config) error {
 	}
 
 	// Ensure no duplicate package paths, since the last one would overwrite the first
-	packagePaths := map[string]bool{}
+	packagePaths := map[string]*ensurefile.Package{}
 	for _, pkg := range packages {
-		if _, ok := packagePaths[pkg.Path]; ok {
+		if otherPkg, ok := packagePaths[pkg.Path]; ok {
 			return erk.WithParams(ErrDuplicatePackagePath, erk.Params{
 				"packagePath": pkg.Path,
+				"source":      packageSource(config, otherPkg),
+				"otherSource": packageSource(config, pkg),
 			})
 		}
 
-		packagePaths[pkg.Path] = true
+		packagePaths[pkg.Path] = pkg
 	}
 
-	return nil
+	return checkMockNames(config)
+}
+
+// packageSource describes where the package is configured, such as /my/app/team/.ensure.yml:4.
+// Packages are listed in the root .ensure.yml file, unless a nested file or annotation added them.
+func packageSource(config *ensurefile.Config, pkg *ensurefile.Package) string {
+	file := pkg.File
+	if file == "" {
+		file = config.ConfigPath
+	}
+
+	if pkg.Line > 0 {
+		return fmt.Sprintf("%s:%d", file, pkg.Line)
+	}
+
+	return file
+}
+
+func applyDefaults(mocks *ensurefile.MockConfig) {
+	if mocks.PrimaryDestination == "" {
+		mocks.PrimaryDestination = defaultPrimaryDestination
+	}
+
+	if mocks.InternalDestination == "" {
+		mocks.InternalDestination = defaultInternalDestination
+	}
+}
+
+// mockgenArgs for generating the package's mocks in reflect mode.
+func mockgenArgs(pkg *ensurefile.Package) []string {
+	mockNames := []string{}
+	for _, iface := range pkg.Interfaces {
+		if mockName, ok := pkg.MockNames[iface]; ok {
+			mockNames = append(mockNames, iface+"="+mockName)
+		}
+	}
+
+	args := []string{}
+	if len(mockNames) > 0 {
+		args = append(args, "-mock_names", strings.Join(mockNames, ","))
+	}
+
+	return append(args, pkg.Path, strings.Join(pkg.Interfaces, ","))
 }
 
-func createNEWMethods(interfaces []string) string {
+func createNEWMethods(pkg *ensurefile.Package) string {
 	str := ""
 
-	for _, iface := range interfaces {
+	for _, iface := range pkg.Interfaces {
+		mockName := pkg.MockName(iface)
 		str += fmt.Sprintf(
-			"\n// NEW creates a Mock%s.\n"+
-				"func (*Mock%s) NEW(ctrl *gomock.Controller) *Mock%s {\n"+
-				"\treturn NewMock%s(ctrl)\n"+
+			"\n// NEW creates a %s.\n"+
+				"func (*%s) NEW(ctrl *gomock.Controller) *%s {\n"+
+				"\treturn New%s(ctrl)\n"+
 				"}\n",
-			iface, iface, iface, iface,
+			mockName, mockName, mockName, mockName,
 		)
 	}
 
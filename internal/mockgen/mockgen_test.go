@@ -14,7 +14,9 @@ import (
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_context"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_exitcleanup"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
 	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
 	"github.com/JosiahWitt/ensure/ensurepkg"
 	"github.com/golang/mock/gomock"
@@ -25,9 +27,69 @@ const (
 	expectedFilePerm = os.FileMode(0664)
 )
 
+const exampleMockSource = `// Code generated by MockGen. DO NOT EDIT.
+
+package mock_abc
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+type MockIface1 struct {
+	ctrl     *gomock.Controller
+	recorder *MockIface1MockRecorder
+}
+
+type MockIface1MockRecorder struct {
+	mock *MockIface1
+}
+
+func NewMockIface1(ctrl *gomock.Controller) *MockIface1 {
+	mock := &MockIface1{ctrl: ctrl}
+	mock.recorder = &MockIface1MockRecorder{mock}
+	return mock
+}
+
+func (m *MockIface1) EXPECT() *MockIface1MockRecorder {
+	return m.recorder
+}
+
+func (m *MockIface1) Find(arg0 string, arg1 ...int) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Find", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *MockIface1MockRecorder) Find(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockIface1)(nil).Find), varargs...)
+}
+
+func (m *MockIface1) Reset() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Reset")
+}
+
+func (mr *MockIface1MockRecorder) Reset() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockIface1)(nil).Reset))
+}
+`
+
 func TestGenerateMocks(t *testing.T) {
 	ensure := ensure.New(t)
 
+	typedFalse := false
+
 	type Mocks struct {
 		Context *mock_context.MockContext `ensure:"ignoreunused"`
 		CmdRun  *mock_runcmd.MockRunnerIface
@@ -263,6 +325,61 @@ func (*MockIface1) NEW(ctrl *gomock.Controller) *MockIface1 {
 			},
 		},
 
+		{
+			Name: "with custom mock names",
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1", "Iface2"},
+							MockNames:  map[string]string{"Iface2": "FakeIface2"},
+						},
+					},
+				},
+				Annotations: []*ensurefile.Annotation{
+					{PackagePath: "github.com/some/pkg/abc", Interface: "Iface1", MockName: "StubIface1"},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				const expectedMockFile1 = `<abc mock stuff here>
+
+// NEW creates a StubIface1.
+func (*StubIface1) NEW(ctrl *gomock.Controller) *StubIface1 {
+	return NewStubIface1(ctrl)
+}
+
+// NEW creates a FakeIface2.
+func (*FakeIface2) NEW(ctrl *gomock.Controller) *FakeIface2 {
+	return NewFakeIface2(ctrl)
+}
+`
+
+				return []*gomock.Call{
+					m.CmdRun.EXPECT().Exec(m.Context, &runcmd.ExecParams{
+						PWD:  "/root/path",
+						CMD:  "mockgen",
+						Args: []string{"-mock_names", "Iface1=StubIface1,Iface2=FakeIface2", "github.com/some/pkg/abc", "Iface1,Iface2"},
+					}).Return("<abc mock stuff here>\n", nil),
+
+					m.FSWrite.EXPECT().
+						MkdirAll("/root/path/internal/mocks/github.com/some/pkg/mock_abc", expectedDirPerm).
+						Return(nil),
+
+					m.FSWrite.EXPECT().
+						WriteFile(
+							"/root/path/internal/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+							expectedMockFile1,
+							expectedFilePerm,
+						).
+						Return(nil),
+				}
+			},
+		},
+
 		{
 			Name: "with simple valid config: default internalDestination",
 			Config: &ensurefile.Config{
@@ -310,6 +427,423 @@ func (*MockIface1) NEW(ctrl *gomock.Controller) *MockIface1 {
 			},
 		},
 
+		{
+			Name: "with package filters",
+			Config: &ensurefile.Config{
+				RootPath:       "/root/path",
+				ModulePath:     "github.com/my/mod",
+				PackageFilters: []string{"store/*", "github.com/my/mod/cache:*3", "github.com/other/*:Iface1"},
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/my/mod/store/users",
+							Interfaces: []string{"Iface1"},
+						},
+						{
+							Path:       "github.com/my/mod/cache",
+							Interfaces: []string{"Iface2", "Iface3"},
+						},
+						{
+							Path:       "github.com/other/pkg",
+							Interfaces: []string{"Iface2"},
+						},
+						{
+							Path:       "github.com/my/mod/store",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					// Package 1
+
+					m.CmdRun.EXPECT().Exec(m.Context, &runcmd.ExecParams{
+						PWD:  "/root/path",
+						CMD:  "mockgen",
+						Args: []string{"github.com/my/mod/store/users", "Iface1"},
+					}).Return("<users mock stuff here>\n", nil),
+
+					m.FSWrite.EXPECT().
+						MkdirAll("/root/path/internal/mocks/github.com/my/mod/store/mock_users", expectedDirPerm).
+						Return(nil),
+
+					m.FSWrite.EXPECT().
+						WriteFile("/root/path/internal/mocks/github.com/my/mod/store/mock_users/mock_users.go", gomock.Any(), expectedFilePerm).
+						Return(nil),
+
+					// Package 2
+
+					m.CmdRun.EXPECT().Exec(m.Context, &runcmd.ExecParams{
+						PWD:  "/root/path",
+						CMD:  "mockgen",
+						Args: []string{"github.com/my/mod/cache", "Iface2,Iface3"},
+					}).Return("<cache mock stuff here>\n", nil),
+
+					m.FSWrite.EXPECT().
+						MkdirAll("/root/path/internal/mocks/github.com/my/mod/mock_cache", expectedDirPerm).
+						Return(nil),
+
+					m.FSWrite.EXPECT().
+						WriteFile("/root/path/internal/mocks/github.com/my/mod/mock_cache/mock_cache.go", gomock.Any(), expectedFilePerm).
+						Return(nil),
+				}
+			},
+		},
+
+		{
+			Name: "with templates",
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Templates: []*ensurefile.Template{
+						{
+							Path:     "package.tmpl",
+							Contents: "// {{.MockPackageName}} mocks {{.PackagePath}}\n",
+						},
+						{
+							Path:  "interface.tmpl",
+							Scope: "interface",
+							Contents: "{{range .Interface.Methods}}" +
+								"// {{$.Interface.MockName}}.{{.Name}}{{.Signature}} forwards ({{.Args}})\n" +
+								"{{end}}",
+						},
+					},
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				const expectedMockFile = exampleMockSource + `
+// NEW creates a MockIface1.
+func (*MockIface1) NEW(ctrl *gomock.Controller) *MockIface1 {
+	return NewMockIface1(ctrl)
+}
+
+// mock_abc mocks github.com/some/pkg/abc
+
+// MockIface1.Find(arg0 string, arg1 ...int) (bool, error) forwards (arg0, arg1...)
+// MockIface1.Reset() forwards ()
+`
+
+				return []*gomock.Call{
+					m.CmdRun.EXPECT().Exec(m.Context, &runcmd.ExecParams{
+						PWD:  "/root/path",
+						CMD:  "mockgen",
+						Args: []string{"github.com/some/pkg/abc", "Iface1"},
+					}).Return(exampleMockSource, nil),
+
+					m.FSWrite.EXPECT().
+						MkdirAll("/root/path/internal/mocks/github.com/some/pkg/mock_abc", expectedDirPerm).
+						Return(nil),
+
+					m.FSWrite.EXPECT().
+						WriteFile(
+							"/root/path/internal/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+							expectedMockFile,
+							expectedFilePerm,
+						).
+						Return(nil),
+				}
+			},
+		},
+
+		{
+			Name: "with typed mocks",
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Typed: true,
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+						{
+							Path:       "github.com/some/pkg/xyz",
+							Interfaces: []string{"Iface1"},
+							Typed:      &typedFalse,
+						},
+					},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				const expectedNEWMethod = `
+// NEW creates a MockIface1.
+func (*MockIface1) NEW(ctrl *gomock.Controller) *MockIface1 {
+	return NewMockIface1(ctrl)
+}
+`
+
+				const expectedTypedMockFile = `// Code generated by MockGen. DO NOT EDIT.
+
+package mock_abc
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+type MockIface1 struct {
+	ctrl     *gomock.Controller
+	recorder *MockIface1MockRecorder
+}
+
+type MockIface1MockRecorder struct {
+	mock *MockIface1
+}
+
+func NewMockIface1(ctrl *gomock.Controller) *MockIface1 {
+	mock := &MockIface1{ctrl: ctrl}
+	mock.recorder = &MockIface1MockRecorder{mock}
+	return mock
+}
+
+func (m *MockIface1) EXPECT() *MockIface1MockRecorder {
+	return m.recorder
+}
+
+func (m *MockIface1) Find(arg0 string, arg1 ...int) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Find", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *MockIface1MockRecorder) Find(arg0 interface{}, arg1 ...interface{}) *MockIface1FindCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return &MockIface1FindCall{Call: mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockIface1)(nil).Find), varargs...)}
+}
+
+func (m *MockIface1) Reset() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Reset")
+}
+
+func (mr *MockIface1MockRecorder) Reset() *MockIface1ResetCall {
+	mr.mock.ctrl.T.Helper()
+	return &MockIface1ResetCall{Call: mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockIface1)(nil).Reset))}
+}
+
+// MockIface1FindCall wraps *gomock.Call with methods typed for MockIface1.Find.
+type MockIface1FindCall struct {
+	*gomock.Call
+}
+
+// Return rewrites *gomock.Call.Return.
+func (c *MockIface1FindCall) Return(arg0 bool, arg1 error) *MockIface1FindCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrites *gomock.Call.Do.
+func (c *MockIface1FindCall) Do(f func(string, ...int) (bool, error)) *MockIface1FindCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrites *gomock.Call.DoAndReturn.
+func (c *MockIface1FindCall) DoAndReturn(f func(string, ...int) (bool, error)) *MockIface1FindCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockIface1ResetCall wraps *gomock.Call with methods typed for MockIface1.Reset.
+type MockIface1ResetCall struct {
+	*gomock.Call
+}
+
+// Return rewrites *gomock.Call.Return.
+func (c *MockIface1ResetCall) Return() *MockIface1ResetCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrites *gomock.Call.Do.
+func (c *MockIface1ResetCall) Do(f func()) *MockIface1ResetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrites *gomock.Call.DoAndReturn.
+func (c *MockIface1ResetCall) DoAndReturn(f func()) *MockIface1ResetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+` + expectedNEWMethod
+
+				return []*gomock.Call{
+					// Package 1
+
+					m.CmdRun.EXPECT().Exec(m.Context, &runcmd.ExecParams{
+						PWD:  "/root/path",
+						CMD:  "mockgen",
+						Args: []string{"github.com/some/pkg/abc", "Iface1"},
+					}).Return(exampleMockSource, nil),
+
+					m.FSWrite.EXPECT().
+						MkdirAll("/root/path/internal/mocks/github.com/some/pkg/mock_abc", expectedDirPerm).
+						Return(nil),
+
+					m.FSWrite.EXPECT().
+						WriteFile(
+							"/root/path/internal/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+							expectedTypedMockFile,
+							expectedFilePerm,
+						).
+						Return(nil),
+
+					// Package 2
+
+					m.CmdRun.EXPECT().Exec(m.Context, &runcmd.ExecParams{
+						PWD:  "/root/path",
+						CMD:  "mockgen",
+						Args: []string{"github.com/some/pkg/xyz", "Iface1"},
+					}).Return(exampleMockSource, nil),
+
+					m.FSWrite.EXPECT().
+						MkdirAll("/root/path/internal/mocks/github.com/some/pkg/mock_xyz", expectedDirPerm).
+						Return(nil),
+
+					m.FSWrite.EXPECT().
+						WriteFile(
+							"/root/path/internal/mocks/github.com/some/pkg/mock_xyz/mock_xyz.go",
+							exampleMockSource+expectedNEWMethod,
+							expectedFilePerm,
+						).
+						Return(nil),
+				}
+			},
+		},
+
+		{
+			Name:          "when template has invalid scope",
+			ExpectedError: mockgen.ErrInvalidTemplateScope,
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Templates: []*ensurefile.Template{
+						{
+							Path:  "invalid.tmpl",
+							Scope: "method",
+						},
+					},
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name:          "when template cannot be parsed",
+			ExpectedError: mockgen.ErrCannotParseTemplate,
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Templates: []*ensurefile.Template{
+						{
+							Path:     "invalid.tmpl",
+							Contents: "{{.Unclosed",
+						},
+					},
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name:          "when template cannot be executed",
+			ExpectedError: mockgen.ErrCannotExecuteTemplate,
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Templates: []*ensurefile.Template{
+						{
+							Path:     "invalid.tmpl",
+							Contents: "{{.DoesNotExist}}",
+						},
+					},
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.CmdRun.EXPECT().Exec(m.Context, &runcmd.ExecParams{
+						PWD:  "/root/path",
+						CMD:  "mockgen",
+						Args: []string{"github.com/some/pkg/abc", "Iface1"},
+					}).Return(exampleMockSource, nil),
+				}
+			},
+		},
+
+		{
+			Name:          "when mock cannot be parsed for templates",
+			ExpectedError: mockgen.ErrCannotParseMock,
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Templates: []*ensurefile.Template{
+						{
+							Path:     "package.tmpl",
+							Contents: "{{.PackagePath}}",
+						},
+					},
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.CmdRun.EXPECT().Exec(m.Context, &runcmd.ExecParams{
+						PWD:  "/root/path",
+						CMD:  "mockgen",
+						Args: []string{"github.com/some/pkg/abc", "Iface1"},
+					}).Return("<abc mock stuff here>\n", nil),
+				}
+			},
+		},
+
 		{
 			Name:          "when unable to run mockgen",
 			ExpectedError: mockgen.ErrMockGenFailed,
@@ -551,6 +1085,42 @@ func (*MockIface1) NEW(ctrl *gomock.Controller) *MockIface1 {
 			},
 		},
 
+		{
+			Name:          "when no packages match the package filters",
+			ExpectedError: mockgen.ErrNoPackagesMatchFilters,
+			Config: &ensurefile.Config{
+				RootPath:       "/root/path",
+				ModulePath:     "github.com/my/mod",
+				PackageFilters: []string{"store/*"},
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/my/mod/cache",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name:          "when package filter is invalid",
+			ExpectedError: mockgen.ErrInvalidPackageFilter,
+			Config: &ensurefile.Config{
+				RootPath:       "/root/path",
+				ModulePath:     "github.com/my/mod",
+				PackageFilters: []string{"store/["},
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/my/mod/store/users",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+		},
+
 		{
 			Name:          "when internal package is outside module",
 			ExpectedError: mockgen.ErrInternalPackageOutsideModule,
@@ -669,4 +1239,197 @@ func (*MockIface1) NEW(ctrl *gomock.Controller) *MockIface1 {
 			ensure(err1).IsError(exampleErr)
 		}
 	})
+
+	ensure.Run("reporting events", func(ensure ensurepkg.Ensure) {
+		mockFSWrite := mock_fswrite.NewMockFSWriteIface(ensure.GoMockController())
+		mockRunCmd := mock_runcmd.NewMockRunnerIface(ensure.GoMockController())
+		mockExitCleanup := mock_exitcleanup.NewMockExitCleaner(ensure.GoMockController())
+		mockReporter := mock_report.NewMockReporterIface(ensure.GoMockController())
+
+		gen := mockgen.MockGen{
+			Logger:   log.New(ioutil.Discard, "", 0),
+			CmdRun:   mockRunCmd,
+			FSWrite:  mockFSWrite,
+			Cleanup:  mockExitCleanup,
+			Reporter: mockReporter,
+		}
+
+		ctx := context.Background()
+		exampleErr := errors.New("example error")
+		mockExitCleanup.EXPECT().Register(gomock.Any()).AnyTimes()
+		mockFSWrite.EXPECT().MkdirAll(gomock.Any(), gomock.Any()).AnyTimes()
+		mockFSWrite.EXPECT().WriteFile(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+		gomock.InOrder(
+			mockReporter.EXPECT().Report(&report.Event{
+				Type:    report.EventPackageStarted,
+				Package: "github.com/some/pkg/abc:Iface1",
+			}),
+			mockRunCmd.EXPECT().Exec(ctx, gomock.Any()).Return("<abc mock stuff here>\n", nil),
+			mockReporter.EXPECT().Report(&report.Event{
+				Type:    report.EventPackageGenerated,
+				Package: "github.com/some/pkg/abc:Iface1",
+				Path:    "/root/path/internal/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+			}),
+
+			mockReporter.EXPECT().Report(&report.Event{
+				Type:    report.EventPackageStarted,
+				Package: "github.com/some/pkg/xyz:Iface2",
+			}),
+			mockRunCmd.EXPECT().Exec(ctx, gomock.Any()).Return("", exampleErr),
+			mockReporter.EXPECT().Report(gomock.Any()).Do(func(event *report.Event) {
+				ensure(event.Type).Equals(report.EventPackageFailed)
+				ensure(event.Package).Equals("github.com/some/pkg/xyz:Iface2")
+				ensure(event.Error.Params["err"]).Equals("example error")
+			}),
+
+			mockReporter.EXPECT().Report(&report.Event{
+				Type: report.EventSummary,
+				Summary: &report.Summary{
+					Generated: 1,
+					Failed:    1,
+				},
+			}),
+		)
+
+		err := gen.GenerateMocks(ctx,
+			&ensurefile.Config{
+				RootPath:                  "/root/path",
+				ModulePath:                "github.com/my/mod",
+				DisableParallelGeneration: true,
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+						{
+							Path:       "github.com/some/pkg/xyz",
+							Interfaces: []string{"Iface2"},
+						},
+					},
+				},
+			},
+		)
+		ensure(err).IsError(mockgen.ErrMultipleGenerationFailures)
+	})
+}
+
+func TestGenerateAllMocks(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Context *mock_context.MockContext `ensure:"ignoreunused"`
+		CmdRun  *mock_runcmd.MockRunnerIface
+		FSWrite *mock_fswrite.MockFSWriteIface
+		Cleanup *mock_exitcleanup.MockExitCleaner
+	}
+
+	newConfigs := func(filters ...string) []*ensurefile.Config {
+		return []*ensurefile.Config{
+			{
+				RootPath:                  "/repo/api",
+				ModulePath:                "github.com/my/api",
+				PackageFilters:            filters,
+				DisableParallelGeneration: true,
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{Path: "github.com/my/api/internal/store", Interfaces: []string{"Store"}},
+					},
+				},
+			},
+			{
+				RootPath:       "/repo/worker",
+				ModulePath:     "github.com/my/worker",
+				PackageFilters: filters,
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{Path: "github.com/my/worker/internal/queue", Interfaces: []string{"Queue"}},
+					},
+				},
+			},
+		}
+	}
+
+	expectGenerated := func(m *Mocks, pwd, pkgPath, iface, mockFilePath string) []*gomock.Call {
+		return []*gomock.Call{
+			m.CmdRun.EXPECT().Exec(m.Context, &runcmd.ExecParams{
+				PWD:  pwd,
+				CMD:  "mockgen",
+				Args: []string{pkgPath, iface},
+			}).Return("<mock stuff here>\n", nil),
+
+			m.FSWrite.EXPECT().MkdirAll(gomock.Any(), expectedDirPerm).Return(nil),
+			m.FSWrite.EXPECT().WriteFile(mockFilePath, gomock.Any(), expectedFilePerm).Return(nil),
+		}
+	}
+
+	table := []struct {
+		Name          string
+		Configs       []*ensurefile.Config
+		ExpectedError error
+
+		Mocks         *Mocks
+		AssembleMocks func(*Mocks) []*gomock.Call
+		Subject       *mockgen.MockGen
+	}{
+		{
+			Name:    "with multiple modules",
+			Configs: newConfigs(),
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return append(
+					expectGenerated(m, "/repo/api", "github.com/my/api/internal/store", "Store",
+						"/repo/api/internal/mocks/mock_store/mock_store.go",
+					),
+					expectGenerated(m, "/repo/worker", "github.com/my/worker/internal/queue", "Queue",
+						"/repo/worker/internal/mocks/mock_queue/mock_queue.go",
+					)...,
+				)
+			},
+		},
+		{
+			Name:    "with package filters matching one module",
+			Configs: newConfigs("*/queue"),
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return expectGenerated(m, "/repo/worker", "github.com/my/worker/internal/queue", "Queue",
+					"/repo/worker/internal/mocks/mock_queue/mock_queue.go",
+				)
+			},
+		},
+		{
+			Name: "with package filters matching packages that did not change",
+			Configs: func() []*ensurefile.Config {
+				configs := newConfigs("*/queue")
+				configs[1].ChangedPackages = []string{}
+				return configs
+			}(),
+		},
+		{
+			Name:          "when package filters match no modules",
+			Configs:       newConfigs("nothing"),
+			ExpectedError: mockgen.ErrNoPackagesMatchFilters,
+		},
+		{
+			Name: "when a module has an invalid config",
+			Configs: append(newConfigs(), &ensurefile.Config{
+				RootPath:   "/repo/other",
+				ModulePath: "github.com/my/other",
+				Mocks:      &ensurefile.MockConfig{},
+			}),
+			ExpectedError: mockgen.ErrMissingPackages,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+		entry.Mocks.Cleanup.EXPECT().Register(gomock.Any()).AnyTimes()
+
+		if entry.AssembleMocks != nil {
+			gomock.InOrder(entry.AssembleMocks(entry.Mocks)...)
+		}
+
+		err := entry.Subject.GenerateAllMocks(entry.Mocks.Context, entry.Configs)
+		ensure(err).IsError(entry.ExpectedError)
+	})
 }
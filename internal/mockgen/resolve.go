@@ -0,0 +1,97 @@
+package mockgen
+
+import (
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+)
+
+// ResolvedConfig is the effective config used to generate mocks, after defaults are applied.
+type ResolvedConfig struct {
+	RootPath            string             `yaml:"rootPath" json:"rootPath"`
+	ModulePath          string             `yaml:"modulePath" json:"modulePath"`
+	ConfigPath          string             `yaml:"configPath" json:"configPath"`
+	PrimaryDestination  string             `yaml:"primaryDestination" json:"primaryDestination"`
+	InternalDestination string             `yaml:"internalDestination" json:"internalDestination"`
+	TidyAfterGenerate   bool               `yaml:"tidyAfterGenerate" json:"tidyAfterGenerate"`
+	Typed               bool               `yaml:"typed" json:"typed"`
+	Templates           []string           `yaml:"templates,omitempty" json:"templates,omitempty"`
+	Sources             map[string]string  `yaml:"sources" json:"sources"` // Where each overridable field came from, such as the config file or a flag
+	Packages            []*ResolvedPackage `yaml:"packages" json:"packages"`
+}
+
+// ResolvedPackage describes where the mocks for a package are generated.
+type ResolvedPackage struct {
+	Path            string               `yaml:"path" json:"path"`
+	PWD             string               `yaml:"pwd" json:"pwd"`
+	MockPackageName string               `yaml:"mockPackageName" json:"mockPackageName"`
+	ImportPath      string               `yaml:"importPath" json:"importPath"`
+	FilePath        string               `yaml:"filePath" json:"filePath"`
+	Typed           bool                 `yaml:"typed" json:"typed"`
+	Interfaces      []*ResolvedInterface `yaml:"interfaces" json:"interfaces"`
+}
+
+// ResolvedInterface describes the mock generated for an interface.
+type ResolvedInterface struct {
+	Name     string `yaml:"name" json:"name"`
+	MockName string `yaml:"mockName" json:"mockName"`
+}
+
+// ResolveConfig applies the defaults to the config, and computes the destination of each mock.
+// Only packages matching the config's package filters are included.
+func ResolveConfig(config *ensurefile.Config) (*ResolvedConfig, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	mockDestinations, err := computeMockDestinations(config)
+	if err != nil {
+		return nil, err
+	}
+
+	mockDestinations, err = mockDestinations.filter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &ResolvedConfig{
+		RootPath:            config.RootPath,
+		ModulePath:          config.ModulePath,
+		ConfigPath:          config.ConfigPath,
+		PrimaryDestination:  config.Mocks.PrimaryDestination,
+		InternalDestination: config.Mocks.InternalDestination,
+		TidyAfterGenerate:   config.Mocks.TidyAfterGenerate,
+		Typed:               config.Mocks.Typed,
+		Sources:             map[string]string{},
+		Packages:            make([]*ResolvedPackage, 0, len(mockDestinations)),
+	}
+
+	for _, field := range ensurefile.OverridableFields() {
+		resolved.Sources[field.Key] = config.Source(field.Key)
+	}
+
+	for _, template := range config.Mocks.Templates {
+		resolved.Templates = append(resolved.Templates, template.Path)
+	}
+
+	for _, mockDestination := range mockDestinations {
+		resolvedPackage := &ResolvedPackage{
+			Path:            mockDestination.Package.Path,
+			PWD:             mockDestination.PWD,
+			MockPackageName: mockDestination.mockPackageName(),
+			ImportPath:      mockDestination.importPath(config),
+			FilePath:        mockDestination.fullPath(),
+			Typed:           mockDestination.Typed,
+			Interfaces:      make([]*ResolvedInterface, 0, len(mockDestination.Package.Interfaces)),
+		}
+
+		for _, iface := range mockDestination.Package.Interfaces {
+			resolvedPackage.Interfaces = append(resolvedPackage.Interfaces, &ResolvedInterface{
+				Name:     iface,
+				MockName: mockDestination.Package.MockName(iface),
+			})
+		}
+
+		resolved.Packages = append(resolved.Packages, resolvedPackage)
+	}
+
+	return resolved, nil
+}
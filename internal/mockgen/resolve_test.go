@@ -0,0 +1,466 @@
+package mockgen_test
+
+import (
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestResolveConfig(t *testing.T) {
+	ensure := ensure.New(t)
+
+	typedFalse := false
+
+	defaultSources := map[string]string{
+		"mocks.primaryDestination":  ensurefile.SourceDefault,
+		"mocks.internalDestination": ensurefile.SourceDefault,
+		"mocks.tidyAfterGenerate":   ensurefile.SourceDefault,
+		"mocks.typed":               ensurefile.SourceDefault,
+	}
+
+	table := []struct {
+		Name           string
+		Config         *ensurefile.Config
+		ExpectedConfig *mockgen.ResolvedConfig
+		ExpectedError  error
+	}{
+		{
+			Name: "with defaults",
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				ConfigPath: "/root/path/.ensure.yml",
+				Mocks: &ensurefile.MockConfig{
+					TidyAfterGenerate: true,
+					Typed:             true,
+					Templates: []*ensurefile.Template{
+						{Path: "mock_helpers.tmpl"},
+					},
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1", "Iface2"},
+						},
+						{
+							Path:       "github.com/my/mod/layer1/internal/layer2/xyz",
+							Interfaces: []string{"Iface3"},
+							Typed:      &typedFalse,
+						},
+					},
+				},
+			},
+			ExpectedConfig: &mockgen.ResolvedConfig{
+				RootPath:            "/root/path",
+				ModulePath:          "github.com/my/mod",
+				ConfigPath:          "/root/path/.ensure.yml",
+				PrimaryDestination:  "internal/mocks",
+				InternalDestination: "mocks",
+				TidyAfterGenerate:   true,
+				Typed:               true,
+				Templates:           []string{"mock_helpers.tmpl"},
+				Sources:             defaultSources,
+				Packages: []*mockgen.ResolvedPackage{
+					{
+						Path:            "github.com/some/pkg/abc",
+						PWD:             "/root/path",
+						MockPackageName: "mock_abc",
+						ImportPath:      "github.com/my/mod/internal/mocks/github.com/some/pkg/mock_abc",
+						FilePath:        "/root/path/internal/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+						Typed:           true,
+						Interfaces: []*mockgen.ResolvedInterface{
+							{Name: "Iface1", MockName: "MockIface1"},
+							{Name: "Iface2", MockName: "MockIface2"},
+						},
+					},
+					{
+						Path:            "github.com/my/mod/layer1/internal/layer2/xyz",
+						PWD:             "/root/path/layer1",
+						MockPackageName: "mock_xyz",
+						ImportPath:      "github.com/my/mod/layer1/internal/mocks/layer2/mock_xyz",
+						FilePath:        "/root/path/layer1/internal/mocks/layer2/mock_xyz/mock_xyz.go",
+						Typed:           false,
+						Interfaces: []*mockgen.ResolvedInterface{
+							{Name: "Iface3", MockName: "MockIface3"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name: "with package filters",
+			Config: &ensurefile.Config{
+				RootPath:       "/root/path",
+				ModulePath:     "github.com/my/mod",
+				PackageFilters: []string{"github.com/some/*/xyz"},
+				Mocks: &ensurefile.MockConfig{
+					PrimaryDestination:  "primary_mocks",
+					InternalDestination: "internal_mocks",
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+						{
+							Path:       "github.com/some/pkg/xyz",
+							Interfaces: []string{"Iface2"},
+						},
+					},
+				},
+			},
+			ExpectedConfig: &mockgen.ResolvedConfig{
+				RootPath:            "/root/path",
+				ModulePath:          "github.com/my/mod",
+				PrimaryDestination:  "primary_mocks",
+				InternalDestination: "internal_mocks",
+				Sources:             defaultSources,
+				Packages: []*mockgen.ResolvedPackage{
+					{
+						Path:            "github.com/some/pkg/xyz",
+						PWD:             "/root/path",
+						MockPackageName: "mock_xyz",
+						ImportPath:      "github.com/my/mod/primary_mocks/github.com/some/pkg/mock_xyz",
+						FilePath:        "/root/path/primary_mocks/github.com/some/pkg/mock_xyz/mock_xyz.go",
+						Interfaces: []*mockgen.ResolvedInterface{
+							{Name: "Iface2", MockName: "MockIface2"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name: "with annotations",
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/my/mod/abc",
+							Interfaces: []string{"Iface1"},
+							MockNames:  map[string]string{"Iface1": "FakeIface1"},
+						},
+					},
+				},
+				Annotations: []*ensurefile.Annotation{
+					{PackagePath: "github.com/my/mod/abc", Interface: "Iface1", MockName: "FakeIface1"},
+					{PackagePath: "github.com/my/mod/abc", Interface: "Iface2"},
+					{PackagePath: "github.com/my/mod/xyz", Interface: "Iface3", MockName: "StubIface3"},
+				},
+			},
+			ExpectedConfig: &mockgen.ResolvedConfig{
+				RootPath:            "/root/path",
+				ModulePath:          "github.com/my/mod",
+				PrimaryDestination:  "internal/mocks",
+				InternalDestination: "mocks",
+				Sources:             defaultSources,
+				Packages: []*mockgen.ResolvedPackage{
+					{
+						Path:            "github.com/my/mod/abc",
+						PWD:             "/root/path",
+						MockPackageName: "mock_abc",
+						ImportPath:      "github.com/my/mod/internal/mocks/github.com/my/mod/mock_abc",
+						FilePath:        "/root/path/internal/mocks/github.com/my/mod/mock_abc/mock_abc.go",
+						Interfaces: []*mockgen.ResolvedInterface{
+							{Name: "Iface1", MockName: "FakeIface1"},
+							{Name: "Iface2", MockName: "MockIface2"},
+						},
+					},
+					{
+						Path:            "github.com/my/mod/xyz",
+						PWD:             "/root/path",
+						MockPackageName: "mock_xyz",
+						ImportPath:      "github.com/my/mod/internal/mocks/github.com/my/mod/mock_xyz",
+						FilePath:        "/root/path/internal/mocks/github.com/my/mod/mock_xyz/mock_xyz.go",
+						Interfaces: []*mockgen.ResolvedInterface{
+							{Name: "Iface3", MockName: "StubIface3"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name: "with only annotations",
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				Annotations: []*ensurefile.Annotation{
+					{PackagePath: "github.com/my/mod/abc", Interface: "Iface1"},
+				},
+			},
+			ExpectedConfig: &mockgen.ResolvedConfig{
+				RootPath:            "/root/path",
+				ModulePath:          "github.com/my/mod",
+				PrimaryDestination:  "internal/mocks",
+				InternalDestination: "mocks",
+				Sources:             defaultSources,
+				Packages: []*mockgen.ResolvedPackage{
+					{
+						Path:            "github.com/my/mod/abc",
+						PWD:             "/root/path",
+						MockPackageName: "mock_abc",
+						ImportPath:      "github.com/my/mod/internal/mocks/github.com/my/mod/mock_abc",
+						FilePath:        "/root/path/internal/mocks/github.com/my/mod/mock_abc/mock_abc.go",
+						Interfaces: []*mockgen.ResolvedInterface{
+							{Name: "Iface1", MockName: "MockIface1"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name: "with nested config packages",
+			Config: &ensurefile.Config{
+				RootPath:   "/root/path",
+				ModulePath: "github.com/my/mod",
+				ConfigPath: "/root/path/.ensure.yml",
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+						{
+							Path:                "github.com/some/pkg/xyz",
+							Interfaces:          []string{"Iface2"},
+							File:                "/root/path/store/.ensure.yml",
+							PrimaryDestination:  "store/mocks",
+							InternalDestination: "fakes",
+						},
+						{
+							Path:                "github.com/my/mod/store/internal/cache",
+							Interfaces:          []string{"Iface3"},
+							File:                "/root/path/store/.ensure.yml",
+							PrimaryDestination:  "store/mocks",
+							InternalDestination: "fakes",
+						},
+					},
+				},
+			},
+			ExpectedConfig: &mockgen.ResolvedConfig{
+				RootPath:            "/root/path",
+				ModulePath:          "github.com/my/mod",
+				ConfigPath:          "/root/path/.ensure.yml",
+				PrimaryDestination:  "internal/mocks",
+				InternalDestination: "mocks",
+				Sources:             defaultSources,
+				Packages: []*mockgen.ResolvedPackage{
+					{
+						Path:            "github.com/some/pkg/abc",
+						PWD:             "/root/path",
+						MockPackageName: "mock_abc",
+						ImportPath:      "github.com/my/mod/internal/mocks/github.com/some/pkg/mock_abc",
+						FilePath:        "/root/path/internal/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+						Interfaces: []*mockgen.ResolvedInterface{
+							{Name: "Iface1", MockName: "MockIface1"},
+						},
+					},
+					{
+						Path:            "github.com/some/pkg/xyz",
+						PWD:             "/root/path",
+						MockPackageName: "mock_xyz",
+						ImportPath:      "github.com/my/mod/store/mocks/github.com/some/pkg/mock_xyz",
+						FilePath:        "/root/path/store/mocks/github.com/some/pkg/mock_xyz/mock_xyz.go",
+						Interfaces: []*mockgen.ResolvedInterface{
+							{Name: "Iface2", MockName: "MockIface2"},
+						},
+					},
+					{
+						Path:            "github.com/my/mod/store/internal/cache",
+						PWD:             "/root/path/store",
+						MockPackageName: "mock_cache",
+						ImportPath:      "github.com/my/mod/store/internal/fakes/mock_cache",
+						FilePath:        "/root/path/store/internal/fakes/mock_cache/mock_cache.go",
+						Interfaces: []*mockgen.ResolvedInterface{
+							{Name: "Iface3", MockName: "MockIface3"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name: "when package path is duplicated across config files",
+			Config: &ensurefile.Config{
+				ModulePath: "github.com/my/mod",
+				ConfigPath: "/root/path/.ensure.yml",
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+							Line:       4,
+						},
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface2"},
+							File:       "/root/path/store/.ensure.yml",
+							Line:       3,
+						},
+					},
+				},
+			},
+			ExpectedError: mockgen.ErrDuplicatePackagePath,
+		},
+
+		{
+			Name: "when annotation conflicts with mock name",
+			Config: &ensurefile.Config{
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/my/mod/abc",
+							Interfaces: []string{"Iface1"},
+							MockNames:  map[string]string{"Iface1": "FakeIface1"},
+						},
+					},
+				},
+				Annotations: []*ensurefile.Annotation{
+					{PackagePath: "github.com/my/mod/abc", Interface: "Iface1", MockName: "StubIface1"},
+				},
+			},
+			ExpectedError: mockgen.ErrConflictingMockName,
+		},
+
+		{
+			Name: "when mock names are duplicated",
+			Config: &ensurefile.Config{
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1", "Iface2"},
+							MockNames:  map[string]string{"Iface2": "MockIface1"},
+						},
+					},
+				},
+			},
+			ExpectedError: mockgen.ErrDuplicateMockName,
+		},
+
+		{
+			Name:          "when missing mocks",
+			Config:        &ensurefile.Config{},
+			ExpectedError: mockgen.ErrMissingMockConfig,
+		},
+
+		{
+			Name: "when internal package is outside module",
+			Config: &ensurefile.Config{
+				ModulePath: "github.com/my/mod",
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/other/internal/pkg",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+			ExpectedError: mockgen.ErrInternalPackageOutsideModule,
+		},
+
+		{
+			Name: "with changed packages",
+			Config: &ensurefile.Config{
+				RootPath:        "/root/path",
+				ModulePath:      "github.com/my/mod",
+				PackageFilters:  []string{"github.com/some/*/*"},
+				ChangedPackages: []string{"github.com/some/pkg/xyz", "github.com/some/pkg/other"},
+				Mocks: &ensurefile.MockConfig{
+					PrimaryDestination:  "primary_mocks",
+					InternalDestination: "internal_mocks",
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+						{
+							Path:       "github.com/some/pkg/xyz",
+							Interfaces: []string{"Iface2"},
+						},
+					},
+				},
+			},
+			ExpectedConfig: &mockgen.ResolvedConfig{
+				RootPath:            "/root/path",
+				ModulePath:          "github.com/my/mod",
+				PrimaryDestination:  "primary_mocks",
+				InternalDestination: "internal_mocks",
+				Sources:             defaultSources,
+				Packages: []*mockgen.ResolvedPackage{
+					{
+						Path:            "github.com/some/pkg/xyz",
+						PWD:             "/root/path",
+						MockPackageName: "mock_xyz",
+						ImportPath:      "github.com/my/mod/primary_mocks/github.com/some/pkg/mock_xyz",
+						FilePath:        "/root/path/primary_mocks/github.com/some/pkg/mock_xyz/mock_xyz.go",
+						Interfaces: []*mockgen.ResolvedInterface{
+							{Name: "Iface2", MockName: "MockIface2"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name: "without any changed packages",
+			Config: &ensurefile.Config{
+				RootPath:        "/root/path",
+				ModulePath:      "github.com/my/mod",
+				ChangedPackages: []string{},
+				Mocks: &ensurefile.MockConfig{
+					PrimaryDestination:  "primary_mocks",
+					InternalDestination: "internal_mocks",
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+			ExpectedConfig: &mockgen.ResolvedConfig{
+				RootPath:            "/root/path",
+				ModulePath:          "github.com/my/mod",
+				PrimaryDestination:  "primary_mocks",
+				InternalDestination: "internal_mocks",
+				Sources:             defaultSources,
+				Packages:            []*mockgen.ResolvedPackage{},
+			},
+		},
+
+		{
+			Name: "when no packages match the package filters",
+			Config: &ensurefile.Config{
+				ModulePath:     "github.com/my/mod",
+				PackageFilters: []string{"nothing"},
+				Mocks: &ensurefile.MockConfig{
+					Packages: []*ensurefile.Package{
+						{
+							Path:       "github.com/some/pkg/abc",
+							Interfaces: []string{"Iface1"},
+						},
+					},
+				},
+			},
+			ExpectedError: mockgen.ErrNoPackagesMatchFilters,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		resolved, err := mockgen.ResolveConfig(entry.Config)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(resolved).Equals(entry.ExpectedConfig)
+	})
+}
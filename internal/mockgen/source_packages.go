@@ -0,0 +1,69 @@
+package mockgen
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+)
+
+// SourcePackages inverts the destination rules, returning the packages whose mocks would be generated
+// in the mock package with the provided import path. A mock package can be the destination of more than one package,
+// such as when mocks.primaryDestination is within an internal directory.
+// Candidates using the internal destination are returned first. The config does not need to list any packages.
+func SourcePackages(config *ensurefile.Config, mockImportPath string) []string {
+	mocks := ensurefile.MockConfig{}
+	if config.Mocks != nil {
+		mocks = *config.Mocks
+	}
+	applyDefaults(&mocks)
+
+	configCopy := *config
+	configCopy.Mocks = &mocks
+	config = &configCopy
+
+	relativePath := strings.TrimPrefix(mockImportPath, config.ModulePath+"/")
+	if relativePath == mockImportPath {
+		return nil // Mocks are always generated within the module
+	}
+
+	mockPackageName := path.Base(relativePath)
+	if !strings.HasPrefix(mockPackageName, "mock_") {
+		return nil
+	}
+
+	packageName := strings.TrimPrefix(mockPackageName, "mock_")
+	segments := strings.Split(path.Dir(relativePath), "/")
+	candidates := []string{}
+
+	// Internal destinations are at <prefix>/internal/<internalDestination>/<suffix>
+	internalSegments := strings.Split(path.Join("internal", filepath.ToSlash(config.Mocks.InternalDestination)), "/")
+	for i := 0; i+len(internalSegments) <= len(segments); i++ {
+		if strings.Join(segments[i:i+len(internalSegments)], "/") != strings.Join(internalSegments, "/") {
+			continue
+		}
+
+		prefix := segments[:i]
+		suffix := segments[i+len(internalSegments):]
+		candidates = append(candidates, path.Join(config.ModulePath, path.Join(prefix...), "internal", path.Join(suffix...), packageName))
+	}
+
+	// Primary destinations are at <primaryDestination>/<package path>
+	primaryDestination := filepath.ToSlash(config.Mocks.PrimaryDestination)
+	if dir := path.Dir(relativePath); dir == primaryDestination || strings.HasPrefix(dir, primaryDestination+"/") {
+		packageDir := strings.TrimPrefix(strings.TrimPrefix(dir, primaryDestination), "/")
+		candidates = append(candidates, path.Join(packageDir, packageName))
+	}
+
+	// Only keep candidates that round trip, since the last internal directory determines the destination
+	sourcePackages := []string{}
+	for _, candidate := range candidates {
+		dest, err := computeMockDestination(config, &ensurefile.Package{Path: candidate})
+		if err == nil && dest.importPath(config) == mockImportPath {
+			sourcePackages = append(sourcePackages, candidate)
+		}
+	}
+
+	return sourcePackages
+}
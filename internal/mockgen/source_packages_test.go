@@ -0,0 +1,91 @@
+package mockgen_test
+
+import (
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestSourcePackages(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name                   string
+		MockConfig             *ensurefile.MockConfig
+		MockImportPath         string
+		ExpectedSourcePackages []string
+	}{
+		{
+			Name:           "with external package",
+			MockImportPath: "github.com/my/mod/internal/mocks/github.com/some/pkg/mock_abc",
+			ExpectedSourcePackages: []string{
+				"github.com/my/mod/internal/github.com/some/pkg/abc",
+				"github.com/some/pkg/abc",
+			},
+		},
+		{
+			Name:                   "with standard library package",
+			MockConfig:             &ensurefile.MockConfig{PrimaryDestination: "mocks"},
+			MockImportPath:         "github.com/my/mod/mocks/mock_context",
+			ExpectedSourcePackages: []string{"context"},
+		},
+		{
+			Name:           "with package that could be internal or external",
+			MockImportPath: "github.com/my/mod/internal/mocks/mock_store",
+			ExpectedSourcePackages: []string{
+				"github.com/my/mod/internal/store",
+				"store",
+			},
+		},
+		{
+			Name:                   "with nested internal package",
+			MockImportPath:         "github.com/my/mod/layer1/internal/mocks/layer2/mock_qwerty",
+			ExpectedSourcePackages: []string{"github.com/my/mod/layer1/internal/layer2/qwerty"},
+		},
+		{
+			Name:                   "with internal package within internal mocks",
+			MockImportPath:         "github.com/my/mod/a/internal/mocks/b/internal/mocks/mock_x",
+			ExpectedSourcePackages: []string{"github.com/my/mod/a/internal/mocks/b/internal/x"},
+		},
+		{
+			Name: "with custom destinations",
+			MockConfig: &ensurefile.MockConfig{
+				PrimaryDestination:  "fakes",
+				InternalDestination: "doubles",
+			},
+			MockImportPath:         "github.com/my/mod/layer1/internal/doubles/mock_qwerty",
+			ExpectedSourcePackages: []string{"github.com/my/mod/layer1/internal/qwerty"},
+		},
+		{
+			Name:                   "with import path outside module",
+			MockImportPath:         "github.com/other/mod/internal/mocks/mock_store",
+			ExpectedSourcePackages: nil,
+		},
+		{
+			Name:                   "with import path that is not a mock package",
+			MockImportPath:         "github.com/my/mod/internal/mocks/store",
+			ExpectedSourcePackages: nil,
+		},
+		{
+			Name:                   "with import path outside of mock directories",
+			MockImportPath:         "github.com/my/mod/some/mock_store",
+			ExpectedSourcePackages: []string{},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		config := &ensurefile.Config{
+			RootPath:   "/root/path",
+			ModulePath: "github.com/my/mod",
+			Mocks:      entry.MockConfig,
+		}
+
+		ensure(mockgen.SourcePackages(config, entry.MockImportPath)).Equals(entry.ExpectedSourcePackages)
+		ensure(config.Mocks).Equals(entry.MockConfig) // Defaults are not applied to the provided config
+	})
+}
@@ -0,0 +1,134 @@
+package mockgen
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/erk"
+)
+
+const (
+	TemplateScopePackage   = "package"
+	TemplateScopeInterface = "interface"
+)
+
+type ErkTemplateError struct{ erk.DefaultKind }
+
+var (
+	ErrInvalidTemplateScope = erk.New(ErkInvalidConfig{},
+		"Template '{{.templatePath}}' has an invalid scope '{{.scope}}'. It must be either 'package' or 'interface'.",
+	)
+	ErrCannotParseTemplate = erk.New(ErkInvalidConfig{}, "Cannot parse the template '{{.templatePath}}': {{.err}}")
+
+	ErrCannotExecuteTemplate = erk.New(ErkTemplateError{},
+		"Could not execute the template '{{.templatePath}}' for '{{.packageDescription}}': {{.err}}",
+	)
+)
+
+// TemplateData is passed to user-defined templates.
+// Interface is only set when the template scope is "interface".
+type TemplateData struct {
+	PackagePath     string
+	MockPackageName string
+	Interfaces      []*TemplateInterface
+	Interface       *TemplateInterface
+}
+
+type mockTemplate struct {
+	path     string
+	scope    string
+	template *template.Template
+}
+
+func parseTemplates(templates []*ensurefile.Template) ([]*mockTemplate, error) {
+	mockTemplates := make([]*mockTemplate, 0, len(templates))
+
+	for _, t := range templates {
+		scope := t.Scope
+		if scope == "" {
+			scope = TemplateScopePackage
+		}
+
+		if scope != TemplateScopePackage && scope != TemplateScopeInterface {
+			return nil, erk.WithParams(ErrInvalidTemplateScope, erk.Params{
+				"templatePath": t.Path,
+				"scope":        t.Scope,
+			})
+		}
+
+		parsed, err := template.New(t.Path).Parse(t.Contents)
+		if err != nil {
+			return nil, erk.WrapWith(ErrCannotParseTemplate, err, erk.Params{
+				"templatePath": t.Path,
+			})
+		}
+
+		mockTemplates = append(mockTemplates, &mockTemplate{
+			path:     t.Path,
+			scope:    scope,
+			template: parsed,
+		})
+	}
+
+	return mockTemplates, nil
+}
+
+// renderTemplates executes each template against the mock source generated for the destination.
+func renderTemplates(templates []*mockTemplate, mockDestination *mockDestination, source string) (string, error) {
+	if len(templates) == 0 {
+		return "", nil
+	}
+
+	pkg := mockDestination.Package
+	interfaces, err := parseMockSource(source, pkg)
+	if err != nil {
+		return "", erk.WrapWith(ErrCannotParseMock, err, erk.Params{
+			"packageDescription": pkg.String(),
+		})
+	}
+
+	str := ""
+	for _, t := range templates {
+		data := &TemplateData{
+			PackagePath:     pkg.Path,
+			MockPackageName: mockDestination.mockPackageName(),
+			Interfaces:      interfaces,
+		}
+
+		if t.scope == TemplateScopePackage {
+			rendered, err := t.execute(data, pkg)
+			if err != nil {
+				return "", err
+			}
+
+			str += rendered
+			continue
+		}
+
+		for _, iface := range interfaces {
+			data.Interface = iface
+
+			rendered, err := t.execute(data, pkg)
+			if err != nil {
+				return "", err
+			}
+
+			str += rendered
+		}
+	}
+
+	return str, nil
+}
+
+func (t *mockTemplate) execute(data *TemplateData, pkg *ensurefile.Package) (string, error) {
+	var buf bytes.Buffer
+	if err := t.template.Execute(&buf, data); err != nil {
+		return "", erk.WrapWith(ErrCannotExecuteTemplate, err, erk.Params{
+			"templatePath":       t.path,
+			"packageDescription": pkg.String(),
+		})
+	}
+
+	return "\n" + buf.String(), nil
+}
@@ -1,7 +1,10 @@
 package mockgen
 
 import (
+	"sort"
+
 	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
 	"github.com/JosiahWitt/erk"
 )
 
@@ -16,13 +19,43 @@ var (
 
 // TidyMocks removes any files other than those that are expected to exist in the mock directories.
 func (g *MockGen) TidyMocks(config *ensurefile.Config) error {
-	if err := validateConfig(config); err != nil {
+	pathsToDelete, err := g.TidyPaths(config)
+	if err != nil {
 		return err
 	}
 
+	if len(pathsToDelete) > 0 {
+		g.Logger.Println("Tidying mocks:")
+		for _, pathToDelete := range pathsToDelete {
+			g.Logger.Printf(" - Removing: %s\n", pathToDelete)
+
+			if err := g.FSWrite.RemoveAll(pathToDelete); err != nil {
+				return erk.WrapWith(ErrTidyUnableToCleanup, err, erk.Params{
+					"path": pathToDelete,
+				})
+			}
+
+			g.report(&report.Event{Type: report.EventFileRemoved, Path: pathToDelete})
+		}
+	}
+
+	g.report(&report.Event{
+		Type:    report.EventSummary,
+		Summary: &report.Summary{Removed: len(pathsToDelete)},
+	})
+
+	return nil
+}
+
+// TidyPaths returns the files and directories in the mock directories that TidyMocks would remove, without removing them.
+func (g *MockGen) TidyPaths(config *ensurefile.Config) ([]string, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
 	mockDestinations, err := computeMockDestinations(config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	mockDestinationsByMockDir := mockDestinations.byFullMockDir()
@@ -31,7 +64,7 @@ func (g *MockGen) TidyMocks(config *ensurefile.Config) error {
 	for mockDir, mockDests := range mockDestinationsByMockDir {
 		recursivePaths, err := g.FSWrite.ListRecursive(mockDir)
 		if err != nil {
-			return erk.WrapWith(ErrTidyUnableToList, err, erk.Params{
+			return nil, erk.WrapWith(ErrTidyUnableToList, err, erk.Params{
 				"path": mockDir,
 			})
 		}
@@ -44,18 +77,6 @@ func (g *MockGen) TidyMocks(config *ensurefile.Config) error {
 		}
 	}
 
-	if len(pathsToDelete) > 0 {
-		g.Logger.Println("Tidying mocks:")
-		for _, pathToDelete := range pathsToDelete {
-			g.Logger.Printf(" - Removing: %s\n", pathToDelete)
-
-			if err := g.FSWrite.RemoveAll(pathToDelete); err != nil {
-				return erk.WrapWith(ErrTidyUnableToCleanup, err, erk.Params{
-					"path": pathToDelete,
-				})
-			}
-		}
-	}
-
-	return nil
+	sort.Strings(pathsToDelete)
+	return pathsToDelete, nil
 }
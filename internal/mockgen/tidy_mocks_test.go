@@ -11,8 +11,11 @@ import (
 	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_exitcleanup"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
 	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
 	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
 )
 
 func TestTidyMocks(t *testing.T) {
@@ -273,3 +276,52 @@ func TestTidyMocks(t *testing.T) {
 		ensure(err).IsError(entry.ExpectedError)
 	})
 }
+
+func TestTidyMocksReportsEvents(t *testing.T) {
+	ensure := ensure.New(t)
+
+	mockFSWrite := mock_fswrite.NewMockFSWriteIface(ensure.GoMockController())
+	mockReporter := mock_report.NewMockReporterIface(ensure.GoMockController())
+
+	gen := mockgen.MockGen{
+		Logger:   log.New(ioutil.Discard, "", 0),
+		FSWrite:  mockFSWrite,
+		Reporter: mockReporter,
+	}
+
+	mockFSWrite.EXPECT().ListRecursive("/root/path/internal/mocks").
+		Return([]string{
+			"/root/path/internal/mocks/github.com",
+			"/root/path/internal/mocks/github.com/some",
+			"/root/path/internal/mocks/github.com/some/pkg",
+			"/root/path/internal/mocks/github.com/some/pkg/mock_abc",
+			"/root/path/internal/mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+			"/root/path/internal/mocks/github.com/some/pkg/mock_abc/extra_file.go",
+		}, nil)
+
+	gomock.InOrder(
+		mockFSWrite.EXPECT().RemoveAll("/root/path/internal/mocks/github.com/some/pkg/mock_abc/extra_file.go").Return(nil),
+		mockReporter.EXPECT().Report(&report.Event{
+			Type: report.EventFileRemoved,
+			Path: "/root/path/internal/mocks/github.com/some/pkg/mock_abc/extra_file.go",
+		}),
+		mockReporter.EXPECT().Report(&report.Event{
+			Type:    report.EventSummary,
+			Summary: &report.Summary{Removed: 1},
+		}),
+	)
+
+	err := gen.TidyMocks(&ensurefile.Config{
+		RootPath:   "/root/path",
+		ModulePath: "github.com/my/mod",
+		Mocks: &ensurefile.MockConfig{
+			Packages: []*ensurefile.Package{
+				{
+					Path:       "github.com/some/pkg/abc",
+					Interfaces: []string{"Iface1"},
+				},
+			},
+		},
+	})
+	ensure(err).IsNotError()
+}
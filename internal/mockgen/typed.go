@@ -0,0 +1,170 @@
+package mockgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+)
+
+type sourceEdit struct {
+	start       int
+	end         int
+	replacement string
+}
+
+// typeMockSource rewrites the recorder methods generated by mockgen to return typed calls,
+// similar to the -typed mode of go.uber.org/mock.
+// Each typed call embeds *gomock.Call, and adds Return, Do, and DoAndReturn methods with the signature of the mocked method.
+func typeMockSource(source string, pkg *ensurefile.Package) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, 0)
+	if err != nil {
+		return "", err
+	}
+
+	mockNames := map[string]bool{}
+	for _, iface := range pkg.Interfaces {
+		mockNames[pkg.MockName(iface)] = true
+	}
+
+	methodsByMockName := map[string]map[string]*TemplateMethod{}
+	recorders := []*ast.FuncDecl{}
+
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 || funcDecl.Name.Name == "EXPECT" {
+			continue
+		}
+
+		receiverName := receiverTypeName(funcDecl.Recv.List[0].Type)
+		if mockNames[receiverName] {
+			if methodsByMockName[receiverName] == nil {
+				methodsByMockName[receiverName] = map[string]*TemplateMethod{}
+			}
+
+			methodsByMockName[receiverName][funcDecl.Name.Name] = parseMockMethod(fset, funcDecl)
+			continue
+		}
+
+		if mockNames[strings.TrimSuffix(receiverName, "MockRecorder")] {
+			recorders = append(recorders, funcDecl)
+		}
+	}
+
+	edits := []*sourceEdit{}
+	callTypes := ""
+
+	for _, recorder := range recorders {
+		mockName := strings.TrimSuffix(receiverTypeName(recorder.Recv.List[0].Type), "MockRecorder")
+		method, ok := methodsByMockName[mockName][recorder.Name.Name]
+		if !ok {
+			continue
+		}
+
+		callTypeName := mockName + method.Name + "Call"
+		recorderEdits := typeRecorderEdits(fset, recorder, callTypeName)
+		if recorderEdits == nil {
+			continue
+		}
+
+		edits = append(edits, recorderEdits...)
+		callTypes += createTypedCall(callTypeName, mockName, method)
+	}
+
+	return applySourceEdits(source, edits) + callTypes, nil
+}
+
+// typeRecorderEdits changes the recorder's result type to the typed call, and wraps the returned *gomock.Call.
+func typeRecorderEdits(fset *token.FileSet, recorder *ast.FuncDecl, callTypeName string) []*sourceEdit {
+	results := recorder.Type.Results
+	if results == nil || len(results.List) != 1 || recorder.Body == nil || len(recorder.Body.List) == 0 {
+		return nil
+	}
+
+	returnStmt, ok := recorder.Body.List[len(recorder.Body.List)-1].(*ast.ReturnStmt)
+	if !ok || len(returnStmt.Results) != 1 {
+		return nil
+	}
+
+	returnedCall := returnStmt.Results[0]
+
+	return []*sourceEdit{
+		{
+			start:       fset.Position(results.List[0].Type.Pos()).Offset,
+			end:         fset.Position(results.List[0].Type.End()).Offset,
+			replacement: "*" + callTypeName,
+		},
+		{
+			start:       fset.Position(returnedCall.Pos()).Offset,
+			end:         fset.Position(returnedCall.Pos()).Offset,
+			replacement: "&" + callTypeName + "{Call: ",
+		},
+		{
+			start:       fset.Position(returnedCall.End()).Offset,
+			end:         fset.Position(returnedCall.End()).Offset,
+			replacement: "}",
+		},
+	}
+}
+
+func applySourceEdits(source string, edits []*sourceEdit) string {
+	// Apply the edits from the end of the source, so the earlier offsets remain valid
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+
+	for _, edit := range edits {
+		source = source[:edit.start] + edit.replacement + source[edit.end:]
+	}
+
+	return source
+}
+
+func createTypedCall(callTypeName, mockName string, method *TemplateMethod) string {
+	paramTypes := make([]string, 0, len(method.Params))
+	for _, param := range method.Params {
+		paramTypes = append(paramTypes, param.Type)
+	}
+
+	returnParams := make([]string, 0, len(method.Results))
+	returnArgs := make([]string, 0, len(method.Results))
+	for i, result := range method.Results {
+		returnParams = append(returnParams, fmt.Sprintf("arg%d %s", i, result))
+		returnArgs = append(returnArgs, fmt.Sprintf("arg%d", i))
+	}
+
+	funcType := "func(" + strings.Join(paramTypes, ", ") + ")" + method.resultsString()
+
+	return fmt.Sprintf(
+		"\n// %[1]s wraps *gomock.Call with methods typed for %[2]s.%[3]s.\n"+
+			"type %[1]s struct {\n"+
+			"\t*gomock.Call\n"+
+			"}\n"+
+			"\n// Return rewrites *gomock.Call.Return.\n"+
+			"func (c *%[1]s) Return(%[4]s) *%[1]s {\n"+
+			"\tc.Call = c.Call.Return(%[5]s)\n"+
+			"\treturn c\n"+
+			"}\n"+
+			"\n// Do rewrites *gomock.Call.Do.\n"+
+			"func (c *%[1]s) Do(f %[6]s) *%[1]s {\n"+
+			"\tc.Call = c.Call.Do(f)\n"+
+			"\treturn c\n"+
+			"}\n"+
+			"\n// DoAndReturn rewrites *gomock.Call.DoAndReturn.\n"+
+			"func (c *%[1]s) DoAndReturn(f %[6]s) *%[1]s {\n"+
+			"\tc.Call = c.Call.DoAndReturn(f)\n"+
+			"\treturn c\n"+
+			"}\n",
+		callTypeName,
+		mockName,
+		method.Name,
+		strings.Join(returnParams, ", "),
+		strings.Join(returnArgs, ", "),
+		funcType,
+	)
+}
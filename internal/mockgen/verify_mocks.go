@@ -0,0 +1,117 @@
+package mockgen
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/erk/erg"
+)
+
+var (
+	ErrMultipleVerificationFailures = erk.New(ErkMultipleFailures{}, "At least one generated mock does not compile")
+	ErrMockDoesNotCompile           = erk.New(ErkMockGenError{},
+		"The mock generated for '{{.packageDescription}}' from {{.source}} does not compile. Check '{{.path}}': {{.err}}",
+	)
+)
+
+// maxParallelVerifications limits the number of mock packages that are compiled at once,
+// since each one runs a separate go command.
+var maxParallelVerifications = runtime.NumCPU()
+
+// VerifyMocks type-checks the mock packages generated for the configs using `go build`, such as after GenerateAllMocks.
+// Only compiling the packages is checked, so the mocks are not held to the analyzers of `go vet`.
+// Like GenerateAllMocks, only packages matching the package filters and changed packages are verified.
+// Each mock package is checked separately, so errors can be reported for the package that generated it.
+func (g *MockGen) VerifyMocks(ctx context.Context, configs []*ensurefile.Config) error {
+	jobs, disableParallel, err := prepareAllJobs(configs)
+	if err != nil {
+		return err
+	}
+
+	var errsMu sync.Mutex
+	var wg sync.WaitGroup
+	errs := erg.NewAs(ErrMultipleVerificationFailures)
+	semaphore := make(chan struct{}, maxParallelVerifications)
+
+	// verifyJob verifies the mock, reporting its progress.
+	verifyJob := func(job *generateJob) {
+		pkg := job.destination.Package.String()
+
+		if err := g.verifyMock(ctx, job.destination); err != nil {
+			errsMu.Lock()
+			errs = erg.Append(errs, err)
+			errsMu.Unlock()
+
+			g.report(&report.Event{Type: report.EventPackageFailed, Package: pkg, Error: report.NewError(err)})
+			return
+		}
+
+		g.Logger.Printf(" - Verified: %s\n", pkg)
+		g.report(&report.Event{Type: report.EventPackageVerified, Package: pkg, Path: job.destination.fullPath()})
+	}
+
+	g.Logger.Println("Verifying mocks:")
+	for _, job := range jobs {
+		if disableParallel {
+			verifyJob(job)
+			continue
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(job *generateJob) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			verifyJob(job)
+		}(job)
+	}
+
+	wg.Wait()
+	if erg.Any(errs) {
+		return errs
+	}
+
+	return nil
+}
+
+func (g *MockGen) verifyMock(ctx context.Context, dest *mockDestination) error {
+	mockPath := dest.fullPath()
+
+	// Building a package that is not a main package only compiles it, without writing any files
+	_, err := g.CmdRun.Exec(ctx, &runcmd.ExecParams{
+		PWD:  dest.PWD,
+		CMD:  "go",
+		Args: []string{"build", buildPath(dest.PWD, filepath.Dir(mockPath))},
+	})
+	if err != nil {
+		return erk.WrapWith(ErrMockDoesNotCompile, err, erk.Params{
+			"packageDescription": dest.Package.String(),
+			"source":             dest.Source,
+			"path":               mockPath,
+		})
+	}
+
+	return nil
+}
+
+// buildPath returns the path of the mock directory passed to `go build`, which must start with ./ when it is relative.
+// Directories outside of pwd are passed as absolute paths.
+func buildPath(pwd, dir string) string {
+	relativeDir, err := filepath.Rel(pwd, dir)
+	if err != nil || relativeDir == ".." || strings.HasPrefix(relativeDir, ".."+string(filepath.Separator)) {
+		return dir
+	}
+
+	if relativeDir == "." {
+		return "."
+	}
+
+	return "./" + filepath.ToSlash(relativeDir)
+}
@@ -0,0 +1,186 @@
+package mockgen_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_report"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/JosiahWitt/erk/erg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestVerifyMocks(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		CmdRun   *mock_runcmd.MockRunnerIface
+		Reporter *mock_report.MockReporterIface `ensure:"ignoreunused"`
+	}
+
+	exampleError := errors.New("mock_abc.go:12:3: undefined: abc.unexported")
+
+	newConfigs := func(filters ...string) []*ensurefile.Config {
+		return []*ensurefile.Config{{
+			RootPath:                  "/root/path",
+			ModulePath:                "github.com/my/mod",
+			ConfigPath:                "/root/path/.ensure.yml",
+			PackageFilters:            filters,
+			DisableParallelGeneration: true,
+			Mocks: &ensurefile.MockConfig{
+				PrimaryDestination: "primary_mocks",
+				Packages: []*ensurefile.Package{
+					{Path: "github.com/some/pkg/abc", Interfaces: []string{"Iface1"}, Line: 4},
+					{Path: "github.com/my/mod/internal/store", Interfaces: []string{"Store"}, Line: 7},
+				},
+			},
+		}}
+	}
+
+	inParallel := func(configs []*ensurefile.Config) []*ensurefile.Config {
+		for _, config := range configs {
+			config.DisableParallelGeneration = false
+		}
+
+		return configs
+	}
+
+	buildParams := func(dir string) *runcmd.ExecParams {
+		return &runcmd.ExecParams{PWD: "/root/path", CMD: "go", Args: []string{"build", dir}}
+	}
+
+	verifiedEvent := func(pkg, path string) *report.Event {
+		return &report.Event{Type: report.EventPackageVerified, Package: pkg, Path: "/root/path/" + path}
+	}
+
+	table := []struct {
+		Name           string
+		Configs        []*ensurefile.Config
+		ExpectedErrors []error
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *mockgen.MockGen
+	}{
+		{
+			Name:    "when mocks compile",
+			Configs: newConfigs(),
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(gomock.Any(), buildParams("./primary_mocks/github.com/some/pkg/mock_abc")).Return("", nil)
+				m.CmdRun.EXPECT().Exec(gomock.Any(), buildParams("./internal/mocks/mock_store")).Return("", nil)
+				m.Reporter.EXPECT().Report(verifiedEvent(
+					"github.com/some/pkg/abc:Iface1",
+					"primary_mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+				))
+				m.Reporter.EXPECT().Report(verifiedEvent("github.com/my/mod/internal/store:Store", "internal/mocks/mock_store/mock_store.go"))
+			},
+		},
+		{
+			Name:    "when mocks compile in parallel",
+			Configs: inParallel(newConfigs()),
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(gomock.Any(), buildParams("./primary_mocks/github.com/some/pkg/mock_abc")).Return("", nil)
+				m.CmdRun.EXPECT().Exec(gomock.Any(), buildParams("./internal/mocks/mock_store")).Return("", nil)
+				m.Reporter.EXPECT().Report(verifiedEvent(
+					"github.com/some/pkg/abc:Iface1",
+					"primary_mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+				))
+				m.Reporter.EXPECT().Report(verifiedEvent("github.com/my/mod/internal/store:Store", "internal/mocks/mock_store/mock_store.go"))
+			},
+		},
+		{
+			Name:    "with package filters",
+			Configs: newConfigs("internal/store"),
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(gomock.Any(), buildParams("./internal/mocks/mock_store")).Return("", nil)
+				m.Reporter.EXPECT().Report(verifiedEvent("github.com/my/mod/internal/store:Store", "internal/mocks/mock_store/mock_store.go"))
+			},
+		},
+		{
+			Name: "when mocks are outside of the module",
+			Configs: func() []*ensurefile.Config {
+				configs := newConfigs("github.com/some/pkg/abc")
+				configs[0].Mocks.PrimaryDestination = "../shared_mocks"
+				return configs
+			}(),
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(gomock.Any(), buildParams("/root/shared_mocks/github.com/some/pkg/mock_abc")).Return("", nil)
+				m.Reporter.EXPECT().Report(&report.Event{
+					Type:    report.EventPackageVerified,
+					Package: "github.com/some/pkg/abc:Iface1",
+					Path:    "/root/shared_mocks/github.com/some/pkg/mock_abc/mock_abc.go",
+				})
+			},
+		},
+		{
+			Name:           "when a mock does not compile",
+			Configs:        newConfigs(),
+			ExpectedError:  mockgen.ErrMultipleVerificationFailures,
+			ExpectedErrors: []error{mockgen.ErrMockDoesNotCompile},
+			SetupMocks: func(m *Mocks) {
+				m.CmdRun.EXPECT().Exec(gomock.Any(), buildParams("./primary_mocks/github.com/some/pkg/mock_abc")).Return("", exampleError)
+				m.CmdRun.EXPECT().Exec(gomock.Any(), buildParams("./internal/mocks/mock_store")).Return("", nil)
+				m.Reporter.EXPECT().Report(gomock.Any()).Do(func(event *report.Event) {
+					ensure(event.Type).Equals(report.EventPackageFailed)
+					ensure(event.Package).Equals("github.com/some/pkg/abc:Iface1")
+					ensure(event.Error == nil).IsFalse()
+				})
+				m.Reporter.EXPECT().Report(verifiedEvent("github.com/my/mod/internal/store:Store", "internal/mocks/mock_store/mock_store.go"))
+			},
+		},
+		{
+			Name:          "with invalid config",
+			Configs:       []*ensurefile.Config{{}},
+			ExpectedError: mockgen.ErrMissingMockConfig,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.Logger = log.New(ioutil.Discard, "", 0)
+
+		err := entry.Subject.VerifyMocks(context.Background(), entry.Configs)
+		ensure(err).IsError(entry.ExpectedError)
+
+		errs := erg.GetErrors(err)
+		ensure(len(errs)).Equals(len(entry.ExpectedErrors))
+		for i, expectedErr := range entry.ExpectedErrors {
+			ensure(errs[i]).IsError(expectedErr)
+		}
+	})
+}
+
+func TestVerifyMocksErrorMessage(t *testing.T) {
+	ensure := ensure.New(t)
+	ctrl := gomock.NewController(t)
+
+	cmdRun := mock_runcmd.NewMockRunnerIface(ctrl)
+	cmdRun.EXPECT().Exec(gomock.Any(), gomock.Any()).Return("", errors.New("undefined: abc.unexported"))
+
+	mockGen := &mockgen.MockGen{CmdRun: cmdRun, Logger: log.New(ioutil.Discard, "", 0)}
+	err := mockGen.VerifyMocks(context.Background(), []*ensurefile.Config{{
+		RootPath:   "/root/path",
+		ModulePath: "github.com/my/mod",
+		ConfigPath: "/root/path/.ensure.yml",
+		Mocks: &ensurefile.MockConfig{
+			Packages: []*ensurefile.Package{{Path: "github.com/my/mod/abc", Interfaces: []string{"Iface1"}, Line: 4}},
+		},
+	}})
+
+	errs := erg.GetErrors(err)
+	ensure(len(errs)).Equals(1)
+	ensure(errs[0].Error()).Equals(
+		"The mock generated for 'github.com/my/mod/abc:Iface1' from /root/path/.ensure.yml:4 does not compile. " +
+			"Check '/root/path/internal/mocks/github.com/my/mod/mock_abc/mock_abc.go': undefined: abc.unexported",
+	)
+}
@@ -0,0 +1,351 @@
+package mockimport
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+)
+
+type mockgenFlags struct {
+	set *flag.FlagSet
+
+	source            string
+	destination       string
+	mockNames         string
+	packageName       string
+	excludeInterfaces string
+	typed             bool
+
+	unsupportedNames []string
+	unsupported      map[string]*string // Flags that change the mocks in ways .ensure.yml cannot express
+}
+
+func newMockgenFlags() *mockgenFlags {
+	f := &mockgenFlags{
+		set: flag.NewFlagSet("mockgen", flag.ContinueOnError),
+
+		unsupportedNames: []string{"aux_files", "build_constraint", "build_flags", "copyright_file", "exec_only", "imports", "model_gob", "prog_only"},
+		unsupported:      map[string]*string{},
+	}
+	f.set.SetOutput(ioutil.Discard)
+
+	f.set.StringVar(&f.source, "source", "", "")
+	f.set.StringVar(&f.destination, "destination", "", "")
+	f.set.StringVar(&f.mockNames, "mock_names", "", "")
+	f.set.StringVar(&f.packageName, "package", "", "")
+	f.set.StringVar(&f.excludeInterfaces, "exclude_interfaces", "", "")
+	f.set.BoolVar(&f.typed, "typed", false, "")
+
+	// Flags that do not change the mocks in a meaningful way
+	f.set.String("self_package", "", "")
+	f.set.Bool("write_package_comment", true, "")
+	f.set.Bool("write_source_comment", true, "")
+	f.set.Bool("write_generate_directive", false, "")
+	f.set.Bool("debug_parse", false, "")
+
+	for _, name := range f.unsupportedNames {
+		f.unsupported[name] = f.set.String(name, "", "")
+	}
+
+	return f
+}
+
+// mockgenArgs returns the arguments passed to mockgen, if the directive runs mockgen.
+// Both `mockgen ...` and `go run <path>/mockgen[@version] ...` are supported.
+func mockgenArgs(text string) ([]string, bool) {
+	words, err := splitWords(strings.TrimPrefix(text, "//go:generate"))
+	if err != nil || len(words) == 0 {
+		return nil, false
+	}
+
+	isMockgen := func(word string) bool {
+		return path.Base(strings.SplitN(word, "@", 2)[0]) == "mockgen"
+	}
+
+	if isMockgen(words[0]) {
+		return words[1:], true
+	}
+
+	if len(words) >= 3 && words[0] == "go" && words[1] == "run" {
+		for idx, word := range words[2:] {
+			if isMockgen(word) {
+				return words[idx+3:], true
+			}
+
+			if !strings.HasPrefix(word, "-") {
+				return nil, false
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// convert the mockgen arguments into a package, or returns the reason they cannot be converted.
+func (m *module) convert(goFile *modfiles.GoFile, directive *Directive, args []string) (*Conversion, string) {
+	dir := filepath.Dir(goFile.Path)
+
+	args, reason := m.expandArgs(goFile, directive, args)
+	if reason != "" {
+		return nil, reason
+	}
+
+	flags := newMockgenFlags()
+	if err := flags.set.Parse(args); err != nil {
+		return nil, fmt.Sprintf("the arguments cannot be parsed: %v", err)
+	}
+
+	for _, name := range flags.unsupportedNames {
+		if *flags.unsupported[name] != "" {
+			return nil, fmt.Sprintf("the -%s flag has no equivalent in .ensure.yml", name)
+		}
+	}
+
+	var pkgPath string
+	var interfaces []string
+
+	if flags.source != "" {
+		pkgPath, interfaces, reason = m.sourceMode(dir, flags)
+	} else {
+		pkgPath, interfaces, reason = m.reflectMode(dir, flags)
+	}
+
+	if reason != "" {
+		return nil, reason
+	}
+
+	mockNames, reason := parseMockNames(flags.mockNames, interfaces)
+	if reason != "" {
+		return nil, reason
+	}
+
+	conversion := &Conversion{
+		Directive:   directive,
+		PackagePath: pkgPath,
+		Interfaces:  interfaces,
+		Typed:       flags.typed,
+		MockNames:   mockNames,
+	}
+
+	resolved, err := m.resolve(pkgPath, directive.File, directive.Line)
+	if err != nil {
+		return nil, err.Error()
+	}
+	destination := resolved.FilePath
+
+	if flags.destination != "" {
+		oldDestination := flags.destination
+		if !filepath.IsAbs(oldDestination) {
+			oldDestination = filepath.Join(dir, oldDestination)
+		}
+
+		if oldDestination != destination {
+			conversion.Notes = append(conversion.Notes,
+				fmt.Sprintf("the mocks move from %s to %s", m.relative(oldDestination), m.relative(destination)),
+			)
+		}
+	}
+
+	if mockPackageName := "mock_" + path.Base(pkgPath); flags.packageName != "" && flags.packageName != mockPackageName {
+		conversion.Notes = append(conversion.Notes,
+			fmt.Sprintf("the mock package is named %s instead of %s", mockPackageName, flags.packageName),
+		)
+	}
+
+	return conversion, ""
+}
+
+// sourceMode uses the interfaces declared in the -source file.
+func (m *module) sourceMode(dir string, flags *mockgenFlags) (string, []string, string) {
+	if flags.set.NArg() > 0 {
+		return "", nil, "source mode does not accept a package or interfaces"
+	}
+
+	sourcePath := flags.source
+	if !filepath.IsAbs(sourcePath) {
+		sourcePath = filepath.Join(dir, sourcePath)
+	}
+
+	sourceFile, ok := m.byPath[sourcePath]
+	if !ok || strings.HasSuffix(sourcePath, "_test.go") {
+		return "", nil, fmt.Sprintf("the source file %s is not part of a package in the module", m.relative(sourcePath))
+	}
+
+	pkgPath, ok := m.importPath(filepath.Dir(sourcePath))
+	if !ok {
+		return "", nil, fmt.Sprintf("the source file %s is outside the module", m.relative(sourcePath))
+	}
+
+	parsed := m.parse(sourceFile)
+	if parsed.err != nil {
+		return "", nil, parsed.err.Error()
+	}
+
+	excluded := map[string]bool{}
+	for _, name := range splitList(flags.excludeInterfaces) {
+		excluded[name] = true
+	}
+
+	interfaces := []string{}
+	for _, iface := range parsed.interfaces {
+		if !excluded[iface] {
+			interfaces = append(interfaces, iface)
+		}
+	}
+
+	if len(interfaces) == 0 {
+		return "", nil, fmt.Sprintf("the source file %s does not declare any interfaces", m.relative(sourcePath))
+	}
+
+	return pkgPath, interfaces, ""
+}
+
+// reflectMode uses the package and interfaces passed as arguments.
+func (m *module) reflectMode(dir string, flags *mockgenFlags) (string, []string, string) {
+	if flags.set.NArg() != 2 {
+		return "", nil, "expected a package and a comma-separated list of interfaces"
+	}
+
+	pkgPath := flags.set.Arg(0)
+	if pkgPath == "." {
+		var ok bool
+		if pkgPath, ok = m.importPath(dir); !ok {
+			return "", nil, "the package is outside the module"
+		}
+	}
+
+	interfaces := splitList(flags.set.Arg(1))
+	if len(interfaces) == 0 {
+		return "", nil, "no interfaces were provided"
+	}
+
+	return pkgPath, interfaces, ""
+}
+
+// parseMockNames returns the mock names of the interfaces that differ from the default Mock<Iface> names,
+// or a reason if they cannot be parsed. Names of interfaces that are not mocked are ignored, like mockgen does.
+func parseMockNames(mockNames string, interfaces []string) (map[string]string, string) {
+	custom := map[string]string{}
+
+	for _, pair := range splitList(mockNames) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Sprintf("the -mock_names entry '%s' cannot be parsed", pair)
+		}
+
+		if parts[1] != "Mock"+parts[0] && containsString(interfaces, parts[0]) {
+			custom[parts[0]] = parts[1]
+		}
+	}
+
+	if len(custom) == 0 {
+		return nil, ""
+	}
+
+	return custom, ""
+}
+
+// expandArgs expands the environment variables that `go generate` provides.
+func (m *module) expandArgs(goFile *modfiles.GoFile, directive *Directive, args []string) ([]string, string) {
+	unknown := []string{}
+	mapping := func(name string) string {
+		switch name {
+		case "GOFILE":
+			return filepath.Base(goFile.Path)
+		case "GOLINE":
+			return strconv.Itoa(directive.Line)
+		case "GOPACKAGE":
+			return m.parse(goFile).packageName
+		case "DOLLAR":
+			return "$"
+		default:
+			unknown = append(unknown, name)
+			return ""
+		}
+	}
+
+	expanded := make([]string, 0, len(args))
+	for _, arg := range args {
+		expanded = append(expanded, os.Expand(arg, mapping))
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Sprintf("the environment variable $%s cannot be resolved", unknown[0])
+	}
+
+	return expanded, ""
+}
+
+var errUnterminatedQuote = errors.New("unterminated quoted string")
+
+// splitWords splits the text into space separated words, where double quoted Go strings are a single word.
+// This matches how `go generate` splits directives.
+func splitWords(text string) ([]string, error) {
+	words := []string{}
+
+	for {
+		text = strings.TrimLeft(text, " \t")
+		if text == "" {
+			return words, nil
+		}
+
+		if text[0] == '"' {
+			end := 1
+			for ; end < len(text) && text[end] != '"'; end++ {
+				if text[end] == '\\' {
+					end++
+				}
+			}
+
+			if end >= len(text) {
+				return nil, errUnterminatedQuote
+			}
+
+			word, err := strconv.Unquote(text[:end+1])
+			if err != nil {
+				return nil, err
+			}
+
+			words = append(words, word)
+			text = text[end+1:]
+			continue
+		}
+
+		end := strings.IndexAny(text, " \t")
+		if end < 0 {
+			end = len(text)
+		}
+
+		words = append(words, text[:end])
+		text = text[end:]
+	}
+}
+
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,553 @@
+package mockimport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/importrewrite"
+	"github.com/JosiahWitt/erk"
+	"gopkg.in/yaml.v3"
+)
+
+var mockeryConfigNames = []string{".mockery.yaml", ".mockery.yml"}
+
+// mockeryOptions are the options that are inherited from the top level, to packages, to interfaces.
+type mockeryOptions struct {
+	All       *bool   `yaml:"all"`
+	Recursive *bool   `yaml:"recursive"`
+	InPackage *bool   `yaml:"inpackage"`
+	Dir       *string `yaml:"dir"`
+	MockName  *string `yaml:"mockname"`
+	OutPkg    *string `yaml:"outpkg"`
+}
+
+type mockeryConfig struct {
+	mockeryOptions `yaml:",inline"`
+	Packages       map[string]*mockeryPackage `yaml:"packages"`
+}
+
+type mockeryPackage struct {
+	Config     mockeryOptions               `yaml:"config"`
+	Interfaces map[string]*mockeryInterface `yaml:"interfaces"`
+}
+
+type mockeryInterface struct {
+	Config  mockeryOptions   `yaml:"config"`
+	Configs []mockeryOptions `yaml:"configs"`
+}
+
+// FindMockery reads the packages config in .mockery.yaml, and converts its interfaces into packages.
+// The moves from the old mock packages to the packages ensure generates are included in the result.
+// The config does not need to contain any packages, or even exist yet.
+func (i *Importer) FindMockery(config *ensurefile.Config) (*Result, error) {
+	mockery, mockeryPath, err := i.loadMockeryConfig(config.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mockery.Packages) == 0 {
+		return nil, erk.WithParams(ErrInvalidMockeryConfig, erk.Params{
+			"path":   mockeryPath,
+			"reason": "it does not list any packages, and only the packages config is supported",
+		})
+	}
+
+	goFiles, err := i.Finder.GoFiles(config.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	m := newModule(config, goFiles)
+	c := &mockeryConverter{
+		module:   m,
+		path:     mockeryPath,
+		packages: m.packages(),
+		result:   &Result{Converted: []*Conversion{}, Unsupported: []*Unsupported{}},
+		newPaths: map[string]map[string]bool{},
+	}
+
+	for _, pkgPath := range sortedPackagePaths(mockery.Packages) {
+		pkg := mockery.Packages[pkgPath]
+		if pkg == nil {
+			pkg = &mockeryPackage{} // Such as `github.com/my/pkg:` without a value
+		}
+
+		opts := mockery.mockeryOptions.merge(pkg.Config)
+		subPkgPaths := []string{}
+		if isTrue(opts.Recursive) {
+			subPkgPaths = c.subPackages(pkgPath)
+		}
+
+		// A recursive package can be a directory without Go files, such as the root of the module
+		if _, ok := c.packages[pkgPath]; ok || len(subPkgPaths) == 0 || len(pkg.Interfaces) > 0 {
+			c.convertPackage(pkgPath, opts, pkg.Interfaces)
+		}
+
+		for _, subPkgPath := range subPkgPaths {
+			if _, ok := mockery.Packages[subPkgPath]; !ok { // Packages that are listed have their own options
+				c.convertPackage(subPkgPath, opts, nil)
+			}
+		}
+	}
+
+	c.addMoves()
+	return c.result, nil
+}
+
+func (i *Importer) loadMockeryConfig(rootPath string) (*mockeryConfig, string, error) {
+	for _, name := range mockeryConfigNames {
+		mockeryPath := filepath.Join(rootPath, name)
+
+		data, err := fs.ReadFile(i.FS, strings.TrimPrefix(mockeryPath, "/"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, "", erk.WrapWith(ErrCannotReadMockeryConfig, err, erk.Params{
+				"path": mockeryPath,
+			})
+		}
+
+		mockery := &mockeryConfig{}
+		if err := yaml.Unmarshal(data, mockery); err != nil {
+			return nil, "", erk.WrapWith(ErrCannotParseMockeryConfig, err, erk.Params{
+				"path": mockeryPath,
+			})
+		}
+
+		return mockery, mockeryPath, nil
+	}
+
+	return nil, "", erk.WithParams(ErrMissingMockeryConfig, erk.Params{
+		"path": rootPath,
+	})
+}
+
+// mockeryConverter accumulates the result of converting the mockery packages.
+type mockeryConverter struct {
+	module   *module
+	path     string // Path to the mockery config
+	packages map[string]*modulePackage
+	result   *Result
+
+	conversions []*mockeryConversion
+	newPaths    map[string]map[string]bool // Old mock import path to new mock import paths
+}
+
+type mockeryConversion struct {
+	conversion *Conversion
+	moves      []*importrewrite.Move
+}
+
+// convertPackage converts the interfaces that mockery mocks in the package.
+// Interfaces are listed explicitly, or found in the package when `all` is true.
+func (c *mockeryConverter) convertPackage(pkgPath string, opts mockeryOptions, interfaces map[string]*mockeryInterface) {
+	pkg := c.packages[pkgPath]
+
+	ifaceOpts := map[string]mockeryOptions{}
+	for _, name := range sortedInterfaceNames(interfaces) {
+		iface := interfaces[name]
+		if iface == nil {
+			iface = &mockeryInterface{}
+		}
+
+		if len(iface.Configs) > 0 {
+			c.unsupported(pkgPath, name, "it lists several configs, and only one mock is generated per interface")
+			continue
+		}
+
+		if pkg != nil && !pkg.declares(name) {
+			c.unsupported(pkgPath, name, "the package does not declare the interface")
+			continue
+		}
+
+		ifaceOpts[name] = opts.merge(iface.Config)
+	}
+
+	if isTrue(opts.All) {
+		if pkg == nil {
+			c.unsupported(pkgPath, "", "`all: true` requires the package to be within the module")
+			return
+		}
+
+		if pkg.err != nil {
+			c.unsupported(pkgPath, "", pkg.err.Error())
+			return
+		}
+
+		for _, name := range pkg.interfaces {
+			if _, ok := ifaceOpts[name]; !ok && ast.IsExported(name) {
+				ifaceOpts[name] = opts
+			}
+		}
+	}
+
+	if len(ifaceOpts) == 0 {
+		return
+	}
+
+	conversion := &Conversion{PackagePath: pkgPath, Interfaces: []string{}}
+	resolved, err := c.module.resolve(pkgPath, c.path, 0)
+	if err != nil {
+		c.unsupported(pkgPath, "", err.Error())
+		return
+	}
+
+	moves := []*importrewrite.Move{}
+	oldPaths := map[string]bool{}
+	names := make([]string, 0, len(ifaceOpts))
+	for name := range ifaceOpts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		move, mockName, reason := c.oldMockPackage(pkgPath, pkg, name, ifaceOpts[name])
+		if reason != "" {
+			c.unsupported(pkgPath, name, reason)
+			continue
+		}
+
+		conversion.Interfaces = append(conversion.Interfaces, name)
+		if mockName != "Mock"+name {
+			if conversion.MockNames == nil {
+				conversion.MockNames = map[string]string{}
+			}
+
+			conversion.MockNames[name] = mockName
+		}
+
+		if move == nil {
+			continue
+		}
+
+		move.NewPath = resolved.ImportPath
+		if !oldPaths[move.OldPath] {
+			oldPaths[move.OldPath] = true
+			moves = append(moves, move)
+		}
+	}
+
+	if len(conversion.Interfaces) == 0 {
+		return
+	}
+
+	for _, move := range moves {
+		if c.newPaths[move.OldPath] == nil {
+			c.newPaths[move.OldPath] = map[string]bool{}
+		}
+
+		c.newPaths[move.OldPath][move.NewPath] = true
+	}
+
+	c.result.Converted = append(c.result.Converted, conversion)
+	c.conversions = append(c.conversions, &mockeryConversion{conversion: conversion, moves: moves})
+}
+
+// oldMockPackage returns the mock package mockery generated for the interface, and the name of the mock.
+// A nil move is returned if the mocks were generated within the package itself.
+func (c *mockeryConverter) oldMockPackage(
+	pkgPath string, pkg *modulePackage, name string, opts mockeryOptions,
+) (*importrewrite.Move, string, string) {
+	data := newTemplateData(c.module, pkgPath, pkg, name)
+
+	mockName, err := renderTemplate(stringOr(opts.MockName, "Mock{{.InterfaceName}}"), data)
+	if err != nil {
+		return nil, "", fmt.Sprintf("the mockname cannot be rendered: %v", err)
+	}
+
+	if !token.IsIdentifier(mockName) {
+		return nil, "", fmt.Sprintf("the mock name %s is not a valid identifier", mockName)
+	}
+
+	if isTrue(opts.InPackage) {
+		return nil, mockName, ""
+	}
+
+	data.MockName = mockName
+	dir, err := renderTemplate(stringOr(opts.Dir, "mocks/{{.PackagePath}}"), data)
+	if err != nil {
+		return nil, "", fmt.Sprintf("the dir cannot be rendered: %v", err)
+	}
+
+	outPkg, err := renderTemplate(stringOr(opts.OutPkg, "{{.PackageName}}"), data)
+	if err != nil {
+		return nil, "", fmt.Sprintf("the outpkg cannot be rendered: %v", err)
+	}
+
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(c.module.rootDir, filepath.FromSlash(dir))
+	}
+
+	oldPath, ok := c.module.importPath(dir)
+	if !ok {
+		return nil, mockName, "" // Mocks outside the module cannot be imported, so there is nothing to rewrite
+	}
+
+	return &importrewrite.Move{OldPath: oldPath, OldName: outPkg}, mockName, ""
+}
+
+// addMoves adds the moves to the result, skipping old mock packages whose mocks move to several packages.
+func (c *mockeryConverter) addMoves() {
+	moves := []*importrewrite.Move{}
+
+	for _, converted := range c.conversions {
+		for _, move := range converted.moves {
+			if len(c.newPaths[move.OldPath]) > 1 {
+				converted.conversion.Notes = append(converted.conversion.Notes,
+					fmt.Sprintf("the mocks in %s move to several packages, so its imports are not rewritten", move.OldPath),
+				)
+				continue
+			}
+
+			if move.OldPath != move.NewPath {
+				moves = append(moves, move)
+			}
+		}
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].OldPath < moves[j].OldPath })
+
+	c.result.Moves = []*importrewrite.Move{}
+	for idx, move := range moves {
+		if idx == 0 || moves[idx-1].OldPath != move.OldPath {
+			c.result.Moves = append(c.result.Moves, move)
+		}
+	}
+}
+
+// subPackages returns the packages in the module nested under the package.
+func (c *mockeryConverter) subPackages(pkgPath string) []string {
+	subPkgPaths := []string{}
+	for subPkgPath := range c.packages {
+		if strings.HasPrefix(subPkgPath, pkgPath+"/") {
+			subPkgPaths = append(subPkgPaths, subPkgPath)
+		}
+	}
+
+	sort.Strings(subPkgPaths)
+	return subPkgPaths
+}
+
+func (c *mockeryConverter) unsupported(pkgPath, iface, reason string) {
+	c.result.Unsupported = append(c.result.Unsupported, &Unsupported{
+		PackagePath: pkgPath,
+		Interface:   iface,
+		Reason:      reason,
+	})
+}
+
+// modulePackage is a package within the module.
+type modulePackage struct {
+	name       string
+	dir        string
+	interfaces []string
+	err        error
+}
+
+func (p *modulePackage) declares(iface string) bool {
+	for _, name := range p.interfaces {
+		if name == iface {
+			return true
+		}
+	}
+
+	return p.err != nil // Assume the interface exists, if the package cannot be parsed
+}
+
+// packages returns the packages within the module, indexed by import path. Test files are ignored.
+func (m *module) packages() map[string]*modulePackage {
+	packages := map[string]*modulePackage{}
+
+	for dir, goFiles := range m.byDir {
+		pkgPath, ok := m.importPath(dir)
+		if !ok {
+			continue
+		}
+
+		pkg := &modulePackage{dir: dir, interfaces: []string{}}
+		for _, goFile := range goFiles {
+			if strings.HasSuffix(goFile.Path, "_test.go") {
+				continue
+			}
+
+			parsed := m.parse(goFile)
+			if parsed.err != nil {
+				pkg.err = parsed.err
+				continue
+			}
+
+			pkg.name = parsed.packageName
+			pkg.interfaces = append(pkg.interfaces, parsed.interfaces...)
+		}
+
+		if pkg.name != "" || pkg.err != nil {
+			sort.Strings(pkg.interfaces)
+			packages[pkgPath] = pkg
+		}
+	}
+
+	return packages
+}
+
+// templateData matches the variables that mockery provides to its templates.
+type templateData struct {
+	InterfaceDir         string
+	InterfaceDirRelative string
+	InterfaceName        string
+	InterfaceNameCamel   string
+	InterfaceNameLower   string
+	InterfaceNameSnake   string
+	PackageName          string
+	PackagePath          string
+	MockName             string
+}
+
+func newTemplateData(m *module, pkgPath string, pkg *modulePackage, iface string) *templateData {
+	data := &templateData{
+		InterfaceName:      iface,
+		InterfaceNameCamel: upperFirst(iface),
+		InterfaceNameLower: strings.ToLower(iface),
+		InterfaceNameSnake: snakeCase(iface),
+		PackageName:        path.Base(pkgPath),
+		PackagePath:        pkgPath,
+	}
+
+	if pkg != nil {
+		data.InterfaceDir = pkg.dir
+		data.InterfaceDirRelative = filepath.ToSlash(m.relative(pkg.dir))
+		if pkg.name != "" {
+			data.PackageName = pkg.name
+		}
+	}
+
+	return data
+}
+
+//nolint:gochecknoglobals // Template functions are constant
+var templateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"base":       path.Base,
+	"dir":        path.Dir,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"replaceAll": strings.ReplaceAll,
+	"camelcase":  upperFirst,
+	"snakecase":  snakeCase,
+}
+
+func renderTemplate(text string, data *templateData) (string, error) {
+	tmpl, err := template.New("mockery").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// merge returns the options, with any set overrides replacing them.
+func (o mockeryOptions) merge(overrides mockeryOptions) mockeryOptions {
+	if overrides.All != nil {
+		o.All = overrides.All
+	}
+
+	if overrides.Recursive != nil {
+		o.Recursive = overrides.Recursive
+	}
+
+	if overrides.InPackage != nil {
+		o.InPackage = overrides.InPackage
+	}
+
+	if overrides.Dir != nil {
+		o.Dir = overrides.Dir
+	}
+
+	if overrides.MockName != nil {
+		o.MockName = overrides.MockName
+	}
+
+	if overrides.OutPkg != nil {
+		o.OutPkg = overrides.OutPkg
+	}
+
+	return o
+}
+
+func isTrue(value *bool) bool {
+	return value != nil && *value
+}
+
+func stringOr(value *string, defaultValue string) string {
+	if value == nil {
+		return defaultValue
+	}
+
+	return *value
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// snakeCase converts a Go identifier, such as HTTPClient, into snake case, such as http_client.
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+
+	for idx, r := range runes {
+		if idx > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[idx-1]) || unicode.IsDigit(runes[idx-1])
+			nextLower := idx+1 < len(runes) && unicode.IsLower(runes[idx+1])
+			if prevLower || (unicode.IsUpper(runes[idx-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
+func sortedPackagePaths(packages map[string]*mockeryPackage) []string {
+	pkgPaths := make([]string, 0, len(packages))
+	for pkgPath := range packages {
+		pkgPaths = append(pkgPaths, pkgPath)
+	}
+
+	sort.Strings(pkgPaths)
+	return pkgPaths
+}
+
+func sortedInterfaceNames(interfaces map[string]*mockeryInterface) []string {
+	names := make([]string, 0, len(interfaces))
+	for name := range interfaces {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,246 @@
+package mockimport_test
+
+import (
+	"errors"
+	"testing"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/importrewrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockimport"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_modfiles"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestFindMockery(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Finder *mock_modfiles.MockFinderIface
+		FS     *mock_fs.MockReadFileFS
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	config := &ensurefile.Config{
+		RootPath:   "/my/app",
+		ModulePath: "github.com/my/app",
+		ConfigPath: "/my/app/.ensure.yml",
+	}
+
+	goFiles := []*modfiles.GoFile{
+		{Path: "/my/app/store/store.go", Contents: "package store\n\ntype Store interface{}\ntype helper interface{}\n"},
+		{Path: "/my/app/services/services.go", Contents: "package services\n\ntype Service interface{}\ntype internalService interface{}\n"},
+		{Path: "/my/app/services/billing/billing.go", Contents: "package billing\n\ntype Biller interface{}\n"},
+		{Path: "/my/app/services/billing/billing_test.go", Contents: "package billing\n\ntype TestOnly interface{}\n"},
+		{Path: "/my/app/inpkg/inpkg.go", Contents: "package inpkg\n\ntype Doer interface{}\n"},
+	}
+
+	expectConfig := func(name, contents string) func(*Mocks) {
+		return func(m *Mocks) {
+			if name == ".mockery.yml" {
+				m.FS.EXPECT().ReadFile("my/app/.mockery.yaml").Return(nil, fs.ErrNotExist)
+			}
+
+			m.FS.EXPECT().ReadFile("my/app/"+name).Return([]byte(contents), nil)
+			m.Finder.EXPECT().GoFiles("/my/app").Return(goFiles, nil)
+		}
+	}
+
+	table := []struct {
+		Name           string
+		ExpectedResult *mockimport.Result
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *mockimport.Importer
+	}{
+		{
+			Name: "with packages config",
+			ExpectedResult: &mockimport.Result{
+				Converted: []*mockimport.Conversion{
+					{PackagePath: "github.com/my/app/inpkg", Interfaces: []string{"Doer"}},
+					{PackagePath: "github.com/my/app/services", Interfaces: []string{"Service"}},
+					{PackagePath: "github.com/my/app/services/billing", Interfaces: []string{"Biller"}},
+					{PackagePath: "github.com/my/app/store", Interfaces: []string{"Store"}},
+					{
+						PackagePath: "github.com/some/ext",
+						Interfaces:  []string{"Client"},
+						MockNames:   map[string]string{"Client": "FakeClient"},
+					},
+				},
+				Unsupported: []*mockimport.Unsupported{
+					{
+						PackagePath: "github.com/my/app/store",
+						Interface:   "Missing",
+						Reason:      "the package does not declare the interface",
+					},
+					{
+						PackagePath: "github.com/other/ext",
+						Reason:      "`all: true` requires the package to be within the module",
+					},
+					{
+						PackagePath: "github.com/some/ext",
+						Interface:   "Server",
+						Reason:      "it lists several configs, and only one mock is generated per interface",
+					},
+				},
+				Moves: []*importrewrite.Move{
+					{
+						OldPath: "github.com/my/app/mocks/github.com/my/app/services",
+						OldName: "services",
+						NewPath: "github.com/my/app/internal/mocks/github.com/my/app/mock_services",
+					},
+					{
+						OldPath: "github.com/my/app/mocks/github.com/my/app/services/billing",
+						OldName: "billing",
+						NewPath: "github.com/my/app/internal/mocks/github.com/my/app/services/mock_billing",
+					},
+					{
+						OldPath: "github.com/my/app/mocks/github.com/my/app/store",
+						OldName: "store",
+						NewPath: "github.com/my/app/internal/mocks/github.com/my/app/mock_store",
+					},
+					{
+						OldPath: "github.com/my/app/mocks/github.com/some/ext",
+						OldName: "ext",
+						NewPath: "github.com/my/app/internal/mocks/github.com/some/mock_ext",
+					},
+				},
+			},
+			SetupMocks: expectConfig(".mockery.yaml", `
+with-expecter: true
+packages:
+  github.com/my:
+    config:
+      recursive: true
+  github.com/my/app/store:
+    interfaces:
+      Store:
+      Missing:
+  github.com/my/app/services:
+    config:
+      all: true
+      recursive: true
+  github.com/my/app/inpkg:
+    config:
+      inpackage: true
+    interfaces:
+      Doer:
+  github.com/other/ext:
+    config:
+      all: true
+  github.com/some/ext:
+    interfaces:
+      Client:
+        config:
+          mockname: FakeClient
+      Server:
+        configs:
+          - mockname: MockServer
+          - mockname: OtherServer
+`),
+		},
+		{
+			Name: "with templated dir next to each package",
+			ExpectedResult: &mockimport.Result{
+				Converted: []*mockimport.Conversion{
+					{PackagePath: "github.com/my/app/store", Interfaces: []string{"Store"}},
+				},
+				Unsupported: []*mockimport.Unsupported{},
+				Moves: []*importrewrite.Move{
+					{
+						OldPath: "github.com/my/app/store/mocks",
+						OldName: "mocks",
+						NewPath: "github.com/my/app/internal/mocks/github.com/my/app/mock_store",
+					},
+				},
+			},
+			SetupMocks: expectConfig(".mockery.yml", `
+dir: "{{.InterfaceDirRelative}}/mocks"
+outpkg: mocks
+mockname: "Mock{{.InterfaceName | camelcase}}"
+packages:
+  github.com/my/app/store:
+    interfaces:
+      Store:
+`),
+		},
+		{
+			Name: "with old mock package used by several packages",
+			ExpectedResult: &mockimport.Result{
+				Converted: []*mockimport.Conversion{
+					{
+						PackagePath: "github.com/my/app/inpkg",
+						Interfaces:  []string{"Doer"},
+						Notes:       []string{"the mocks in github.com/my/app/mocks move to several packages, so its imports are not rewritten"},
+					},
+					{
+						PackagePath: "github.com/my/app/store",
+						Interfaces:  []string{"Store"},
+						Notes:       []string{"the mocks in github.com/my/app/mocks move to several packages, so its imports are not rewritten"},
+					},
+				},
+				Unsupported: []*mockimport.Unsupported{},
+				Moves:       []*importrewrite.Move{},
+			},
+			SetupMocks: expectConfig(".mockery.yaml", `
+dir: mocks
+outpkg: mocks
+all: true
+packages:
+  github.com/my/app/store:
+  github.com/my/app/inpkg:
+`),
+		},
+		{
+			Name:          "when missing mockery config",
+			ExpectedError: mockimport.ErrMissingMockeryConfig,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.mockery.yaml").Return(nil, fs.ErrNotExist)
+				m.FS.EXPECT().ReadFile("my/app/.mockery.yml").Return(nil, fs.ErrNotExist)
+			},
+		},
+		{
+			Name:          "when unable to read mockery config",
+			ExpectedError: mockimport.ErrCannotReadMockeryConfig,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.mockery.yaml").Return(nil, exampleError)
+			},
+		},
+		{
+			Name:          "when unable to parse mockery config",
+			ExpectedError: mockimport.ErrCannotParseMockeryConfig,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.mockery.yaml").Return([]byte("packages: ["), nil)
+			},
+		},
+		{
+			Name:          "when mockery config does not list packages",
+			ExpectedError: mockimport.ErrInvalidMockeryConfig,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.mockery.yaml").Return([]byte("name: Store\n"), nil)
+			},
+		},
+		{
+			Name:          "when unable to find Go files",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.mockery.yaml").Return([]byte("packages:\n  github.com/my/app/store:\n"), nil)
+				m.Finder.EXPECT().GoFiles("/my/app").Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		result, err := entry.Subject.FindMockery(config)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(result).Equals(entry.ExpectedResult)
+	})
+}
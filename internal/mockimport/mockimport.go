@@ -0,0 +1,332 @@
+// Package mockimport converts existing mock generation setups into packages in .ensure.yml.
+package mockimport
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"bursavich.dev/fs-shim/io/fs"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/importrewrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/erk/erg"
+)
+
+type ErkCannotImport struct{ erk.DefaultKind }
+
+var (
+	ErrDirectiveChanged         = erk.New(ErkCannotImport{}, "Cannot remove the directive on line {{.line}} of '{{.path}}', since the file changed")
+	ErrMissingMockeryConfig     = erk.New(ErkCannotImport{}, "Could not find .mockery.yaml or .mockery.yml in '{{.path}}'")
+	ErrInvalidMockeryConfig     = erk.New(ErkCannotImport{}, "Cannot import '{{.path}}', since {{.reason}}")
+	ErrCannotReadMockeryConfig  = erk.New(ErkCannotImport{}, "Could not read '{{.path}}': {{.err}}")
+	ErrCannotParseMockeryConfig = erk.New(ErkCannotImport{}, "Could not parse '{{.path}}': {{.err}}")
+	ErrCannotWriteFile          = erk.New(mockgen.ErkFSWriteError{}, "Could not write '{{.path}}': {{.err}}")
+)
+
+// Directive is a `//go:generate` line in a Go file.
+type Directive struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Conversion of a directive or mockery package into a package in .ensure.yml.
+type Conversion struct {
+	Directive   *Directive        `json:"directive,omitempty"`
+	PackagePath string            `json:"packagePath"`
+	Interfaces  []string          `json:"interfaces"`
+	Typed       bool              `json:"typed"`
+	MockNames   map[string]string `json:"mockNames,omitempty"` // Custom mock names, keyed by interface
+	Notes       []string          `json:"notes,omitempty"`
+}
+
+// Unsupported is a directive or mockery interface that cannot be expressed in .ensure.yml.
+type Unsupported struct {
+	Directive   *Directive `json:"directive,omitempty"`
+	PackagePath string     `json:"packagePath,omitempty"`
+	Interface   string     `json:"interface,omitempty"`
+	Reason      string     `json:"reason"`
+}
+
+// Result of searching for directives or mockery packages.
+type Result struct {
+	Converted   []*Conversion         `json:"converted"`
+	Unsupported []*Unsupported        `json:"unsupported"`
+	Moves       []*importrewrite.Move `json:"moves,omitempty"` // Old mock packages that move to new import paths
+}
+
+type ImporterIface interface {
+	FindDirectives(config *ensurefile.Config) (*Result, error)
+	FindMockery(config *ensurefile.Config) (*Result, error)
+	Apply(config *ensurefile.Config, conversions []*Conversion, removeDirectives bool) error
+}
+
+// Importer converts `//go:generate mockgen` directives and mockery configs into packages in .ensure.yml.
+type Importer struct {
+	Finder           modfiles.FinderIface
+	EnsureFileLoader ensurefile.LoaderIface
+	FS               fs.FS
+	FSWrite          fswrite.FSWriteIface
+}
+
+var _ ImporterIface = &Importer{}
+
+// FindDirectives finds the mockgen directives in the module, and converts them into packages.
+// The config does not need to contain any packages, or even exist yet.
+func (i *Importer) FindDirectives(config *ensurefile.Config) (*Result, error) {
+	goFiles, err := i.Finder.GoFiles(config.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	module := newModule(config, goFiles)
+	result := &Result{Converted: []*Conversion{}, Unsupported: []*Unsupported{}}
+
+	for _, goFile := range goFiles {
+		for _, directive := range findDirectives(goFile) {
+			args, ok := mockgenArgs(directive.Text)
+			if !ok {
+				continue // Not a mockgen directive
+			}
+
+			conversion, reason := module.convert(goFile, directive, args)
+			if reason != "" {
+				result.Unsupported = append(result.Unsupported, &Unsupported{Directive: directive, Reason: reason})
+				continue
+			}
+
+			result.Converted = append(result.Converted, conversion)
+		}
+	}
+
+	return result, nil
+}
+
+// Apply adds the converted packages to .ensure.yml, creating it if needed.
+// If removeDirectives is true, the converted directives are removed from their files.
+func (i *Importer) Apply(config *ensurefile.Config, conversions []*Conversion, removeDirectives bool) error {
+	doc, err := i.EnsureFileLoader.LoadDocument(config.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	for _, conversion := range conversions {
+		if err := doc.AddInterfaces(conversion.PackagePath, conversion.Interfaces); err != nil {
+			return err
+		}
+
+		if conversion.Typed {
+			if err := doc.SetTyped(conversion.PackagePath, true); err != nil {
+				return err
+			}
+		}
+
+		for _, iface := range conversion.Interfaces {
+			if mockName, ok := conversion.MockNames[iface]; ok {
+				if err := doc.SetMockName(conversion.PackagePath, iface, mockName); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	data, err := doc.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err := i.writeFile(config.ConfigPath, string(data)); err != nil {
+		return err
+	}
+
+	if !removeDirectives {
+		return nil
+	}
+
+	directives := make([]*Directive, 0, len(conversions))
+	for _, conversion := range conversions {
+		if conversion.Directive != nil { // Mockery packages do not have directives
+			directives = append(directives, conversion.Directive)
+		}
+	}
+
+	return i.removeDirectives(config, directives)
+}
+
+func (i *Importer) removeDirectives(config *ensurefile.Config, directives []*Directive) error {
+	linesByFile := map[string]map[int]string{}
+	for _, directive := range directives {
+		if linesByFile[directive.File] == nil {
+			linesByFile[directive.File] = map[int]string{}
+		}
+
+		linesByFile[directive.File][directive.Line] = directive.Text
+	}
+
+	goFiles, err := i.Finder.GoFiles(config.RootPath)
+	if err != nil {
+		return err
+	}
+
+	for _, goFile := range goFiles {
+		lines, ok := linesByFile[goFile.Path]
+		if !ok {
+			continue
+		}
+
+		contents, err := removeLines(goFile, lines)
+		if err != nil {
+			return err
+		}
+
+		if err := i.writeFile(goFile.Path, contents); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i *Importer) writeFile(filePath, contents string) error {
+	if err := i.FSWrite.WriteFile(filePath, contents, 0664); err != nil {
+		return erk.WrapWith(ErrCannotWriteFile, err, erk.Params{
+			"path": filePath,
+		})
+	}
+
+	return nil
+}
+
+// removeLines removes the lines from the file, after checking they are unchanged.
+func removeLines(goFile *modfiles.GoFile, lines map[int]string) (string, error) {
+	fileLines := strings.SplitAfter(goFile.Contents, "\n")
+	keptLines := make([]string, 0, len(fileLines))
+
+	for idx, line := range fileLines {
+		text, ok := lines[idx+1]
+		if !ok {
+			keptLines = append(keptLines, line)
+			continue
+		}
+
+		if strings.TrimRight(line, "\r\n") != text {
+			return "", erk.WithParams(ErrDirectiveChanged, erk.Params{
+				"path": goFile.Path,
+				"line": idx + 1,
+			})
+		}
+	}
+
+	return strings.Join(keptLines, ""), nil
+}
+
+// findDirectives returns the `//go:generate` lines, using the same rules as `go generate`.
+func findDirectives(goFile *modfiles.GoFile) []*Directive {
+	directives := []*Directive{}
+
+	for idx, line := range strings.Split(goFile.Contents, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "//go:generate ") || strings.HasPrefix(line, "//go:generate\t") {
+			directives = append(directives, &Directive{File: goFile.Path, Line: idx + 1, Text: line})
+		}
+	}
+
+	return directives
+}
+
+// module indexes the Go files, so directives can be resolved to packages and interfaces.
+type module struct {
+	config  *ensurefile.Config
+	byPath  map[string]*modfiles.GoFile
+	byDir   map[string][]*modfiles.GoFile
+	parsed  map[string]*parsedFile
+	rootDir string
+}
+
+type parsedFile struct {
+	packageName string
+	interfaces  []string
+	err         error
+}
+
+func newModule(config *ensurefile.Config, goFiles []*modfiles.GoFile) *module {
+	m := &module{
+		config:  config,
+		byPath:  map[string]*modfiles.GoFile{},
+		byDir:   map[string][]*modfiles.GoFile{},
+		parsed:  map[string]*parsedFile{},
+		rootDir: config.RootPath,
+	}
+
+	for _, goFile := range goFiles {
+		m.byPath[goFile.Path] = goFile
+		m.byDir[filepath.Dir(goFile.Path)] = append(m.byDir[filepath.Dir(goFile.Path)], goFile)
+	}
+
+	return m
+}
+
+func (m *module) parse(goFile *modfiles.GoFile) *parsedFile {
+	if parsed, ok := m.parsed[goFile.Path]; ok {
+		return parsed
+	}
+
+	parsed := &parsedFile{}
+	file, err := goFile.Parse()
+	if err != nil {
+		parsed.err = err
+	} else {
+		parsed.packageName = file.Name.Name
+		parsed.interfaces = modfiles.Interfaces(file)
+	}
+
+	m.parsed[goFile.Path] = parsed
+	return parsed
+}
+
+// importPath of the package in the directory within the module.
+func (m *module) importPath(dir string) (string, bool) {
+	relativeDir, err := filepath.Rel(m.rootDir, dir)
+	if err != nil || strings.HasPrefix(relativeDir, "..") {
+		return "", false
+	}
+
+	return path.Join(m.config.ModulePath, filepath.ToSlash(relativeDir)), true
+}
+
+// resolve returns the mock package that ensure generates for the package.
+// The file and line are where the package was found, and are included in errors.
+func (m *module) resolve(pkgPath, file string, line int) (*mockgen.ResolvedPackage, error) {
+	mocks := ensurefile.MockConfig{}
+	if m.config.Mocks != nil {
+		mocks = *m.config.Mocks
+	}
+	mocks.Packages = []*ensurefile.Package{{Path: pkgPath, File: file, Line: line}}
+
+	config := *m.config
+	config.Mocks = &mocks
+	config.PackageFilters = nil
+
+	resolved, err := mockgen.ResolveConfig(&config)
+	if errs := erg.GetErrors(err); len(errs) > 0 {
+		return nil, errs[0] // Only one package is resolved
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resolved.Packages[0], nil
+}
+
+func (m *module) relative(filePath string) string {
+	if relativePath, err := filepath.Rel(m.rootDir, filePath); err == nil {
+		return relativePath
+	}
+
+	return filePath
+}
@@ -0,0 +1,373 @@
+package mockimport_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockimport"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_modfiles"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+const expectedFilePerm = os.FileMode(0664)
+
+func TestFindDirectives(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Finder *mock_modfiles.MockFinderIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	newConfig := func() *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			ConfigPath: "/my/app/.ensure.yml",
+		}
+	}
+
+	newFile := func(path string, lines ...string) *modfiles.GoFile {
+		contents := "package pkg\n\n"
+		for _, line := range lines {
+			contents += line + "\n"
+		}
+
+		return &modfiles.GoFile{Path: path, Contents: contents + "\ntype Iface1 interface{}\ntype Iface2 interface{}\n"}
+	}
+
+	directive := func(path string, line int, text string) *mockimport.Directive {
+		return &mockimport.Directive{File: path, Line: line, Text: text}
+	}
+
+	brokenFile := &modfiles.GoFile{Path: "/my/app/broken/broken.go", Contents: "package\n//go:generate mockgen -source=broken.go\n"}
+	_, brokenFileErr := brokenFile.Parse()
+
+	table := []struct {
+		Name           string
+		Config         *ensurefile.Config
+		GoFiles        []*modfiles.GoFile
+		ExpectedResult *mockimport.Result
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *mockimport.Importer
+	}{
+		{
+			Name:   "with reflect mode directives",
+			Config: newConfig(),
+			GoFiles: []*modfiles.GoFile{
+				newFile("/my/app/pkg/pkg.go",
+					"//go:generate mockgen -mock_names=Iface1=FakeIface1,Iface2=MockIface2,Other=FakeOther github.com/some/pkg Iface1,Iface2",
+					"//go:generate go run -mod=mod github.com/golang/mock/mockgen@v1.6.0 -typed . Iface1",
+					`//go:generate mockgen -destination "internal/mocks/github.com/my/app/mock_pkg/mock_pkg.go" . Iface2`,
+					"//go:generate stringer -type=Thing",
+				),
+			},
+			ExpectedResult: &mockimport.Result{
+				Converted: []*mockimport.Conversion{
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 3,
+							"//go:generate mockgen -mock_names=Iface1=FakeIface1,Iface2=MockIface2,Other=FakeOther github.com/some/pkg Iface1,Iface2",
+						),
+						PackagePath: "github.com/some/pkg",
+						Interfaces:  []string{"Iface1", "Iface2"},
+						MockNames:   map[string]string{"Iface1": "FakeIface1"},
+					},
+					{
+						Directive:   directive("/my/app/pkg/pkg.go", 4, "//go:generate go run -mod=mod github.com/golang/mock/mockgen@v1.6.0 -typed . Iface1"),
+						PackagePath: "github.com/my/app/pkg",
+						Interfaces:  []string{"Iface1"},
+						Typed:       true,
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 5,
+							`//go:generate mockgen -destination "internal/mocks/github.com/my/app/mock_pkg/mock_pkg.go" . Iface2`,
+						),
+						PackagePath: "github.com/my/app/pkg",
+						Interfaces:  []string{"Iface2"},
+						Notes: []string{
+							"the mocks move from pkg/internal/mocks/github.com/my/app/mock_pkg/mock_pkg.go " +
+								"to internal/mocks/github.com/my/app/mock_pkg/mock_pkg.go",
+						},
+					},
+				},
+				Unsupported: []*mockimport.Unsupported{},
+			},
+		},
+		{
+			Name: "with source mode directives",
+			Config: &ensurefile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				Mocks:      &ensurefile.MockConfig{PrimaryDestination: "mocks"},
+			},
+			GoFiles: []*modfiles.GoFile{
+				newFile("/my/app/pkg/pkg.go",
+					"//go:generate mockgen -source=$GOFILE -destination=../mocks/github.com/my/app/mock_pkg/mock_pkg.go -package=mock_$GOPACKAGE",
+					"//go:generate mockgen -source=pkg.go -exclude_interfaces=Iface1 -mock_names=Iface2=MockIface2 -package=fakes",
+				),
+			},
+			ExpectedResult: &mockimport.Result{
+				Converted: []*mockimport.Conversion{
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 3,
+							"//go:generate mockgen -source=$GOFILE -destination=../mocks/github.com/my/app/mock_pkg/mock_pkg.go -package=mock_$GOPACKAGE",
+						),
+						PackagePath: "github.com/my/app/pkg",
+						Interfaces:  []string{"Iface1", "Iface2"},
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 4,
+							"//go:generate mockgen -source=pkg.go -exclude_interfaces=Iface1 -mock_names=Iface2=MockIface2 -package=fakes",
+						),
+						PackagePath: "github.com/my/app/pkg",
+						Interfaces:  []string{"Iface2"},
+						Notes:       []string{"the mock package is named mock_pkg instead of fakes"},
+					},
+				},
+				Unsupported: []*mockimport.Unsupported{},
+			},
+		},
+		{
+			Name:   "with unsupported directives",
+			Config: newConfig(),
+			GoFiles: []*modfiles.GoFile{
+				newFile("/my/app/pkg/pkg.go",
+					"//go:generate mockgen -copyright_file=LICENSE . Iface1",
+					"//go:generate mockgen -build_flags=-tags=integration . Iface1",
+					"//go:generate mockgen -mock_names=Iface1 . Iface1",
+					"//go:generate mockgen -unknown . Iface1",
+					"//go:generate mockgen .",
+					"//go:generate mockgen . ,",
+					"//go:generate mockgen -source=$OTHER",
+					"//go:generate mockgen -source=missing.go",
+					"//go:generate mockgen -source=pkg.go . Iface1",
+					"//go:generate mockgen -source=pkg.go -exclude_interfaces=Iface1,Iface2",
+					"//go:generate mockgen github.com/other/internal/pkg Iface1",
+				),
+				brokenFile,
+				{Path: "/my/app/bad/bad.go", Contents: "package bad\n//go:generate mockgen \"-source=bad.go\n"},
+			},
+			ExpectedResult: &mockimport.Result{
+				Converted: []*mockimport.Conversion{},
+				Unsupported: []*mockimport.Unsupported{
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 3, "//go:generate mockgen -copyright_file=LICENSE . Iface1"),
+						Reason:    "the -copyright_file flag has no equivalent in .ensure.yml",
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 4, "//go:generate mockgen -build_flags=-tags=integration . Iface1"),
+						Reason:    "the -build_flags flag has no equivalent in .ensure.yml",
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 5, "//go:generate mockgen -mock_names=Iface1 . Iface1"),
+						Reason:    "the -mock_names entry 'Iface1' cannot be parsed",
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 6, "//go:generate mockgen -unknown . Iface1"),
+						Reason:    "the arguments cannot be parsed: flag provided but not defined: -unknown",
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 7, "//go:generate mockgen ."),
+						Reason:    "expected a package and a comma-separated list of interfaces",
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 8, "//go:generate mockgen . ,"),
+						Reason:    "no interfaces were provided",
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 9, "//go:generate mockgen -source=$OTHER"),
+						Reason:    "the environment variable $OTHER cannot be resolved",
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 10, "//go:generate mockgen -source=missing.go"),
+						Reason:    "the source file pkg/missing.go is not part of a package in the module",
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 11, "//go:generate mockgen -source=pkg.go . Iface1"),
+						Reason:    "source mode does not accept a package or interfaces",
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 12, "//go:generate mockgen -source=pkg.go -exclude_interfaces=Iface1,Iface2"),
+						Reason:    "the source file pkg/pkg.go does not declare any interfaces",
+					},
+					{
+						Directive: directive("/my/app/pkg/pkg.go", 13, "//go:generate mockgen github.com/other/internal/pkg Iface1"),
+						Reason: "Cannot generate mock of internal package, since package 'github.com/other/internal/pkg' " +
+							"in /my/app/pkg/pkg.go:13 is not in the current module 'github.com/my/app'",
+					},
+					{
+						Directive: directive("/my/app/broken/broken.go", 2, "//go:generate mockgen -source=broken.go"),
+						Reason:    brokenFileErr.Error(),
+					},
+				},
+			},
+		},
+		{
+			Name:          "when unable to find Go files",
+			Config:        newConfig(),
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.Finder.EXPECT().GoFiles("/my/app").Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		if entry.GoFiles != nil {
+			entry.Mocks.Finder.EXPECT().GoFiles("/my/app").Return(entry.GoFiles, nil)
+		}
+
+		result, err := entry.Subject.FindDirectives(entry.Config)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(result).Equals(entry.ExpectedResult)
+	})
+}
+
+func TestApply(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Finder  *mock_modfiles.MockFinderIface
+		FS      *mock_fs.MockReadFileFS
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	config := &ensurefile.Config{RootPath: "/my/app", ConfigPath: "/my/app/.ensure.yml"}
+	conversions := []*mockimport.Conversion{
+		{
+			Directive:   &mockimport.Directive{File: "/my/app/pkg/pkg.go", Line: 3, Text: "//go:generate mockgen . Iface1"},
+			PackagePath: "github.com/my/app/pkg",
+			Interfaces:  []string{"Iface1"},
+		},
+		{
+			Directive:   &mockimport.Directive{File: "/my/app/pkg/pkg.go", Line: 4, Text: "//go:generate mockgen -typed . Iface2"},
+			PackagePath: "github.com/my/app/pkg",
+			Interfaces:  []string{"Iface2"},
+			Typed:       true,
+			MockNames:   map[string]string{"Iface2": "FakeIface2"},
+		},
+	}
+
+	const expectedConfigFile = "version: 1\n" +
+		"mocks:\n" +
+		"  packages:\n" +
+		"    - path: github.com/my/app/pkg\n" +
+		"      interfaces: [Iface1, Iface2]\n" +
+		"      typed: true\n" +
+		"      mockNames:\n" +
+		"        Iface2: FakeIface2\n"
+
+	goFiles := []*modfiles.GoFile{
+		{
+			Path:     "/my/app/pkg/pkg.go",
+			Contents: "package pkg\n\n//go:generate mockgen . Iface1\n//go:generate mockgen -typed . Iface2\n//go:generate stringer\n",
+		},
+		{Path: "/my/app/other.go", Contents: "package app\n"},
+	}
+
+	table := []struct {
+		Name             string
+		RemoveDirectives bool
+		ExpectedError    error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *mockimport.Importer
+	}{
+		{
+			Name: "adds the packages",
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml", expectedConfigFile, expectedFilePerm).Return(nil)
+			},
+		},
+		{
+			Name:             "adds the packages and removes the directives",
+			RemoveDirectives: true,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml", expectedConfigFile, expectedFilePerm).Return(nil)
+				m.Finder.EXPECT().GoFiles("/my/app").Return(goFiles, nil)
+				m.FSWrite.EXPECT().WriteFile("/my/app/pkg/pkg.go", "package pkg\n\n//go:generate stringer\n", expectedFilePerm).Return(nil)
+			},
+		},
+		{
+			Name:             "when directive changed",
+			RemoveDirectives: true,
+			ExpectedError:    mockimport.ErrDirectiveChanged,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml", expectedConfigFile, expectedFilePerm).Return(nil)
+				m.Finder.EXPECT().GoFiles("/my/app").Return([]*modfiles.GoFile{
+					{Path: "/my/app/pkg/pkg.go", Contents: "package pkg\n\n//go:generate mockgen . Changed\n"},
+				}, nil)
+			},
+		},
+		{
+			Name:          "when unable to load document",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, exampleError)
+			},
+		},
+		{
+			Name:          "when unable to edit document",
+			ExpectedError: ensurefile.ErrCannotEditFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte("mocks: nope\n"), nil)
+			},
+		},
+		{
+			Name:          "when unable to write config",
+			ExpectedError: mockimport.ErrCannotWriteFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml", gomock.Any(), expectedFilePerm).Return(exampleError)
+			},
+		},
+		{
+			Name:             "when unable to find Go files",
+			RemoveDirectives: true,
+			ExpectedError:    exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml", expectedConfigFile, expectedFilePerm).Return(nil)
+				m.Finder.EXPECT().GoFiles("/my/app").Return(nil, exampleError)
+			},
+		},
+		{
+			Name:             "when unable to write Go file",
+			RemoveDirectives: true,
+			ExpectedError:    mockimport.ErrCannotWriteFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, os.ErrNotExist)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml", expectedConfigFile, expectedFilePerm).Return(nil)
+				m.Finder.EXPECT().GoFiles("/my/app").Return(goFiles, nil)
+				m.FSWrite.EXPECT().WriteFile("/my/app/pkg/pkg.go", gomock.Any(), expectedFilePerm).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.EnsureFileLoader = &ensurefile.Loader{FS: entry.Mocks.FS}
+
+		err := entry.Subject.Apply(config, conversions, entry.RemoveDirectives)
+		ensure(err).IsError(entry.ExpectedError)
+	})
+}
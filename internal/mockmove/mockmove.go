@@ -0,0 +1,209 @@
+// Package mockmove moves the generated mocks when the mock destinations change.
+package mockmove
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/importrewrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/erk"
+)
+
+var (
+	ErrCannotWriteFile = erk.New(mockgen.ErkFSWriteError{}, "Could not write '{{.path}}': {{.err}}")
+	ErrCannotRemove    = erk.New(mockgen.ErkFSWriteError{}, "Could not delete '{{.path}}': {{.err}}")
+)
+
+// Destinations of the mocks. Empty destinations are the same as the config's.
+type Destinations struct {
+	Primary  string `json:"primaryDestination"`
+	Internal string `json:"internalDestination"`
+}
+
+// Plan for moving the mocks between destinations.
+type Plan struct {
+	From   *Destinations         `json:"from"`
+	To     *Destinations         `json:"to"`
+	Moves  []*importrewrite.Move `json:"moves"`
+	Remove []string              `json:"remove"` // Paths at the old destinations
+}
+
+// Unchanged is true if the destinations are the same.
+func (p *Plan) Unchanged() bool {
+	return *p.From == *p.To
+}
+
+// PlanMove computes the old and new import path of every mock package, and the paths to remove from the old destinations.
+// If an old mock directory does not overlap the new mock directories, the whole directory is removed, like tidying it would.
+// Otherwise, only the old mock packages are removed. The config is not modified.
+func PlanMove(config *ensurefile.Config, from, to *Destinations) (*Plan, error) {
+	oldConfig := withDestinations(config, from)
+	newConfig := withDestinations(config, to)
+
+	oldResolved, err := mockgen.ResolveConfig(oldConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	newResolved, err := mockgen.ResolveConfig(newConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		From:   &Destinations{Primary: oldResolved.PrimaryDestination, Internal: oldResolved.InternalDestination},
+		To:     &Destinations{Primary: newResolved.PrimaryDestination, Internal: newResolved.InternalDestination},
+		Moves:  []*importrewrite.Move{},
+		Remove: []string{},
+	}
+
+	// Both configs list the same packages, so they resolve in the same order
+	movedDirs := []string{}
+	newPackageDirs := []string{}
+	for idx, oldPkg := range oldResolved.Packages {
+		newPkg := newResolved.Packages[idx]
+		newPackageDirs = append(newPackageDirs, filepath.Dir(newPkg.FilePath))
+
+		if oldPkg.ImportPath != newPkg.ImportPath {
+			plan.Moves = append(plan.Moves, &importrewrite.Move{OldPath: oldPkg.ImportPath, NewPath: newPkg.ImportPath})
+			movedDirs = append(movedDirs, filepath.Dir(oldPkg.FilePath))
+		}
+	}
+
+	oldMockDirs, err := mockgen.MockDirs(oldConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	newMockDirs, err := mockgen.MockDirs(newConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	remove := map[string]bool{}
+	for _, oldMockDir := range oldMockDirs {
+		if !overlapsAny(oldMockDir, newMockDirs) {
+			remove[oldMockDir] = true
+			continue
+		}
+
+		for _, movedDir := range movedDirs {
+			if isWithin(movedDir, oldMockDir) && !overlapsAny(movedDir, newPackageDirs) {
+				remove[movedDir] = true
+			}
+		}
+	}
+
+	for path := range remove {
+		plan.Remove = append(plan.Remove, path)
+	}
+	sort.Strings(plan.Remove)
+
+	sort.Slice(plan.Moves, func(i, j int) bool { return plan.Moves[i].OldPath < plan.Moves[j].OldPath })
+	return plan, nil
+}
+
+// Apply returns a copy of the config using the new destinations, which SetDestinations writes to .ensure.yml.
+// This avoids reloading .ensure.yml, where ENSURE_MOCKS_* environment variables would take precedence over the new destinations.
+func (p *Plan) Apply(config *ensurefile.Config) *ensurefile.Config {
+	return withDestinations(config, p.To)
+}
+
+type MoverIface interface {
+	SetDestinations(config *ensurefile.Config, plan *Plan) error
+	RemoveOld(plan *Plan) error
+}
+
+// Mover edits .ensure.yml and removes the old mocks.
+type Mover struct {
+	EnsureFileLoader ensurefile.LoaderIface
+	FSWrite          fswrite.FSWriteIface
+}
+
+var _ MoverIface = &Mover{}
+
+// SetDestinations writes the destinations that changed in the plan to .ensure.yml, preserving its comments.
+func (m *Mover) SetDestinations(config *ensurefile.Config, plan *Plan) error {
+	doc, err := m.EnsureFileLoader.LoadDocument(config.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	if plan.From.Primary != plan.To.Primary {
+		if err := doc.SetMockOption("primaryDestination", plan.To.Primary); err != nil {
+			return err
+		}
+	}
+
+	if plan.From.Internal != plan.To.Internal {
+		if err := doc.SetMockOption("internalDestination", plan.To.Internal); err != nil {
+			return err
+		}
+	}
+
+	data, err := doc.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err := m.FSWrite.WriteFile(config.ConfigPath, string(data), 0664); err != nil {
+		return erk.WrapWith(ErrCannotWriteFile, err, erk.Params{
+			"path": config.ConfigPath,
+		})
+	}
+
+	return nil
+}
+
+// RemoveOld removes the paths at the old destinations.
+func (m *Mover) RemoveOld(plan *Plan) error {
+	for _, path := range plan.Remove {
+		if err := m.FSWrite.RemoveAll(path); err != nil {
+			return erk.WrapWith(ErrCannotRemove, err, erk.Params{
+				"path": path,
+			})
+		}
+	}
+
+	return nil
+}
+
+// withDestinations returns a copy of the config using the destinations.
+func withDestinations(config *ensurefile.Config, destinations *Destinations) *ensurefile.Config {
+	mocks := ensurefile.MockConfig{}
+	if config.Mocks != nil {
+		mocks = *config.Mocks
+	}
+
+	if destinations.Primary != "" {
+		mocks.PrimaryDestination = destinations.Primary
+	}
+
+	if destinations.Internal != "" {
+		mocks.InternalDestination = destinations.Internal
+	}
+
+	configCopy := *config
+	configCopy.Mocks = &mocks
+	configCopy.PackageFilters = nil
+	return &configCopy
+}
+
+func overlapsAny(dir string, dirs []string) bool {
+	for _, other := range dirs {
+		if isWithin(dir, other) || isWithin(other, dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isWithin returns true if the path is the dir, or is nested within it.
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
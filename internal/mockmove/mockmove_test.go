@@ -0,0 +1,307 @@
+package mockmove_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/importrewrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mockmove"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+func TestPlanMove(t *testing.T) {
+	ensure := ensure.New(t)
+
+	newConfig := func() *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			Mocks: &ensurefile.MockConfig{
+				Packages: []*ensurefile.Package{
+					{Path: "github.com/my/app/store", Interfaces: []string{"Store"}},
+					{Path: "github.com/my/app/internal/svc", Interfaces: []string{"Service"}},
+				},
+			},
+		}
+	}
+
+	table := []struct {
+		Name          string
+		Config        *ensurefile.Config
+		From          *mockmove.Destinations
+		To            *mockmove.Destinations
+		ExpectedPlan  *mockmove.Plan
+		ExpectedError error
+	}{
+		{
+			Name:   "with new destinations",
+			Config: newConfig(),
+			From:   &mockmove.Destinations{},
+			To:     &mockmove.Destinations{Primary: "mocks", Internal: "fakes"},
+			ExpectedPlan: &mockmove.Plan{
+				From: &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+				To:   &mockmove.Destinations{Primary: "mocks", Internal: "fakes"},
+				Moves: []*importrewrite.Move{
+					{
+						OldPath: "github.com/my/app/internal/mocks/github.com/my/app/mock_store",
+						NewPath: "github.com/my/app/mocks/github.com/my/app/mock_store",
+					},
+					{
+						OldPath: "github.com/my/app/internal/mocks/mock_svc",
+						NewPath: "github.com/my/app/internal/fakes/mock_svc",
+					},
+				},
+				Remove: []string{"/my/app/internal/mocks"},
+			},
+		},
+		{
+			Name:   "with old destinations after .ensure.yml changed",
+			Config: newConfig(),
+			From:   &mockmove.Destinations{Primary: "mocks"},
+			To:     &mockmove.Destinations{},
+			ExpectedPlan: &mockmove.Plan{
+				From: &mockmove.Destinations{Primary: "mocks", Internal: "mocks"},
+				To:   &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+				Moves: []*importrewrite.Move{
+					{
+						OldPath: "github.com/my/app/mocks/github.com/my/app/mock_store",
+						NewPath: "github.com/my/app/internal/mocks/github.com/my/app/mock_store",
+					},
+				},
+				Remove: []string{"/my/app/mocks"},
+			},
+		},
+		{
+			Name:   "with overlapping destinations",
+			Config: newConfig(),
+			From:   &mockmove.Destinations{},
+			To:     &mockmove.Destinations{Primary: "internal/mocks/external"},
+			ExpectedPlan: &mockmove.Plan{
+				From: &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+				To:   &mockmove.Destinations{Primary: "internal/mocks/external", Internal: "mocks"},
+				Moves: []*importrewrite.Move{
+					{
+						OldPath: "github.com/my/app/internal/mocks/github.com/my/app/mock_store",
+						NewPath: "github.com/my/app/internal/mocks/external/github.com/my/app/mock_store",
+					},
+				},
+				Remove: []string{"/my/app/internal/mocks/github.com/my/app/mock_store"},
+			},
+		},
+		{
+			Name:   "with unchanged destinations",
+			Config: newConfig(),
+			From:   &mockmove.Destinations{},
+			To:     &mockmove.Destinations{Primary: "internal/mocks"},
+			ExpectedPlan: &mockmove.Plan{
+				From:   &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+				To:     &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+				Moves:  []*importrewrite.Move{},
+				Remove: []string{},
+			},
+		},
+		{
+			Name:          "when missing packages",
+			Config:        &ensurefile.Config{RootPath: "/my/app", ModulePath: "github.com/my/app"},
+			From:          &mockmove.Destinations{},
+			To:            &mockmove.Destinations{Primary: "mocks"},
+			ExpectedError: mockgen.ErrMissingPackages,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		plan, err := mockmove.PlanMove(entry.Config, entry.From, entry.To)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(plan).Equals(entry.ExpectedPlan)
+		ensure(entry.Config.Mocks == nil || entry.Config.Mocks.PrimaryDestination == "").IsTrue() // Config is not modified
+	})
+}
+
+func TestPlanUnchanged(t *testing.T) {
+	ensure := ensure.New(t)
+
+	plan := &mockmove.Plan{
+		From: &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+		To:   &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+	}
+	ensure(plan.Unchanged()).IsTrue()
+
+	plan.To.Internal = "fakes"
+	ensure(plan.Unchanged()).IsFalse()
+}
+
+func TestPlanApply(t *testing.T) {
+	ensure := ensure.New(t)
+
+	config := &ensurefile.Config{
+		RootPath: "/my/app",
+		Mocks: &ensurefile.MockConfig{
+			PrimaryDestination:  "internal/mocks",
+			InternalDestination: "mocks",
+			TidyAfterGenerate:   true,
+		},
+	}
+
+	plan := &mockmove.Plan{
+		From: &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+		To:   &mockmove.Destinations{Primary: "mocks", Internal: "fakes"},
+	}
+
+	ensure(plan.Apply(config)).Equals(&ensurefile.Config{
+		RootPath: "/my/app",
+		Mocks: &ensurefile.MockConfig{
+			PrimaryDestination:  "mocks",
+			InternalDestination: "fakes",
+			TidyAfterGenerate:   true,
+		},
+	})
+	ensure(config.Mocks.PrimaryDestination).Equals("internal/mocks") // Config is not modified
+}
+
+func TestSetDestinations(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS      *mock_fs.MockReadFileFS
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	const filePerm = os.FileMode(0664)
+
+	config := &ensurefile.Config{RootPath: "/my/app", ConfigPath: "/my/app/.ensure.yml"}
+	const configFile = "mocks:\n  packages:\n    - path: github.com/my/app/store\n"
+
+	table := []struct {
+		Name          string
+		Plan          *mockmove.Plan
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *mockmove.Mover
+	}{
+		{
+			Name: "writes changed destinations",
+			Plan: &mockmove.Plan{
+				From: &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+				To:   &mockmove.Destinations{Primary: "mocks", Internal: "fakes"},
+			},
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(configFile), nil)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml",
+					"mocks:\n  primaryDestination: mocks\n  internalDestination: fakes\n  packages:\n    - path: github.com/my/app/store\n",
+					filePerm,
+				).Return(nil)
+			},
+		},
+		{
+			Name: "skips unchanged destinations",
+			Plan: &mockmove.Plan{
+				From: &mockmove.Destinations{Primary: "internal/mocks", Internal: "mocks"},
+				To:   &mockmove.Destinations{Primary: "internal/mocks", Internal: "fakes"},
+			},
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(configFile), nil)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml",
+					"mocks:\n  internalDestination: fakes\n  packages:\n    - path: github.com/my/app/store\n",
+					filePerm,
+				).Return(nil)
+			},
+		},
+		{
+			Name: "when unable to read file",
+			Plan: &mockmove.Plan{
+				From: &mockmove.Destinations{Primary: "internal/mocks"},
+				To:   &mockmove.Destinations{Primary: "mocks"},
+			},
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, exampleError)
+			},
+		},
+		{
+			Name: "when unable to edit file",
+			Plan: &mockmove.Plan{
+				From: &mockmove.Destinations{Primary: "internal/mocks"},
+				To:   &mockmove.Destinations{Primary: "mocks"},
+			},
+			ExpectedError: ensurefile.ErrCannotEditFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte("mocks: []\n"), nil)
+			},
+		},
+		{
+			Name: "when unable to write file",
+			Plan: &mockmove.Plan{
+				From: &mockmove.Destinations{Primary: "internal/mocks"},
+				To:   &mockmove.Destinations{Primary: "mocks"},
+			},
+			ExpectedError: mockmove.ErrCannotWriteFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(configFile), nil)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml", gomock.Any(), filePerm).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		entry.Subject.EnsureFileLoader = &ensurefile.Loader{FS: entry.Mocks.FS}
+
+		err := entry.Subject.SetDestinations(config, entry.Plan)
+		ensure(err).IsError(entry.ExpectedError)
+	})
+}
+
+func TestRemoveOld(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+	plan := &mockmove.Plan{Remove: []string{"/my/app/internal/mocks", "/my/app/pkg/internal/mocks"}}
+
+	table := []struct {
+		Name          string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *mockmove.Mover
+	}{
+		{
+			Name: "removes old paths",
+			SetupMocks: func(m *Mocks) {
+				m.FSWrite.EXPECT().RemoveAll("/my/app/internal/mocks").Return(nil)
+				m.FSWrite.EXPECT().RemoveAll("/my/app/pkg/internal/mocks").Return(nil)
+			},
+		},
+		{
+			Name:          "when unable to remove path",
+			ExpectedError: mockmove.ErrCannotRemove,
+			SetupMocks: func(m *Mocks) {
+				m.FSWrite.EXPECT().RemoveAll("/my/app/internal/mocks").Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		err := entry.Subject.RemoveOld(plan)
+		ensure(err).IsError(entry.ExpectedError)
+	})
+}
@@ -34,6 +34,36 @@ func (m *MockLoaderIface) EXPECT() *MockLoaderIfaceMockRecorder {
 	return m.recorder
 }
 
+// FindConfigFile mocks base method.
+func (m *MockLoaderIface) FindConfigFile(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindConfigFile", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindConfigFile indicates an expected call of FindConfigFile.
+func (mr *MockLoaderIfaceMockRecorder) FindConfigFile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindConfigFile", reflect.TypeOf((*MockLoaderIface)(nil).FindConfigFile), arg0)
+}
+
+// FindConfigFiles mocks base method.
+func (m *MockLoaderIface) FindConfigFiles(arg0 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindConfigFiles", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindConfigFiles indicates an expected call of FindConfigFiles.
+func (mr *MockLoaderIfaceMockRecorder) FindConfigFiles(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindConfigFiles", reflect.TypeOf((*MockLoaderIface)(nil).FindConfigFiles), arg0)
+}
+
 // LoadConfig mocks base method.
 func (m *MockLoaderIface) LoadConfig(arg0 string) (*ensurefile.Config, error) {
 	m.ctrl.T.Helper()
@@ -49,6 +79,78 @@ func (mr *MockLoaderIfaceMockRecorder) LoadConfig(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadConfig", reflect.TypeOf((*MockLoaderIface)(nil).LoadConfig), arg0)
 }
 
+// LoadConfigs mocks base method.
+func (m *MockLoaderIface) LoadConfigs(arg0 string) ([]*ensurefile.Config, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadConfigs", arg0)
+	ret0, _ := ret[0].([]*ensurefile.Config)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadConfigs indicates an expected call of LoadConfigs.
+func (mr *MockLoaderIfaceMockRecorder) LoadConfigs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadConfigs", reflect.TypeOf((*MockLoaderIface)(nil).LoadConfigs), arg0)
+}
+
+// LoadDocument mocks base method.
+func (m *MockLoaderIface) LoadDocument(arg0 string) (*ensurefile.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadDocument", arg0)
+	ret0, _ := ret[0].(*ensurefile.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadDocument indicates an expected call of LoadDocument.
+func (mr *MockLoaderIfaceMockRecorder) LoadDocument(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadDocument", reflect.TypeOf((*MockLoaderIface)(nil).LoadDocument), arg0)
+}
+
+// LoadModule mocks base method.
+func (m *MockLoaderIface) LoadModule(arg0 string) (*ensurefile.Config, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadModule", arg0)
+	ret0, _ := ret[0].(*ensurefile.Config)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadModule indicates an expected call of LoadModule.
+func (mr *MockLoaderIfaceMockRecorder) LoadModule(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadModule", reflect.TypeOf((*MockLoaderIface)(nil).LoadModule), arg0)
+}
+
+// UseConfigFile mocks base method.
+func (m *MockLoaderIface) UseConfigFile(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UseConfigFile", arg0)
+}
+
+// UseConfigFile indicates an expected call of UseConfigFile.
+func (mr *MockLoaderIfaceMockRecorder) UseConfigFile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseConfigFile", reflect.TypeOf((*MockLoaderIface)(nil).UseConfigFile), arg0)
+}
+
+// ValidateConfigFile mocks base method.
+func (m *MockLoaderIface) ValidateConfigFile(arg0 string) ([]*ensurefile.SchemaError, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateConfigFile", arg0)
+	ret0, _ := ret[0].([]*ensurefile.SchemaError)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateConfigFile indicates an expected call of ValidateConfigFile.
+func (mr *MockLoaderIfaceMockRecorder) ValidateConfigFile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateConfigFile", reflect.TypeOf((*MockLoaderIface)(nil).ValidateConfigFile), arg0)
+}
+
 // NEW creates a MockLoaderIface.
 func (*MockLoaderIface) NEW(ctrl *gomock.Controller) *MockLoaderIface {
 	return NewMockLoaderIface(ctrl)
@@ -34,6 +34,20 @@ func (m *MockFSWriteIface) EXPECT() *MockFSWriteIfaceMockRecorder {
 	return m.recorder
 }
 
+// Chmod mocks base method.
+func (m *MockFSWriteIface) Chmod(arg0 string, arg1 os.FileMode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Chmod", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Chmod indicates an expected call of Chmod.
+func (mr *MockFSWriteIfaceMockRecorder) Chmod(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Chmod", reflect.TypeOf((*MockFSWriteIface)(nil).Chmod), arg0, arg1)
+}
+
 // GlobRemoveAll mocks base method.
 func (m *MockFSWriteIface) GlobRemoveAll(arg0 string) error {
 	m.ctrl.T.Helper()
@@ -77,6 +91,21 @@ func (mr *MockFSWriteIfaceMockRecorder) MkdirAll(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MkdirAll", reflect.TypeOf((*MockFSWriteIface)(nil).MkdirAll), arg0, arg1)
 }
 
+// ReadFile mocks base method.
+func (m *MockFSWriteIface) ReadFile(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadFile", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadFile indicates an expected call of ReadFile.
+func (mr *MockFSWriteIfaceMockRecorder) ReadFile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadFile", reflect.TypeOf((*MockFSWriteIface)(nil).ReadFile), arg0)
+}
+
 // RemoveAll mocks base method.
 func (m *MockFSWriteIface) RemoveAll(arg0 string) error {
 	m.ctrl.T.Helper()
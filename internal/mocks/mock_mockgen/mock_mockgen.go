@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	ensurefile "github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	mockgen "github.com/JosiahWitt/ensure-cli/internal/mockgen"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,6 +36,35 @@ func (m *MockMockGenerator) EXPECT() *MockMockGeneratorMockRecorder {
 	return m.recorder
 }
 
+// CheckMocks mocks base method.
+func (m *MockMockGenerator) CheckMocks(arg0 context.Context, arg1 []*ensurefile.Config) (*mockgen.CheckResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckMocks", arg0, arg1)
+	ret0, _ := ret[0].(*mockgen.CheckResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckMocks indicates an expected call of CheckMocks.
+func (mr *MockMockGeneratorMockRecorder) CheckMocks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckMocks", reflect.TypeOf((*MockMockGenerator)(nil).CheckMocks), arg0, arg1)
+}
+
+// GenerateAllMocks mocks base method.
+func (m *MockMockGenerator) GenerateAllMocks(arg0 context.Context, arg1 []*ensurefile.Config) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateAllMocks", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GenerateAllMocks indicates an expected call of GenerateAllMocks.
+func (mr *MockMockGeneratorMockRecorder) GenerateAllMocks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAllMocks", reflect.TypeOf((*MockMockGenerator)(nil).GenerateAllMocks), arg0, arg1)
+}
+
 // GenerateMocks mocks base method.
 func (m *MockMockGenerator) GenerateMocks(arg0 context.Context, arg1 *ensurefile.Config) error {
 	m.ctrl.T.Helper()
@@ -63,6 +93,20 @@ func (mr *MockMockGeneratorMockRecorder) TidyMocks(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TidyMocks", reflect.TypeOf((*MockMockGenerator)(nil).TidyMocks), arg0)
 }
 
+// VerifyMocks mocks base method.
+func (m *MockMockGenerator) VerifyMocks(arg0 context.Context, arg1 []*ensurefile.Config) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMocks", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyMocks indicates an expected call of VerifyMocks.
+func (mr *MockMockGeneratorMockRecorder) VerifyMocks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMocks", reflect.TypeOf((*MockMockGenerator)(nil).VerifyMocks), arg0, arg1)
+}
+
 // NEW creates a MockMockGenerator.
 func (*MockMockGenerator) NEW(ctrl *gomock.Controller) *MockMockGenerator {
 	return NewMockMockGenerator(ctrl)
@@ -0,0 +1,320 @@
+// Package mocksync finds mocks that are referenced within the module, but are missing from .ensure.yml.
+package mocksync
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/erk"
+)
+
+var ErrCannotWriteFile = erk.New(mockgen.ErkFSWriteError{}, "Could not write '{{.path}}': {{.err}}")
+
+// Mock is a referenced interface that is missing from .ensure.yml.
+type Mock struct {
+	PackagePath string `json:"packagePath"`
+	Interface   string `json:"interface"`
+	ImportPath  string `json:"importPath"`
+	File        string `json:"file"` // The first file referencing the mock
+}
+
+// Reference to a mock that could not be mapped back to a package and interface.
+type Reference struct {
+	ImportPath string `json:"importPath"`
+	Name       string `json:"name"`
+	File       string `json:"file"` // The first file with the reference
+	Reason     string `json:"reason"`
+}
+
+// Result of searching for missing mocks.
+type Result struct {
+	Missing    []*Mock      `json:"missing"`
+	Unresolved []*Reference `json:"unresolved"`
+}
+
+type SyncerIface interface {
+	FindMissing(config *ensurefile.Config) (*Result, error)
+	AddMissing(config *ensurefile.Config, mocks []*Mock) error
+}
+
+// Syncer maps references to mock packages back to the packages and interfaces they would be generated from.
+// Files are scanned syntactically, since the files referencing missing mocks cannot be type-checked until the mocks exist.
+type Syncer struct {
+	Finder           modfiles.FinderIface
+	EnsureFileLoader ensurefile.LoaderIface
+	FSWrite          fswrite.FSWriteIface
+}
+
+var _ SyncerIface = &Syncer{}
+
+// FindMissing returns the mocks referenced through Mock<Iface> or NewMock<Iface> that are not in .ensure.yml.
+// The source package of a mock package is found by inverting the destination rules. When more than one package
+// could generate the mock package, a package declared within the module is preferred.
+func (s *Syncer) FindMissing(config *ensurefile.Config) (*Result, error) {
+	goFiles, err := s.Finder.GoFiles(config.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	scan, err := scanFiles(config, goFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	configured := map[string]map[string]bool{}
+	if config.Mocks != nil {
+		for _, pkg := range config.Mocks.Packages {
+			configured[pkg.Path] = stringSet(pkg.Interfaces)
+		}
+	}
+
+	for _, annotation := range config.Annotations {
+		if configured[annotation.PackagePath] == nil {
+			configured[annotation.PackagePath] = map[string]bool{}
+		}
+
+		configured[annotation.PackagePath][annotation.Interface] = true
+	}
+
+	result := &Result{Missing: []*Mock{}, Unresolved: []*Reference{}}
+	seen := map[string]bool{}
+
+	importPaths := make([]string, 0, len(scan.references))
+	for importPath := range scan.references {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+
+	for _, importPath := range importPaths {
+		files := scan.references[importPath]
+		pkgPath, verifiable := s.sourcePackage(config, scan, importPath)
+
+		for _, name := range sortedKeys(files) {
+			if !isMockName(name) {
+				continue
+			}
+
+			if pkgPath == "" {
+				result.Unresolved = append(result.Unresolved, &Reference{
+					ImportPath: importPath,
+					Name:       name,
+					File:       files[name],
+					Reason:     "no package within the module would generate its mocks in " + importPath,
+				})
+				continue
+			}
+
+			known := map[string]bool{}
+			for iface := range configured[pkgPath] {
+				known[iface] = true
+			}
+			for iface := range scan.interfaces[pkgPath] {
+				known[iface] = true
+			}
+
+			iface, ok := interfaceFor(name, known, verifiable)
+			if !ok {
+				result.Unresolved = append(result.Unresolved, &Reference{
+					ImportPath: importPath,
+					Name:       name,
+					File:       files[name],
+					Reason:     "no matching interface is declared in " + pkgPath,
+				})
+				continue
+			}
+
+			key := pkgPath + ":" + iface
+			if configured[pkgPath][iface] || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			result.Missing = append(result.Missing, &Mock{
+				PackagePath: pkgPath,
+				Interface:   iface,
+				ImportPath:  importPath,
+				File:        files[name],
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// AddMissing adds the mocks to .ensure.yml.
+func (s *Syncer) AddMissing(config *ensurefile.Config, mocks []*Mock) error {
+	doc, err := s.EnsureFileLoader.LoadDocument(config.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	interfacesByPackage := map[string][]string{}
+	packagePaths := []string{}
+	for _, mock := range mocks {
+		if _, ok := interfacesByPackage[mock.PackagePath]; !ok {
+			packagePaths = append(packagePaths, mock.PackagePath)
+		}
+
+		interfacesByPackage[mock.PackagePath] = append(interfacesByPackage[mock.PackagePath], mock.Interface)
+	}
+
+	for _, packagePath := range packagePaths {
+		if err := doc.AddInterfaces(packagePath, interfacesByPackage[packagePath]); err != nil {
+			return err
+		}
+	}
+
+	data, err := doc.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err := s.FSWrite.WriteFile(config.ConfigPath, string(data), 0664); err != nil {
+		return erk.WrapWith(ErrCannotWriteFile, err, erk.Params{
+			"path": config.ConfigPath,
+		})
+	}
+
+	return nil
+}
+
+// sourcePackage picks the package that generates the mock package, and whether its interfaces can be verified.
+// Packages outside the module are not scanned, so their interfaces cannot be verified.
+func (s *Syncer) sourcePackage(config *ensurefile.Config, scan *scanResult, importPath string) (string, bool) {
+	outsideModule := ""
+
+	for _, candidate := range mockgen.SourcePackages(config, importPath) {
+		if candidate == config.ModulePath || strings.HasPrefix(candidate, config.ModulePath+"/") {
+			if scan.packages[candidate] {
+				return candidate, true
+			}
+
+			continue
+		}
+
+		if outsideModule == "" {
+			outsideModule = candidate
+		}
+	}
+
+	return outsideModule, false
+}
+
+type scanResult struct {
+	packages   map[string]bool              // Import paths of the packages in the module
+	interfaces map[string]map[string]bool   // Interfaces declared by each package in the module
+	references map[string]map[string]string // Identifiers referenced through each mock import, and the first file referencing them
+}
+
+func scanFiles(config *ensurefile.Config, goFiles []*modfiles.GoFile) (*scanResult, error) {
+	scan := &scanResult{
+		packages:   map[string]bool{},
+		interfaces: map[string]map[string]bool{},
+		references: map[string]map[string]string{},
+	}
+
+	isMockImport := func(importPath string) bool {
+		return strings.HasPrefix(importPath, config.ModulePath+"/") && strings.HasPrefix(path.Base(importPath), "mock_")
+	}
+
+	for _, goFile := range goFiles {
+		file, err := goFile.Parse()
+		if err != nil {
+			return nil, err
+		}
+
+		for importPath, names := range modfiles.References(file, isMockImport) {
+			if scan.references[importPath] == nil {
+				scan.references[importPath] = map[string]string{}
+			}
+
+			for name := range names {
+				if _, ok := scan.references[importPath][name]; !ok {
+					scan.references[importPath][name] = goFile.Path
+				}
+			}
+		}
+
+		if strings.HasSuffix(goFile.Path, "_test.go") {
+			continue
+		}
+
+		relativeDir, err := filepath.Rel(config.RootPath, filepath.Dir(goFile.Path))
+		if err != nil {
+			continue
+		}
+
+		pkgPath := path.Join(config.ModulePath, filepath.ToSlash(relativeDir))
+		scan.packages[pkgPath] = true
+		if scan.interfaces[pkgPath] == nil {
+			scan.interfaces[pkgPath] = map[string]bool{}
+		}
+
+		for _, iface := range modfiles.Interfaces(file) {
+			scan.interfaces[pkgPath][iface] = true
+		}
+	}
+
+	return scan, nil
+}
+
+func isMockName(name string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(name, "New"), "Mock")
+}
+
+// interfaceFor returns the interface mocked by the identifier.
+// Known interfaces are matched first, so recorders and typed calls map to the interface they belong to.
+// If the interface cannot be verified, the name following Mock is assumed to be the interface.
+func interfaceFor(name string, known map[string]bool, verifiable bool) (string, bool) {
+	rest := strings.TrimPrefix(strings.TrimPrefix(name, "New"), "Mock")
+	if known[rest] {
+		return rest, true
+	}
+
+	match := ""
+	for iface := range known {
+		suffix := strings.TrimPrefix(rest, iface)
+		if suffix == rest || len(iface) <= len(match) {
+			continue
+		}
+
+		if suffix == "MockRecorder" || strings.HasSuffix(suffix, "Call") {
+			match = iface
+		}
+	}
+
+	if match != "" {
+		return match, true
+	}
+
+	if verifiable || rest == "" {
+		return "", false
+	}
+
+	return strings.TrimSuffix(rest, "MockRecorder"), true
+}
+
+func stringSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		set[value] = true
+	}
+
+	return set
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,320 @@
+package mocksync_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_modfiles"
+	"github.com/JosiahWitt/ensure-cli/internal/mocksync"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+const expectedFilePerm = os.FileMode(0664)
+
+func TestFindMissing(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Finder *mock_modfiles.MockFinderIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	newConfig := func() *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			ConfigPath: "/my/app/.ensure.yml",
+			Mocks: &ensurefile.MockConfig{
+				Packages: []*ensurefile.Package{
+					{
+						Path:       "github.com/my/app/internal/store",
+						Interfaces: []string{"OrderStore"},
+					},
+				},
+			},
+		}
+	}
+
+	storeFile := &modfiles.GoFile{
+		Path: "/my/app/internal/store/store.go",
+		Contents: `package store
+
+type UserStore interface{}
+type OrderStore interface{}
+type NotAnInterface struct{}
+`,
+	}
+
+	table := []struct {
+		Name           string
+		Config         *ensurefile.Config
+		GoFiles        []*modfiles.GoFile
+		ExpectedResult *mocksync.Result
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *mocksync.Syncer
+	}{
+		{
+			Name:   "with missing mocks of internal packages",
+			Config: newConfig(),
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/api/api_test.go",
+					Contents: `package api_test
+
+import "github.com/my/app/internal/mocks/mock_store"
+
+var _ = mock_store.NewMockUserStore
+var _ *mock_store.MockUserStore
+var _ *mock_store.MockOrderStoreMockRecorder
+var _ = mock_store.Helper
+`,
+				},
+				storeFile,
+			},
+			ExpectedResult: &mocksync.Result{
+				Missing: []*mocksync.Mock{
+					{
+						PackagePath: "github.com/my/app/internal/store",
+						Interface:   "UserStore",
+						ImportPath:  "github.com/my/app/internal/mocks/mock_store",
+						File:        "/my/app/api/api_test.go",
+					},
+				},
+				Unresolved: []*mocksync.Reference{},
+			},
+		},
+		{
+			Name:   "with missing mocks of external packages",
+			Config: &ensurefile.Config{RootPath: "/my/app", ModulePath: "github.com/my/app"},
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/api/api_test.go",
+					Contents: `package api_test
+
+import (
+	"github.com/my/app/internal/mocks/github.com/some/mock_pkg"
+	mock_ctx "github.com/my/app/internal/mocks/mock_context"
+)
+
+var _ = mock_pkg.NewMockIface1
+var _ = mock_pkg.MockIface1MockRecorder{}
+var _ *mock_ctx.MockContext
+`,
+				},
+			},
+			ExpectedResult: &mocksync.Result{
+				Missing: []*mocksync.Mock{
+					{
+						PackagePath: "github.com/some/pkg",
+						Interface:   "Iface1",
+						ImportPath:  "github.com/my/app/internal/mocks/github.com/some/mock_pkg",
+						File:        "/my/app/api/api_test.go",
+					},
+					{
+						PackagePath: "context",
+						Interface:   "Context",
+						ImportPath:  "github.com/my/app/internal/mocks/mock_context",
+						File:        "/my/app/api/api_test.go",
+					},
+				},
+				Unresolved: []*mocksync.Reference{},
+			},
+		},
+		{
+			Name:   "with unresolved references",
+			Config: newConfig(),
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/api/api_test.go",
+					Contents: `package api_test
+
+import (
+	"github.com/my/app/internal/mocks/mock_store"
+	"github.com/my/app/some/mock_thing"
+)
+
+var _ *mock_store.MockMissingStore
+var _ *mock_thing.MockThing
+`,
+				},
+				storeFile,
+			},
+			ExpectedResult: &mocksync.Result{
+				Missing: []*mocksync.Mock{},
+				Unresolved: []*mocksync.Reference{
+					{
+						ImportPath: "github.com/my/app/internal/mocks/mock_store",
+						Name:       "MockMissingStore",
+						File:       "/my/app/api/api_test.go",
+						Reason:     "no matching interface is declared in github.com/my/app/internal/store",
+					},
+					{
+						ImportPath: "github.com/my/app/some/mock_thing",
+						Name:       "MockThing",
+						File:       "/my/app/api/api_test.go",
+						Reason:     "no package within the module would generate its mocks in github.com/my/app/some/mock_thing",
+					},
+				},
+			},
+		},
+		{
+			Name: "with annotated interfaces",
+			Config: &ensurefile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				Annotations: []*ensurefile.Annotation{
+					{PackagePath: "github.com/my/app/internal/store", Interface: "UserStore"},
+				},
+			},
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/api/api_test.go",
+					Contents: `package api_test
+
+import "github.com/my/app/internal/mocks/mock_store"
+
+var _ = mock_store.NewMockUserStore
+var _ = mock_store.NewMockOrderStore
+`,
+				},
+				storeFile,
+			},
+			ExpectedResult: &mocksync.Result{
+				Missing: []*mocksync.Mock{
+					{
+						PackagePath: "github.com/my/app/internal/store",
+						Interface:   "OrderStore",
+						ImportPath:  "github.com/my/app/internal/mocks/mock_store",
+						File:        "/my/app/api/api_test.go",
+					},
+				},
+				Unresolved: []*mocksync.Reference{},
+			},
+		},
+		{
+			Name:   "with no mock references",
+			Config: newConfig(),
+			GoFiles: []*modfiles.GoFile{
+				storeFile,
+				{Path: "/my/app/main.go", Contents: "package main\n\nimport \"github.com/my/app/internal/store\"\n\nvar _ store.UserStore\n"},
+			},
+			ExpectedResult: &mocksync.Result{Missing: []*mocksync.Mock{}, Unresolved: []*mocksync.Reference{}},
+		},
+		{
+			Name:          "when unable to find Go files",
+			Config:        newConfig(),
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.Finder.EXPECT().GoFiles("/my/app").Return(nil, exampleError)
+			},
+		},
+		{
+			Name:          "when unable to parse Go file",
+			Config:        newConfig(),
+			GoFiles:       []*modfiles.GoFile{{Path: "/my/app/broken.go", Contents: "package"}},
+			ExpectedError: modfiles.ErrCannotParseFile,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		if entry.GoFiles != nil {
+			entry.Mocks.Finder.EXPECT().GoFiles("/my/app").Return(entry.GoFiles, nil)
+		}
+
+		result, err := entry.Subject.FindMissing(entry.Config)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(result).Equals(entry.ExpectedResult)
+	})
+}
+
+func TestAddMissing(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const configFile = `mocks:
+  packages:
+    - path: github.com/my/app/internal/store
+      interfaces: [OrderStore]
+`
+
+	type Mocks struct {
+		FS      *mock_fs.MockReadFileFS
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	config := &ensurefile.Config{ConfigPath: "/my/app/.ensure.yml"}
+	missing := []*mocksync.Mock{
+		{PackagePath: "github.com/my/app/internal/store", Interface: "UserStore"},
+		{PackagePath: "github.com/some/pkg", Interface: "Iface1"},
+		{PackagePath: "github.com/my/app/internal/store", Interface: "CartStore"},
+	}
+
+	table := []struct {
+		Name          string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *mocksync.Syncer
+	}{
+		{
+			Name: "adds the interfaces",
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(configFile), nil)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml",
+					"mocks:\n"+
+						"  packages:\n"+
+						"    - path: github.com/my/app/internal/store\n"+
+						"      interfaces: [OrderStore, UserStore, CartStore]\n"+
+						"    - path: github.com/some/pkg\n"+
+						"      interfaces: [Iface1]\n",
+					expectedFilePerm,
+				).Return(nil)
+			},
+		},
+		{
+			Name:          "when unable to load document",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, exampleError)
+			},
+		},
+		{
+			Name:          "when unable to edit document",
+			ExpectedError: ensurefile.ErrCannotEditFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte("mocks: nope\n"), nil)
+			},
+		},
+		{
+			Name:          "when unable to write file",
+			ExpectedError: mocksync.ErrCannotWriteFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(configFile), nil)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml", gomock.Any(), expectedFilePerm).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.EnsureFileLoader = &ensurefile.Loader{FS: entry.Mocks.FS}
+
+		err := entry.Subject.AddMissing(config, missing)
+		ensure(err).IsError(entry.ExpectedError)
+	})
+}
@@ -0,0 +1,130 @@
+// Package modfiles finds the Go files that belong to a module.
+package modfiles
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+)
+
+type ErkCannotFindFiles struct{ erk.DefaultKind }
+
+var ErrCannotFindFiles = erk.New(ErkCannotFindFiles{}, "Could not find the files in '{{.path}}': {{.err}}")
+
+// GoFile is a Go source file, including test files.
+type GoFile struct {
+	Path     string
+	Contents string
+}
+
+type FinderIface interface {
+	GoFiles(rootPath string) ([]*GoFile, error)
+	GoFilesContaining(rootPath string, substr string) ([]*GoFile, error)
+	Files(rootPath string, names ...string) ([]string, error)
+}
+
+// Finder finds Go files within a module, using the same rules as the go tool.
+type Finder struct{}
+
+var _ FinderIface = &Finder{}
+
+// GoFiles returns the Go files within the module rooted at rootPath.
+// Like the go tool, it skips vendor and testdata directories, directories starting with "." or "_",
+// and nested modules.
+func (f *Finder) GoFiles(rootPath string) ([]*GoFile, error) {
+	return f.goFiles(rootPath, nil)
+}
+
+// GoFilesContaining returns the Go files within the module rooted at rootPath that contain substr.
+// It is cheaper than GoFiles for large modules, since only the contents of the matching files are kept.
+func (f *Finder) GoFilesContaining(rootPath string, substr string) ([]*GoFile, error) {
+	return f.goFiles(rootPath, []byte(substr))
+}
+
+// goFiles returns the Go files within the module that contain substr, or all of them if substr is nil.
+func (*Finder) goFiles(rootPath string, substr []byte) ([]*GoFile, error) {
+	goFiles := []*GoFile{}
+
+	err := walkModule(rootPath, func(path string, info os.FileInfo) error {
+		if !strings.HasSuffix(info.Name(), ".go") {
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if substr != nil && !bytes.Contains(contents, substr) {
+			return nil
+		}
+
+		goFiles = append(goFiles, &GoFile{Path: path, Contents: string(contents)})
+		return nil
+	})
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotFindFiles, err, erk.Params{
+			"path": rootPath,
+		})
+	}
+
+	return goFiles, nil
+}
+
+// Files returns the paths of the files with any of the provided names within the module rooted at rootPath.
+// Directories are skipped using the same rules as GoFiles.
+func (*Finder) Files(rootPath string, names ...string) ([]string, error) {
+	paths := []string{}
+	matches := map[string]bool{}
+	for _, name := range names {
+		matches[name] = true
+	}
+
+	err := walkModule(rootPath, func(path string, info os.FileInfo) error {
+		if matches[info.Name()] {
+			paths = append(paths, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotFindFiles, err, erk.Params{
+			"path": rootPath,
+		})
+	}
+
+	return paths, nil
+}
+
+// walkModule calls fn for each file within the module rooted at rootPath.
+func walkModule(rootPath string, fn func(path string, info os.FileInfo) error) error {
+	return filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != rootPath && shouldSkipDir(path, info.Name()) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		return fn(path, info)
+	})
+}
+
+func shouldSkipDir(path, name string) bool {
+	if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return true
+	}
+
+	// Nested modules are not part of this module
+	_, err := os.Stat(filepath.Join(path, "go.mod"))
+	return err == nil
+}
@@ -0,0 +1,129 @@
+package modfiles_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestGoFiles(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("with module files", func(ensure ensurepkg.Ensure) {
+		rootPath := t.TempDir()
+		writeFiles(ensure, rootPath, map[string]string{
+			"go.mod":                     "module github.com/my/app",
+			"main.go":                    "package main",
+			"pkg/pkg.go":                 "package pkg",
+			"pkg/pkg_test.go":            "package pkg_test",
+			"pkg/README.md":              "Not Go",
+			"vendor/dep/dep.go":          "package dep",
+			"pkg/testdata/data.go":       "package data",
+			".hidden/hidden.go":          "package hidden",
+			"_ignored/ignored.go":        "package ignored",
+			"nested/go.mod":              "module github.com/my/app/nested",
+			"nested/nested.go":           "package nested",
+			"pkg/internal/inner/file.go": "package inner",
+		})
+
+		goFiles, err := (&modfiles.Finder{}).GoFiles(rootPath)
+		ensure(err).IsNotError()
+		ensure(goFiles).Equals([]*modfiles.GoFile{
+			{Path: filepath.Join(rootPath, "main.go"), Contents: "package main"},
+			{Path: filepath.Join(rootPath, "pkg/internal/inner/file.go"), Contents: "package inner"},
+			{Path: filepath.Join(rootPath, "pkg/pkg.go"), Contents: "package pkg"},
+			{Path: filepath.Join(rootPath, "pkg/pkg_test.go"), Contents: "package pkg_test"},
+		})
+	})
+
+	ensure.Run("when root does not exist", func(ensure ensurepkg.Ensure) {
+		goFiles, err := (&modfiles.Finder{}).GoFiles(filepath.Join(t.TempDir(), "missing"))
+		ensure(err).IsError(modfiles.ErrCannotFindFiles)
+		ensure(goFiles).IsNil()
+	})
+}
+
+func TestGoFilesContaining(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("with matching files", func(ensure ensurepkg.Ensure) {
+		rootPath := t.TempDir()
+		writeFiles(ensure, rootPath, map[string]string{
+			"go.mod":              "module github.com/my/app",
+			"main.go":             "package main",
+			"pkg/pkg.go":          "package pkg\n\n//ensure:mock\ntype Iface interface{}",
+			"pkg/pkg_test.go":     "package pkg_test\n\n//ensure:mock\ntype Iface interface{}",
+			"vendor/dep/dep.go":   "package dep\n\n//ensure:mock\ntype Iface interface{}",
+			"pkg/other/other.go":  "package other",
+			"nested/go.mod":       "module github.com/my/app/nested",
+			"nested/nested.go":    "package nested\n\n//ensure:mock\ntype Iface interface{}",
+			"pkg/other/README.md": "//ensure:mock",
+		})
+
+		goFiles, err := (&modfiles.Finder{}).GoFilesContaining(rootPath, "//ensure:mock")
+		ensure(err).IsNotError()
+		ensure(goFiles).Equals([]*modfiles.GoFile{
+			{Path: filepath.Join(rootPath, "pkg/pkg.go"), Contents: "package pkg\n\n//ensure:mock\ntype Iface interface{}"},
+			{Path: filepath.Join(rootPath, "pkg/pkg_test.go"), Contents: "package pkg_test\n\n//ensure:mock\ntype Iface interface{}"},
+		})
+	})
+
+	ensure.Run("when root does not exist", func(ensure ensurepkg.Ensure) {
+		goFiles, err := (&modfiles.Finder{}).GoFilesContaining(filepath.Join(t.TempDir(), "missing"), "//ensure:mock")
+		ensure(err).IsError(modfiles.ErrCannotFindFiles)
+		ensure(goFiles).IsNil()
+	})
+}
+
+func TestFiles(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("with matching files", func(ensure ensurepkg.Ensure) {
+		rootPath := t.TempDir()
+		writeFiles(ensure, rootPath, map[string]string{
+			"go.mod":                    "module github.com/my/app",
+			".ensure.yml":               "mocks: {}",
+			"team1/.ensure.yml":         "mocks: {}",
+			"team1/store/.ensure.yml":   "mocks: {}",
+			"team1/store/ensure.yml":    "mocks: {}",
+			"team2/.ensure.json":        `{"mocks": {}}`,
+			"team2/.ensure.toml":        "[mocks]",
+			"vendor/dep/.ensure.yml":    "mocks: {}",
+			"nested/go.mod":             "module github.com/my/app/nested",
+			"nested/.ensure.yml":        "mocks: {}",
+			"_ignored/team/.ensure.yml": "mocks: {}",
+		})
+
+		paths, err := (&modfiles.Finder{}).Files(rootPath, ".ensure.yml", ".ensure.json")
+		ensure(err).IsNotError()
+		ensure(paths).Equals([]string{
+			filepath.Join(rootPath, ".ensure.yml"),
+			filepath.Join(rootPath, "team1/.ensure.yml"),
+			filepath.Join(rootPath, "team1/store/.ensure.yml"),
+			filepath.Join(rootPath, "team2/.ensure.json"),
+		})
+	})
+
+	ensure.Run("when root does not exist", func(ensure ensurepkg.Ensure) {
+		paths, err := (&modfiles.Finder{}).Files(filepath.Join(t.TempDir(), "missing"), ".ensure.yml")
+		ensure(err).IsError(modfiles.ErrCannotFindFiles)
+		ensure(paths).IsNil()
+	})
+}
+
+func writeFiles(ensure ensurepkg.Ensure, rootPath string, files map[string]string) {
+	for name, contents := range files {
+		path := filepath.Join(rootPath, name)
+
+		err := os.MkdirAll(filepath.Dir(path), 0775)
+		ensure(err).IsNotError()
+
+		err = ioutil.WriteFile(path, []byte(contents), 0600)
+		ensure(err).IsNotError()
+	}
+}
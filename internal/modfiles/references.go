@@ -0,0 +1,123 @@
+package modfiles
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strconv"
+
+	"github.com/JosiahWitt/erk"
+)
+
+type ErkCannotParseFile struct{ erk.DefaultKind }
+
+var ErrCannotParseFile = erk.New(ErkCannotParseFile{}, "Could not parse '{{.path}}': {{.err}}")
+
+// Parse the Go file, without resolving its imports.
+func (f *GoFile) Parse() (*ast.File, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), f.Path, f.Contents, 0)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotParseFile, err, erk.Params{
+			"path": f.Path,
+		})
+	}
+
+	return file, nil
+}
+
+// References returns the identifiers the file references through the imports matching matchImport, keyed by import path.
+// Imports are assumed to be named after the last element of their path, unless they are aliased.
+// Every identifier in the file is included for dot imports, since they cannot be told apart from local identifiers.
+func References(file *ast.File, matchImport func(importPath string) bool) map[string]map[string]bool {
+	references := map[string]map[string]bool{}
+	importPathsByName := map[string]string{}
+	dotImportPaths := []string{}
+
+	for _, imp := range file.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || !matchImport(importPath) {
+			continue
+		}
+
+		name := path.Base(importPath)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+
+		switch name {
+		case "_":
+		case ".":
+			dotImportPaths = append(dotImportPaths, importPath)
+		default:
+			importPathsByName[name] = importPath
+		}
+	}
+
+	if len(importPathsByName) == 0 && len(dotImportPaths) == 0 {
+		return references
+	}
+
+	addReference := func(importPath, name string) {
+		if references[importPath] == nil {
+			references[importPath] = map[string]bool{}
+		}
+
+		references[importPath][name] = true
+	}
+
+	var inspect func(node ast.Node) bool
+	inspect = func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.GenDecl:
+			return node.Tok != token.IMPORT
+		case *ast.SelectorExpr:
+			if ident, ok := node.X.(*ast.Ident); ok {
+				if importPath, ok := importPathsByName[ident.Name]; ok {
+					addReference(importPath, node.Sel.Name)
+					return false
+				}
+			}
+
+			ast.Inspect(node.X, inspect) // The selected name is a field or method, not a dot imported identifier
+			return false
+		case *ast.Ident:
+			for _, importPath := range dotImportPaths {
+				addReference(importPath, node.Name)
+			}
+		}
+
+		return true
+	}
+
+	for _, decl := range file.Decls {
+		ast.Inspect(decl, inspect)
+	}
+
+	return references
+}
+
+// Interfaces returns the names of the interfaces declared at the top level of the file.
+func Interfaces(file *ast.File) []string {
+	interfaces := []string{}
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			if _, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+				interfaces = append(interfaces, typeSpec.Name.Name)
+			}
+		}
+	}
+
+	return interfaces
+}
@@ -0,0 +1,92 @@
+package modfiles_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+)
+
+func TestReferences(t *testing.T) {
+	ensure := ensure.New(t)
+
+	isMockImport := func(importPath string) bool {
+		return strings.Contains(importPath, "/mock_")
+	}
+
+	table := []struct {
+		Name               string
+		Contents           string
+		ExpectedReferences map[string]map[string]bool
+	}{
+		{
+			Name: "with named, aliased, and dot imports",
+			Contents: `package pkg_test
+
+import (
+	"fmt"
+
+	"github.com/my/app/internal/mocks/mock_abc"
+	xyz "github.com/my/app/internal/mocks/mock_xyz"
+	. "github.com/my/app/internal/mocks/mock_dot"
+	_ "github.com/my/app/internal/mocks/mock_blank"
+)
+
+var _ = mock_abc.NewMockIface1
+var _ *xyz.MockIface2
+var _ = fmt.Sprint(MockIface3)
+`,
+			ExpectedReferences: map[string]map[string]bool{
+				"github.com/my/app/internal/mocks/mock_abc": {"NewMockIface1": true},
+				"github.com/my/app/internal/mocks/mock_xyz": {"MockIface2": true},
+				"github.com/my/app/internal/mocks/mock_dot": {"_": true, "fmt": true, "MockIface3": true},
+			},
+		},
+		{
+			Name:               "without matching imports",
+			Contents:           "package pkg\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint\n",
+			ExpectedReferences: map[string]map[string]bool{},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		file, err := (&modfiles.GoFile{Path: "/my/app/pkg/pkg_test.go", Contents: entry.Contents}).Parse()
+		ensure(err).IsNotError()
+		ensure(modfiles.References(file, isMockImport)).Equals(entry.ExpectedReferences)
+	})
+}
+
+func TestParse(t *testing.T) {
+	ensure := ensure.New(t)
+
+	file, err := (&modfiles.GoFile{Path: "/my/app/broken.go", Contents: "package"}).Parse()
+	ensure(err).IsError(modfiles.ErrCannotParseFile)
+	ensure(file).IsNil()
+}
+
+func TestInterfaces(t *testing.T) {
+	ensure := ensure.New(t)
+
+	file, err := (&modfiles.GoFile{
+		Path: "/my/app/pkg/pkg.go",
+		Contents: `package pkg
+
+type Iface1 interface{}
+
+type (
+	Iface2 interface{ Method() }
+	Struct struct{}
+)
+
+func Func() {
+	type Local interface{}
+}
+`,
+	}).Parse()
+	ensure(err).IsNotError()
+	ensure(modfiles.Interfaces(file)).Equals([]string{"Iface1", "Iface2"})
+}
@@ -0,0 +1,154 @@
+// Package report emits machine-readable events describing the progress of a command.
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/erk/erg"
+)
+
+// Event types.
+const (
+	EventPackageStarted   = "package_started"
+	EventPackageGenerated = "package_generated"
+	EventPackageFailed    = "package_failed"
+	EventPackageVerified  = "package_verified"
+	EventFileRemoved      = "file_removed"
+	EventSummary          = "summary"
+	EventCheck            = "check"
+	EventMocksChecked     = "mocks_checked"
+	EventError            = "error"
+)
+
+// Check statuses.
+const (
+	CheckPassed  = "pass"
+	CheckWarning = "warn"
+	CheckFailed  = "fail"
+	CheckSkipped = "skip"
+)
+
+// Event is written as a single line of JSON.
+type Event struct {
+	Type       string      `json:"type"`
+	Package    string      `json:"package,omitempty"`
+	Path       string      `json:"path,omitempty"`
+	Error      *Error      `json:"error,omitempty"`
+	ExitCode   int         `json:"exitCode,omitempty"`
+	Summary    *Summary    `json:"summary,omitempty"`
+	Check      *Check      `json:"check,omitempty"`
+	MocksCheck *MocksCheck `json:"mocksCheck,omitempty"`
+}
+
+// Error describes an error, including its erk kind and params.
+type Error struct {
+	Kind    string                 `json:"kind,omitempty"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+	Errors  []*Error               `json:"errors,omitempty"`
+}
+
+// Summary counts the results of a command.
+type Summary struct {
+	Generated int `json:"generated"`
+	Failed    int `json:"failed"`
+	Removed   int `json:"removed"`
+}
+
+// Check is the result of a diagnostic check.
+type Check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+// MocksCheck lists the mock files that are out of date, as found by `ensure mocks check`.
+type MocksCheck struct {
+	Outdated []*MockFile `json:"outdated"`
+	Missing  []*MockFile `json:"missing"`
+	Extra    []string    `json:"extra"`
+}
+
+// MockFile is a mock file that would be generated for a package.
+type MockFile struct {
+	Package string `json:"package"`
+	Source  string `json:"source"`
+	Path    string `json:"path"`
+}
+
+type ReporterIface interface {
+	Enable()
+	Enabled() bool
+	Report(event *Event)
+}
+
+// Reporter writes events as JSON lines to Writer.
+// Events are ignored until the reporter is enabled.
+type Reporter struct {
+	Writer io.Writer
+
+	mu      sync.Mutex
+	enabled bool
+}
+
+var _ ReporterIface = &Reporter{}
+
+// Enable writing events.
+func (r *Reporter) Enable() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = true
+}
+
+// Enabled returns true if events are being written.
+func (r *Reporter) Enabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enabled
+}
+
+// Report writes the event, if the reporter is enabled.
+// It is safe to call from multiple goroutines.
+func (r *Reporter) Report(event *Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.enabled {
+		return
+	}
+
+	_ = json.NewEncoder(r.Writer).Encode(event) // Events only contain values that can be encoded
+}
+
+// NewError converts the error, preserving its erk kind and params, and any errors grouped using erg.
+func NewError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	reportErr := &Error{
+		Kind:    erk.GetKindString(err),
+		Message: err.Error(),
+	}
+
+	if params := erk.GetParams(err); len(params) > 0 {
+		reportErr.Params = make(map[string]interface{}, len(params))
+		for key, value := range params {
+			if valueErr, ok := value.(error); ok {
+				value = valueErr.Error() // Errors cannot be encoded as JSON
+			}
+
+			reportErr.Params[key] = value
+		}
+	}
+
+	for _, groupedErr := range erg.GetErrors(err) {
+		reportErr.Errors = append(reportErr.Errors, NewError(groupedErr))
+	}
+
+	return reportErr
+}
@@ -0,0 +1,83 @@
+package report_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/erk/erg"
+)
+
+type ErkExample struct{ erk.DefaultKind }
+
+func TestReport(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("when not enabled", func(ensure ensurepkg.Ensure) {
+		var buf bytes.Buffer
+		reporter := &report.Reporter{Writer: &buf}
+
+		reporter.Report(&report.Event{Type: report.EventPackageStarted, Package: "github.com/my/pkg:Iface1"})
+		ensure(reporter.Enabled()).IsFalse()
+		ensure(buf.String()).Equals("")
+	})
+
+	ensure.Run("when enabled", func(ensure ensurepkg.Ensure) {
+		var buf bytes.Buffer
+		reporter := &report.Reporter{Writer: &buf}
+		reporter.Enable()
+
+		reporter.Report(&report.Event{Type: report.EventPackageStarted, Package: "github.com/my/pkg:Iface1"})
+		reporter.Report(&report.Event{Type: report.EventSummary, Summary: &report.Summary{Generated: 1}})
+		ensure(reporter.Enabled()).IsTrue()
+		ensure(buf.String()).Equals(
+			`{"type":"package_started","package":"github.com/my/pkg:Iface1"}` + "\n" +
+				`{"type":"summary","summary":{"generated":1,"failed":0,"removed":0}}` + "\n",
+		)
+	})
+}
+
+func TestNewError(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("when nil", func(ensure ensurepkg.Ensure) {
+		ensure(report.NewError(nil)).IsNil()
+	})
+
+	ensure.Run("with plain error", func(ensure ensurepkg.Ensure) {
+		ensure(report.NewError(errors.New("something went wrong"))).Equals(&report.Error{
+			Message: "something went wrong",
+		})
+	})
+
+	ensure.Run("with erk error", func(ensure ensurepkg.Ensure) {
+		errExample := erk.New(ErkExample{}, "Could not process '{{.path}}': {{.err}}")
+		err := erk.WrapWith(errExample, errors.New("inner error"), erk.Params{"path": "/some/path"})
+
+		reportErr := report.NewError(err)
+		ensure(reportErr.Kind).Equals(erk.GetKindString(err))
+		ensure(reportErr.Message).Equals(err.Error())
+		ensure(reportErr.Params).Equals(map[string]interface{}{
+			"path": "/some/path",
+			"err":  "inner error",
+		})
+		ensure(reportErr.Errors).IsEmpty()
+	})
+
+	ensure.Run("with grouped errors", func(ensure ensurepkg.Ensure) {
+		errGroup := erk.New(ErkExample{}, "Multiple failures")
+		err := erg.NewAs(errGroup)
+		err = erg.Append(err, errors.New("first"), errors.New("second"))
+
+		reportErr := report.NewError(err)
+		ensure(reportErr.Kind).Equals(erk.GetKindString(err))
+		ensure(reportErr.Errors).Equals([]*report.Error{
+			{Message: "first"},
+			{Message: "second"},
+		})
+	})
+}
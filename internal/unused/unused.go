@@ -0,0 +1,183 @@
+// Package unused detects configured mocks that are never referenced within the module.
+package unused
+
+import (
+	"sort"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/erk"
+)
+
+var ErrCannotWriteFile = erk.New(mockgen.ErkFSWriteError{}, "Could not write '{{.path}}': {{.err}}")
+
+// Mock is a configured interface whose mock is not referenced.
+type Mock struct {
+	PackagePath string `json:"packagePath"`
+	Interface   string `json:"interface"`
+	MockName    string `json:"mockName"`
+	ImportPath  string `json:"importPath"`
+	File        string `json:"file"` // The .ensure.yml file listing the package, or the file with the //ensure:mock annotation
+	Line        int    `json:"line"`
+}
+
+type DetectorIface interface {
+	FindUnused(config *ensurefile.Config) ([]*Mock, error)
+	RemoveUnused(config *ensurefile.Config, mocks []*Mock) error
+}
+
+// Detector scans the module's Go files, including tests, for references to the generated mocks.
+type Detector struct {
+	Finder           modfiles.FinderIface
+	EnsureFileLoader ensurefile.LoaderIface
+	FSWrite          fswrite.FSWriteIface
+}
+
+var _ DetectorIface = &Detector{}
+
+type usage struct {
+	importPath string
+	iface      string
+}
+
+// FindUnused returns the configured interfaces whose Mock<Iface> or NewMock<Iface> are never referenced
+// through an import of the generated mock package.
+func (d *Detector) FindUnused(config *ensurefile.Config) ([]*Mock, error) {
+	resolved, err := mockgen.ResolveConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	mockNamesByImportPath := map[string]map[string]string{}
+	for _, pkg := range resolved.Packages {
+		mockNames := map[string]string{}
+		for _, iface := range pkg.Interfaces {
+			mockNames[iface.MockName] = iface.Name
+			mockNames["New"+iface.MockName] = iface.Name
+		}
+
+		mockNamesByImportPath[pkg.ImportPath] = mockNames
+	}
+
+	goFiles, err := d.Finder.GoFiles(config.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[usage]bool{}
+	for _, goFile := range goFiles {
+		if err := findUsages(goFile, mockNamesByImportPath, used); err != nil {
+			return nil, err
+		}
+	}
+
+	unused := []*Mock{}
+	for _, pkg := range resolved.Packages {
+		for _, iface := range pkg.Interfaces {
+			if used[usage{importPath: pkg.ImportPath, iface: iface.Name}] {
+				continue
+			}
+
+			file, line := mockSource(config, pkg.Path, iface.Name)
+			unused = append(unused, &Mock{
+				PackagePath: pkg.Path,
+				Interface:   iface.Name,
+				MockName:    iface.MockName,
+				ImportPath:  pkg.ImportPath,
+				File:        file,
+				Line:        line,
+			})
+		}
+	}
+
+	return unused, nil
+}
+
+// RemoveUnused removes the interfaces from .ensure.yml, and removes any packages that no longer have interfaces.
+// Mocks configured by nested .ensure.yml files or //ensure:mock annotations are skipped, since they must be removed by hand.
+func (d *Detector) RemoveUnused(config *ensurefile.Config, mocks []*Mock) error {
+	doc, err := d.EnsureFileLoader.LoadDocument(config.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	interfacesByPackage := map[string][]string{}
+	packagePaths := []string{}
+	for _, mock := range mocks {
+		if mock.File != config.ConfigPath {
+			continue
+		}
+
+		if _, ok := interfacesByPackage[mock.PackagePath]; !ok {
+			packagePaths = append(packagePaths, mock.PackagePath)
+		}
+
+		interfacesByPackage[mock.PackagePath] = append(interfacesByPackage[mock.PackagePath], mock.Interface)
+	}
+	sort.Strings(packagePaths)
+
+	for _, packagePath := range packagePaths {
+		if err := doc.RemoveInterfaces(packagePath, interfacesByPackage[packagePath]); err != nil {
+			return err
+		}
+	}
+
+	data, err := doc.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err := d.FSWrite.WriteFile(config.ConfigPath, string(data), 0664); err != nil {
+		return erk.WrapWith(ErrCannotWriteFile, err, erk.Params{
+			"path": config.ConfigPath,
+		})
+	}
+
+	return nil
+}
+
+// findUsages records the mocks referenced by the file through imports of the mock packages.
+func findUsages(goFile *modfiles.GoFile, mockNamesByImportPath map[string]map[string]string, used map[usage]bool) error {
+	file, err := goFile.Parse()
+	if err != nil {
+		return err
+	}
+
+	references := modfiles.References(file, func(importPath string) bool {
+		return mockNamesByImportPath[importPath] != nil
+	})
+
+	for importPath, names := range references {
+		for name := range names {
+			if iface, ok := mockNamesByImportPath[importPath][name]; ok {
+				used[usage{importPath: importPath, iface: iface}] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// mockSource returns the file and line configuring the mock.
+// Annotations take precedence, since the mock is generated as long as the annotation exists.
+func mockSource(config *ensurefile.Config, packagePath, iface string) (string, int) {
+	for _, annotation := range config.Annotations {
+		if annotation.PackagePath == packagePath && annotation.Interface == iface {
+			return annotation.File, annotation.Line
+		}
+	}
+
+	for _, pkg := range config.Mocks.Packages {
+		if pkg.Path == packagePath && pkg.File != "" {
+			return pkg.File, pkg.Line
+		}
+
+		if pkg.Path == packagePath {
+			return config.ConfigPath, pkg.Line
+		}
+	}
+
+	return config.ConfigPath, 0
+}
@@ -0,0 +1,355 @@
+package unused_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/bursavich.dev/fs-shim/io/mock_fs"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_fswrite"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_modfiles"
+	"github.com/JosiahWitt/ensure-cli/internal/modfiles"
+	"github.com/JosiahWitt/ensure-cli/internal/unused"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+const expectedFilePerm = os.FileMode(0664)
+
+func TestFindUnused(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Finder *mock_modfiles.MockFinderIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	newConfig := func() *ensurefile.Config {
+		return &ensurefile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			ConfigPath: "/my/app/.ensure.yml",
+			Mocks: &ensurefile.MockConfig{
+				PrimaryDestination:  "internal/mocks",
+				InternalDestination: "mocks",
+				Packages: []*ensurefile.Package{
+					{
+						Path:       "github.com/some/pkg",
+						Interfaces: []string{"Iface1", "Iface2"},
+						Line:       4,
+					},
+					{
+						Path:       "github.com/my/app/layer1/internal/pkg",
+						Interfaces: []string{"Iface3"},
+						Line:       7,
+					},
+				},
+			},
+		}
+	}
+
+	allUnused := []*unused.Mock{
+		{
+			PackagePath: "github.com/some/pkg",
+			Interface:   "Iface1",
+			MockName:    "MockIface1",
+			ImportPath:  "github.com/my/app/internal/mocks/github.com/some/mock_pkg",
+			File:        "/my/app/.ensure.yml",
+			Line:        4,
+		},
+		{
+			PackagePath: "github.com/some/pkg",
+			Interface:   "Iface2",
+			MockName:    "MockIface2",
+			ImportPath:  "github.com/my/app/internal/mocks/github.com/some/mock_pkg",
+			File:        "/my/app/.ensure.yml",
+			Line:        4,
+		},
+		{
+			PackagePath: "github.com/my/app/layer1/internal/pkg",
+			Interface:   "Iface3",
+			MockName:    "MockIface3",
+			ImportPath:  "github.com/my/app/layer1/internal/mocks/mock_pkg",
+			File:        "/my/app/.ensure.yml",
+			Line:        7,
+		},
+	}
+
+	annotations := []*ensurefile.Annotation{
+		{
+			PackagePath: "github.com/my/app/layer1/internal/pkg",
+			Interface:   "Iface4",
+			MockName:    "FakeIface4",
+			File:        "/my/app/layer1/internal/pkg/pkg.go",
+			Line:        12,
+		},
+	}
+
+	table := []struct {
+		Name           string
+		Annotations    []*ensurefile.Annotation
+		NestedPackages []*ensurefile.Package
+		GoFiles        []*modfiles.GoFile
+		ExpectedUnused []*unused.Mock
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *unused.Detector
+	}{
+		{
+			Name:           "with no Go files",
+			GoFiles:        []*modfiles.GoFile{},
+			ExpectedUnused: allUnused,
+		},
+		{
+			Name: "with mocks referenced by a test file",
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/pkg/pkg_test.go",
+					Contents: `package pkg_test
+
+import (
+	"testing"
+
+	"github.com/my/app/internal/mocks/github.com/some/mock_pkg"
+)
+
+func TestSomething(t *testing.T) {
+	var _ *mock_pkg.MockIface1
+}
+`,
+				},
+				{
+					Path: "/my/app/layer1/thing_test.go",
+					Contents: `package layer1_test
+
+import "github.com/my/app/layer1/internal/mocks/mock_pkg"
+
+var _ = mock_pkg.NewMockIface3
+`,
+				},
+			},
+			ExpectedUnused: []*unused.Mock{allUnused[1]},
+		},
+		{
+			Name: "with aliased import",
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/pkg/pkg_test.go",
+					Contents: `package pkg_test
+
+import somemocks "github.com/my/app/internal/mocks/github.com/some/mock_pkg"
+
+var _ = somemocks.NewMockIface2
+`,
+				},
+			},
+			ExpectedUnused: []*unused.Mock{allUnused[0], allUnused[2]},
+		},
+		{
+			Name: "with dot import",
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/pkg/pkg_test.go",
+					Contents: `package pkg_test
+
+import . "github.com/my/app/internal/mocks/github.com/some/mock_pkg"
+
+var _ *MockIface1
+`,
+				},
+			},
+			ExpectedUnused: []*unused.Mock{allUnused[1], allUnused[2]},
+		},
+		{
+			Name: "ignores blank imports and identically named selectors of other packages",
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/pkg/pkg_test.go",
+					Contents: `package pkg_test
+
+import (
+	_ "github.com/my/app/internal/mocks/github.com/some/mock_pkg"
+	mock_pkg "github.com/other/mock_pkg"
+)
+
+var _ = mock_pkg.MockIface1
+var _ = MockIface2
+`,
+				},
+			},
+			ExpectedUnused: allUnused,
+		},
+		{
+			Name:        "with annotated interfaces",
+			Annotations: annotations,
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/layer1/thing_test.go",
+					Contents: `package layer1_test
+
+import "github.com/my/app/layer1/internal/mocks/mock_pkg"
+
+var _ = mock_pkg.NewMockIface3
+`,
+				},
+			},
+			ExpectedUnused: []*unused.Mock{
+				allUnused[0],
+				allUnused[1],
+				{
+					PackagePath: "github.com/my/app/layer1/internal/pkg",
+					Interface:   "Iface4",
+					MockName:    "FakeIface4",
+					ImportPath:  "github.com/my/app/layer1/internal/mocks/mock_pkg",
+					File:        "/my/app/layer1/internal/pkg/pkg.go",
+					Line:        12,
+				},
+			},
+		},
+		{
+			Name: "with nested packages",
+			NestedPackages: []*ensurefile.Package{
+				{
+					Path:               "github.com/some/other",
+					Interfaces:         []string{"Iface5"},
+					Line:               3,
+					File:               "/my/app/layer2/.ensure.yml",
+					PrimaryDestination: "layer2/mocks",
+				},
+			},
+			GoFiles: []*modfiles.GoFile{
+				{
+					Path: "/my/app/layer1/thing_test.go",
+					Contents: `package layer1_test
+
+import "github.com/my/app/layer1/internal/mocks/mock_pkg"
+
+var _ = mock_pkg.NewMockIface3
+`,
+				},
+			},
+			ExpectedUnused: []*unused.Mock{
+				allUnused[0],
+				allUnused[1],
+				{
+					PackagePath: "github.com/some/other",
+					Interface:   "Iface5",
+					MockName:    "MockIface5",
+					ImportPath:  "github.com/my/app/layer2/mocks/github.com/some/mock_other",
+					File:        "/my/app/layer2/.ensure.yml",
+					Line:        3,
+				},
+			},
+		},
+		{
+			Name:          "when unable to find Go files",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.Finder.EXPECT().GoFiles("/my/app").Return(nil, exampleError)
+			},
+		},
+		{
+			Name: "when unable to parse Go file",
+			GoFiles: []*modfiles.GoFile{
+				{Path: "/my/app/pkg/broken.go", Contents: "package"},
+			},
+			ExpectedError: modfiles.ErrCannotParseFile,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+
+		if entry.GoFiles != nil {
+			entry.Mocks.Finder.EXPECT().GoFiles("/my/app").Return(entry.GoFiles, nil)
+		}
+
+		config := newConfig()
+		config.Annotations = entry.Annotations
+		config.Mocks.Packages = append(config.Mocks.Packages, entry.NestedPackages...)
+
+		unusedMocks, err := entry.Subject.FindUnused(config)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(unusedMocks).Equals(entry.ExpectedUnused)
+	})
+}
+
+func TestRemoveUnused(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const configFile = `mocks:
+  packages:
+    - path: github.com/some/pkg
+      interfaces: [Iface1, Iface2]
+    - path: github.com/my/app/layer1/internal/pkg
+      interfaces: [Iface3]
+`
+
+	type Mocks struct {
+		FS      *mock_fs.MockReadFileFS
+		FSWrite *mock_fswrite.MockFSWriteIface
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	config := &ensurefile.Config{ConfigPath: "/my/app/.ensure.yml"}
+	unusedMocks := []*unused.Mock{
+		{PackagePath: "github.com/some/pkg", Interface: "Iface2", File: "/my/app/.ensure.yml"},
+		{PackagePath: "github.com/my/app/layer1/internal/pkg", Interface: "Iface3", File: "/my/app/.ensure.yml"},
+		{PackagePath: "github.com/my/app/layer1/internal/pkg", Interface: "Iface4", File: "/my/app/layer1/internal/pkg/pkg.go"},
+	}
+
+	table := []struct {
+		Name          string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *unused.Detector
+	}{
+		{
+			Name: "removes the interfaces",
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(configFile), nil)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml",
+					"mocks:\n  packages:\n    - path: github.com/some/pkg\n      interfaces: [Iface1]\n", expectedFilePerm,
+				).Return(nil)
+			},
+		},
+		{
+			Name:          "when unable to load document",
+			ExpectedError: ensurefile.ErrCannotOpenFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return(nil, exampleError)
+			},
+		},
+		{
+			Name:          "when unable to edit document",
+			ExpectedError: ensurefile.ErrCannotEditFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte("other: true\n"), nil)
+			},
+		},
+		{
+			Name:          "when unable to write file",
+			ExpectedError: unused.ErrCannotWriteFile,
+			SetupMocks: func(m *Mocks) {
+				m.FS.EXPECT().ReadFile("my/app/.ensure.yml").Return([]byte(configFile), nil)
+				m.FSWrite.EXPECT().WriteFile("/my/app/.ensure.yml", gomock.Any(), expectedFilePerm).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensurepkg.Ensure, i int) {
+		entry := table[i]
+		entry.Subject.EnsureFileLoader = &ensurefile.Loader{FS: entry.Mocks.FS}
+
+		err := entry.Subject.RemoveUnused(config, unusedMocks)
+		ensure(err).IsError(entry.ExpectedError)
+	})
+}
@@ -0,0 +1,77 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot of the state of the watched files, keyed by path.
+type snapshot map[string]fileState
+
+// takeSnapshot of the Go files directly within the directories, and the provided files.
+// Missing files and directories are omitted, so their creation is detected as a change.
+func takeSnapshot(dirs []string, files []string) snapshot {
+	snap := snapshot{}
+
+	for _, dir := range dirs {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, info := range infos {
+			if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") {
+				continue
+			}
+
+			snap.add(filepath.Join(dir, info.Name()), info)
+		}
+	}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+
+		snap.add(file, info)
+	}
+
+	return snap
+}
+
+func (snap snapshot) add(path string, info os.FileInfo) {
+	snap[path] = fileState{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+}
+
+// changedPaths returns the sorted paths that were added, removed, or modified in the newer snapshot.
+func (snap snapshot) changedPaths(newer snapshot) []string {
+	changed := []string{}
+
+	for path, state := range newer {
+		if oldState, ok := snap[path]; !ok || !oldState.modTime.Equal(state.modTime) || oldState.size != state.size {
+			changed = append(changed, path)
+		}
+	}
+
+	for path := range snap {
+		if _, ok := newer[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed
+}
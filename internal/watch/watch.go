@@ -0,0 +1,373 @@
+// Package watch regenerates mocks when the source packages or .ensure.yml change.
+package watch
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/report"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/erk"
+)
+
+const (
+	defaultPollInterval = 250 * time.Millisecond
+	defaultDebounce     = 500 * time.Millisecond
+)
+
+type ErkWatchError struct{ erk.DefaultKind }
+
+var ErrCannotResolvePackages = erk.New(ErkWatchError{}, "Could not resolve the source directories of the packages in .ensure.yml: {{.err}}")
+
+type WatcherIface interface {
+	Watch(ctx context.Context, loadConfigs func() ([]*ensurefile.Config, error), findConfigFiles func() ([]string, error)) error
+}
+
+// Watcher regenerates mocks whenever the Go files of a configured package or a .ensure.yml file change.
+type Watcher struct {
+	CmdRun        runcmd.RunnerIface
+	MockGenerator mockgen.MockGenerator
+	Logger        *log.Logger
+	Reporter      report.ReporterIface // Optional
+
+	PollInterval time.Duration // Defaults to 250ms
+	Debounce     time.Duration // Defaults to 500ms
+}
+
+var _ WatcherIface = &Watcher{}
+
+// Watch for changes until the context is canceled.
+// All mocks are generated when watching starts, and whenever a config file changes.
+// Otherwise, only mocks for the changed packages are regenerated.
+// Errors are logged and reported instead of returned, so watching can continue.
+// While the configs cannot be loaded, only the config files found by findConfigFiles are watched,
+// and no mocks are generated until they load again. An error is only returned if there is nothing to watch.
+func (w *Watcher) Watch(
+	ctx context.Context,
+	loadConfigs func() ([]*ensurefile.Config, error),
+	findConfigFiles func() ([]string, error),
+) error {
+	pollInterval := w.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = defaultDebounce
+	}
+
+	s := &session{watcher: w, loadConfigs: loadConfigs, findConfigFiles: findConfigFiles}
+	defer s.stop()
+
+	if err := s.reload(ctx); err != nil && len(s.configFiles) == 0 {
+		return err
+	}
+
+	snapshot := s.snapshot()
+	s.regenerate(ctx, nil)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	pending := map[string]bool{}
+	lastChange := time.Time{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case now := <-ticker.C:
+			newSnapshot := s.snapshot()
+			changedPaths := snapshot.changedPaths(newSnapshot)
+			snapshot = newSnapshot
+
+			if len(changedPaths) > 0 {
+				for _, changedPath := range changedPaths {
+					pending[changedPath] = true
+				}
+
+				lastChange = now
+				continue
+			}
+
+			if len(pending) == 0 || now.Sub(lastChange) < debounce {
+				continue
+			}
+
+			if reloaded := s.handleChanges(ctx, pending); reloaded {
+				snapshot = s.snapshot() // The watched directories may have changed
+			}
+
+			pending = map[string]bool{}
+		}
+	}
+}
+
+type session struct {
+	watcher         *Watcher
+	loadConfigs     func() ([]*ensurefile.Config, error)
+	findConfigFiles func() ([]string, error)
+
+	configs        []*ensurefile.Config // Nil while the configs cannot be loaded
+	configFiles    []string
+	packagesByDir  map[string][]string
+	cancelInFlight context.CancelFunc
+	inFlightDone   chan struct{}
+}
+
+// reload the configs, and the files to watch.
+// If the configs cannot be loaded, the error is logged and returned, and only the config files are watched.
+func (s *session) reload(ctx context.Context) error {
+	logger := s.watcher.Logger
+
+	configs, err := s.loadConfigs()
+	if err != nil {
+		s.logError(err)
+
+		// Keep watching the previous config files, since one of them is likely the cause
+		s.configs = nil
+		s.packagesByDir = map[string][]string{}
+		s.configFiles = uniquePaths(append(s.configFiles, s.foundConfigFiles()...))
+		if len(s.configFiles) > 0 {
+			logger.Printf("Watching %s for changes...\n", strings.Join(s.configFiles, ", "))
+		}
+
+		return err
+	}
+
+	packagesByDir := map[string][]string{}
+	configFiles := s.foundConfigFiles()
+	for _, config := range configs {
+		configPackagesByDir, err := s.resolvePackageDirs(ctx, config)
+		if err != nil {
+			s.logError(err)
+		}
+
+		for dir, pkgPaths := range configPackagesByDir {
+			packagesByDir[dir] = append(packagesByDir[dir], pkgPaths...)
+		}
+
+		configFiles = append(configFiles, configFilePaths(config)...)
+	}
+
+	s.configs = configs
+	s.configFiles = uniquePaths(configFiles)
+	s.packagesByDir = packagesByDir
+	logger.Printf("Watching %d package directories and %s for changes...\n", len(packagesByDir), strings.Join(s.configFiles, ", "))
+	return nil
+}
+
+// foundConfigFiles returns the config files found without loading them, or none if they cannot be found.
+func (s *session) foundConfigFiles() []string {
+	configFiles, err := s.findConfigFiles()
+	if err != nil {
+		return nil
+	}
+
+	return configFiles
+}
+
+// configFilePaths returns the paths of the root and nested config files of the config.
+func configFilePaths(config *ensurefile.Config) []string {
+	paths := []string{}
+	if config.ConfigPath != "" {
+		paths = append(paths, config.ConfigPath)
+	}
+
+	if config.Mocks == nil {
+		return paths
+	}
+
+	for _, pkg := range config.Mocks.Packages {
+		if pkg.File != "" && ensurefile.IsConfigFileName(filepath.Base(pkg.File)) {
+			paths = append(paths, pkg.File)
+		}
+	}
+
+	return paths
+}
+
+// uniquePaths returns the sorted paths without duplicates.
+func uniquePaths(paths []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+
+	sort.Strings(unique)
+	return unique
+}
+
+// resolvePackageDirs uses `go list` to find the source directories of the configured packages within the module.
+func (s *session) resolvePackageDirs(ctx context.Context, config *ensurefile.Config) (map[string][]string, error) {
+	packagesByDir := map[string][]string{}
+	if config.Mocks == nil || len(config.Mocks.Packages) == 0 {
+		return packagesByDir, nil
+	}
+
+	args := []string{"list", "-e", "-f", "{{.ImportPath}} {{.Dir}}"}
+	for _, pkg := range config.Mocks.Packages {
+		args = append(args, pkg.Path)
+	}
+
+	out, err := s.watcher.CmdRun.Exec(ctx, &runcmd.ExecParams{
+		PWD:  config.RootPath,
+		CMD:  "go",
+		Args: args,
+	})
+	if err != nil {
+		return packagesByDir, erk.WrapAs(ErrCannotResolvePackages, err)
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			continue
+		}
+
+		pkgPath, dir := parts[0], parts[1]
+		if dir != config.RootPath && !strings.HasPrefix(dir, config.RootPath+string(filepath.Separator)) {
+			continue // Packages outside the module cannot be changed locally
+		}
+
+		packagesByDir[dir] = append(packagesByDir[dir], pkgPath)
+	}
+
+	return packagesByDir, nil
+}
+
+func (s *session) snapshot() snapshot {
+	dirs := make([]string, 0, len(s.packagesByDir))
+	for dir := range s.packagesByDir {
+		dirs = append(dirs, dir)
+	}
+
+	return takeSnapshot(dirs, s.configFiles)
+}
+
+// handleChanges regenerates the mocks affected by the changed paths.
+// It returns true if the config was reloaded.
+func (s *session) handleChanges(ctx context.Context, changedPaths map[string]bool) bool {
+	logger := s.watcher.Logger
+
+	if s.configs == nil || s.configFileChanged(changedPaths) {
+		logger.Println("Config changed, regenerating all mocks.")
+		_ = s.reload(ctx) // Logged by reload, and regenerate is skipped until the configs load
+		s.regenerate(ctx, nil)
+		return true
+	}
+
+	affectedPackages := map[string]bool{}
+	for changedPath := range changedPaths {
+		for _, pkgPath := range s.packagesByDir[filepath.Dir(changedPath)] {
+			affectedPackages[pkgPath] = true
+		}
+	}
+
+	if len(affectedPackages) == 0 {
+		return false
+	}
+
+	filters := make([]string, 0, len(affectedPackages))
+	for pkgPath := range affectedPackages {
+		filters = append(filters, pkgPath)
+	}
+	sort.Strings(filters)
+
+	logger.Printf("Changes detected in: %s\n", strings.Join(filters, ", "))
+	s.regenerate(ctx, filters)
+	return false
+}
+
+func (s *session) configFileChanged(changedPaths map[string]bool) bool {
+	for _, configFile := range s.configFiles {
+		if changedPaths[configFile] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// regenerate cancels any in-flight generation, and starts generating the mocks for the provided package filters.
+// If there are no filters, all mocks are generated, and tidied if configured.
+func (s *session) regenerate(ctx context.Context, filters []string) {
+	s.stop()
+
+	if s.configs == nil {
+		return
+	}
+
+	configs := make([]*ensurefile.Config, 0, len(s.configs))
+	for _, config := range s.configs {
+		config := *config
+		config.PackageFilters = filters
+		configs = append(configs, &config)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	s.cancelInFlight = cancel
+	s.inFlightDone = done
+
+	go func() {
+		defer close(done)
+		s.generate(runCtx, configs, len(filters) > 0)
+	}()
+}
+
+func (s *session) generate(ctx context.Context, configs []*ensurefile.Config, filtered bool) {
+	generator := s.watcher.MockGenerator
+
+	if err := generator.GenerateAllMocks(ctx, configs); err != nil {
+		s.logError(err)
+		return
+	}
+
+	if ctx.Err() != nil || filtered {
+		return
+	}
+
+	for _, config := range configs {
+		if config.Mocks == nil || !config.Mocks.TidyAfterGenerate {
+			continue
+		}
+
+		if err := generator.TidyMocks(config); err != nil {
+			s.logError(err)
+		}
+	}
+}
+
+func (s *session) logError(err error) {
+	s.watcher.Logger.Printf("ERROR: %v\n", err)
+
+	if s.watcher.Reporter != nil {
+		s.watcher.Reporter.Report(&report.Event{Type: report.EventError, Error: report.NewError(err)})
+	}
+}
+
+// stop cancels any in-flight generation, and waits for it to exit.
+func (s *session) stop() {
+	if s.cancelInFlight == nil {
+		return
+	}
+
+	s.cancelInFlight()
+	<-s.inFlightDone
+	s.cancelInFlight = nil
+	s.inFlightDone = nil
+}
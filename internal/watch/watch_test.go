@@ -0,0 +1,372 @@
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure-cli/internal/ensurefile"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_mockgen"
+	"github.com/JosiahWitt/ensure-cli/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/runcmd"
+	"github.com/JosiahWitt/ensure-cli/internal/watch"
+	"github.com/JosiahWitt/ensure/ensurepkg"
+	"github.com/golang/mock/gomock"
+)
+
+const waitTimeout = 5 * time.Second
+
+func TestWatch(t *testing.T) {
+	ensure := ensure.New(t)
+
+	setup := func(ensure ensurepkg.Ensure) (string, *ensurefile.Config) {
+		rootPath := t.TempDir()
+		err := os.MkdirAll(filepath.Join(rootPath, "pkg"), 0775)
+		ensure(err).IsNotError()
+
+		err = ioutil.WriteFile(filepath.Join(rootPath, "pkg", "pkg.go"), []byte("package pkg"), 0600)
+		ensure(err).IsNotError()
+
+		err = ioutil.WriteFile(filepath.Join(rootPath, ".ensure.yml"), []byte("mocks: {}"), 0600)
+		ensure(err).IsNotError()
+
+		return rootPath, &ensurefile.Config{
+			RootPath:   rootPath,
+			ModulePath: "github.com/my/mod",
+			ConfigPath: filepath.Join(rootPath, ".ensure.yml"),
+			Mocks: &ensurefile.MockConfig{
+				TidyAfterGenerate: true,
+				Packages: []*ensurefile.Package{
+					{
+						Path:       "github.com/my/mod/pkg",
+						Interfaces: []string{"Iface1"},
+					},
+					{
+						Path:       "context",
+						Interfaces: []string{"Context"},
+					},
+				},
+			},
+		}
+	}
+
+	expectGoList := func(mockCmdRun *mock_runcmd.MockRunnerIface, rootPath string) *gomock.Call {
+		return mockCmdRun.EXPECT().
+			Exec(gomock.Any(), &runcmd.ExecParams{
+				PWD:  rootPath,
+				CMD:  "go",
+				Args: []string{"list", "-e", "-f", "{{.ImportPath}} {{.Dir}}", "github.com/my/mod/pkg", "context"},
+			}).
+			Return("github.com/my/mod/pkg "+filepath.Join(rootPath, "pkg")+"\ncontext /usr/local/go/src/context\n", nil)
+	}
+
+	newWatcher := func(ensure ensurepkg.Ensure) (*watch.Watcher, *mock_runcmd.MockRunnerIface, *mock_mockgen.MockMockGenerator) {
+		mockCmdRun := mock_runcmd.NewMockRunnerIface(ensure.GoMockController())
+		mockGenerator := mock_mockgen.NewMockMockGenerator(ensure.GoMockController())
+
+		return &watch.Watcher{
+			CmdRun:        mockCmdRun,
+			MockGenerator: mockGenerator,
+			Logger:        log.New(ioutil.Discard, "", 0),
+			PollInterval:  5 * time.Millisecond,
+			Debounce:      20 * time.Millisecond,
+		}, mockCmdRun, mockGenerator
+	}
+
+	ensure.Run("regenerates changed packages", func(ensure ensurepkg.Ensure) {
+		rootPath, config := setup(ensure)
+		watcher, mockCmdRun, mockGenerator := newWatcher(ensure)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		initialDone := make(chan struct{})
+		changeDone := make(chan struct{})
+
+		gomock.InOrder(
+			expectGoList(mockCmdRun, rootPath),
+
+			mockGenerator.EXPECT().GenerateAllMocks(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, configs []*ensurefile.Config) error {
+					ensure(len(configs)).Equals(1)
+					ensure(configs[0].PackageFilters).IsEmpty()
+					return nil
+				}),
+
+			mockGenerator.EXPECT().TidyMocks(gomock.Any()).
+				DoAndReturn(func(c *ensurefile.Config) error {
+					close(initialDone)
+					return nil
+				}),
+
+			mockGenerator.EXPECT().GenerateAllMocks(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, configs []*ensurefile.Config) error {
+					ensure(len(configs)).Equals(1)
+					ensure(configs[0].PackageFilters).Equals([]string{"github.com/my/mod/pkg"})
+					close(changeDone)
+					return nil
+				}),
+		)
+
+		watchDone := make(chan error)
+		go func() {
+			watchDone <- watcher.Watch(ctx, loadConfigs(config), findConfigFiles(config.ConfigPath))
+		}()
+
+		waitFor(ensure, initialDone)
+		err := ioutil.WriteFile(filepath.Join(rootPath, "pkg", "pkg.go"), []byte("package pkg // Changed"), 0600)
+		ensure(err).IsNotError()
+		waitFor(ensure, changeDone)
+
+		cancel()
+		ensure(<-watchDone).IsNotError()
+	})
+
+	ensure.Run("regenerates all packages when config changes", func(ensure ensurepkg.Ensure) {
+		rootPath, config := setup(ensure)
+		watcher, mockCmdRun, mockGenerator := newWatcher(ensure)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		initialDone := make(chan struct{})
+		changeDone := make(chan struct{})
+
+		gomock.InOrder(
+			expectGoList(mockCmdRun, rootPath),
+			mockGenerator.EXPECT().GenerateAllMocks(gomock.Any(), gomock.Any()).Return(nil),
+			mockGenerator.EXPECT().TidyMocks(gomock.Any()).
+				DoAndReturn(func(c *ensurefile.Config) error {
+					close(initialDone)
+					return nil
+				}),
+
+			expectGoList(mockCmdRun, rootPath),
+			mockGenerator.EXPECT().GenerateAllMocks(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, configs []*ensurefile.Config) error {
+					ensure(len(configs)).Equals(1)
+					ensure(configs[0].PackageFilters).IsEmpty()
+					return errors.New("errors are logged without exiting")
+				}),
+			mockGenerator.EXPECT().GenerateAllMocks(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, configs []*ensurefile.Config) error {
+					ensure(len(configs)).Equals(1)
+					ensure(configs[0].PackageFilters).Equals([]string{"github.com/my/mod/pkg"})
+					close(changeDone)
+					return nil
+				}),
+		)
+
+		watchDone := make(chan error)
+		go func() {
+			watchDone <- watcher.Watch(ctx, loadConfigs(config), findConfigFiles(config.ConfigPath))
+		}()
+
+		waitFor(ensure, initialDone)
+		err := ioutil.WriteFile(config.ConfigPath, []byte("mocks: {tidyAfterGenerate: true}"), 0600)
+		ensure(err).IsNotError()
+
+		time.Sleep(100 * time.Millisecond) // Allow the config change to be handled
+		err = os.Remove(filepath.Join(rootPath, "pkg", "pkg.go"))
+		ensure(err).IsNotError()
+		waitFor(ensure, changeDone)
+
+		cancel()
+		ensure(<-watchDone).IsNotError()
+	})
+}
+
+func TestWatchWhenConfigCannotLoad(t *testing.T) {
+	ensure := ensure.New(t)
+
+	setup := func(ensure ensurepkg.Ensure) (string, *ensurefile.Config) {
+		rootPath := t.TempDir()
+		err := os.MkdirAll(filepath.Join(rootPath, "pkg"), 0775)
+		ensure(err).IsNotError()
+
+		err = ioutil.WriteFile(filepath.Join(rootPath, "pkg", "pkg.go"), []byte("package pkg"), 0600)
+		ensure(err).IsNotError()
+
+		err = ioutil.WriteFile(filepath.Join(rootPath, ".ensure.yml"), []byte("mocks: {"), 0600)
+		ensure(err).IsNotError()
+
+		return rootPath, &ensurefile.Config{
+			RootPath:   rootPath,
+			ModulePath: "github.com/my/mod",
+			ConfigPath: filepath.Join(rootPath, ".ensure.yml"),
+			Mocks: &ensurefile.MockConfig{
+				Packages: []*ensurefile.Package{
+					{
+						Path:       "github.com/my/mod/pkg",
+						Interfaces: []string{"Iface1"},
+					},
+				},
+			},
+		}
+	}
+
+	expectGoList := func(mockCmdRun *mock_runcmd.MockRunnerIface, rootPath string) *gomock.Call {
+		return mockCmdRun.EXPECT().
+			Exec(gomock.Any(), &runcmd.ExecParams{
+				PWD:  rootPath,
+				CMD:  "go",
+				Args: []string{"list", "-e", "-f", "{{.ImportPath}} {{.Dir}}", "github.com/my/mod/pkg"},
+			}).
+			Return("github.com/my/mod/pkg "+filepath.Join(rootPath, "pkg")+"\n", nil)
+	}
+
+	newWatcher := func(ensure ensurepkg.Ensure) (*watch.Watcher, *mock_runcmd.MockRunnerIface, *mock_mockgen.MockMockGenerator) {
+		mockCmdRun := mock_runcmd.NewMockRunnerIface(ensure.GoMockController())
+		mockGenerator := mock_mockgen.NewMockMockGenerator(ensure.GoMockController())
+
+		return &watch.Watcher{
+			CmdRun:        mockCmdRun,
+			MockGenerator: mockGenerator,
+			Logger:        log.New(ioutil.Discard, "", 0),
+			PollInterval:  5 * time.Millisecond,
+			Debounce:      20 * time.Millisecond,
+		}, mockCmdRun, mockGenerator
+	}
+
+	ensure.Run("generates mocks once the config file is fixed", func(ensure ensurepkg.Ensure) {
+		rootPath, config := setup(ensure)
+		watcher, mockCmdRun, mockGenerator := newWatcher(ensure)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		generated := make(chan struct{})
+
+		gomock.InOrder(
+			expectGoList(mockCmdRun, rootPath),
+			mockGenerator.EXPECT().GenerateAllMocks(gomock.Any(), []*ensurefile.Config{config}).
+				DoAndReturn(func(ctx context.Context, configs []*ensurefile.Config) error {
+					close(generated)
+					return nil
+				}),
+		)
+
+		results := []loadResult{{err: errors.New("cannot parse")}, {config: config}}
+
+		watchDone := make(chan error)
+		go func() {
+			watchDone <- watcher.Watch(ctx, loadConfigsInOrder(results), findConfigFiles(config.ConfigPath))
+		}()
+
+		time.Sleep(100 * time.Millisecond) // Allow watching to start
+		err := ioutil.WriteFile(config.ConfigPath, []byte("mocks: {}"), 0600)
+		ensure(err).IsNotError()
+		waitFor(ensure, generated)
+
+		cancel()
+		ensure(<-watchDone).IsNotError()
+	})
+
+	ensure.Run("skips generation until the config file loads again", func(ensure ensurepkg.Ensure) {
+		rootPath, config := setup(ensure)
+		watcher, mockCmdRun, mockGenerator := newWatcher(ensure)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		initialDone := make(chan struct{})
+		reloadDone := make(chan struct{})
+
+		gomock.InOrder(
+			expectGoList(mockCmdRun, rootPath),
+			mockGenerator.EXPECT().GenerateAllMocks(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, configs []*ensurefile.Config) error {
+					close(initialDone)
+					return nil
+				}),
+
+			expectGoList(mockCmdRun, rootPath),
+			mockGenerator.EXPECT().GenerateAllMocks(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, configs []*ensurefile.Config) error {
+					ensure(configs[0].PackageFilters).IsEmpty()
+					close(reloadDone)
+					return nil
+				}),
+		)
+
+		results := []loadResult{{config: config}, {err: errors.New("cannot parse")}, {config: config}}
+
+		watchDone := make(chan error)
+		go func() {
+			watchDone <- watcher.Watch(ctx, loadConfigsInOrder(results), findConfigFiles(config.ConfigPath))
+		}()
+
+		waitFor(ensure, initialDone)
+		err := ioutil.WriteFile(config.ConfigPath, []byte("mocks: {}}"), 0600)
+		ensure(err).IsNotError()
+
+		time.Sleep(100 * time.Millisecond) // Allow the config change to be handled
+		err = ioutil.WriteFile(filepath.Join(rootPath, "pkg", "pkg.go"), []byte("package pkg // Changed"), 0600)
+		ensure(err).IsNotError()
+
+		time.Sleep(100 * time.Millisecond) // Allow the package change to be ignored
+		err = ioutil.WriteFile(config.ConfigPath, []byte("mocks: {}"), 0600)
+		ensure(err).IsNotError()
+		waitFor(ensure, reloadDone)
+
+		cancel()
+		ensure(<-watchDone).IsNotError()
+	})
+
+	ensure.Run("returns the error when there is nothing to watch", func(ensure ensurepkg.Ensure) {
+		watcher, _, _ := newWatcher(ensure)
+		loadErr := errors.New("cannot find go.mod")
+
+		err := watcher.Watch(
+			context.Background(),
+			func() ([]*ensurefile.Config, error) { return nil, loadErr },
+			func() ([]string, error) { return nil, loadErr },
+		)
+		ensure(err).IsError(loadErr)
+	})
+}
+
+type loadResult struct {
+	config *ensurefile.Config
+	err    error
+}
+
+func loadConfigs(config *ensurefile.Config) func() ([]*ensurefile.Config, error) {
+	return func() ([]*ensurefile.Config, error) {
+		return []*ensurefile.Config{config}, nil
+	}
+}
+
+// loadConfigsInOrder returns each result in order, repeating the last one.
+func loadConfigsInOrder(results []loadResult) func() ([]*ensurefile.Config, error) {
+	i := 0
+
+	return func() ([]*ensurefile.Config, error) {
+		result := results[i]
+		if i < len(results)-1 {
+			i++
+		}
+
+		if result.err != nil {
+			return nil, result.err
+		}
+
+		return []*ensurefile.Config{result.config}, nil
+	}
+}
+
+func findConfigFiles(paths ...string) func() ([]string, error) {
+	return func() ([]string, error) {
+		return paths, nil
+	}
+}
+
+func waitFor(ensure ensurepkg.Ensure, done chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(waitTimeout):
+		ensure.T().Fatal("timed out waiting for mocks to be generated")
+	}
+}